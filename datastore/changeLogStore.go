@@ -0,0 +1,12 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+type ChangeLogStore interface {
+	CreateChangeLogStore()
+
+	InsertChangeLogs(changeLogs []*models.ChangeLog) StoreResult
+	SelectChangeLogs(userId string, sinceSeq uint64, limit int) StoreResult
+	SelectChangeLogSeqRange(userId string) StoreResult
+	DeleteChangeLogs(created int64) StoreResult
+}
@@ -0,0 +1,11 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+type ExportJobStore interface {
+	CreateExportJobStore()
+
+	InsertExportJob(exportJob *models.ExportJob) StoreResult
+	SelectExportJob(exportJobId string) StoreResult
+	UpdateExportJob(exportJob *models.ExportJob) StoreResult
+}
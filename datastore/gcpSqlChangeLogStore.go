@@ -0,0 +1,23 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *gcpSqlProvider) CreateChangeLogStore() {
+	RdbCreateChangeLogStore()
+}
+
+func (p *gcpSqlProvider) InsertChangeLogs(changeLogs []*models.ChangeLog) StoreResult {
+	return RdbInsertChangeLogs(changeLogs)
+}
+
+func (p *gcpSqlProvider) SelectChangeLogs(userId string, sinceSeq uint64, limit int) StoreResult {
+	return RdbSelectChangeLogs(userId, sinceSeq, limit)
+}
+
+func (p *gcpSqlProvider) SelectChangeLogSeqRange(userId string) StoreResult {
+	return RdbSelectChangeLogSeqRange(userId)
+}
+
+func (p *gcpSqlProvider) DeleteChangeLogs(created int64) StoreResult {
+	return RdbDeleteChangeLogs(created)
+}
@@ -0,0 +1,19 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *gcpSqlProvider) CreateExportJobStore() {
+	RdbCreateExportJobStore()
+}
+
+func (p *gcpSqlProvider) InsertExportJob(exportJob *models.ExportJob) StoreResult {
+	return RdbInsertExportJob(exportJob)
+}
+
+func (p *gcpSqlProvider) SelectExportJob(exportJobId string) StoreResult {
+	return RdbSelectExportJob(exportJobId)
+}
+
+func (p *gcpSqlProvider) UpdateExportJob(exportJob *models.ExportJob) StoreResult {
+	return RdbUpdateExportJob(exportJob)
+}
@@ -0,0 +1,27 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *gcpSqlProvider) CreateHeldMessageStore() {
+	RdbCreateHeldMessageStore()
+}
+
+func (p *gcpSqlProvider) InsertHeldMessage(heldMessage *models.HeldMessage) StoreResult {
+	return RdbInsertHeldMessage(heldMessage)
+}
+
+func (p *gcpSqlProvider) SelectHeldMessage(heldMessageId string) StoreResult {
+	return RdbSelectHeldMessage(heldMessageId)
+}
+
+func (p *gcpSqlProvider) SelectHeldMessages(status string, limit, offset int) StoreResult {
+	return RdbSelectHeldMessages(status, limit, offset)
+}
+
+func (p *gcpSqlProvider) UpdateHeldMessage(heldMessage *models.HeldMessage) StoreResult {
+	return RdbUpdateHeldMessage(heldMessage)
+}
+
+func (p *gcpSqlProvider) ClaimHeldMessage(heldMessage *models.HeldMessage, status string) StoreResult {
+	return RdbClaimHeldMessage(heldMessage, status)
+}
@@ -0,0 +1,27 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *gcpSqlProvider) CreateIncomingWebhookStore() {
+	RdbCreateIncomingWebhookStore()
+}
+
+func (p *gcpSqlProvider) InsertIncomingWebhook(incomingWebhook *models.IncomingWebhook) StoreResult {
+	return RdbInsertIncomingWebhook(incomingWebhook)
+}
+
+func (p *gcpSqlProvider) SelectIncomingWebhook(incomingWebhookId string) StoreResult {
+	return RdbSelectIncomingWebhook(incomingWebhookId)
+}
+
+func (p *gcpSqlProvider) SelectIncomingWebhookBySecret(secret string) StoreResult {
+	return RdbSelectIncomingWebhookBySecret(secret)
+}
+
+func (p *gcpSqlProvider) SelectIncomingWebhooks(roomId string) StoreResult {
+	return RdbSelectIncomingWebhooks(roomId)
+}
+
+func (p *gcpSqlProvider) UpdateIncomingWebhook(incomingWebhook *models.IncomingWebhook) StoreResult {
+	return RdbUpdateIncomingWebhook(incomingWebhook)
+}
@@ -6,8 +6,12 @@ func (p *gcpSqlProvider) CreateMessageStore() {
 	RdbCreateMessageStore()
 }
 
-func (p *gcpSqlProvider) InsertMessage(message *models.Message) StoreResult {
-	return RdbInsertMessage(message)
+func (p *gcpSqlProvider) InsertMessage(message *models.Message, outboxItems []*models.OutboxItem) StoreResult {
+	return RdbInsertMessage(message, outboxItems)
+}
+
+func (p *gcpSqlProvider) InsertMessages(messages []*models.Message, outboxItems []*models.OutboxItem, heldMessages []*models.HeldMessage) StoreResult {
+	return RdbInsertMessages(messages, outboxItems, heldMessages)
 }
 
 func (p *gcpSqlProvider) SelectMessage(messageId string) StoreResult {
@@ -22,6 +26,22 @@ func (p *gcpSqlProvider) SelectCountMessagesByRoomId(roomId string) StoreResult
 	return RdbSelectCountMessagesByRoomId(roomId)
 }
 
+func (p *gcpSqlProvider) SelectExportMessages(roomId string, from, to int64, includeDeleted bool, afterId uint64, limit int) StoreResult {
+	return RdbSelectExportMessages(roomId, from, to, includeDeleted, afterId, limit)
+}
+
+func (p *gcpSqlProvider) SelectCountExportMessages(roomId string, from, to int64, includeDeleted bool) StoreResult {
+	return RdbSelectCountExportMessages(roomId, from, to, includeDeleted)
+}
+
 func (p *gcpSqlProvider) UpdateMessage(message *models.Message) StoreResult {
 	return RdbUpdateMessage(message)
 }
+
+func (p *gcpSqlProvider) SelectExpiredMessages(roomId string, cutoff int64, limit int) StoreResult {
+	return RdbSelectExpiredMessages(roomId, cutoff, limit)
+}
+
+func (p *gcpSqlProvider) DeleteMessages(roomId string, messageIds []string) StoreResult {
+	return RdbDeleteMessages(roomId, messageIds)
+}
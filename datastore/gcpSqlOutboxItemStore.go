@@ -0,0 +1,31 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *gcpSqlProvider) CreateOutboxItemStore() {
+	RdbCreateOutboxItemStore()
+}
+
+func (p *gcpSqlProvider) SelectOutboxItem(outboxItemId string) StoreResult {
+	return RdbSelectOutboxItem(outboxItemId)
+}
+
+func (p *gcpSqlProvider) SelectOutboxItems(status, kind string, limit, offset int) StoreResult {
+	return RdbSelectOutboxItems(status, kind, limit, offset)
+}
+
+func (p *gcpSqlProvider) SelectRetryableOutboxItems(now int64, limit int) StoreResult {
+	return RdbSelectRetryableOutboxItems(now, limit)
+}
+
+func (p *gcpSqlProvider) UpdateOutboxItem(item *models.OutboxItem) StoreResult {
+	return RdbUpdateOutboxItem(item)
+}
+
+func (p *gcpSqlProvider) ClaimOutboxItem(item *models.OutboxItem, nextAttempt int64) StoreResult {
+	return RdbClaimOutboxItem(item, nextAttempt)
+}
+
+func (p *gcpSqlProvider) DeleteSucceededOutboxItems(modified int64) StoreResult {
+	return RdbDeleteSucceededOutboxItems(modified)
+}
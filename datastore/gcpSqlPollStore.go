@@ -0,0 +1,35 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *gcpSqlProvider) CreatePollStore() {
+	RdbCreatePollStore()
+}
+
+func (p *gcpSqlProvider) SelectPoll(messageId string) StoreResult {
+	return RdbSelectPoll(messageId)
+}
+
+func (p *gcpSqlProvider) SelectPolls(messageIds []string) StoreResult {
+	return RdbSelectPolls(messageIds)
+}
+
+func (p *gcpSqlProvider) SelectExpiredPolls(now int64) StoreResult {
+	return RdbSelectExpiredPolls(now)
+}
+
+func (p *gcpSqlProvider) UpdatePoll(poll *models.Poll) StoreResult {
+	return RdbUpdatePoll(poll)
+}
+
+func (p *gcpSqlProvider) SelectPollVotes(messageId string) StoreResult {
+	return RdbSelectPollVotes(messageId)
+}
+
+func (p *gcpSqlProvider) SelectPollVotesByMessageIds(messageIds []string) StoreResult {
+	return RdbSelectPollVotesByMessageIds(messageIds)
+}
+
+func (p *gcpSqlProvider) ReplacePollVotes(messageId, userId string, votes []*models.PollVote) StoreResult {
+	return RdbReplacePollVotes(messageId, userId, votes)
+}
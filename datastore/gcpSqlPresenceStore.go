@@ -0,0 +1,31 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *gcpSqlProvider) CreatePresenceStore() {
+	RdbCreatePresenceStore()
+}
+
+func (p *gcpSqlProvider) InsertPresence(presence *models.Presence) StoreResult {
+	return RdbInsertPresence(presence)
+}
+
+func (p *gcpSqlProvider) SelectPresence(userId string) StoreResult {
+	return RdbSelectPresence(userId)
+}
+
+func (p *gcpSqlProvider) SelectPresences(userIds []string) StoreResult {
+	return RdbSelectPresences(userIds)
+}
+
+func (p *gcpSqlProvider) SelectExpiredPresences(lastSeen int64) StoreResult {
+	return RdbSelectExpiredPresences(lastSeen)
+}
+
+func (p *gcpSqlProvider) UpdatePresence(presence *models.Presence) StoreResult {
+	return RdbUpdatePresence(presence)
+}
+
+func (p *gcpSqlProvider) UpdatePresencesLastSeen(userIds []string, lastSeen int64) StoreResult {
+	return RdbUpdatePresencesLastSeen(userIds, lastSeen)
+}
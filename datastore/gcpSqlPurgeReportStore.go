@@ -0,0 +1,19 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *gcpSqlProvider) CreatePurgeReportStore() {
+	RdbCreatePurgeReportStore()
+}
+
+func (p *gcpSqlProvider) InsertPurgeReport(purgeReport *models.PurgeReport) StoreResult {
+	return RdbInsertPurgeReport(purgeReport)
+}
+
+func (p *gcpSqlProvider) SelectPurgeReport(purgeReportId string) StoreResult {
+	return RdbSelectPurgeReport(purgeReportId)
+}
+
+func (p *gcpSqlProvider) SelectPurgeReports(limit, offset int) StoreResult {
+	return RdbSelectPurgeReports(limit, offset)
+}
@@ -0,0 +1,23 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *gcpSqlProvider) CreateReportStore() {
+	RdbCreateReportStore()
+}
+
+func (p *gcpSqlProvider) InsertReport(report *models.Report) StoreResult {
+	return RdbInsertReport(report)
+}
+
+func (p *gcpSqlProvider) SelectReport(reportId string) StoreResult {
+	return RdbSelectReport(reportId)
+}
+
+func (p *gcpSqlProvider) SelectReports(status string, limit, offset int) StoreResult {
+	return RdbSelectReports(status, limit, offset)
+}
+
+func (p *gcpSqlProvider) UpdateReport(report *models.Report) StoreResult {
+	return RdbUpdateReport(report)
+}
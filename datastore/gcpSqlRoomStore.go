@@ -26,6 +26,10 @@ func (p *gcpSqlProvider) SelectCountRooms() StoreResult {
 	return RdbSelectCountRooms()
 }
 
+func (p *gcpSqlProvider) SelectRetentionRooms(globalDays int64) StoreResult {
+	return RdbSelectRetentionRooms(globalDays)
+}
+
 func (p *gcpSqlProvider) UpdateRoom(room *models.Room) StoreResult {
 	return RdbUpdateRoom(room)
 }
@@ -34,6 +34,14 @@ func (p *gcpSqlProvider) SelectRoomUsersByRoomIdAndUserIds(roomId *string, userI
 	return RdbSelectRoomUsersByRoomIdAndUserIds(roomId, userIds)
 }
 
+func (p *gcpSqlProvider) SelectRecipientUserIds(roomId, senderUserId string) StoreResult {
+	return RdbSelectRecipientUserIds(roomId, senderUserId)
+}
+
+func (p *gcpSqlProvider) SelectPushSettings(roomId, senderUserId string) StoreResult {
+	return RdbSelectPushSettings(roomId, senderUserId)
+}
+
 func (p *gcpSqlProvider) UpdateRoomUser(roomUser *models.RoomUser) StoreResult {
 	return RdbUpdateRoomUser(roomUser)
 }
@@ -41,3 +49,7 @@ func (p *gcpSqlProvider) UpdateRoomUser(roomUser *models.RoomUser) StoreResult {
 func (p *gcpSqlProvider) DeleteRoomUser(roomId string, userIds []string) StoreResult {
 	return RdbDeleteRoomUser(roomId, userIds)
 }
+
+func (p *gcpSqlProvider) UpdateRoomUserDraft(roomId, userId, draft string, draftUpdated int64) StoreResult {
+	return RdbUpdateRoomUserDraft(roomId, userId, draft, draftUpdated)
+}
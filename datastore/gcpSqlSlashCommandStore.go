@@ -0,0 +1,23 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *gcpSqlProvider) CreateSlashCommandStore() {
+	RdbCreateSlashCommandStore()
+}
+
+func (p *gcpSqlProvider) InsertSlashCommand(slashCommand *models.SlashCommand) StoreResult {
+	return RdbInsertSlashCommand(slashCommand)
+}
+
+func (p *gcpSqlProvider) SelectSlashCommand(commandId string) StoreResult {
+	return RdbSelectSlashCommand(commandId)
+}
+
+func (p *gcpSqlProvider) SelectSlashCommands(roomId string) StoreResult {
+	return RdbSelectSlashCommands(roomId)
+}
+
+func (p *gcpSqlProvider) UpdateSlashCommand(slashCommand *models.SlashCommand) StoreResult {
+	return RdbUpdateSlashCommand(slashCommand)
+}
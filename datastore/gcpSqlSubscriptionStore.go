@@ -29,3 +29,11 @@ func (p *gcpSqlProvider) SelectDeletedSubscriptionsByUserIdAndPlatform(userId st
 func (p *gcpSqlProvider) DeleteSubscription(subscription *models.Subscription) StoreResult {
 	return RdbDeleteSubscription(subscription)
 }
+
+func (p *gcpSqlProvider) SelectSubscriptionsByRoomId(roomId string) StoreResult {
+	return RdbSelectSubscriptionsByRoomId(roomId)
+}
+
+func (p *gcpSqlProvider) DeleteSubscriptionsByUserIdAndPlatform(userId string, platform int) StoreResult {
+	return RdbDeleteSubscriptionsByUserIdAndPlatform(userId, platform)
+}
@@ -0,0 +1,31 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *gcpSqlProvider) CreateWebhookDeliveryStore() {
+	RdbCreateWebhookDeliveryStore()
+}
+
+func (p *gcpSqlProvider) InsertWebhookDelivery(delivery *models.WebhookDelivery) StoreResult {
+	return RdbInsertWebhookDelivery(delivery)
+}
+
+func (p *gcpSqlProvider) SelectWebhookDelivery(deliveryId string) StoreResult {
+	return RdbSelectWebhookDelivery(deliveryId)
+}
+
+func (p *gcpSqlProvider) SelectWebhookDeliveries(webhookId, status string, limit, offset int) StoreResult {
+	return RdbSelectWebhookDeliveries(webhookId, status, limit, offset)
+}
+
+func (p *gcpSqlProvider) SelectRetryableWebhookDeliveries(now int64, limit int) StoreResult {
+	return RdbSelectRetryableWebhookDeliveries(now, limit)
+}
+
+func (p *gcpSqlProvider) UpdateWebhookDelivery(delivery *models.WebhookDelivery) StoreResult {
+	return RdbUpdateWebhookDelivery(delivery)
+}
+
+func (p *gcpSqlProvider) ClaimWebhookDelivery(delivery *models.WebhookDelivery, nextAttempt int64) StoreResult {
+	return RdbClaimWebhookDelivery(delivery, nextAttempt)
+}
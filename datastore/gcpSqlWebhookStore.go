@@ -0,0 +1,23 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *gcpSqlProvider) CreateWebhookStore() {
+	RdbCreateWebhookStore()
+}
+
+func (p *gcpSqlProvider) InsertWebhook(webhook *models.Webhook) StoreResult {
+	return RdbInsertWebhook(webhook)
+}
+
+func (p *gcpSqlProvider) SelectWebhook(webhookId string) StoreResult {
+	return RdbSelectWebhook(webhookId)
+}
+
+func (p *gcpSqlProvider) SelectWebhooks() StoreResult {
+	return RdbSelectWebhooks()
+}
+
+func (p *gcpSqlProvider) UpdateWebhook(webhook *models.Webhook) StoreResult {
+	return RdbUpdateWebhook(webhook)
+}
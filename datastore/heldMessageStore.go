@@ -0,0 +1,13 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+type HeldMessageStore interface {
+	CreateHeldMessageStore()
+
+	InsertHeldMessage(heldMessage *models.HeldMessage) StoreResult
+	SelectHeldMessage(heldMessageId string) StoreResult
+	SelectHeldMessages(status string, limit, offset int) StoreResult
+	UpdateHeldMessage(heldMessage *models.HeldMessage) StoreResult
+	ClaimHeldMessage(heldMessage *models.HeldMessage, status string) StoreResult
+}
@@ -0,0 +1,13 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+type IncomingWebhookStore interface {
+	CreateIncomingWebhookStore()
+
+	InsertIncomingWebhook(incomingWebhook *models.IncomingWebhook) StoreResult
+	SelectIncomingWebhook(incomingWebhookId string) StoreResult
+	SelectIncomingWebhookBySecret(secret string) StoreResult
+	SelectIncomingWebhooks(roomId string) StoreResult
+	UpdateIncomingWebhook(incomingWebhook *models.IncomingWebhook) StoreResult
+}
@@ -5,9 +5,14 @@ import "github.com/swagchat/chat-api/models"
 type MessageStore interface {
 	CreateMessageStore()
 
-	InsertMessage(message *models.Message) StoreResult
+	InsertMessage(message *models.Message, outboxItems []*models.OutboxItem) StoreResult
+	InsertMessages(messages []*models.Message, outboxItems []*models.OutboxItem, heldMessages []*models.HeldMessage) StoreResult
 	SelectMessage(messageId string) StoreResult
 	SelectMessages(roomId string, limit, offset int, order string) StoreResult
 	SelectCountMessagesByRoomId(roomId string) StoreResult
+	SelectExportMessages(roomId string, from, to int64, includeDeleted bool, afterId uint64, limit int) StoreResult
+	SelectCountExportMessages(roomId string, from, to int64, includeDeleted bool) StoreResult
 	UpdateMessage(message *models.Message) StoreResult
+	SelectExpiredMessages(roomId string, cutoff int64, limit int) StoreResult
+	DeleteMessages(roomId string, messageIds []string) StoreResult
 }
@@ -0,0 +1,23 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *mysqlProvider) CreateChangeLogStore() {
+	RdbCreateChangeLogStore()
+}
+
+func (p *mysqlProvider) InsertChangeLogs(changeLogs []*models.ChangeLog) StoreResult {
+	return RdbInsertChangeLogs(changeLogs)
+}
+
+func (p *mysqlProvider) SelectChangeLogs(userId string, sinceSeq uint64, limit int) StoreResult {
+	return RdbSelectChangeLogs(userId, sinceSeq, limit)
+}
+
+func (p *mysqlProvider) SelectChangeLogSeqRange(userId string) StoreResult {
+	return RdbSelectChangeLogSeqRange(userId)
+}
+
+func (p *mysqlProvider) DeleteChangeLogs(created int64) StoreResult {
+	return RdbDeleteChangeLogs(created)
+}
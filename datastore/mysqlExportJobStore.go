@@ -0,0 +1,19 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *mysqlProvider) CreateExportJobStore() {
+	RdbCreateExportJobStore()
+}
+
+func (p *mysqlProvider) InsertExportJob(exportJob *models.ExportJob) StoreResult {
+	return RdbInsertExportJob(exportJob)
+}
+
+func (p *mysqlProvider) SelectExportJob(exportJobId string) StoreResult {
+	return RdbSelectExportJob(exportJobId)
+}
+
+func (p *mysqlProvider) UpdateExportJob(exportJob *models.ExportJob) StoreResult {
+	return RdbUpdateExportJob(exportJob)
+}
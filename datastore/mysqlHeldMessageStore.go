@@ -0,0 +1,27 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *mysqlProvider) CreateHeldMessageStore() {
+	RdbCreateHeldMessageStore()
+}
+
+func (p *mysqlProvider) InsertHeldMessage(heldMessage *models.HeldMessage) StoreResult {
+	return RdbInsertHeldMessage(heldMessage)
+}
+
+func (p *mysqlProvider) SelectHeldMessage(heldMessageId string) StoreResult {
+	return RdbSelectHeldMessage(heldMessageId)
+}
+
+func (p *mysqlProvider) SelectHeldMessages(status string, limit, offset int) StoreResult {
+	return RdbSelectHeldMessages(status, limit, offset)
+}
+
+func (p *mysqlProvider) UpdateHeldMessage(heldMessage *models.HeldMessage) StoreResult {
+	return RdbUpdateHeldMessage(heldMessage)
+}
+
+func (p *mysqlProvider) ClaimHeldMessage(heldMessage *models.HeldMessage, status string) StoreResult {
+	return RdbClaimHeldMessage(heldMessage, status)
+}
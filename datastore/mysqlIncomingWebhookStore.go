@@ -0,0 +1,27 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *mysqlProvider) CreateIncomingWebhookStore() {
+	RdbCreateIncomingWebhookStore()
+}
+
+func (p *mysqlProvider) InsertIncomingWebhook(incomingWebhook *models.IncomingWebhook) StoreResult {
+	return RdbInsertIncomingWebhook(incomingWebhook)
+}
+
+func (p *mysqlProvider) SelectIncomingWebhook(incomingWebhookId string) StoreResult {
+	return RdbSelectIncomingWebhook(incomingWebhookId)
+}
+
+func (p *mysqlProvider) SelectIncomingWebhookBySecret(secret string) StoreResult {
+	return RdbSelectIncomingWebhookBySecret(secret)
+}
+
+func (p *mysqlProvider) SelectIncomingWebhooks(roomId string) StoreResult {
+	return RdbSelectIncomingWebhooks(roomId)
+}
+
+func (p *mysqlProvider) UpdateIncomingWebhook(incomingWebhook *models.IncomingWebhook) StoreResult {
+	return RdbUpdateIncomingWebhook(incomingWebhook)
+}
@@ -6,8 +6,12 @@ func (p *mysqlProvider) CreateMessageStore() {
 	RdbCreateMessageStore()
 }
 
-func (p *mysqlProvider) InsertMessage(message *models.Message) StoreResult {
-	return RdbInsertMessage(message)
+func (p *mysqlProvider) InsertMessage(message *models.Message, outboxItems []*models.OutboxItem) StoreResult {
+	return RdbInsertMessage(message, outboxItems)
+}
+
+func (p *mysqlProvider) InsertMessages(messages []*models.Message, outboxItems []*models.OutboxItem, heldMessages []*models.HeldMessage) StoreResult {
+	return RdbInsertMessages(messages, outboxItems, heldMessages)
 }
 
 func (p *mysqlProvider) SelectMessage(messageId string) StoreResult {
@@ -22,6 +26,22 @@ func (p *mysqlProvider) SelectCountMessagesByRoomId(roomId string) StoreResult {
 	return RdbSelectCountMessagesByRoomId(roomId)
 }
 
+func (p *mysqlProvider) SelectExportMessages(roomId string, from, to int64, includeDeleted bool, afterId uint64, limit int) StoreResult {
+	return RdbSelectExportMessages(roomId, from, to, includeDeleted, afterId, limit)
+}
+
+func (p *mysqlProvider) SelectCountExportMessages(roomId string, from, to int64, includeDeleted bool) StoreResult {
+	return RdbSelectCountExportMessages(roomId, from, to, includeDeleted)
+}
+
 func (p *mysqlProvider) UpdateMessage(message *models.Message) StoreResult {
 	return RdbUpdateMessage(message)
 }
+
+func (p *mysqlProvider) SelectExpiredMessages(roomId string, cutoff int64, limit int) StoreResult {
+	return RdbSelectExpiredMessages(roomId, cutoff, limit)
+}
+
+func (p *mysqlProvider) DeleteMessages(roomId string, messageIds []string) StoreResult {
+	return RdbDeleteMessages(roomId, messageIds)
+}
@@ -0,0 +1,31 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *mysqlProvider) CreateOutboxItemStore() {
+	RdbCreateOutboxItemStore()
+}
+
+func (p *mysqlProvider) SelectOutboxItem(outboxItemId string) StoreResult {
+	return RdbSelectOutboxItem(outboxItemId)
+}
+
+func (p *mysqlProvider) SelectOutboxItems(status, kind string, limit, offset int) StoreResult {
+	return RdbSelectOutboxItems(status, kind, limit, offset)
+}
+
+func (p *mysqlProvider) SelectRetryableOutboxItems(now int64, limit int) StoreResult {
+	return RdbSelectRetryableOutboxItems(now, limit)
+}
+
+func (p *mysqlProvider) UpdateOutboxItem(item *models.OutboxItem) StoreResult {
+	return RdbUpdateOutboxItem(item)
+}
+
+func (p *mysqlProvider) ClaimOutboxItem(item *models.OutboxItem, nextAttempt int64) StoreResult {
+	return RdbClaimOutboxItem(item, nextAttempt)
+}
+
+func (p *mysqlProvider) DeleteSucceededOutboxItems(modified int64) StoreResult {
+	return RdbDeleteSucceededOutboxItems(modified)
+}
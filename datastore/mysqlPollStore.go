@@ -0,0 +1,35 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *mysqlProvider) CreatePollStore() {
+	RdbCreatePollStore()
+}
+
+func (p *mysqlProvider) SelectPoll(messageId string) StoreResult {
+	return RdbSelectPoll(messageId)
+}
+
+func (p *mysqlProvider) SelectPolls(messageIds []string) StoreResult {
+	return RdbSelectPolls(messageIds)
+}
+
+func (p *mysqlProvider) SelectExpiredPolls(now int64) StoreResult {
+	return RdbSelectExpiredPolls(now)
+}
+
+func (p *mysqlProvider) UpdatePoll(poll *models.Poll) StoreResult {
+	return RdbUpdatePoll(poll)
+}
+
+func (p *mysqlProvider) SelectPollVotes(messageId string) StoreResult {
+	return RdbSelectPollVotes(messageId)
+}
+
+func (p *mysqlProvider) SelectPollVotesByMessageIds(messageIds []string) StoreResult {
+	return RdbSelectPollVotesByMessageIds(messageIds)
+}
+
+func (p *mysqlProvider) ReplacePollVotes(messageId, userId string, votes []*models.PollVote) StoreResult {
+	return RdbReplacePollVotes(messageId, userId, votes)
+}
@@ -0,0 +1,31 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *mysqlProvider) CreatePresenceStore() {
+	RdbCreatePresenceStore()
+}
+
+func (p *mysqlProvider) InsertPresence(presence *models.Presence) StoreResult {
+	return RdbInsertPresence(presence)
+}
+
+func (p *mysqlProvider) SelectPresence(userId string) StoreResult {
+	return RdbSelectPresence(userId)
+}
+
+func (p *mysqlProvider) SelectPresences(userIds []string) StoreResult {
+	return RdbSelectPresences(userIds)
+}
+
+func (p *mysqlProvider) SelectExpiredPresences(lastSeen int64) StoreResult {
+	return RdbSelectExpiredPresences(lastSeen)
+}
+
+func (p *mysqlProvider) UpdatePresence(presence *models.Presence) StoreResult {
+	return RdbUpdatePresence(presence)
+}
+
+func (p *mysqlProvider) UpdatePresencesLastSeen(userIds []string, lastSeen int64) StoreResult {
+	return RdbUpdatePresencesLastSeen(userIds, lastSeen)
+}
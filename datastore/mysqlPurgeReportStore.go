@@ -0,0 +1,19 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *mysqlProvider) CreatePurgeReportStore() {
+	RdbCreatePurgeReportStore()
+}
+
+func (p *mysqlProvider) InsertPurgeReport(purgeReport *models.PurgeReport) StoreResult {
+	return RdbInsertPurgeReport(purgeReport)
+}
+
+func (p *mysqlProvider) SelectPurgeReport(purgeReportId string) StoreResult {
+	return RdbSelectPurgeReport(purgeReportId)
+}
+
+func (p *mysqlProvider) SelectPurgeReports(limit, offset int) StoreResult {
+	return RdbSelectPurgeReports(limit, offset)
+}
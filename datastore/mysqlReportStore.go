@@ -0,0 +1,23 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *mysqlProvider) CreateReportStore() {
+	RdbCreateReportStore()
+}
+
+func (p *mysqlProvider) InsertReport(report *models.Report) StoreResult {
+	return RdbInsertReport(report)
+}
+
+func (p *mysqlProvider) SelectReport(reportId string) StoreResult {
+	return RdbSelectReport(reportId)
+}
+
+func (p *mysqlProvider) SelectReports(status string, limit, offset int) StoreResult {
+	return RdbSelectReports(status, limit, offset)
+}
+
+func (p *mysqlProvider) UpdateReport(report *models.Report) StoreResult {
+	return RdbUpdateReport(report)
+}
@@ -26,6 +26,10 @@ func (p *mysqlProvider) SelectCountRooms() StoreResult {
 	return RdbSelectCountRooms()
 }
 
+func (p *mysqlProvider) SelectRetentionRooms(globalDays int64) StoreResult {
+	return RdbSelectRetentionRooms(globalDays)
+}
+
 func (p *mysqlProvider) UpdateRoom(room *models.Room) StoreResult {
 	return RdbUpdateRoom(room)
 }
@@ -34,6 +34,14 @@ func (p *mysqlProvider) SelectRoomUsersByRoomIdAndUserIds(roomId *string, userId
 	return RdbSelectRoomUsersByRoomIdAndUserIds(roomId, userIds)
 }
 
+func (p *mysqlProvider) SelectRecipientUserIds(roomId, senderUserId string) StoreResult {
+	return RdbSelectRecipientUserIds(roomId, senderUserId)
+}
+
+func (p *mysqlProvider) SelectPushSettings(roomId, senderUserId string) StoreResult {
+	return RdbSelectPushSettings(roomId, senderUserId)
+}
+
 func (p *mysqlProvider) UpdateRoomUser(roomUser *models.RoomUser) StoreResult {
 	return RdbUpdateRoomUser(roomUser)
 }
@@ -41,3 +49,7 @@ func (p *mysqlProvider) UpdateRoomUser(roomUser *models.RoomUser) StoreResult {
 func (p *mysqlProvider) DeleteRoomUser(roomId string, userIds []string) StoreResult {
 	return RdbDeleteRoomUser(roomId, userIds)
 }
+
+func (p *mysqlProvider) UpdateRoomUserDraft(roomId, userId, draft string, draftUpdated int64) StoreResult {
+	return RdbUpdateRoomUserDraft(roomId, userId, draft, draftUpdated)
+}
@@ -0,0 +1,23 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *mysqlProvider) CreateSlashCommandStore() {
+	RdbCreateSlashCommandStore()
+}
+
+func (p *mysqlProvider) InsertSlashCommand(slashCommand *models.SlashCommand) StoreResult {
+	return RdbInsertSlashCommand(slashCommand)
+}
+
+func (p *mysqlProvider) SelectSlashCommand(commandId string) StoreResult {
+	return RdbSelectSlashCommand(commandId)
+}
+
+func (p *mysqlProvider) SelectSlashCommands(roomId string) StoreResult {
+	return RdbSelectSlashCommands(roomId)
+}
+
+func (p *mysqlProvider) UpdateSlashCommand(slashCommand *models.SlashCommand) StoreResult {
+	return RdbUpdateSlashCommand(slashCommand)
+}
@@ -29,3 +29,11 @@ func (p *mysqlProvider) SelectDeletedSubscriptionsByUserIdAndPlatform(userId str
 func (p *mysqlProvider) DeleteSubscription(subscription *models.Subscription) StoreResult {
 	return RdbDeleteSubscription(subscription)
 }
+
+func (p *mysqlProvider) SelectSubscriptionsByRoomId(roomId string) StoreResult {
+	return RdbSelectSubscriptionsByRoomId(roomId)
+}
+
+func (p *mysqlProvider) DeleteSubscriptionsByUserIdAndPlatform(userId string, platform int) StoreResult {
+	return RdbDeleteSubscriptionsByUserIdAndPlatform(userId, platform)
+}
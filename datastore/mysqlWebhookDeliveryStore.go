@@ -0,0 +1,31 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *mysqlProvider) CreateWebhookDeliveryStore() {
+	RdbCreateWebhookDeliveryStore()
+}
+
+func (p *mysqlProvider) InsertWebhookDelivery(delivery *models.WebhookDelivery) StoreResult {
+	return RdbInsertWebhookDelivery(delivery)
+}
+
+func (p *mysqlProvider) SelectWebhookDelivery(deliveryId string) StoreResult {
+	return RdbSelectWebhookDelivery(deliveryId)
+}
+
+func (p *mysqlProvider) SelectWebhookDeliveries(webhookId, status string, limit, offset int) StoreResult {
+	return RdbSelectWebhookDeliveries(webhookId, status, limit, offset)
+}
+
+func (p *mysqlProvider) SelectRetryableWebhookDeliveries(now int64, limit int) StoreResult {
+	return RdbSelectRetryableWebhookDeliveries(now, limit)
+}
+
+func (p *mysqlProvider) UpdateWebhookDelivery(delivery *models.WebhookDelivery) StoreResult {
+	return RdbUpdateWebhookDelivery(delivery)
+}
+
+func (p *mysqlProvider) ClaimWebhookDelivery(delivery *models.WebhookDelivery, nextAttempt int64) StoreResult {
+	return RdbClaimWebhookDelivery(delivery, nextAttempt)
+}
@@ -0,0 +1,23 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *mysqlProvider) CreateWebhookStore() {
+	RdbCreateWebhookStore()
+}
+
+func (p *mysqlProvider) InsertWebhook(webhook *models.Webhook) StoreResult {
+	return RdbInsertWebhook(webhook)
+}
+
+func (p *mysqlProvider) SelectWebhook(webhookId string) StoreResult {
+	return RdbSelectWebhook(webhookId)
+}
+
+func (p *mysqlProvider) SelectWebhooks() StoreResult {
+	return RdbSelectWebhooks()
+}
+
+func (p *mysqlProvider) UpdateWebhook(webhook *models.Webhook) StoreResult {
+	return RdbUpdateWebhook(webhook)
+}
@@ -0,0 +1,14 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+type OutboxItemStore interface {
+	CreateOutboxItemStore()
+
+	SelectOutboxItem(outboxItemId string) StoreResult
+	SelectOutboxItems(status, kind string, limit, offset int) StoreResult
+	SelectRetryableOutboxItems(now int64, limit int) StoreResult
+	UpdateOutboxItem(item *models.OutboxItem) StoreResult
+	ClaimOutboxItem(item *models.OutboxItem, nextAttempt int64) StoreResult
+	DeleteSucceededOutboxItems(modified int64) StoreResult
+}
@@ -0,0 +1,15 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+type PollStore interface {
+	CreatePollStore()
+
+	SelectPoll(messageId string) StoreResult
+	SelectPolls(messageIds []string) StoreResult
+	SelectExpiredPolls(now int64) StoreResult
+	UpdatePoll(poll *models.Poll) StoreResult
+	SelectPollVotes(messageId string) StoreResult
+	SelectPollVotesByMessageIds(messageIds []string) StoreResult
+	ReplacePollVotes(messageId, userId string, votes []*models.PollVote) StoreResult
+}
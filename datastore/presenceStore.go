@@ -0,0 +1,14 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+type PresenceStore interface {
+	CreatePresenceStore()
+
+	InsertPresence(presence *models.Presence) StoreResult
+	SelectPresence(userId string) StoreResult
+	SelectPresences(userIds []string) StoreResult
+	SelectExpiredPresences(lastSeen int64) StoreResult
+	UpdatePresence(presence *models.Presence) StoreResult
+	UpdatePresencesLastSeen(userIds []string, lastSeen int64) StoreResult
+}
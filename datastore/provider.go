@@ -30,6 +30,18 @@ type Provider interface {
 	MessageStore
 	DeviceStore
 	SubscriptionStore
+	HeldMessageStore
+	ReportStore
+	WebhookStore
+	WebhookDeliveryStore
+	IncomingWebhookStore
+	SlashCommandStore
+	PollStore
+	ExportJobStore
+	PurgeReportStore
+	OutboxItemStore
+	PresenceStore
+	ChangeLogStore
 }
 
 func GetProvider() Provider {
@@ -0,0 +1,11 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+type PurgeReportStore interface {
+	CreatePurgeReportStore()
+
+	InsertPurgeReport(purgeReport *models.PurgeReport) StoreResult
+	SelectPurgeReport(purgeReportId string) StoreResult
+	SelectPurgeReports(limit, offset int) StoreResult
+}
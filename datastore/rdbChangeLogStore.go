@@ -0,0 +1,166 @@
+package datastore
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+	gorp "gopkg.in/gorp.v2"
+)
+
+func RdbCreateChangeLogStore() {
+	master := RdbStoreInstance().master()
+	tableMap := master.AddTableWithName(models.ChangeLog{}, TABLE_NAME_CHANGE_LOG)
+	tableMap.SetKeys(true, "id")
+	seqTableMap := master.AddTableWithName(models.ChangeLogSeq{}, TABLE_NAME_CHANGE_LOG_SEQ)
+	seqTableMap.SetKeys(true, "id")
+	for _, columnMap := range seqTableMap.Columns {
+		if columnMap.ColumnName == "user_id" {
+			columnMap.SetUnique(true)
+		}
+	}
+	if err := master.CreateTablesIfNotExists(); err != nil {
+		log.Println(err)
+	}
+
+	var addIndexQuery string
+	if utils.Cfg.Datastore.Provider == "sqlite" {
+		addIndexQuery = utils.AppendStrings("CREATE INDEX IF NOT EXISTS user_id_seq ON ", TABLE_NAME_CHANGE_LOG, "(user_id, seq)")
+	} else {
+		addIndexQuery = utils.AppendStrings("ALTER TABLE ", TABLE_NAME_CHANGE_LOG, " ADD INDEX user_id_seq (user_id, seq)")
+	}
+	if _, err := master.Exec(addIndexQuery); err != nil {
+		errMessage := err.Error()
+		if strings.Index(errMessage, "Duplicate key name") < 0 {
+			log.Println(errMessage)
+		}
+	}
+}
+
+type changeLogsByUserId []*models.ChangeLog
+
+func (c changeLogsByUserId) Len() int           { return len(c) }
+func (c changeLogsByUserId) Less(i, j int) bool { return c[i].UserId < c[j].UserId }
+func (c changeLogsByUserId) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+// RdbInsertChangeLogs inserts the change log entries of an event in a single transaction.
+// Each entry takes the next number in the sequence of its user. The row of the sequence stays locked until the commit,
+// so that the entries of a user are committed in the order of their numbers and a sync never passes one still in flight.
+func RdbInsertChangeLogs(changeLogs []*models.ChangeLog) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	trans, err := master.Begin()
+	if err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while creating change log items.", err)
+		return result
+	}
+	// The sequences are locked in the order of the users, so that two events never wait for each other
+	sort.Sort(changeLogsByUserId(changeLogs))
+	for _, changeLog := range changeLogs {
+		seq, err := nextChangeLogSeq(trans, changeLog.UserId)
+		if err == nil {
+			changeLog.Seq = seq
+			err = trans.Insert(changeLog)
+		}
+		if err != nil {
+			result.ProblemDetail = createProblemDetail("An error occurred while creating change log items.", err)
+			if err := trans.Rollback(); err != nil {
+				result.ProblemDetail = createProblemDetail("An error occurred while rollback creating change log items.", err)
+			}
+			return result
+		}
+	}
+	if err := trans.Commit(); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while commit creating change log items.", err)
+	}
+	return result
+}
+
+// nextChangeLogSeq increments the sequence of the user, and returns its new number.
+func nextChangeLogSeq(trans *gorp.Transaction, userId string) (uint64, error) {
+	query := utils.AppendStrings("UPDATE ", TABLE_NAME_CHANGE_LOG_SEQ, " SET seq=seq+1 WHERE user_id=:userId;")
+	params := map[string]interface{}{"userId": userId}
+	res, err := trans.Exec(query, params)
+	if err != nil {
+		return 0, err
+	}
+	if count, _ := res.RowsAffected(); count == 0 {
+		// The first change of the user starts the sequence, unless another event has just started it
+		if err := trans.Insert(&models.ChangeLogSeq{UserId: userId, Seq: 1}); err == nil {
+			return 1, nil
+		}
+		res, err = trans.Exec(query, params)
+		if err != nil {
+			return 0, err
+		}
+		if count, _ := res.RowsAffected(); count == 0 {
+			return 0, errors.New(utils.AppendStrings("the change log sequence of ", userId, " could not be started"))
+		}
+	}
+	query = utils.AppendStrings("SELECT seq FROM ", TABLE_NAME_CHANGE_LOG_SEQ, " WHERE user_id=:userId;")
+	seq, err := trans.SelectInt(query, params)
+	return uint64(seq), err
+}
+
+// RdbSelectChangeLogs returns the changes of the user after sinceSeq, oldest first.
+// It reads the master so that a sync does not miss a change that has not been replicated yet.
+func RdbSelectChangeLogs(userId string, sinceSeq uint64, limit int) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	var changeLogs []*models.ChangeLog
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_CHANGE_LOG, " ",
+		"WHERE user_id=:userId AND seq>:sinceSeq ",
+		"ORDER BY seq ",
+		"LIMIT :limit;")
+	params := map[string]interface{}{
+		"userId":   userId,
+		"sinceSeq": sinceSeq,
+		"limit":    limit,
+	}
+	if _, err := master.Select(&changeLogs, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting change log items.", err)
+	}
+	result.Data = changeLogs
+	return result
+}
+
+// RdbSelectChangeLogSeqRange returns the oldest number of the changes the user has left, and the last number of the sequence of the user.
+// Both are 0 when the user has had no change, and the oldest is 0 when all the changes have been purged.
+func RdbSelectChangeLogSeqRange(userId string) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	params := map[string]interface{}{"userId": userId}
+	query := utils.AppendStrings("SELECT COALESCE(MIN(seq), 0) FROM ", TABLE_NAME_CHANGE_LOG, " WHERE user_id=:userId;")
+	minSeq, err := master.SelectInt(query, params)
+	if err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting change log items.", err)
+		return result
+	}
+	query = utils.AppendStrings("SELECT COALESCE(MAX(seq), 0) FROM ", TABLE_NAME_CHANGE_LOG_SEQ, " WHERE user_id=:userId;")
+	maxSeq, err := master.SelectInt(query, params)
+	if err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting change log items.", err)
+		return result
+	}
+	result.Data = []uint64{uint64(minSeq), uint64(maxSeq)}
+	return result
+}
+
+// RdbDeleteChangeLogs deletes the changes created before created.
+func RdbDeleteChangeLogs(created int64) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	query := utils.AppendStrings("DELETE FROM ", TABLE_NAME_CHANGE_LOG, " WHERE created<:created;")
+	params := map[string]interface{}{"created": created}
+	res, err := master.Exec(query, params)
+	if err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while deleting change log items.", err)
+		return result
+	}
+	count, _ := res.RowsAffected()
+	result.Data = count
+	return result
+}
@@ -115,10 +115,12 @@ func RdbUpdateDevice(device *models.Device) StoreResult {
 		return result
 	}
 
-	query = utils.AppendStrings("UPDATE ", TABLE_NAME_DEVICE, " SET token=:token, notification_device_id=:notificationDeviceId WHERE user_id=:userId AND platform=:platform;")
+	query = utils.AppendStrings("UPDATE ", TABLE_NAME_DEVICE, " SET token=:token, notification_device_id=:notificationDeviceId, p256dh=:p256dh, auth=:auth WHERE user_id=:userId AND platform=:platform;")
 	params = map[string]interface{}{
 		"token":                device.Token,
 		"notificationDeviceId": device.NotificationDeviceId,
+		"p256dh":               device.P256dh,
+		"auth":                 device.Auth,
 		"userId":               device.UserId,
 		"platform":             device.Platform,
 	}
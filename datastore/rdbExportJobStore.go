@@ -0,0 +1,57 @@
+package datastore
+
+import (
+	"log"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func RdbCreateExportJobStore() {
+	master := RdbStoreInstance().master()
+	tableMap := master.AddTableWithName(models.ExportJob{}, TABLE_NAME_EXPORT_JOB)
+	tableMap.SetKeys(true, "id")
+	for _, columnMap := range tableMap.Columns {
+		if columnMap.ColumnName == "export_job_id" {
+			columnMap.SetUnique(true)
+		}
+	}
+	if err := master.CreateTablesIfNotExists(); err != nil {
+		log.Println(err)
+	}
+}
+
+func RdbInsertExportJob(exportJob *models.ExportJob) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	if err := master.Insert(exportJob); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while creating export job item.", err)
+	}
+	result.Data = exportJob
+	return result
+}
+
+func RdbSelectExportJob(exportJobId string) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var exportJobs []*models.ExportJob
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_EXPORT_JOB, " WHERE export_job_id=:exportJobId;")
+	params := map[string]interface{}{"exportJobId": exportJobId}
+	if _, err := slave.Select(&exportJobs, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting export job item.", err)
+	}
+	if len(exportJobs) == 1 {
+		result.Data = exportJobs[0]
+	}
+	return result
+}
+
+func RdbUpdateExportJob(exportJob *models.ExportJob) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	if _, err := master.Update(exportJob); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while updating export job item.", err)
+	}
+	result.Data = exportJob
+	return result
+}
@@ -0,0 +1,110 @@
+package datastore
+
+import (
+	"log"
+	"time"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func RdbCreateHeldMessageStore() {
+	master := RdbStoreInstance().master()
+	tableMap := master.AddTableWithName(models.HeldMessage{}, TABLE_NAME_HELD_MESSAGE)
+	tableMap.SetKeys(true, "id")
+	for _, columnMap := range tableMap.Columns {
+		if columnMap.ColumnName == "held_message_id" {
+			columnMap.SetUnique(true)
+		}
+	}
+	if err := master.CreateTablesIfNotExists(); err != nil {
+		log.Println(err)
+	}
+}
+
+func RdbInsertHeldMessage(heldMessage *models.HeldMessage) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	if err := master.Insert(heldMessage); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while creating held message item.", err)
+	}
+	result.Data = heldMessage
+	return result
+}
+
+func RdbSelectHeldMessage(heldMessageId string) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var heldMessages []*models.HeldMessage
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_HELD_MESSAGE, " WHERE held_message_id=:heldMessageId;")
+	params := map[string]interface{}{"heldMessageId": heldMessageId}
+	if _, err := slave.Select(&heldMessages, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting held message item.", err)
+	}
+	if len(heldMessages) == 1 {
+		result.Data = heldMessages[0]
+	}
+	return result
+}
+
+func RdbSelectHeldMessages(status string, limit, offset int) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var heldMessages []*models.HeldMessage
+	query := utils.AppendStrings("SELECT * ",
+		"FROM ", TABLE_NAME_HELD_MESSAGE, " ",
+		"WHERE status=:status ",
+		"ORDER BY created ASC ",
+		"LIMIT :limit ",
+		"OFFSET :offset;")
+	params := map[string]interface{}{
+		"status": status,
+		"limit":  limit,
+		"offset": offset,
+	}
+	if _, err := slave.Select(&heldMessages, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting held message items.", err)
+	}
+	result.Data = heldMessages
+	return result
+}
+
+func RdbUpdateHeldMessage(heldMessage *models.HeldMessage) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	if _, err := master.Update(heldMessage); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while updating held message item.", err)
+	}
+	result.Data = heldMessage
+	return result
+}
+
+// RdbClaimHeldMessage resolves the held message with status unless it has been resolved by another request first.
+// Data is whether the held message was claimed.
+func RdbClaimHeldMessage(heldMessage *models.HeldMessage, status string) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	modified := time.Now().Unix()
+	query := utils.AppendStrings("UPDATE ", TABLE_NAME_HELD_MESSAGE, " ",
+		"SET status=:status, modified=:modified ",
+		"WHERE held_message_id=:heldMessageId ",
+		"AND status=:heldStatus;")
+	params := map[string]interface{}{
+		"status":        status,
+		"modified":      modified,
+		"heldMessageId": heldMessage.HeldMessageId,
+		"heldStatus":    models.HELD_MESSAGE_STATUS_HELD,
+	}
+	res, err := master.Exec(query, params)
+	if err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while updating held message item.", err)
+		return result
+	}
+	count, _ := res.RowsAffected()
+	if count == 1 {
+		heldMessage.Status = status
+		heldMessage.Modified = modified
+	}
+	result.Data = count == 1
+	return result
+}
@@ -0,0 +1,87 @@
+package datastore
+
+import (
+	"log"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func RdbCreateIncomingWebhookStore() {
+	master := RdbStoreInstance().master()
+	tableMap := master.AddTableWithName(models.IncomingWebhook{}, TABLE_NAME_INCOMING_WEBHOOK)
+	tableMap.SetKeys(true, "id")
+	for _, columnMap := range tableMap.Columns {
+		if columnMap.ColumnName == "incoming_webhook_id" || columnMap.ColumnName == "secret" {
+			columnMap.SetUnique(true)
+		}
+	}
+	if err := master.CreateTablesIfNotExists(); err != nil {
+		log.Println(err)
+	}
+}
+
+func RdbInsertIncomingWebhook(incomingWebhook *models.IncomingWebhook) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	if err := master.Insert(incomingWebhook); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while creating incoming webhook item.", err)
+	}
+	result.Data = incomingWebhook
+	return result
+}
+
+func RdbSelectIncomingWebhook(incomingWebhookId string) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var incomingWebhooks []*models.IncomingWebhook
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_INCOMING_WEBHOOK, " WHERE incoming_webhook_id=:incomingWebhookId;")
+	params := map[string]interface{}{"incomingWebhookId": incomingWebhookId}
+	if _, err := slave.Select(&incomingWebhooks, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting incoming webhook item.", err)
+	}
+	if len(incomingWebhooks) == 1 {
+		result.Data = incomingWebhooks[0]
+	}
+	return result
+}
+
+func RdbSelectIncomingWebhookBySecret(secret string) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var incomingWebhooks []*models.IncomingWebhook
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_INCOMING_WEBHOOK, " WHERE secret=:secret AND revoked=0;")
+	params := map[string]interface{}{"secret": secret}
+	if _, err := slave.Select(&incomingWebhooks, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting incoming webhook item.", err)
+	}
+	if len(incomingWebhooks) == 1 {
+		result.Data = incomingWebhooks[0]
+	}
+	return result
+}
+
+// RdbSelectIncomingWebhooks returns every hook including revoked ones.
+// An empty roomId matches every room.
+func RdbSelectIncomingWebhooks(roomId string) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var incomingWebhooks []*models.IncomingWebhook
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_INCOMING_WEBHOOK, " WHERE (room_id=:roomId OR :roomId='') ORDER BY created ASC;")
+	params := map[string]interface{}{"roomId": roomId}
+	if _, err := slave.Select(&incomingWebhooks, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting incoming webhook items.", err)
+	}
+	result.Data = incomingWebhooks
+	return result
+}
+
+func RdbUpdateIncomingWebhook(incomingWebhook *models.IncomingWebhook) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	if _, err := master.Update(incomingWebhook); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while updating incoming webhook item.", err)
+	}
+	result.Data = incomingWebhook
+	return result
+}
@@ -1,13 +1,13 @@
 package datastore
 
 import (
-	"encoding/json"
 	"log"
 	"strings"
 	"time"
 
 	"github.com/swagchat/chat-api/models"
 	"github.com/swagchat/chat-api/utils"
+	gorp "gopkg.in/gorp.v2"
 )
 
 func RdbCreateMessageStore() {
@@ -38,53 +38,132 @@ func RdbCreateMessageStore() {
 	}
 }
 
-func RdbInsertMessage(message *models.Message) StoreResult {
+// RdbInsertMessage inserts the message and its outbox items in a single transaction.
+func RdbInsertMessage(message *models.Message, outboxItems []*models.OutboxItem) StoreResult {
 	master := RdbStoreInstance().master()
 	trans, err := master.Begin()
 	result := StoreResult{}
-	if err = trans.Insert(message); err != nil {
+	if err != nil {
 		result.ProblemDetail = createProblemDetail("An error occurred while creating message item.", err)
+		return result
+	}
+
+	lastMessage, pd := rdbInsertMessage(trans, message)
+	if pd == nil {
+		pd = rdbInsertOutboxItems(trans, outboxItems)
+	}
+	if pd != nil {
+		result.ProblemDetail = pd
 		if err := trans.Rollback(); err != nil {
 			result.ProblemDetail = createProblemDetail("An error occurred while rollback creating message item.", err)
 		}
 		return result
 	}
 
+	if err := trans.Commit(); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while commit creating message item.", err)
+		return result
+	}
+	result.Data = lastMessage
+	return result
+}
+
+// RdbInsertMessages inserts all messages, their outbox items and the messages held by moderation in a single transaction.
+// Data is a map of room id to the last message of that room.
+func RdbInsertMessages(messages []*models.Message, outboxItems []*models.OutboxItem, heldMessages []*models.HeldMessage) StoreResult {
+	master := RdbStoreInstance().master()
+	trans, err := master.Begin()
+	result := StoreResult{}
+	if err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while creating message items.", err)
+		return result
+	}
+
+	lastMessages := make(map[string]string)
+	for _, message := range messages {
+		lastMessage, pd := rdbInsertMessage(trans, message)
+		if pd != nil {
+			result.ProblemDetail = pd
+			if err := trans.Rollback(); err != nil {
+				result.ProblemDetail = createProblemDetail("An error occurred while rollback creating message items.", err)
+			}
+			return result
+		}
+		if message.Type != models.MESSAGE_TYPE_SYSTEM {
+			lastMessages[message.RoomId] = lastMessage
+		}
+	}
+	if pd := rdbInsertOutboxItems(trans, outboxItems); pd != nil {
+		result.ProblemDetail = pd
+		if err := trans.Rollback(); err != nil {
+			result.ProblemDetail = createProblemDetail("An error occurred while rollback creating message items.", err)
+		}
+		return result
+	}
+	for _, heldMessage := range heldMessages {
+		if err := trans.Insert(heldMessage); err != nil {
+			result.ProblemDetail = createProblemDetail("An error occurred while creating held message item.", err)
+			if err := trans.Rollback(); err != nil {
+				result.ProblemDetail = createProblemDetail("An error occurred while rollback creating message items.", err)
+			}
+			return result
+		}
+	}
+
+	if err := trans.Commit(); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while commit creating message items.", err)
+		return result
+	}
+	result.Data = lastMessages
+	return result
+}
+
+func rdbInsertOutboxItems(trans *gorp.Transaction, outboxItems []*models.OutboxItem) *models.ProblemDetail {
+	for _, item := range outboxItems {
+		if err := trans.Insert(item); err != nil {
+			return createProblemDetail("An error occurred while creating outbox item.", err)
+		}
+	}
+	return nil
+}
+
+func rdbInsertMessage(trans *gorp.Transaction, message *models.Message) (string, *models.ProblemDetail) {
+	if err := trans.Insert(message); err != nil {
+		return "", createProblemDetail("An error occurred while creating message item.", err)
+	}
+
+	if message.Type == models.MESSAGE_TYPE_POLL {
+		if err := trans.Insert(models.NewPoll(message)); err != nil {
+			return "", createProblemDetail("An error occurred while creating poll item.", err)
+		}
+	}
+
+	// System messages are history only unless they are configured to count as unread
+	if message.Type == models.MESSAGE_TYPE_SYSTEM && !utils.Cfg.SystemMessage.UnreadCount {
+		return "", nil
+	}
+
 	var rooms []*models.Room
 	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_ROOM, " WHERE room_id=:roomId AND deleted=0;")
 	params := map[string]interface{}{"roomId": message.RoomId}
 	if _, err := trans.Select(&rooms, query, params); err != nil {
-		result.ProblemDetail = createProblemDetail("An error occurred while getting room item.", err)
-		if err := trans.Rollback(); err != nil {
-			result.ProblemDetail = createProblemDetail("An error occurred while rollback creating message item.", err)
-		}
-		return result
+		return "", createProblemDetail("An error occurred while getting room item.", err)
 	}
 	if len(rooms) != 1 {
-		result.ProblemDetail = createProblemDetail("An error occurred while getting room item.", err)
+		return "", createProblemDetail("An error occurred while getting room item.", nil)
 	}
 
 	room := rooms[0]
-	var lastMessage string
-	switch message.Type {
-	case "text":
-		var payloadText models.PayloadText
-		json.Unmarshal(message.Payload, &payloadText)
-		lastMessage = payloadText.Text
-	case "image":
-		lastMessage = "画像を受信しました"
-	default:
-		lastMessage = "メッセージを受信しました"
-	}
-	room.LastMessage = lastMessage
-	room.LastMessageUpdated = time.Now().Unix()
-	_, err = trans.Update(room)
-	if err != nil {
-		result.ProblemDetail = createProblemDetail("An error occurred while updating room item.", err)
-		if err := trans.Rollback(); err != nil {
-			result.ProblemDetail = createProblemDetail("An error occurred while rollback creating message item.", err)
-		}
-		return result
+	lastMessage := message.LastMessageText()
+	if message.Type == models.MESSAGE_TYPE_SYSTEM {
+		lastMessage = room.LastMessage
+	}
+	if message.Type != models.MESSAGE_TYPE_SYSTEM {
+		room.LastMessage = lastMessage
+		room.LastMessageUpdated = time.Now().Unix()
+	}
+	if _, err := trans.Update(room); err != nil {
+		return "", createProblemDetail("An error occurred while updating room item.", err)
 	}
 
 	query = utils.AppendStrings("UPDATE ", TABLE_NAME_ROOM_USER, " SET unread_count=unread_count+1 WHERE room_id=:roomId AND user_id!=:userId;")
@@ -92,13 +171,19 @@ func RdbInsertMessage(message *models.Message) StoreResult {
 		"roomId": message.RoomId,
 		"userId": message.UserId,
 	}
-	_, err = trans.Exec(query, params)
-	if err != nil {
-		result.ProblemDetail = createProblemDetail("An error occurred while updating room's user unread count.", err)
-		if err := trans.Rollback(); err != nil {
-			result.ProblemDetail = createProblemDetail("An error occurred while rollback creating message item.", err)
+	if _, err := trans.Exec(query, params); err != nil {
+		return "", createProblemDetail("An error occurred while updating room's user unread count.", err)
+	}
+
+	if message.Type != models.MESSAGE_TYPE_SYSTEM {
+		query = utils.AppendStrings("UPDATE ", TABLE_NAME_ROOM_USER, " SET draft='', draft_updated=0 WHERE room_id=:roomId AND user_id=:userId;")
+		params = map[string]interface{}{
+			"roomId": message.RoomId,
+			"userId": message.UserId,
+		}
+		if _, err := trans.Exec(query, params); err != nil {
+			return "", createProblemDetail("An error occurred while clearing room's user draft.", err)
 		}
-		return result
 	}
 
 	var users []*models.User
@@ -108,13 +193,8 @@ func RdbInsertMessage(message *models.Message) StoreResult {
 		"ON ru.user_id = u.user_id ",
 		"WHERE room_id = :roomId;")
 	params = map[string]interface{}{"roomId": message.RoomId}
-	_, err = trans.Select(&users, query, params)
-	if err != nil {
-		result.ProblemDetail = createProblemDetail("An error occurred while getting room's user items.", err)
-		if err := trans.Rollback(); err != nil {
-			result.ProblemDetail = createProblemDetail("An error occurred while rollback creating message item.", err)
-		}
-		return result
+	if _, err := trans.Select(&users, query, params); err != nil {
+		return "", createProblemDetail("An error occurred while getting room's user items.", err)
 	}
 	for _, user := range users {
 		if user.UserId == message.UserId {
@@ -122,23 +202,12 @@ func RdbInsertMessage(message *models.Message) StoreResult {
 		}
 		query := utils.AppendStrings("UPDATE ", TABLE_NAME_USER, " SET unread_count=unread_count+1 WHERE user_id=:userId;")
 		params := map[string]interface{}{"userId": user.UserId}
-		_, err := trans.Exec(query, params)
-		if err != nil {
-			result.ProblemDetail = createProblemDetail("An error occurred while updating user unread count.", err)
-			if err := trans.Rollback(); err != nil {
-				result.ProblemDetail = createProblemDetail("An error occurred while rollback creating message item.", err)
-			}
-			return result
+		if _, err := trans.Exec(query, params); err != nil {
+			return "", createProblemDetail("An error occurred while updating user unread count.", err)
 		}
 	}
 
-	if result.ProblemDetail == nil {
-		if err := trans.Commit(); err != nil {
-			result.ProblemDetail = createProblemDetail("An error occurred while commit creating message item.", err)
-		}
-	}
-	result.Data = lastMessage
-	return result
+	return lastMessage, nil
 }
 
 func RdbSelectMessage(messageId string) StoreResult {
@@ -198,6 +267,62 @@ func RdbSelectCountMessagesByRoomId(roomId string) StoreResult {
 	return result
 }
 
+// RdbSelectExportMessages returns up to limit messages of the room with an id greater than afterId,
+// so that exports can page through the whole history without OFFSET.
+func RdbSelectExportMessages(roomId string, from, to int64, includeDeleted bool, afterId uint64, limit int) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var messages []*models.ExportMessage
+	condition, params := rdbExportMessagesCondition(roomId, from, to, includeDeleted)
+	query := utils.AppendStrings("SELECT m.id, m.message_id, m.room_id, m.user_id, COALESCE(u.name, '') AS user_name, ",
+		"m.type, m.payload, m.created, m.modified, m.deleted ",
+		"FROM ", TABLE_NAME_MESSAGE, " AS m ",
+		"LEFT JOIN ", TABLE_NAME_USER, " AS u ",
+		"ON m.user_id = u.user_id ",
+		"WHERE ", condition, " ",
+		"AND m.id > :afterId ",
+		"ORDER BY m.id ASC ",
+		"LIMIT :limit;")
+	params["afterId"] = afterId
+	params["limit"] = limit
+	if _, err := slave.Select(&messages, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting export message items.", err)
+	}
+	result.Data = messages
+	return result
+}
+
+func RdbSelectCountExportMessages(roomId string, from, to int64, includeDeleted bool) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	condition, params := rdbExportMessagesCondition(roomId, from, to, includeDeleted)
+	query := utils.AppendStrings("SELECT count(m.id) ",
+		"FROM ", TABLE_NAME_MESSAGE, " AS m ",
+		"WHERE ", condition, ";")
+	count, err := slave.SelectInt(query, params)
+	if err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting export message count.", err)
+	}
+	result.Data = count
+	return result
+}
+
+func rdbExportMessagesCondition(roomId string, from, to int64, includeDeleted bool) (string, map[string]interface{}) {
+	condition := "m.room_id = :roomId AND m.created >= :from"
+	params := map[string]interface{}{
+		"roomId": roomId,
+		"from":   from,
+	}
+	if to != 0 {
+		condition = utils.AppendStrings(condition, " AND m.created <= :to")
+		params["to"] = to
+	}
+	if !includeDeleted {
+		condition = utils.AppendStrings(condition, " AND m.deleted = 0")
+	}
+	return condition, params
+}
+
 func RdbUpdateMessage(message *models.Message) StoreResult {
 	master := RdbStoreInstance().master()
 	result := StoreResult{}
@@ -208,3 +333,95 @@ func RdbUpdateMessage(message *models.Message) StoreResult {
 	result.Data = message
 	return result
 }
+
+// RdbSelectExpiredMessages returns messages of the room created before cutoff.
+// Messages of users on legal hold are kept.
+func RdbSelectExpiredMessages(roomId string, cutoff int64, limit int) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var messages []*models.Message
+	query := utils.AppendStrings("SELECT * ",
+		"FROM ", TABLE_NAME_MESSAGE, " ",
+		"WHERE room_id = :roomId ",
+		"AND created < :cutoff ",
+		"AND user_id NOT IN (SELECT user_id FROM ", TABLE_NAME_USER, " WHERE legal_hold = 1) ",
+		"ORDER BY id ASC ",
+		"LIMIT :limit;")
+	params := map[string]interface{}{
+		"roomId": roomId,
+		"cutoff": cutoff,
+		"limit":  limit,
+	}
+	if _, err := slave.Select(&messages, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting expired message items.", err)
+	}
+	result.Data = messages
+	return result
+}
+
+// RdbDeleteMessages hard deletes messages of the room with their polls, held edits, outbox items and webhook deliveries,
+// and redacts the comments of their reports. The reports themselves are kept for the moderation history.
+// The room's last message is set again from the latest remaining message.
+func RdbDeleteMessages(roomId string, messageIds []string) StoreResult {
+	master := RdbStoreInstance().master()
+	trans, err := master.Begin()
+	result := StoreResult{}
+	if err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while deleting message items.", err)
+		return result
+	}
+
+	messageIdsQuery, params := utils.MakePrepareForInExpression(messageIds)
+	queries := make([]string, 0)
+	for _, tableName := range []string{TABLE_NAME_POLL_VOTE, TABLE_NAME_POLL, TABLE_NAME_HELD_MESSAGE, TABLE_NAME_OUTBOX_ITEM, TABLE_NAME_WEBHOOK_DELIVERY, TABLE_NAME_MESSAGE} {
+		queries = append(queries, utils.AppendStrings("DELETE FROM ", tableName, " WHERE message_id IN (", messageIdsQuery, ");"))
+	}
+	queries = append(queries, utils.AppendStrings("UPDATE ", TABLE_NAME_REPORT, " SET comment='' WHERE message_id IN (", messageIdsQuery, ");"))
+	for _, query := range queries {
+		if _, err := trans.Exec(query, params); err != nil {
+			result.ProblemDetail = createProblemDetail("An error occurred while deleting message items.", err)
+			if err := trans.Rollback(); err != nil {
+				result.ProblemDetail = createProblemDetail("An error occurred while rollback deleting message items.", err)
+			}
+			return result
+		}
+	}
+
+	var messages []*models.Message
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_MESSAGE, " ",
+		"WHERE room_id=:roomId AND type!=:systemType AND deleted=0 ",
+		"ORDER BY created DESC, id DESC LIMIT 1;")
+	params = map[string]interface{}{
+		"roomId":     roomId,
+		"systemType": models.MESSAGE_TYPE_SYSTEM,
+	}
+	if _, err := trans.Select(&messages, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting message items.", err)
+		if err := trans.Rollback(); err != nil {
+			result.ProblemDetail = createProblemDetail("An error occurred while rollback deleting message items.", err)
+		}
+		return result
+	}
+	params = map[string]interface{}{
+		"roomId":             roomId,
+		"lastMessage":        "",
+		"lastMessageUpdated": int64(0),
+	}
+	if len(messages) == 1 {
+		params["lastMessage"] = messages[0].LastMessageText()
+		params["lastMessageUpdated"] = messages[0].Created
+	}
+	query = utils.AppendStrings("UPDATE ", TABLE_NAME_ROOM, " SET last_message=:lastMessage, last_message_updated=:lastMessageUpdated WHERE room_id=:roomId;")
+	if _, err := trans.Exec(query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while updating room's last message.", err)
+		if err := trans.Rollback(); err != nil {
+			result.ProblemDetail = createProblemDetail("An error occurred while rollback deleting message items.", err)
+		}
+		return result
+	}
+
+	if err := trans.Commit(); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while commit deleting message items.", err)
+	}
+	return result
+}
@@ -0,0 +1,142 @@
+package datastore
+
+import (
+	"log"
+	"time"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func RdbCreateOutboxItemStore() {
+	master := RdbStoreInstance().master()
+	tableMap := master.AddTableWithName(models.OutboxItem{}, TABLE_NAME_OUTBOX_ITEM)
+	tableMap.SetKeys(true, "id")
+	for _, columnMap := range tableMap.Columns {
+		if columnMap.ColumnName == "outbox_item_id" {
+			columnMap.SetUnique(true)
+		}
+	}
+	if err := master.CreateTablesIfNotExists(); err != nil {
+		log.Println(err)
+	}
+}
+
+func RdbSelectOutboxItem(outboxItemId string) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var items []*models.OutboxItem
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_OUTBOX_ITEM, " WHERE outbox_item_id=:outboxItemId;")
+	params := map[string]interface{}{"outboxItemId": outboxItemId}
+	if _, err := slave.Select(&items, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting outbox item.", err)
+	}
+	if len(items) == 1 {
+		result.Data = items[0]
+	}
+	return result
+}
+
+// RdbSelectOutboxItems returns the outbox, newest first.
+// Empty status or kind matches every value.
+func RdbSelectOutboxItems(status, kind string, limit, offset int) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var items []*models.OutboxItem
+	query := utils.AppendStrings("SELECT * ",
+		"FROM ", TABLE_NAME_OUTBOX_ITEM, " ",
+		"WHERE (status=:status OR :status='') ",
+		"AND (kind=:kind OR :kind='') ",
+		"ORDER BY created DESC, id DESC ",
+		"LIMIT :limit ",
+		"OFFSET :offset;")
+	params := map[string]interface{}{
+		"status": status,
+		"kind":   kind,
+		"limit":  limit,
+		"offset": offset,
+	}
+	if _, err := slave.Select(&items, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting outbox items.", err)
+	}
+	result.Data = items
+	return result
+}
+
+func RdbSelectRetryableOutboxItems(now int64, limit int) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	var items []*models.OutboxItem
+	query := utils.AppendStrings("SELECT * ",
+		"FROM ", TABLE_NAME_OUTBOX_ITEM, " ",
+		"WHERE status=:status ",
+		"AND next_attempt<=:now ",
+		"ORDER BY next_attempt ASC, id ASC ",
+		"LIMIT :limit;")
+	params := map[string]interface{}{
+		"status": models.OUTBOX_ITEM_STATUS_PENDING,
+		"now":    now,
+		"limit":  limit,
+	}
+	if _, err := master.Select(&items, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting outbox items.", err)
+	}
+	result.Data = items
+	return result
+}
+
+func RdbUpdateOutboxItem(item *models.OutboxItem) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	if _, err := master.Update(item); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while updating outbox item.", err)
+	}
+	result.Data = item
+	return result
+}
+
+// RdbClaimOutboxItem moves the next attempt of the item to nextAttempt unless another worker has done it first.
+// Data is true when the item has been claimed.
+func RdbClaimOutboxItem(item *models.OutboxItem, nextAttempt int64) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	query := utils.AppendStrings("UPDATE ", TABLE_NAME_OUTBOX_ITEM, " ",
+		"SET next_attempt=:nextAttempt, modified=:modified ",
+		"WHERE id=:id ",
+		"AND status=:status ",
+		"AND next_attempt=:oldNextAttempt;")
+	params := map[string]interface{}{
+		"nextAttempt":    nextAttempt,
+		"modified":       time.Now().Unix(),
+		"id":             item.Id,
+		"status":         models.OUTBOX_ITEM_STATUS_PENDING,
+		"oldNextAttempt": item.NextAttempt,
+	}
+	res, err := master.Exec(query, params)
+	if err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while updating outbox item.", err)
+		return result
+	}
+	count, _ := res.RowsAffected()
+	result.Data = count == 1
+	return result
+}
+
+// RdbDeleteSucceededOutboxItems deletes the items delivered successfully before modified.
+func RdbDeleteSucceededOutboxItems(modified int64) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	query := utils.AppendStrings("DELETE FROM ", TABLE_NAME_OUTBOX_ITEM, " WHERE status=:status AND modified<:modified;")
+	params := map[string]interface{}{
+		"status":   models.OUTBOX_ITEM_STATUS_SUCCEEDED,
+		"modified": modified,
+	}
+	res, err := master.Exec(query, params)
+	if err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while deleting outbox items.", err)
+		return result
+	}
+	count, _ := res.RowsAffected()
+	result.Data = count
+	return result
+}
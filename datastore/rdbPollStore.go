@@ -0,0 +1,152 @@
+package datastore
+
+import (
+	"log"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func RdbCreatePollStore() {
+	master := RdbStoreInstance().master()
+	tableMap := master.AddTableWithName(models.Poll{}, TABLE_NAME_POLL)
+	tableMap.SetKeys(true, "id")
+	for _, columnMap := range tableMap.Columns {
+		if columnMap.ColumnName == "message_id" {
+			columnMap.SetUnique(true)
+		}
+	}
+	tableMap = master.AddTableWithName(models.PollVote{}, TABLE_NAME_POLL_VOTE)
+	tableMap.SetKeys(true, "id")
+	tableMap.SetUniqueTogether("message_id", "option_id", "user_id")
+	if err := master.CreateTablesIfNotExists(); err != nil {
+		log.Println(err)
+	}
+}
+
+func RdbSelectPoll(messageId string) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var polls []*models.Poll
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_POLL, " WHERE message_id=:messageId;")
+	params := map[string]interface{}{"messageId": messageId}
+	if _, err := slave.Select(&polls, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting poll item.", err)
+	}
+	if len(polls) == 1 {
+		result.Data = polls[0]
+	}
+	return result
+}
+
+func RdbSelectPolls(messageIds []string) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var polls []*models.Poll
+	if len(messageIds) == 0 {
+		result.Data = polls
+		return result
+	}
+	messageIdsQuery, params := utils.MakePrepareForInExpression(messageIds)
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_POLL, " WHERE message_id IN (", messageIdsQuery, ");")
+	if _, err := slave.Select(&polls, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting poll items.", err)
+	}
+	result.Data = polls
+	return result
+}
+
+// RdbSelectExpiredPolls returns open polls whose deadline has passed.
+func RdbSelectExpiredPolls(now int64) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	var polls []*models.Poll
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_POLL, " WHERE closed=0 AND deadline!=0 AND deadline<=:now;")
+	params := map[string]interface{}{"now": now}
+	if _, err := master.Select(&polls, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting poll items.", err)
+	}
+	result.Data = polls
+	return result
+}
+
+func RdbUpdatePoll(poll *models.Poll) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	if _, err := master.Update(poll); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while updating poll item.", err)
+	}
+	result.Data = poll
+	return result
+}
+
+func RdbSelectPollVotes(messageId string) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var votes []*models.PollVote
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_POLL_VOTE, " WHERE message_id=:messageId ORDER BY created ASC, id ASC;")
+	params := map[string]interface{}{"messageId": messageId}
+	if _, err := slave.Select(&votes, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting poll vote items.", err)
+	}
+	result.Data = votes
+	return result
+}
+
+func RdbSelectPollVotesByMessageIds(messageIds []string) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var votes []*models.PollVote
+	if len(messageIds) == 0 {
+		result.Data = votes
+		return result
+	}
+	messageIdsQuery, params := utils.MakePrepareForInExpression(messageIds)
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_POLL_VOTE, " WHERE message_id IN (", messageIdsQuery, ") ORDER BY created ASC, id ASC;")
+	if _, err := slave.Select(&votes, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting poll vote items.", err)
+	}
+	result.Data = votes
+	return result
+}
+
+// RdbReplacePollVotes deletes the user's previous votes on the poll and inserts votes in one transaction.
+func RdbReplacePollVotes(messageId, userId string, votes []*models.PollVote) StoreResult {
+	master := RdbStoreInstance().master()
+	trans, err := master.Begin()
+	result := StoreResult{}
+	if err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while creating poll vote items.", err)
+		return result
+	}
+
+	query := utils.AppendStrings("DELETE FROM ", TABLE_NAME_POLL_VOTE, " WHERE message_id=:messageId AND user_id=:userId;")
+	params := map[string]interface{}{
+		"messageId": messageId,
+		"userId":    userId,
+	}
+	if _, err := trans.Exec(query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while deleting poll vote items.", err)
+		if err := trans.Rollback(); err != nil {
+			result.ProblemDetail = createProblemDetail("An error occurred while rollback creating poll vote items.", err)
+		}
+		return result
+	}
+
+	for _, vote := range votes {
+		if err := trans.Insert(vote); err != nil {
+			result.ProblemDetail = createProblemDetail("An error occurred while creating poll vote item.", err)
+			if err := trans.Rollback(); err != nil {
+				result.ProblemDetail = createProblemDetail("An error occurred while rollback creating poll vote items.", err)
+			}
+			return result
+		}
+	}
+
+	if err := trans.Commit(); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while commit creating poll vote items.", err)
+		return result
+	}
+	result.Data = votes
+	return result
+}
@@ -0,0 +1,106 @@
+package datastore
+
+import (
+	"log"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func RdbCreatePresenceStore() {
+	master := RdbStoreInstance().master()
+	tableMap := master.AddTableWithName(models.Presence{}, TABLE_NAME_PRESENCE)
+	tableMap.SetKeys(true, "id")
+	for _, columnMap := range tableMap.Columns {
+		if columnMap.ColumnName == "user_id" {
+			columnMap.SetUnique(true)
+		}
+	}
+	if err := master.CreateTablesIfNotExists(); err != nil {
+		log.Println(err)
+	}
+}
+
+func RdbInsertPresence(presence *models.Presence) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	if err := master.Insert(presence); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while creating presence item.", err)
+	}
+	result.Data = presence
+	return result
+}
+
+func RdbSelectPresence(userId string) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var presences []*models.Presence
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_PRESENCE, " WHERE user_id=:userId;")
+	params := map[string]interface{}{"userId": userId}
+	if _, err := slave.Select(&presences, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting presence item.", err)
+	}
+	if len(presences) == 1 {
+		result.Data = presences[0]
+	}
+	return result
+}
+
+func RdbSelectPresences(userIds []string) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var presences []*models.Presence
+	if len(userIds) == 0 {
+		result.Data = presences
+		return result
+	}
+	userIdsQuery, params := utils.MakePrepareForInExpression(userIds)
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_PRESENCE, " WHERE user_id IN (", userIdsQuery, ");")
+	if _, err := slave.Select(&presences, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting presence items.", err)
+	}
+	result.Data = presences
+	return result
+}
+
+// RdbSelectExpiredPresences returns the users who are not offline but have not been seen since lastSeen.
+func RdbSelectExpiredPresences(lastSeen int64) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	var presences []*models.Presence
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_PRESENCE, " WHERE status!=:status AND last_seen<:lastSeen;")
+	params := map[string]interface{}{
+		"status":   models.PRESENCE_STATUS_OFFLINE,
+		"lastSeen": lastSeen,
+	}
+	if _, err := master.Select(&presences, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting presence items.", err)
+	}
+	result.Data = presences
+	return result
+}
+
+func RdbUpdatePresence(presence *models.Presence) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	if _, err := master.Update(presence); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while updating presence item.", err)
+	}
+	result.Data = presence
+	return result
+}
+
+func RdbUpdatePresencesLastSeen(userIds []string, lastSeen int64) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	if len(userIds) == 0 {
+		return result
+	}
+	userIdsQuery, params := utils.MakePrepareForInExpression(userIds)
+	params["lastSeen"] = lastSeen
+	query := utils.AppendStrings("UPDATE ", TABLE_NAME_PRESENCE, " SET last_seen=:lastSeen WHERE user_id IN (", userIdsQuery, ");")
+	if _, err := master.Exec(query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while updating presence items.", err)
+	}
+	return result
+}
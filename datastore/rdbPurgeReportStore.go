@@ -0,0 +1,67 @@
+package datastore
+
+import (
+	"log"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func RdbCreatePurgeReportStore() {
+	master := RdbStoreInstance().master()
+	tableMap := master.AddTableWithName(models.PurgeReport{}, TABLE_NAME_PURGE_REPORT)
+	tableMap.SetKeys(true, "id")
+	for _, columnMap := range tableMap.Columns {
+		if columnMap.ColumnName == "purge_report_id" {
+			columnMap.SetUnique(true)
+		}
+	}
+	if err := master.CreateTablesIfNotExists(); err != nil {
+		log.Println(err)
+	}
+}
+
+func RdbInsertPurgeReport(purgeReport *models.PurgeReport) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	if err := master.Insert(purgeReport); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while creating purge report item.", err)
+	}
+	result.Data = purgeReport
+	return result
+}
+
+func RdbSelectPurgeReport(purgeReportId string) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var purgeReports []*models.PurgeReport
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_PURGE_REPORT, " WHERE purge_report_id=:purgeReportId;")
+	params := map[string]interface{}{"purgeReportId": purgeReportId}
+	if _, err := slave.Select(&purgeReports, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting purge report item.", err)
+	}
+	if len(purgeReports) == 1 {
+		result.Data = purgeReports[0]
+	}
+	return result
+}
+
+func RdbSelectPurgeReports(limit, offset int) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var purgeReports []*models.PurgeReport
+	query := utils.AppendStrings("SELECT * ",
+		"FROM ", TABLE_NAME_PURGE_REPORT, " ",
+		"ORDER BY id DESC ",
+		"LIMIT :limit ",
+		"OFFSET :offset;")
+	params := map[string]interface{}{
+		"limit":  limit,
+		"offset": offset,
+	}
+	if _, err := slave.Select(&purgeReports, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting purge report items.", err)
+	}
+	result.Data = purgeReports
+	return result
+}
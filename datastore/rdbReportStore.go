@@ -0,0 +1,79 @@
+package datastore
+
+import (
+	"log"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func RdbCreateReportStore() {
+	master := RdbStoreInstance().master()
+	tableMap := master.AddTableWithName(models.Report{}, TABLE_NAME_REPORT)
+	tableMap.SetKeys(true, "id")
+	for _, columnMap := range tableMap.Columns {
+		if columnMap.ColumnName == "report_id" {
+			columnMap.SetUnique(true)
+		}
+	}
+	if err := master.CreateTablesIfNotExists(); err != nil {
+		log.Println(err)
+	}
+}
+
+func RdbInsertReport(report *models.Report) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	if err := master.Insert(report); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while creating report item.", err)
+	}
+	result.Data = report
+	return result
+}
+
+func RdbSelectReport(reportId string) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var reports []*models.Report
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_REPORT, " WHERE report_id=:reportId;")
+	params := map[string]interface{}{"reportId": reportId}
+	if _, err := slave.Select(&reports, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting report item.", err)
+	}
+	if len(reports) == 1 {
+		result.Data = reports[0]
+	}
+	return result
+}
+
+func RdbSelectReports(status string, limit, offset int) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var reports []*models.Report
+	query := utils.AppendStrings("SELECT * ",
+		"FROM ", TABLE_NAME_REPORT, " ",
+		"WHERE status=:status ",
+		"ORDER BY created ASC ",
+		"LIMIT :limit ",
+		"OFFSET :offset;")
+	params := map[string]interface{}{
+		"status": status,
+		"limit":  limit,
+		"offset": offset,
+	}
+	if _, err := slave.Select(&reports, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting report items.", err)
+	}
+	result.Data = reports
+	return result
+}
+
+func RdbUpdateReport(report *models.Report) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	if _, err := master.Update(report); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while updating report item.", err)
+	}
+	result.Data = report
+	return result
+}
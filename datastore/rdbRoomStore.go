@@ -212,3 +212,19 @@ func RdbUpdateRoomDeleted(roomId string) StoreResult {
 	}
 	return result
 }
+
+// RdbSelectRetentionRooms returns rooms, including deleted ones, whose messages expire and that are not on legal hold.
+func RdbSelectRetentionRooms(globalDays int64) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var rooms []*models.Room
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_ROOM, " ",
+		"WHERE legal_hold = 0 ",
+		"AND (retention_days > 0 OR (retention_days IS NULL AND :globalDays > 0));")
+	params := map[string]interface{}{"globalDays": globalDays}
+	if _, err := slave.Select(&rooms, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting retention room items.", err)
+	}
+	result.Data = rooms
+	return result
+}
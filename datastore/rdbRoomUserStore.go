@@ -184,6 +184,53 @@ func RdbSelectRoomUsersByRoomIdAndUserIds(roomId *string, userIds []string) Stor
 	return result
 }
 
+// RdbSelectRecipientUserIds returns the users of the room, except those who blocked senderUserId.
+// Muted users are returned, as a mute only silences the push notifications of the room.
+func RdbSelectRecipientUserIds(roomId, senderUserId string) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var userIds []string
+	query := utils.AppendStrings("SELECT user_id FROM ", TABLE_NAME_ROOM_USER, " WHERE room_id=:roomId ",
+		"AND user_id NOT IN (SELECT user_id FROM ", TABLE_NAME_BLOCK_USER, " WHERE block_user_id=:senderUserId);")
+	params := map[string]interface{}{
+		"roomId":       roomId,
+		"senderUserId": senderUserId,
+	}
+	if _, err := slave.Select(&userIds, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting room's user items.", err)
+	}
+	result.Data = userIds
+	return result
+}
+
+// RdbSelectPushSettings returns the push settings of the recipients of senderUserId in the room.
+func RdbSelectPushSettings(roomId, senderUserId string) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var pushSettings []*models.PushSetting
+	query := utils.AppendStrings("SELECT ",
+		"ru.user_id, ",
+		"ru.notify, ",
+		"ru.muted_until, ",
+		"u.quiet_hours_start, ",
+		"u.quiet_hours_end, ",
+		"u.quiet_hours_timezone ",
+		"FROM ", TABLE_NAME_ROOM_USER, " AS ru ",
+		"INNER JOIN ", TABLE_NAME_USER, " AS u ON ru.user_id=u.user_id ",
+		"WHERE ru.room_id=:roomId AND ru.user_id!=:senderUserId1 AND u.deleted=0 ",
+		"AND ru.user_id NOT IN (SELECT user_id FROM ", TABLE_NAME_BLOCK_USER, " WHERE block_user_id=:senderUserId2);")
+	params := map[string]interface{}{
+		"roomId":        roomId,
+		"senderUserId1": senderUserId,
+		"senderUserId2": senderUserId,
+	}
+	if _, err := slave.Select(&pushSettings, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting push settings.", err)
+	}
+	result.Data = pushSettings
+	return result
+}
+
 func RdbUpdateRoomUser(roomUser *models.RoomUser) StoreResult {
 	master := RdbStoreInstance().master()
 	trans, err := master.Begin()
@@ -205,6 +252,15 @@ func RdbUpdateRoomUser(roomUser *models.RoomUser) StoreResult {
 			updateQuery = utils.AppendStrings(updateQuery, ",", "meta_data=:metaData")
 		}
 	}
+	if roomUser.Notify != "" {
+		params["notify"] = roomUser.Notify
+		params["mutedUntil"] = roomUser.MutedUntil
+		if updateQuery == "" {
+			updateQuery = "notify=:notify,muted_until=:mutedUntil"
+		} else {
+			updateQuery = utils.AppendStrings(updateQuery, ",", "notify=:notify,muted_until=:mutedUntil")
+		}
+	}
 	if updateQuery != "" {
 		query := utils.AppendStrings("UPDATE ", TABLE_NAME_ROOM_USER, " SET "+updateQuery+" WHERE room_id=:roomId AND user_id=:userId;")
 		_, err = trans.Exec(query, params)
@@ -308,3 +364,19 @@ func RdbDeleteRoomUser(roomId string, userIds []string) StoreResult {
 	}
 	return result
 }
+
+func RdbUpdateRoomUserDraft(roomId, userId, draft string, draftUpdated int64) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	query := utils.AppendStrings("UPDATE ", TABLE_NAME_ROOM_USER, " SET draft=:draft, draft_updated=:draftUpdated WHERE room_id=:roomId AND user_id=:userId;")
+	params := map[string]interface{}{
+		"roomId":       roomId,
+		"userId":       userId,
+		"draft":        draft,
+		"draftUpdated": draftUpdated,
+	}
+	if _, err := master.Exec(query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while updating room's user draft.", err)
+	}
+	return result
+}
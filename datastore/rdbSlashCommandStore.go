@@ -0,0 +1,70 @@
+package datastore
+
+import (
+	"log"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func RdbCreateSlashCommandStore() {
+	master := RdbStoreInstance().master()
+	tableMap := master.AddTableWithName(models.SlashCommand{}, TABLE_NAME_SLASH_COMMAND)
+	tableMap.SetKeys(true, "id")
+	for _, columnMap := range tableMap.Columns {
+		if columnMap.ColumnName == "command_id" {
+			columnMap.SetUnique(true)
+		}
+	}
+	if err := master.CreateTablesIfNotExists(); err != nil {
+		log.Println(err)
+	}
+}
+
+func RdbInsertSlashCommand(slashCommand *models.SlashCommand) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	if err := master.Insert(slashCommand); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while creating command item.", err)
+	}
+	result.Data = slashCommand
+	return result
+}
+
+func RdbSelectSlashCommand(commandId string) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var slashCommands []*models.SlashCommand
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_SLASH_COMMAND, " WHERE command_id=:commandId AND deleted=0;")
+	params := map[string]interface{}{"commandId": commandId}
+	if _, err := slave.Select(&slashCommands, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting command item.", err)
+	}
+	if len(slashCommands) == 1 {
+		result.Data = slashCommands[0]
+	}
+	return result
+}
+
+func RdbSelectSlashCommands(roomId string) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var slashCommands []*models.SlashCommand
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_SLASH_COMMAND, " WHERE room_id=:roomId AND deleted=0 ORDER BY command ASC;")
+	params := map[string]interface{}{"roomId": roomId}
+	if _, err := slave.Select(&slashCommands, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting command items.", err)
+	}
+	result.Data = slashCommands
+	return result
+}
+
+func RdbUpdateSlashCommand(slashCommand *models.SlashCommand) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	if _, err := master.Update(slashCommand); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while updating command item.", err)
+	}
+	result.Data = slashCommand
+	return result
+}
@@ -8,15 +8,29 @@ import (
 )
 
 var (
-	rdbStoreInstance        *rdbStore = nil
-	TABLE_NAME_API                    = utils.Cfg.Datastore.TableNamePrefix + "api"
-	TABLE_NAME_USER                   = utils.Cfg.Datastore.TableNamePrefix + "user"
-	TABLE_NAME_BLOCK_USER             = utils.Cfg.Datastore.TableNamePrefix + "block_user"
-	TABLE_NAME_ROOM                   = utils.Cfg.Datastore.TableNamePrefix + "room"
-	TABLE_NAME_ROOM_USER              = utils.Cfg.Datastore.TableNamePrefix + "room_user"
-	TABLE_NAME_MESSAGE                = utils.Cfg.Datastore.TableNamePrefix + "message"
-	TABLE_NAME_DEVICE                 = utils.Cfg.Datastore.TableNamePrefix + "device"
-	TABLE_NAME_SUBSCRIPTION           = utils.Cfg.Datastore.TableNamePrefix + "subscription"
+	rdbStoreInstance            *rdbStore = nil
+	TABLE_NAME_API                        = utils.Cfg.Datastore.TableNamePrefix + "api"
+	TABLE_NAME_USER                       = utils.Cfg.Datastore.TableNamePrefix + "user"
+	TABLE_NAME_BLOCK_USER                 = utils.Cfg.Datastore.TableNamePrefix + "block_user"
+	TABLE_NAME_ROOM                       = utils.Cfg.Datastore.TableNamePrefix + "room"
+	TABLE_NAME_ROOM_USER                  = utils.Cfg.Datastore.TableNamePrefix + "room_user"
+	TABLE_NAME_MESSAGE                    = utils.Cfg.Datastore.TableNamePrefix + "message"
+	TABLE_NAME_DEVICE                     = utils.Cfg.Datastore.TableNamePrefix + "device"
+	TABLE_NAME_SUBSCRIPTION               = utils.Cfg.Datastore.TableNamePrefix + "subscription"
+	TABLE_NAME_HELD_MESSAGE               = utils.Cfg.Datastore.TableNamePrefix + "held_message"
+	TABLE_NAME_REPORT                     = utils.Cfg.Datastore.TableNamePrefix + "report"
+	TABLE_NAME_WEBHOOK                    = utils.Cfg.Datastore.TableNamePrefix + "webhook"
+	TABLE_NAME_WEBHOOK_DELIVERY           = utils.Cfg.Datastore.TableNamePrefix + "webhook_delivery"
+	TABLE_NAME_INCOMING_WEBHOOK           = utils.Cfg.Datastore.TableNamePrefix + "incoming_webhook"
+	TABLE_NAME_SLASH_COMMAND              = utils.Cfg.Datastore.TableNamePrefix + "slash_command"
+	TABLE_NAME_POLL                       = utils.Cfg.Datastore.TableNamePrefix + "poll"
+	TABLE_NAME_POLL_VOTE                  = utils.Cfg.Datastore.TableNamePrefix + "poll_vote"
+	TABLE_NAME_EXPORT_JOB                 = utils.Cfg.Datastore.TableNamePrefix + "export_job"
+	TABLE_NAME_PURGE_REPORT               = utils.Cfg.Datastore.TableNamePrefix + "purge_report"
+	TABLE_NAME_OUTBOX_ITEM                = utils.Cfg.Datastore.TableNamePrefix + "outbox_item"
+	TABLE_NAME_PRESENCE                   = utils.Cfg.Datastore.TableNamePrefix + "presence"
+	TABLE_NAME_CHANGE_LOG                 = utils.Cfg.Datastore.TableNamePrefix + "change_log"
+	TABLE_NAME_CHANGE_LOG_SEQ             = utils.Cfg.Datastore.TableNamePrefix + "change_log_seq"
 )
 
 type rdbStore struct {
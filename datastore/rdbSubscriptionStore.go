@@ -44,6 +44,21 @@ func RdbSelectSubscription(roomId, userId string, platform int) StoreResult {
 	return result
 }
 
+func RdbSelectSubscriptionsByRoomId(roomId string) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var subscriptions []*models.Subscription
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_SUBSCRIPTION, " WHERE room_id=:roomId AND deleted=0;")
+	params := map[string]interface{}{
+		"roomId": roomId,
+	}
+	if _, err := slave.Select(&subscriptions, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting subscription items.", err)
+	}
+	result.Data = subscriptions
+	return result
+}
+
 func RdbSelectDeletedSubscriptionsByRoomId(roomId string) StoreResult {
 	slave := RdbStoreInstance().replica()
 	result := StoreResult{}
@@ -105,3 +120,18 @@ func RdbDeleteSubscription(subscription *models.Subscription) StoreResult {
 	}
 	return result
 }
+
+func RdbDeleteSubscriptionsByUserIdAndPlatform(userId string, platform int) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	query := utils.AppendStrings("DELETE FROM ", TABLE_NAME_SUBSCRIPTION, " WHERE user_id=:userId AND platform=:platform;")
+	params := map[string]interface{}{
+		"userId":   userId,
+		"platform": platform,
+	}
+	_, err := master.Exec(query, params)
+	if err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while deleting subscription items.", err)
+	}
+	return result
+}
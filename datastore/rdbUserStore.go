@@ -87,6 +87,8 @@ func RdbSelectUser(userId string, isWithRooms, isWithDevices, isWithBlocks bool)
 				"r.modified, ",
 				"ru.unread_count AS ru_unread_count, ",
 				"ru.meta_data AS ru_meta_data, ",
+				"ru.draft AS ru_draft, ",
+				"ru.draft_updated AS ru_draft_updated, ",
 				"ru.created AS ru_created, ",
 				"ru.modified AS ru_modified ",
 				"FROM ", TABLE_NAME_ROOM_USER, " AS ru ",
@@ -179,7 +181,7 @@ func RdbSelectUsers() StoreResult {
 	slave := RdbStoreInstance().replica()
 	result := StoreResult{}
 	var users []*models.User
-	query := utils.AppendStrings("SELECT user_id, name, picture_url, information_url, unread_count, meta_data, is_public, created, modified FROM ", TABLE_NAME_USER, " WHERE deleted = 0 ORDER BY unread_count DESC;")
+	query := utils.AppendStrings("SELECT user_id, name, picture_url, information_url, unread_count, meta_data, is_public, is_bot, created, modified FROM ", TABLE_NAME_USER, " WHERE deleted = 0 ORDER BY unread_count DESC;")
 	_, err := slave.Select(&users, query)
 	if err != nil {
 		result.ProblemDetail = createProblemDetail("An error occurred while getting user items.", err)
@@ -324,6 +326,7 @@ func RdbSelectContacts(userId string) StoreResult {
 		"u.unread_count, ",
 		"u.meta_data, ",
 		"u.is_public, ",
+		"u.is_show_users, ",
 		"u.created, ",
 		"u.modified ",
 		"FROM ", TABLE_NAME_USER, " as u ",
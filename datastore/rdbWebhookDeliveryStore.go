@@ -0,0 +1,147 @@
+package datastore
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func RdbCreateWebhookDeliveryStore() {
+	master := RdbStoreInstance().master()
+	tableMap := master.AddTableWithName(models.WebhookDelivery{}, TABLE_NAME_WEBHOOK_DELIVERY)
+	tableMap.SetKeys(true, "id")
+	for _, columnMap := range tableMap.Columns {
+		if columnMap.ColumnName == "delivery_id" {
+			columnMap.SetUnique(true)
+		}
+	}
+	if err := master.CreateTablesIfNotExists(); err != nil {
+		log.Println(err)
+	}
+
+	var addIndexQuery string
+	if utils.Cfg.Datastore.Provider == "sqlite" {
+		addIndexQuery = utils.AppendStrings("CREATE INDEX IF NOT EXISTS webhook_delivery_message_id ON ", TABLE_NAME_WEBHOOK_DELIVERY, "(message_id)")
+	} else {
+		addIndexQuery = utils.AppendStrings("ALTER TABLE ", TABLE_NAME_WEBHOOK_DELIVERY, " ADD INDEX webhook_delivery_message_id (message_id)")
+	}
+	if _, err := master.Exec(addIndexQuery); err != nil {
+		errMessage := err.Error()
+		if strings.Index(errMessage, "Duplicate key name") < 0 {
+			log.Println(errMessage)
+		}
+	}
+}
+
+func RdbInsertWebhookDelivery(delivery *models.WebhookDelivery) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	if err := master.Insert(delivery); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while creating webhook delivery item.", err)
+	}
+	result.Data = delivery
+	return result
+}
+
+func RdbSelectWebhookDelivery(deliveryId string) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var deliveries []*models.WebhookDelivery
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_WEBHOOK_DELIVERY, " WHERE delivery_id=:deliveryId;")
+	params := map[string]interface{}{"deliveryId": deliveryId}
+	if _, err := slave.Select(&deliveries, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting webhook delivery item.", err)
+	}
+	if len(deliveries) == 1 {
+		result.Data = deliveries[0]
+	}
+	return result
+}
+
+// RdbSelectWebhookDeliveries returns the delivery log, newest first.
+// Empty webhookId or status matches every value.
+func RdbSelectWebhookDeliveries(webhookId, status string, limit, offset int) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var deliveries []*models.WebhookDelivery
+	query := utils.AppendStrings("SELECT * ",
+		"FROM ", TABLE_NAME_WEBHOOK_DELIVERY, " ",
+		"WHERE (webhook_id=:webhookId OR :webhookId='') ",
+		"AND (status=:status OR :status='') ",
+		"ORDER BY created DESC, id DESC ",
+		"LIMIT :limit ",
+		"OFFSET :offset;")
+	params := map[string]interface{}{
+		"webhookId": webhookId,
+		"status":    status,
+		"limit":     limit,
+		"offset":    offset,
+	}
+	if _, err := slave.Select(&deliveries, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting webhook delivery items.", err)
+	}
+	result.Data = deliveries
+	return result
+}
+
+func RdbSelectRetryableWebhookDeliveries(now int64, limit int) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	var deliveries []*models.WebhookDelivery
+	query := utils.AppendStrings("SELECT * ",
+		"FROM ", TABLE_NAME_WEBHOOK_DELIVERY, " ",
+		"WHERE status=:status ",
+		"AND next_attempt<=:now ",
+		"ORDER BY next_attempt ASC ",
+		"LIMIT :limit;")
+	params := map[string]interface{}{
+		"status": models.WEBHOOK_DELIVERY_STATUS_PENDING,
+		"now":    now,
+		"limit":  limit,
+	}
+	if _, err := master.Select(&deliveries, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting webhook delivery items.", err)
+	}
+	result.Data = deliveries
+	return result
+}
+
+func RdbUpdateWebhookDelivery(delivery *models.WebhookDelivery) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	if _, err := master.Update(delivery); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while updating webhook delivery item.", err)
+	}
+	result.Data = delivery
+	return result
+}
+
+// RdbClaimWebhookDelivery moves the next attempt of the delivery to nextAttempt unless another worker has done it first.
+// Data is true when the delivery has been claimed.
+func RdbClaimWebhookDelivery(delivery *models.WebhookDelivery, nextAttempt int64) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	query := utils.AppendStrings("UPDATE ", TABLE_NAME_WEBHOOK_DELIVERY, " ",
+		"SET next_attempt=:nextAttempt, modified=:modified ",
+		"WHERE id=:id ",
+		"AND status=:status ",
+		"AND next_attempt=:oldNextAttempt;")
+	params := map[string]interface{}{
+		"nextAttempt":    nextAttempt,
+		"modified":       time.Now().Unix(),
+		"id":             delivery.Id,
+		"status":         models.WEBHOOK_DELIVERY_STATUS_PENDING,
+		"oldNextAttempt": delivery.NextAttempt,
+	}
+	res, err := master.Exec(query, params)
+	if err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while updating webhook delivery item.", err)
+		return result
+	}
+	count, _ := res.RowsAffected()
+	result.Data = count == 1
+	return result
+}
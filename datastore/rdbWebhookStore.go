@@ -0,0 +1,73 @@
+package datastore
+
+import (
+	"log"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func RdbCreateWebhookStore() {
+	master := RdbStoreInstance().master()
+	tableMap := master.AddTableWithName(models.Webhook{}, TABLE_NAME_WEBHOOK)
+	tableMap.SetKeys(true, "id")
+	for _, columnMap := range tableMap.Columns {
+		if columnMap.ColumnName == "webhook_id" {
+			columnMap.SetUnique(true)
+		}
+	}
+	if err := master.CreateTablesIfNotExists(); err != nil {
+		log.Println(err)
+	}
+}
+
+func RdbInsertWebhook(webhook *models.Webhook) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	if err := master.Insert(webhook); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while creating webhook item.", err)
+	}
+	result.Data = webhook
+	return result
+}
+
+func RdbSelectWebhook(webhookId string) StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var webhooks []*models.Webhook
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_WEBHOOK, " WHERE webhook_id=:webhookId AND deleted=0;")
+	params := map[string]interface{}{"webhookId": webhookId}
+	if _, err := slave.Select(&webhooks, query, params); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting webhook item.", err)
+	}
+	if len(webhooks) == 1 {
+		webhooks[0].AfterLoad()
+		result.Data = webhooks[0]
+	}
+	return result
+}
+
+func RdbSelectWebhooks() StoreResult {
+	slave := RdbStoreInstance().replica()
+	result := StoreResult{}
+	var webhooks []*models.Webhook
+	query := utils.AppendStrings("SELECT * FROM ", TABLE_NAME_WEBHOOK, " WHERE deleted=0 ORDER BY created ASC;")
+	if _, err := slave.Select(&webhooks, query); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while getting webhook items.", err)
+	}
+	for _, webhook := range webhooks {
+		webhook.AfterLoad()
+	}
+	result.Data = webhooks
+	return result
+}
+
+func RdbUpdateWebhook(webhook *models.Webhook) StoreResult {
+	master := RdbStoreInstance().master()
+	result := StoreResult{}
+	if _, err := master.Update(webhook); err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while updating webhook item.", err)
+	}
+	result.Data = webhook
+	return result
+}
@@ -0,0 +1,12 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+type ReportStore interface {
+	CreateReportStore()
+
+	InsertReport(report *models.Report) StoreResult
+	SelectReport(reportId string) StoreResult
+	SelectReports(status string, limit, offset int) StoreResult
+	UpdateReport(report *models.Report) StoreResult
+}
@@ -10,6 +10,7 @@ type RoomStore interface {
 	SelectRooms() StoreResult
 	SelectUsersForRoom(roomId string) StoreResult
 	SelectCountRooms() StoreResult
+	SelectRetentionRooms(globalDays int64) StoreResult
 	UpdateRoom(room *models.Room) StoreResult
 	UpdateRoomDeleted(roomId string) StoreResult
 }
@@ -12,6 +12,9 @@ type RoomUserStore interface {
 	SelectRoomUsersByRoomId(roomId string) StoreResult
 	SelectRoomUsersByUserId(userId string) StoreResult
 	SelectRoomUsersByRoomIdAndUserIds(roomId *string, userIds []string) StoreResult
+	SelectRecipientUserIds(roomId, senderUserId string) StoreResult
+	SelectPushSettings(roomId, senderUserId string) StoreResult
 	UpdateRoomUser(*models.RoomUser) StoreResult
+	UpdateRoomUserDraft(roomId, userId, draft string, draftUpdated int64) StoreResult
 	DeleteRoomUser(roomId string, userIds []string) StoreResult
 }
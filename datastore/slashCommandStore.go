@@ -0,0 +1,12 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+type SlashCommandStore interface {
+	CreateSlashCommandStore()
+
+	InsertSlashCommand(slashCommand *models.SlashCommand) StoreResult
+	SelectSlashCommand(commandId string) StoreResult
+	SelectSlashCommands(roomId string) StoreResult
+	UpdateSlashCommand(slashCommand *models.SlashCommand) StoreResult
+}
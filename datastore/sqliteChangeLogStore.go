@@ -0,0 +1,23 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *sqliteProvider) CreateChangeLogStore() {
+	RdbCreateChangeLogStore()
+}
+
+func (p *sqliteProvider) InsertChangeLogs(changeLogs []*models.ChangeLog) StoreResult {
+	return RdbInsertChangeLogs(changeLogs)
+}
+
+func (p *sqliteProvider) SelectChangeLogs(userId string, sinceSeq uint64, limit int) StoreResult {
+	return RdbSelectChangeLogs(userId, sinceSeq, limit)
+}
+
+func (p *sqliteProvider) SelectChangeLogSeqRange(userId string) StoreResult {
+	return RdbSelectChangeLogSeqRange(userId)
+}
+
+func (p *sqliteProvider) DeleteChangeLogs(created int64) StoreResult {
+	return RdbDeleteChangeLogs(created)
+}
@@ -0,0 +1,19 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *sqliteProvider) CreateExportJobStore() {
+	RdbCreateExportJobStore()
+}
+
+func (p *sqliteProvider) InsertExportJob(exportJob *models.ExportJob) StoreResult {
+	return RdbInsertExportJob(exportJob)
+}
+
+func (p *sqliteProvider) SelectExportJob(exportJobId string) StoreResult {
+	return RdbSelectExportJob(exportJobId)
+}
+
+func (p *sqliteProvider) UpdateExportJob(exportJob *models.ExportJob) StoreResult {
+	return RdbUpdateExportJob(exportJob)
+}
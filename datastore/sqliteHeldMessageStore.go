@@ -0,0 +1,27 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *sqliteProvider) CreateHeldMessageStore() {
+	RdbCreateHeldMessageStore()
+}
+
+func (p *sqliteProvider) InsertHeldMessage(heldMessage *models.HeldMessage) StoreResult {
+	return RdbInsertHeldMessage(heldMessage)
+}
+
+func (p *sqliteProvider) SelectHeldMessage(heldMessageId string) StoreResult {
+	return RdbSelectHeldMessage(heldMessageId)
+}
+
+func (p *sqliteProvider) SelectHeldMessages(status string, limit, offset int) StoreResult {
+	return RdbSelectHeldMessages(status, limit, offset)
+}
+
+func (p *sqliteProvider) UpdateHeldMessage(heldMessage *models.HeldMessage) StoreResult {
+	return RdbUpdateHeldMessage(heldMessage)
+}
+
+func (p *sqliteProvider) ClaimHeldMessage(heldMessage *models.HeldMessage, status string) StoreResult {
+	return RdbClaimHeldMessage(heldMessage, status)
+}
@@ -0,0 +1,27 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *sqliteProvider) CreateIncomingWebhookStore() {
+	RdbCreateIncomingWebhookStore()
+}
+
+func (p *sqliteProvider) InsertIncomingWebhook(incomingWebhook *models.IncomingWebhook) StoreResult {
+	return RdbInsertIncomingWebhook(incomingWebhook)
+}
+
+func (p *sqliteProvider) SelectIncomingWebhook(incomingWebhookId string) StoreResult {
+	return RdbSelectIncomingWebhook(incomingWebhookId)
+}
+
+func (p *sqliteProvider) SelectIncomingWebhookBySecret(secret string) StoreResult {
+	return RdbSelectIncomingWebhookBySecret(secret)
+}
+
+func (p *sqliteProvider) SelectIncomingWebhooks(roomId string) StoreResult {
+	return RdbSelectIncomingWebhooks(roomId)
+}
+
+func (p *sqliteProvider) UpdateIncomingWebhook(incomingWebhook *models.IncomingWebhook) StoreResult {
+	return RdbUpdateIncomingWebhook(incomingWebhook)
+}
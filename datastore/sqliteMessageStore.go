@@ -6,8 +6,12 @@ func (p *sqliteProvider) CreateMessageStore() {
 	RdbCreateMessageStore()
 }
 
-func (p *sqliteProvider) InsertMessage(message *models.Message) StoreResult {
-	return RdbInsertMessage(message)
+func (p *sqliteProvider) InsertMessage(message *models.Message, outboxItems []*models.OutboxItem) StoreResult {
+	return RdbInsertMessage(message, outboxItems)
+}
+
+func (p *sqliteProvider) InsertMessages(messages []*models.Message, outboxItems []*models.OutboxItem, heldMessages []*models.HeldMessage) StoreResult {
+	return RdbInsertMessages(messages, outboxItems, heldMessages)
 }
 
 func (p *sqliteProvider) SelectMessage(messageId string) StoreResult {
@@ -22,6 +26,22 @@ func (p *sqliteProvider) SelectCountMessagesByRoomId(roomId string) StoreResult
 	return RdbSelectCountMessagesByRoomId(roomId)
 }
 
+func (p *sqliteProvider) SelectExportMessages(roomId string, from, to int64, includeDeleted bool, afterId uint64, limit int) StoreResult {
+	return RdbSelectExportMessages(roomId, from, to, includeDeleted, afterId, limit)
+}
+
+func (p *sqliteProvider) SelectCountExportMessages(roomId string, from, to int64, includeDeleted bool) StoreResult {
+	return RdbSelectCountExportMessages(roomId, from, to, includeDeleted)
+}
+
 func (p *sqliteProvider) UpdateMessage(message *models.Message) StoreResult {
 	return RdbUpdateMessage(message)
 }
+
+func (p *sqliteProvider) SelectExpiredMessages(roomId string, cutoff int64, limit int) StoreResult {
+	return RdbSelectExpiredMessages(roomId, cutoff, limit)
+}
+
+func (p *sqliteProvider) DeleteMessages(roomId string, messageIds []string) StoreResult {
+	return RdbDeleteMessages(roomId, messageIds)
+}
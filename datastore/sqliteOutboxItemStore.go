@@ -0,0 +1,31 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *sqliteProvider) CreateOutboxItemStore() {
+	RdbCreateOutboxItemStore()
+}
+
+func (p *sqliteProvider) SelectOutboxItem(outboxItemId string) StoreResult {
+	return RdbSelectOutboxItem(outboxItemId)
+}
+
+func (p *sqliteProvider) SelectOutboxItems(status, kind string, limit, offset int) StoreResult {
+	return RdbSelectOutboxItems(status, kind, limit, offset)
+}
+
+func (p *sqliteProvider) SelectRetryableOutboxItems(now int64, limit int) StoreResult {
+	return RdbSelectRetryableOutboxItems(now, limit)
+}
+
+func (p *sqliteProvider) UpdateOutboxItem(item *models.OutboxItem) StoreResult {
+	return RdbUpdateOutboxItem(item)
+}
+
+func (p *sqliteProvider) ClaimOutboxItem(item *models.OutboxItem, nextAttempt int64) StoreResult {
+	return RdbClaimOutboxItem(item, nextAttempt)
+}
+
+func (p *sqliteProvider) DeleteSucceededOutboxItems(modified int64) StoreResult {
+	return RdbDeleteSucceededOutboxItems(modified)
+}
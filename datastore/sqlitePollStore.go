@@ -0,0 +1,35 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *sqliteProvider) CreatePollStore() {
+	RdbCreatePollStore()
+}
+
+func (p *sqliteProvider) SelectPoll(messageId string) StoreResult {
+	return RdbSelectPoll(messageId)
+}
+
+func (p *sqliteProvider) SelectPolls(messageIds []string) StoreResult {
+	return RdbSelectPolls(messageIds)
+}
+
+func (p *sqliteProvider) SelectExpiredPolls(now int64) StoreResult {
+	return RdbSelectExpiredPolls(now)
+}
+
+func (p *sqliteProvider) UpdatePoll(poll *models.Poll) StoreResult {
+	return RdbUpdatePoll(poll)
+}
+
+func (p *sqliteProvider) SelectPollVotes(messageId string) StoreResult {
+	return RdbSelectPollVotes(messageId)
+}
+
+func (p *sqliteProvider) SelectPollVotesByMessageIds(messageIds []string) StoreResult {
+	return RdbSelectPollVotesByMessageIds(messageIds)
+}
+
+func (p *sqliteProvider) ReplacePollVotes(messageId, userId string, votes []*models.PollVote) StoreResult {
+	return RdbReplacePollVotes(messageId, userId, votes)
+}
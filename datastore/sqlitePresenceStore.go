@@ -0,0 +1,31 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *sqliteProvider) CreatePresenceStore() {
+	RdbCreatePresenceStore()
+}
+
+func (p *sqliteProvider) InsertPresence(presence *models.Presence) StoreResult {
+	return RdbInsertPresence(presence)
+}
+
+func (p *sqliteProvider) SelectPresence(userId string) StoreResult {
+	return RdbSelectPresence(userId)
+}
+
+func (p *sqliteProvider) SelectPresences(userIds []string) StoreResult {
+	return RdbSelectPresences(userIds)
+}
+
+func (p *sqliteProvider) SelectExpiredPresences(lastSeen int64) StoreResult {
+	return RdbSelectExpiredPresences(lastSeen)
+}
+
+func (p *sqliteProvider) UpdatePresence(presence *models.Presence) StoreResult {
+	return RdbUpdatePresence(presence)
+}
+
+func (p *sqliteProvider) UpdatePresencesLastSeen(userIds []string, lastSeen int64) StoreResult {
+	return RdbUpdatePresencesLastSeen(userIds, lastSeen)
+}
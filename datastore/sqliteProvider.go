@@ -40,6 +40,18 @@ func (p *sqliteProvider) Init() {
 	p.CreateMessageStore()
 	p.CreateDeviceStore()
 	p.CreateSubscriptionStore()
+	p.CreateHeldMessageStore()
+	p.CreateReportStore()
+	p.CreateWebhookStore()
+	p.CreateWebhookDeliveryStore()
+	p.CreateIncomingWebhookStore()
+	p.CreateSlashCommandStore()
+	p.CreatePollStore()
+	p.CreateExportJobStore()
+	p.CreatePurgeReportStore()
+	p.CreateOutboxItemStore()
+	p.CreatePresenceStore()
+	p.CreateChangeLogStore()
 }
 
 func (p *sqliteProvider) DropDatabase() error {
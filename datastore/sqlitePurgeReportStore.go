@@ -0,0 +1,19 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *sqliteProvider) CreatePurgeReportStore() {
+	RdbCreatePurgeReportStore()
+}
+
+func (p *sqliteProvider) InsertPurgeReport(purgeReport *models.PurgeReport) StoreResult {
+	return RdbInsertPurgeReport(purgeReport)
+}
+
+func (p *sqliteProvider) SelectPurgeReport(purgeReportId string) StoreResult {
+	return RdbSelectPurgeReport(purgeReportId)
+}
+
+func (p *sqliteProvider) SelectPurgeReports(limit, offset int) StoreResult {
+	return RdbSelectPurgeReports(limit, offset)
+}
@@ -0,0 +1,23 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *sqliteProvider) CreateReportStore() {
+	RdbCreateReportStore()
+}
+
+func (p *sqliteProvider) InsertReport(report *models.Report) StoreResult {
+	return RdbInsertReport(report)
+}
+
+func (p *sqliteProvider) SelectReport(reportId string) StoreResult {
+	return RdbSelectReport(reportId)
+}
+
+func (p *sqliteProvider) SelectReports(status string, limit, offset int) StoreResult {
+	return RdbSelectReports(status, limit, offset)
+}
+
+func (p *sqliteProvider) UpdateReport(report *models.Report) StoreResult {
+	return RdbUpdateReport(report)
+}
@@ -26,6 +26,10 @@ func (p *sqliteProvider) SelectCountRooms() StoreResult {
 	return RdbSelectCountRooms()
 }
 
+func (p *sqliteProvider) SelectRetentionRooms(globalDays int64) StoreResult {
+	return RdbSelectRetentionRooms(globalDays)
+}
+
 func (p *sqliteProvider) UpdateRoom(room *models.Room) StoreResult {
 	return RdbUpdateRoom(room)
 }
@@ -34,6 +34,14 @@ func (p *sqliteProvider) SelectRoomUsersByRoomIdAndUserIds(roomId *string, userI
 	return RdbSelectRoomUsersByRoomIdAndUserIds(roomId, userIds)
 }
 
+func (p *sqliteProvider) SelectRecipientUserIds(roomId, senderUserId string) StoreResult {
+	return RdbSelectRecipientUserIds(roomId, senderUserId)
+}
+
+func (p *sqliteProvider) SelectPushSettings(roomId, senderUserId string) StoreResult {
+	return RdbSelectPushSettings(roomId, senderUserId)
+}
+
 func (p *sqliteProvider) UpdateRoomUser(roomUser *models.RoomUser) StoreResult {
 	return RdbUpdateRoomUser(roomUser)
 }
@@ -41,3 +49,7 @@ func (p *sqliteProvider) UpdateRoomUser(roomUser *models.RoomUser) StoreResult {
 func (p *sqliteProvider) DeleteRoomUser(roomId string, userIds []string) StoreResult {
 	return RdbDeleteRoomUser(roomId, userIds)
 }
+
+func (p *sqliteProvider) UpdateRoomUserDraft(roomId, userId, draft string, draftUpdated int64) StoreResult {
+	return RdbUpdateRoomUserDraft(roomId, userId, draft, draftUpdated)
+}
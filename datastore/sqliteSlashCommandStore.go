@@ -0,0 +1,23 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *sqliteProvider) CreateSlashCommandStore() {
+	RdbCreateSlashCommandStore()
+}
+
+func (p *sqliteProvider) InsertSlashCommand(slashCommand *models.SlashCommand) StoreResult {
+	return RdbInsertSlashCommand(slashCommand)
+}
+
+func (p *sqliteProvider) SelectSlashCommand(commandId string) StoreResult {
+	return RdbSelectSlashCommand(commandId)
+}
+
+func (p *sqliteProvider) SelectSlashCommands(roomId string) StoreResult {
+	return RdbSelectSlashCommands(roomId)
+}
+
+func (p *sqliteProvider) UpdateSlashCommand(slashCommand *models.SlashCommand) StoreResult {
+	return RdbUpdateSlashCommand(slashCommand)
+}
@@ -29,3 +29,11 @@ func (p *sqliteProvider) SelectDeletedSubscriptionsByUserIdAndPlatform(userId st
 func (p *sqliteProvider) DeleteSubscription(subscription *models.Subscription) StoreResult {
 	return RdbDeleteSubscription(subscription)
 }
+
+func (p *sqliteProvider) SelectSubscriptionsByRoomId(roomId string) StoreResult {
+	return RdbSelectSubscriptionsByRoomId(roomId)
+}
+
+func (p *sqliteProvider) DeleteSubscriptionsByUserIdAndPlatform(userId string, platform int) StoreResult {
+	return RdbDeleteSubscriptionsByUserIdAndPlatform(userId, platform)
+}
@@ -0,0 +1,31 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *sqliteProvider) CreateWebhookDeliveryStore() {
+	RdbCreateWebhookDeliveryStore()
+}
+
+func (p *sqliteProvider) InsertWebhookDelivery(delivery *models.WebhookDelivery) StoreResult {
+	return RdbInsertWebhookDelivery(delivery)
+}
+
+func (p *sqliteProvider) SelectWebhookDelivery(deliveryId string) StoreResult {
+	return RdbSelectWebhookDelivery(deliveryId)
+}
+
+func (p *sqliteProvider) SelectWebhookDeliveries(webhookId, status string, limit, offset int) StoreResult {
+	return RdbSelectWebhookDeliveries(webhookId, status, limit, offset)
+}
+
+func (p *sqliteProvider) SelectRetryableWebhookDeliveries(now int64, limit int) StoreResult {
+	return RdbSelectRetryableWebhookDeliveries(now, limit)
+}
+
+func (p *sqliteProvider) UpdateWebhookDelivery(delivery *models.WebhookDelivery) StoreResult {
+	return RdbUpdateWebhookDelivery(delivery)
+}
+
+func (p *sqliteProvider) ClaimWebhookDelivery(delivery *models.WebhookDelivery, nextAttempt int64) StoreResult {
+	return RdbClaimWebhookDelivery(delivery, nextAttempt)
+}
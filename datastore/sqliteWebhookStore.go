@@ -0,0 +1,23 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+func (p *sqliteProvider) CreateWebhookStore() {
+	RdbCreateWebhookStore()
+}
+
+func (p *sqliteProvider) InsertWebhook(webhook *models.Webhook) StoreResult {
+	return RdbInsertWebhook(webhook)
+}
+
+func (p *sqliteProvider) SelectWebhook(webhookId string) StoreResult {
+	return RdbSelectWebhook(webhookId)
+}
+
+func (p *sqliteProvider) SelectWebhooks() StoreResult {
+	return RdbSelectWebhooks()
+}
+
+func (p *sqliteProvider) UpdateWebhook(webhook *models.Webhook) StoreResult {
+	return RdbUpdateWebhook(webhook)
+}
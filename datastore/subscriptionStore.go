@@ -7,8 +7,10 @@ type SubscriptionStore interface {
 
 	InsertSubscription(subscription *models.Subscription) StoreResult
 	SelectSubscription(roomId, userId string, platform int) StoreResult
+	SelectSubscriptionsByRoomId(roomId string) StoreResult
 	SelectDeletedSubscriptionsByRoomId(roomId string) StoreResult
 	SelectDeletedSubscriptionsByUserId(userId string) StoreResult
 	SelectDeletedSubscriptionsByUserIdAndPlatform(userId string, platform int) StoreResult
 	DeleteSubscription(subscription *models.Subscription) StoreResult
+	DeleteSubscriptionsByUserIdAndPlatform(userId string, platform int) StoreResult
 }
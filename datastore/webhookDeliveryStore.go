@@ -0,0 +1,14 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+type WebhookDeliveryStore interface {
+	CreateWebhookDeliveryStore()
+
+	InsertWebhookDelivery(delivery *models.WebhookDelivery) StoreResult
+	SelectWebhookDelivery(deliveryId string) StoreResult
+	SelectWebhookDeliveries(webhookId, status string, limit, offset int) StoreResult
+	SelectRetryableWebhookDeliveries(now int64, limit int) StoreResult
+	UpdateWebhookDelivery(delivery *models.WebhookDelivery) StoreResult
+	ClaimWebhookDelivery(delivery *models.WebhookDelivery, nextAttempt int64) StoreResult
+}
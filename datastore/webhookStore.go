@@ -0,0 +1,12 @@
+package datastore
+
+import "github.com/swagchat/chat-api/models"
+
+type WebhookStore interface {
+	CreateWebhookStore()
+
+	InsertWebhook(webhook *models.Webhook) StoreResult
+	SelectWebhook(webhookId string) StoreResult
+	SelectWebhooks() StoreResult
+	UpdateWebhook(webhook *models.Webhook) StoreResult
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/go-zoo/bone"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/services"
+	"github.com/swagchat/chat-api/utils"
+	"go.uber.org/zap"
+)
+
+func SetExportMux() {
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/rooms/#roomId^[a-z0-9-]$/export"), colsHandler(aclHandler(ExportRoom)))
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/exports/#exportJobId^[a-z0-9-]$"), colsHandler(aclHandler(GetExportJob)))
+}
+
+func ExportRoom(w http.ResponseWriter, r *http.Request) {
+	roomId := bone.GetValue(r, "roomId")
+	params, _ := url.ParseQuery(r.URL.RawQuery)
+	userId, isAdmin := exportRequester(r)
+	job, pd := services.PostRoomExport(roomId, userId, isAdmin, params)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	if job.Status == models.EXPORT_JOB_STATUS_QUEUED {
+		w.Header().Set("Location", utils.AppendStrings("/", utils.API_VERSION, "/exports/", job.ExportJobId))
+		respond(w, r, http.StatusAccepted, "application/json", job)
+		return
+	}
+
+	w.Header().Set("Content-Type", job.ContentType())
+	w.Header().Set("Content-Disposition", utils.AppendStrings("attachment; filename=\"", job.FileName(), "\""))
+	w.WriteHeader(http.StatusOK)
+	if err := services.WriteRoomExport(w, job); err != nil {
+		utils.AppLogger.Error("",
+			zap.String("msg", "Export room messages error."),
+			zap.String("roomId", roomId),
+			zap.String("err", err.Error()),
+		)
+	}
+}
+
+func GetExportJob(w http.ResponseWriter, r *http.Request) {
+	exportJobId := bone.GetValue(r, "exportJobId")
+	userId, isAdmin := exportRequester(r)
+	job, pd := services.GetExportJob(exportJobId, userId, isAdmin)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	setLastModified(w, job.Modified)
+	respond(w, r, http.StatusOK, "application/json", job)
+}
+
+// exportRequester returns the authenticated user id, which is empty for administrators and guests.
+func exportRequester(r *http.Request) (string, bool) {
+	switch r.Context().Value("role") {
+	case "admin":
+		return "", true
+	case "user":
+		return r.Header.Get(utils.HEADER_USER_ID), false
+	}
+	return "", false
+}
@@ -9,7 +9,9 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -20,6 +22,8 @@ import (
 	"github.com/shogo82148/go-gracedown"
 	"github.com/swagchat/chat-api/datastore"
 	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/rtm"
+	"github.com/swagchat/chat-api/services"
 	"github.com/swagchat/chat-api/utils"
 )
 
@@ -38,6 +42,7 @@ var (
 		http.StatusNotFound,
 		http.StatusConflict,
 	}
+	shutdownOnce sync.Once
 )
 
 func StartServer(ctx context.Context) {
@@ -54,19 +59,39 @@ func StartServer(ctx context.Context) {
 	SetBlockUserMux()
 	SetRoomMux()
 	SetRoomUserMux()
+	SetRoomEventMux()
+	SetSlashCommandMux()
 	SetMessageMux()
 	SetAssetMux()
 	SetDeviceMux()
 	SetContactMux()
+	SetHeldMessageMux()
+	SetReportMux()
+	SetWebhookMux()
+	SetIncomingWebhookMux()
+	SetExportMux()
+	SetRetentionMux()
+	SetOutboxMux()
 	if utils.Cfg.Profiling {
 		SetPprofMux()
 	}
 	if utils.Cfg.Storage.Provider == "awsS3" {
 		SetAssetAwsSnsMux()
 	}
+	if rtm.HubEnabled() {
+		SetWsMux()
+		SetSseMux()
+	}
 	Mux.NotFoundFunc(notFoundHandler)
 
 	go run(ctx)
+	go services.RunWebhookWorker(ctx)
+	go services.RunPollCloser(ctx)
+	go services.RunPurger(ctx)
+	go services.RunOutboxDispatcher(ctx)
+	go services.RunPresenceWorker(ctx)
+	go services.RunSyncPurger(ctx)
+	go rtm.RunRedisSubscriber(ctx)
 
 	utils.AppLogger.Info("",
 		zap.String("msg", "swagchat Chat API Start!"),
@@ -88,19 +113,31 @@ func run(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
-			gracedown.Close()
+			shutdown()
 		case s := <-signalChan:
 			if s == syscall.SIGTERM || s == syscall.SIGINT {
 				utils.AppLogger.Info("",
 					zap.String("msg", "swagchat Chat API Shutdown start!"),
 					zap.String("signal", s.String()),
 				)
-				gracedown.Close()
+				shutdown()
 			}
 		}
 	}
 }
 
+// shutdown drains real-time connections before gracedown closes the server,
+// because gracedown does not wait for hijacked connections and would wait forever for event streams.
+func shutdown() {
+	shutdownOnce.Do(func() {
+		if rtm.HubEnabled() {
+			drainTimeout, _ := strconv.Atoi(utils.Cfg.Rtm.WsDrainTimeout)
+			rtm.GetHub().Drain(time.Duration(drainTimeout) * time.Second)
+		}
+		gracedown.Close()
+	})
+}
+
 func indexHandler(w http.ResponseWriter, r *http.Request) {
 	respond(w, r, http.StatusOK, "text/plain", utils.AppendStrings("swagchat Chat API version ", utils.API_VERSION))
 }
@@ -166,6 +203,38 @@ func aclHandler(fn http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+func adminHandler(fn http.HandlerFunc) http.HandlerFunc {
+	return aclHandler(func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value("role") != "admin" {
+			respondErr(w, r, http.StatusForbidden, &models.ProblemDetail{
+				Title:     "Administrator privileges are required.",
+				Status:    http.StatusForbidden,
+				ErrorName: models.ERROR_NAME_OPERATION_NOT_PERMITTED,
+			})
+			return
+		}
+		fn(w, r)
+	})
+}
+
+// isStreamUser reports whether the request is authenticated as the user opening a real-time connection.
+// Browsers can not set headers on WebSocket nor EventSource, so the access token may also be given with the accessToken query parameter.
+func isStreamUser(r *http.Request, userId string) bool {
+	if userId == "" {
+		return false
+	}
+	if r.Context().Value("role") == "user" {
+		return r.Header.Get(utils.HEADER_USER_ID) == userId
+	}
+
+	token := r.URL.Query().Get("accessToken")
+	if token == "" {
+		return false
+	}
+	dRes := datastore.GetProvider().SelectUserByUserIdAndAccessToken(userId, token)
+	return dRes.ProblemDetail == nil && dRes.Data != nil
+}
+
 func decodeBody(r *http.Request, v interface{}) error {
 	defer r.Body.Close()
 	bufbody := new(bytes.Buffer)
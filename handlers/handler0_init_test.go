@@ -8,10 +8,12 @@ import (
 	"net/http/httptest"
 	"os"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/swagchat/chat-api/datastore"
+	"github.com/swagchat/chat-api/models"
 	"github.com/swagchat/chat-api/utils"
 )
 
@@ -21,8 +23,11 @@ type testRecord struct {
 	userId         string
 	messageId      string
 	platform       string
+	method         string
+	path           string
 	in             string
 	out            string
+	notOut         string
 	httpStatusCode int
 }
 
@@ -43,6 +48,54 @@ func TestMain(m *testing.M) {
 	os.Exit(testRC)
 }
 
+// adminRequest sends a request with the API key of the administrator, and returns the status code and the response body.
+func adminRequest(ts *httptest.Server, method, path, in string) (int, string, error) {
+	req, err := http.NewRequest(method, ts.URL+"/"+utils.API_VERSION+path, strings.NewReader(in))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	dRes := datastore.GetProvider().SelectLatestApi("admin")
+	if dRes.Data != nil {
+		api := dRes.Data.(*models.Api)
+		req.Header.Set(utils.HEADER_API_KEY, api.Key)
+		req.Header.Set(utils.HEADER_API_SECRET, api.Secret)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return 0, "", err
+	}
+	return res.StatusCode, string(data), nil
+}
+
+// runTestTable sends the request of each record in order, and checks its status code and response body.
+// The response body must match out, and must not match notOut if any.
+func runTestTable(t *testing.T, ts *httptest.Server, testTable []testRecord) {
+	for _, testRecord := range testTable {
+		statusCode, data, err := adminRequest(ts, testRecord.method, testRecord.path, testRecord.in)
+		if err != nil {
+			t.Fatalf("TestNo %d\nhttp request failed: %v", testRecord.testNo, err)
+		}
+
+		if statusCode != testRecord.httpStatusCode {
+			t.Fatalf("TestNo %d\nHTTP Status Code Failure\n[expected]%d\n[result  ]%d\n%s", testRecord.testNo, testRecord.httpStatusCode, statusCode, data)
+		}
+
+		r := regexp.MustCompile(testRecord.out)
+		if !r.MatchString(data) {
+			t.Fatalf("TestNo %d\nResponse Body Failure\n[expected]%s\n[result  ]%s", testRecord.testNo, testRecord.out, data)
+		}
+		if testRecord.notOut != "" && regexp.MustCompile(testRecord.notOut).MatchString(data) {
+			t.Fatalf("TestNo %d\nResponse Body Failure\n[unexpected]%s\n[result    ]%s", testRecord.testNo, testRecord.notOut, data)
+		}
+	}
+}
+
 func TestIndex(t *testing.T) {
 	testRecord := &testRecord{
 		testNo:         1,
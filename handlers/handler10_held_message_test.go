@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+type heldMessagesStruct struct {
+	HeldMessages []struct {
+		HeldMessageId string `json:"heldMessageId"`
+		MessageId     string `json:"messageId"`
+	} `json:"heldMessages"`
+}
+
+// setupModerationWebhook holds the texts containing "hold me" through a moderation webhook, and returns the function restoring the config.
+func setupModerationWebhook() func() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var m struct {
+			Payload struct {
+				Text string `json:"text"`
+			} `json:"payload"`
+		}
+		json.NewDecoder(r.Body).Decode(&m)
+		if strings.Contains(m.Payload.Text, "hold me") {
+			w.Write([]byte(`{"action":"hold","reason":"held by the test"}`))
+			return
+		}
+		w.Write([]byte(`{"action":"allow"}`))
+	}))
+	cfg := *utils.Cfg.Moderation
+	utils.Cfg.Moderation.Hooks = "webhook"
+	utils.Cfg.Moderation.WebhookEndpoint = server.URL
+	return func() {
+		*utils.Cfg.Moderation = cfg
+		server.Close()
+	}
+}
+
+func heldMessageIds(t *testing.T, ts *httptest.Server) map[string]string {
+	statusCode, data, err := adminRequest(ts, "GET", "/admin/heldMessages?limit=100", "")
+	if err != nil || statusCode != 200 {
+		t.Fatalf("GET /admin/heldMessages failed: %d %s %v", statusCode, data, err)
+	}
+	var heldMessages heldMessagesStruct
+	if err := json.Unmarshal([]byte(data), &heldMessages); err != nil {
+		t.Fatalf("Error by json.Unmarshal(): %v", err)
+	}
+	ids := make(map[string]string)
+	for _, heldMessage := range heldMessages.HeldMessages {
+		ids[heldMessage.MessageId] = heldMessage.HeldMessageId
+	}
+	return ids
+}
+
+func TestHeldMessages(t *testing.T) {
+	ts := httptest.NewServer(Mux)
+	defer ts.Close()
+	defer setupModerationWebhook()()
+
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         1,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "held-user-1", "name": "held user 1"}`,
+			out:            `(?m)^{"userId":"held-user-1",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         2,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "held-user-2", "name": "held user 2"}`,
+			out:            `(?m)^{"userId":"held-user-2",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         3,
+			method:         "POST",
+			path:           "/rooms",
+			in:             `{"roomId": "held-room-1", "userId": "held-user-1", "name": "held room", "type": 3, "userIds": ["held-user-2"]}`,
+			out:            `(?m)^{"roomId":"held-room-1",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo: 4,
+			method: "POST",
+			path:   "/messages",
+			in: `
+				{
+					"messages": [
+						{"messageId": "held-message-1", "roomId": "held-room-1", "userId": "held-user-1", "type": "text", "payload": {"text": "hold me 1"}},
+						{"messageId": "held-message-2", "roomId": "held-room-1", "userId": "held-user-1", "type": "text", "payload": {"text": "hold me 2"}}
+					]
+				}
+			`,
+			out:            `(?m)^{"heldMessageIds":\["held-message-1","held-message-2"\]}$`,
+			httpStatusCode: 202,
+		},
+		{
+			// Held messages are committed together with the rest of an atomic batch
+			testNo: 5,
+			method: "POST",
+			path:   "/messages?atomic=true",
+			in: `
+				{
+					"messages": [
+						{"messageId": "held-message-3", "roomId": "held-room-1", "userId": "held-user-1", "type": "text", "payload": {"text": "posted 3"}},
+						{"messageId": "held-message-4", "roomId": "held-room-1", "userId": "held-user-1", "type": "text", "payload": {"text": "hold me 4"}}
+					]
+				}
+			`,
+			out:            `(?m)^{"messageIds":\["held-message-3"\],"heldMessageIds":\["held-message-4"\]`,
+			httpStatusCode: 201,
+		},
+		{
+			// An atomic batch failing validation holds nothing
+			testNo: 6,
+			method: "POST",
+			path:   "/messages?atomic=true",
+			in: `
+				{
+					"messages": [
+						{"messageId": "held-message-5", "roomId": "held-room-1", "userId": "held-user-1", "type": "text", "payload": {"text": "hold me 5"}},
+						{"messageId": "held-message-6", "roomId": "not-exist-room", "userId": "held-user-1", "type": "text", "payload": {"text": "posted 6"}}
+					]
+				}
+			`,
+			out:            `(?m)"roomId is invalid. Not exist room."`,
+			httpStatusCode: 400,
+		},
+	})
+
+	ids := heldMessageIds(t, ts)
+	for _, messageId := range []string{"held-message-1", "held-message-2", "held-message-4"} {
+		if ids[messageId] == "" {
+			t.Fatalf("%s was not held: %v", messageId, ids)
+		}
+	}
+	if ids["held-message-5"] != "" {
+		t.Fatalf("held-message-5 was held by a failed atomic batch")
+	}
+
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         7,
+			method:         "POST",
+			path:           "/admin/heldMessages/" + ids["held-message-1"] + "/approve",
+			out:            `(?m)^{"heldMessageId":"[a-z0-9-]+","messageId":"held-message-1",.*"status":"approved",`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         8,
+			method:         "POST",
+			path:           "/admin/heldMessages/" + ids["held-message-2"] + "/reject",
+			out:            `(?m)^{"heldMessageId":"[a-z0-9-]+","messageId":"held-message-2",.*"status":"rejected",`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         9,
+			method:         "POST",
+			path:           "/admin/heldMessages/" + ids["held-message-1"] + "/reject",
+			out:            ``,
+			httpStatusCode: 409,
+		},
+		{
+			testNo:         10,
+			method:         "GET",
+			path:           "/messages/held-message-1",
+			out:            `(?m)^{"messageId":"held-message-1","roomId":"held-room-1","userId":"held-user-1","type":"text","payload":{"text":"hold me 1"}`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         11,
+			method:         "GET",
+			path:           "/messages/held-message-2",
+			out:            ``,
+			httpStatusCode: 404,
+		},
+		{
+			// The author is required to edit a message
+			testNo:         12,
+			method:         "PUT",
+			path:           "/messages/held-message-1",
+			in:             `{"payload": {"text": "edited"}}`,
+			out:            `(?m)"name":"userId","reason":"userId is required, but it's empty."`,
+			httpStatusCode: 400,
+		},
+		{
+			testNo:         13,
+			method:         "PUT",
+			path:           "/messages/held-message-1",
+			in:             `{"userId": "held-user-2", "payload": {"text": "edited"}}`,
+			out:            `(?m)"errorName":"operation-not-permitted"`,
+			httpStatusCode: 403,
+		},
+		{
+			testNo:         14,
+			method:         "PUT",
+			path:           "/messages/held-message-1",
+			in:             `{"userId": "held-user-1", "payload": {"text": "edited"}}`,
+			out:            `(?m)^{"messageId":"held-message-1",.*"payload":{"text":"edited"}`,
+			httpStatusCode: 200,
+		},
+		{
+			// An edit can be held too, leaving the message unchanged until it is approved
+			testNo:         15,
+			method:         "PUT",
+			path:           "/messages/held-message-1",
+			in:             `{"userId": "held-user-1", "payload": {"text": "hold me edited"}}`,
+			out:            `(?m)^{"heldMessageId":"[a-z0-9-]+","messageId":"held-message-1",.*"kind":"edit",.*"status":"held",`,
+			httpStatusCode: 202,
+		},
+		{
+			testNo:         16,
+			method:         "GET",
+			path:           "/messages/held-message-1",
+			out:            `(?m)"payload":{"text":"edited"}`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         17,
+			method:         "POST",
+			path:           "/rooms",
+			in:             `{"roomId": "held-room-2", "userId": "held-user-1", "name": "held room 2", "type": 3, "userIds": ["held-user-2"]}`,
+			out:            `(?m)^{"roomId":"held-room-2",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         18,
+			method:         "POST",
+			path:           "/messages",
+			in:             `{"messages": [{"messageId": "held-message-7", "roomId": "held-room-2", "userId": "held-user-1", "type": "text", "payload": {"text": "hold me 7"}}]}`,
+			out:            `(?m)^{"heldMessageIds":\["held-message-7"\]}$`,
+			httpStatusCode: 202,
+		},
+		{
+			testNo:         19,
+			method:         "DELETE",
+			path:           "/rooms/held-room-2",
+			out:            ``,
+			httpStatusCode: 204,
+		},
+	})
+
+	// Concurrent approvals publish the message once, and the others conflict
+	const approvalCount = 5
+	var wg sync.WaitGroup
+	statusCodes := make(chan int, approvalCount)
+	for i := 0; i < approvalCount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statusCode, data, err := adminRequest(ts, "POST", "/admin/heldMessages/"+ids["held-message-4"]+"/approve", "")
+			if err != nil {
+				t.Errorf("POST approve failed: %v", err)
+			}
+			if statusCode != 200 && statusCode != 409 {
+				t.Errorf("unexpected approval: %d %s", statusCode, data)
+			}
+			statusCodes <- statusCode
+		}()
+	}
+	wg.Wait()
+	close(statusCodes)
+	approved := 0
+	for statusCode := range statusCodes {
+		if statusCode == 200 {
+			approved++
+		}
+	}
+	if approved != 1 {
+		t.Fatalf("%d of %d concurrent approvals succeeded", approved, approvalCount)
+	}
+
+	// A message that can not be published stays held
+	heldMessageId := heldMessageIds(t, ts)["held-message-7"]
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         20,
+			method:         "GET",
+			path:           "/messages/held-message-4",
+			out:            `(?m)^{"messageId":"held-message-4",`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         21,
+			method:         "POST",
+			path:           "/admin/heldMessages/" + heldMessageId + "/approve",
+			out:            ``,
+			httpStatusCode: 404,
+		},
+		{
+			testNo:         22,
+			method:         "GET",
+			path:           "/admin/heldMessages/" + heldMessageId,
+			out:            `(?m)^{"heldMessageId":"` + heldMessageId + `","messageId":"held-message-7",.*"status":"held",`,
+			httpStatusCode: 200,
+		},
+	})
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPresenceVisibility(t *testing.T) {
+	ts := httptest.NewServer(Mux)
+	defer ts.Close()
+
+	testTable := []testRecord{
+		{
+			testNo:         1,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "presence-user-1", "name": "presence user 1"}`,
+			out:            `(?m)^{"userId":"presence-user-1",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         2,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "presence-user-2", "name": "presence user 2"}`,
+			out:            `(?m)^{"userId":"presence-user-2",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         3,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "presence-hidden", "name": "presence hidden", "isShowUsers": false}`,
+			out:            `(?m)^{"userId":"presence-hidden",.*"isShowUsers":false,`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         4,
+			method:         "POST",
+			path:           "/rooms",
+			in:             `{"roomId": "presence-room-1", "userId": "presence-user-1", "name": "presence room", "type": 3, "userIds": ["presence-user-2", "presence-hidden"]}`,
+			out:            `(?m)^{"roomId":"presence-room-1",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         5,
+			method:         "PUT",
+			path:           "/users/presence-user-2/presence",
+			in:             `{"status": "away", "statusText": "lunch"}`,
+			out:            `(?m)^{"userId":"presence-user-2","status":"away","statusText":"lunch",`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         6,
+			method:         "PUT",
+			path:           "/users/presence-hidden/presence",
+			in:             `{"status": "online", "statusText": "secret"}`,
+			out:            `(?m)^{"userId":"presence-hidden","status":"online","statusText":"secret",`,
+			httpStatusCode: 200,
+		},
+		{
+			// A user hiding from others is always seen offline
+			testNo:         7,
+			method:         "GET",
+			path:           "/users/presence-hidden/presence",
+			out:            `(?m)^{"userId":"presence-hidden","status":"offline"}$`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         8,
+			method:         "GET",
+			path:           "/users/presence-user-2/presence",
+			out:            `(?m)^{"userId":"presence-user-2","status":"away","statusText":"lunch",`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         9,
+			method:         "GET",
+			path:           "/rooms/presence-room-1",
+			out:            `(?m)"presence":{"userId":"presence-user-2","status":"away","statusText":"lunch",`,
+			notOut:         `"presence":{"userId":"presence-hidden"`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         10,
+			method:         "GET",
+			path:           "/contacts/presence-user-1",
+			out:            `(?m)"presence":{"userId":"presence-user-2","status":"away","statusText":"lunch",`,
+			notOut:         `presence-hidden`,
+			httpStatusCode: 200,
+		},
+	}
+	runTestTable(t, ts, testTable)
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+type webhookDeliveriesStruct struct {
+	WebhookDeliveries []struct {
+		DeliveryId     string `json:"deliveryId"`
+		EventName      string `json:"eventName"`
+		Status         string `json:"status"`
+		Attempts       int    `json:"attempts"`
+		LastStatusCode int    `json:"lastStatusCode"`
+	} `json:"webhookDeliveries"`
+}
+
+// webhookServer answers every delivery with statusCode, and reports the deliveries whose signature is invalid.
+func webhookServer(t *testing.T, secret string, statusCode int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(r.Header.Get("X-SwagChat-Webhook-Timestamp")))
+		mac.Write([]byte("."))
+		mac.Write(body)
+		signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		if !hmac.Equal([]byte(signature), []byte(r.Header.Get("X-SwagChat-Webhook-Signature"))) {
+			t.Errorf("the delivery %s has an invalid signature", r.Header.Get("X-SwagChat-Webhook-Delivery"))
+		}
+		w.WriteHeader(statusCode)
+	}))
+}
+
+// waitWebhookDelivery polls the deliveries of the webhook until count of them have the status.
+func waitWebhookDelivery(t *testing.T, ts *httptest.Server, webhookId, status string, count int) *webhookDeliveriesStruct {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		statusCode, data, err := adminRequest(ts, "GET", "/admin/webhooks/"+webhookId+"/deliveries?status="+status, "")
+		if err != nil || statusCode != 200 {
+			t.Fatalf("GET deliveries failed: %d %s %v", statusCode, data, err)
+		}
+		var deliveries webhookDeliveriesStruct
+		if err := json.Unmarshal([]byte(data), &deliveries); err != nil {
+			t.Fatalf("Error by json.Unmarshal(): %v", err)
+		}
+		if len(deliveries.WebhookDeliveries) >= count {
+			return &deliveries
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d deliveries of %s did not become %s", count, webhookId, status)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestWebhookDeadLetters(t *testing.T) {
+	ts := httptest.NewServer(Mux)
+	defer ts.Close()
+
+	okServer := webhookServer(t, "ok-secret", http.StatusOK)
+	defer okServer.Close()
+	failServer := webhookServer(t, "fail-secret", http.StatusInternalServerError)
+	defer failServer.Close()
+
+	cfg := *utils.Cfg.Webhook
+	utils.Cfg.Webhook.MaxAttempts = "1"
+	defer func() {
+		*utils.Cfg.Webhook = cfg
+	}()
+
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         1,
+			method:         "POST",
+			path:           "/admin/webhooks",
+			in:             `{"webhookId": "webhook-ok", "url": "` + okServer.URL + `", "secret": "ok-secret", "events": ["roomCreated"]}`,
+			out:            `(?m)^{"webhookId":"webhook-ok",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         2,
+			method:         "POST",
+			path:           "/admin/webhooks",
+			in:             `{"webhookId": "webhook-fail", "url": "` + failServer.URL + `", "secret": "fail-secret", "events": ["roomCreated"]}`,
+			out:            `(?m)^{"webhookId":"webhook-fail",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         3,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "webhook-user-1", "name": "webhook user 1"}`,
+			out:            `(?m)^{"userId":"webhook-user-1",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         4,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "webhook-user-2", "name": "webhook user 2"}`,
+			out:            `(?m)^{"userId":"webhook-user-2",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         5,
+			method:         "POST",
+			path:           "/rooms",
+			in:             `{"roomId": "webhook-room-1", "userId": "webhook-user-1", "name": "webhook room", "type": 3, "userIds": ["webhook-user-2"]}`,
+			out:            `(?m)^{"roomId":"webhook-room-1",`,
+			httpStatusCode: 201,
+		},
+	})
+
+	succeeded := waitWebhookDelivery(t, ts, "webhook-ok", "succeeded", 1).WebhookDeliveries[0]
+	if succeeded.EventName != "roomCreated" || succeeded.Attempts != 1 || succeeded.LastStatusCode != 200 {
+		t.Fatalf("unexpected delivery: %+v", succeeded)
+	}
+
+	// A delivery failing MaxAttempts times is moved to the dead letters
+	dead := waitWebhookDelivery(t, ts, "webhook-fail", "dead", 1).WebhookDeliveries[0]
+	if dead.Attempts != 1 || dead.LastStatusCode != 500 {
+		t.Fatalf("unexpected dead letter: %+v", dead)
+	}
+
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         6,
+			method:         "GET",
+			path:           "/admin/webhookDeadLetters",
+			out:            `(?m)"deliveryId":"` + dead.DeliveryId + `","webhookId":"webhook-fail","eventName":"roomCreated",`,
+			notOut:         `"webhookId":"webhook-ok"`,
+			httpStatusCode: 200,
+		},
+		{
+			// A dead letter is replayed with its delivery id, and dead again after one more failure
+			testNo:         7,
+			method:         "POST",
+			path:           "/admin/webhookDeliveries/" + dead.DeliveryId + "/replay",
+			out:            `(?m)^{"deliveryId":"` + dead.DeliveryId + `",.*"status":"dead","attempts":1,"lastStatusCode":500,`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         8,
+			method:         "POST",
+			path:           "/admin/webhookDeliveries/" + succeeded.DeliveryId + "/replay",
+			out:            `(?m)^{"deliveryId":"` + succeeded.DeliveryId + `",.*"status":"succeeded","attempts":1,`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         9,
+			method:         "DELETE",
+			path:           "/admin/webhooks/webhook-ok",
+			out:            ``,
+			httpStatusCode: 204,
+		},
+		{
+			testNo:         10,
+			method:         "DELETE",
+			path:           "/admin/webhooks/webhook-fail",
+			out:            ``,
+			httpStatusCode: 204,
+		},
+	})
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/swagchat/chat-api/datastore"
+	"github.com/swagchat/chat-api/models"
+)
+
+// ageMessage moves the creation time of the message days back.
+func ageMessage(t *testing.T, messageId string, days int64) {
+	dRes := datastore.GetProvider().SelectMessage(messageId)
+	if dRes.ProblemDetail != nil || dRes.Data == nil {
+		t.Fatalf("%s could not be selected: %v", messageId, dRes.ProblemDetail)
+	}
+	message := dRes.Data.(*models.Message)
+	message.Created = time.Now().Unix() - days*24*60*60
+	if dRes := datastore.GetProvider().UpdateMessage(message); dRes.ProblemDetail != nil {
+		t.Fatalf("%s could not be updated: %v", messageId, dRes.ProblemDetail)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	ts := httptest.NewServer(Mux)
+	defer ts.Close()
+	defer setupModerationWebhook()()
+
+	webhook := webhookServer(t, "retention-secret", http.StatusOK)
+	defer webhook.Close()
+
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         1,
+			method:         "POST",
+			path:           "/admin/webhooks",
+			in:             `{"webhookId": "retention-webhook", "url": "` + webhook.URL + `", "secret": "retention-secret", "events": ["messageCreated"]}`,
+			out:            `(?m)^{"webhookId":"retention-webhook",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         2,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "retention-user-1", "name": "retention user 1"}`,
+			out:            `(?m)^{"userId":"retention-user-1",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         3,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "retention-user-2", "name": "retention user 2"}`,
+			out:            `(?m)^{"userId":"retention-user-2",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         4,
+			method:         "POST",
+			path:           "/rooms",
+			in:             `{"roomId": "retention-room-1", "userId": "retention-user-1", "name": "retention room 1", "type": 3, "userIds": ["retention-user-2"]}`,
+			out:            `(?m)^{"roomId":"retention-room-1",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         5,
+			method:         "POST",
+			path:           "/rooms",
+			in:             `{"roomId": "retention-room-2", "userId": "retention-user-1", "name": "retention room 2", "type": 3, "userIds": ["retention-user-2"]}`,
+			out:            `(?m)^{"roomId":"retention-room-2",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         6,
+			method:         "PUT",
+			path:           "/admin/rooms/retention-room-1/retention",
+			in:             `{"retentionDays": 1}`,
+			out:            `(?m)^{"roomId":"retention-room-1","retentionDays":1,"effectiveRetentionDays":1,"legalHold":false}$`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         7,
+			method:         "PUT",
+			path:           "/admin/rooms/retention-room-2/retention",
+			in:             `{"retentionDays": 1, "legalHold": true}`,
+			out:            `(?m)^{"roomId":"retention-room-2","retentionDays":1,"effectiveRetentionDays":1,"legalHold":true}$`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         8,
+			method:         "PUT",
+			path:           "/admin/users/retention-user-2/legalHold",
+			in:             `{"legalHold": true}`,
+			out:            `(?m)^{"userId":"retention-user-2","legalHold":true}$`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo: 9,
+			method: "POST",
+			path:   "/messages",
+			in: `
+				{
+					"messages": [
+						{"messageId": "retention-message-1", "roomId": "retention-room-1", "userId": "retention-user-1", "type": "text", "payload": {"text": "expired"}},
+						{"messageId": "retention-message-2", "roomId": "retention-room-1", "userId": "retention-user-2", "type": "text", "payload": {"text": "user on legal hold"}},
+						{"messageId": "retention-message-3", "roomId": "retention-room-2", "userId": "retention-user-1", "type": "text", "payload": {"text": "room on legal hold"}},
+						{"messageId": "retention-message-4", "roomId": "retention-room-1", "userId": "retention-user-1", "type": "text", "payload": {"text": "recent"}}
+					]
+				}
+			`,
+			out:            `(?m)^{"messageIds":\["retention-message-1","retention-message-2","retention-message-3","retention-message-4"\]}$`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         10,
+			method:         "POST",
+			path:           "/messages/retention-message-1/reports",
+			in:             `{"reporterUserId": "retention-user-2", "reasonCode": "spam", "comment": "it says expired"}`,
+			out:            `(?m)"messageId":"retention-message-1",.*"comment":"it says expired"`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         11,
+			method:         "PUT",
+			path:           "/messages/retention-message-1",
+			in:             `{"userId": "retention-user-1", "payload": {"text": "hold me expired"}}`,
+			out:            `(?m)^{"heldMessageId":"[a-z0-9-]+","messageId":"retention-message-1",.*"status":"held",`,
+			httpStatusCode: 202,
+		},
+	})
+
+	waitWebhookDelivery(t, ts, "retention-webhook", "succeeded", 4)
+	for _, messageId := range []string{"retention-message-1", "retention-message-2", "retention-message-3"} {
+		ageMessage(t, messageId, 2)
+	}
+
+	runTestTable(t, ts, []testRecord{
+		{
+			// Only the message neither on legal hold nor recent is purged
+			testNo:         12,
+			method:         "POST",
+			path:           "/admin/purgeReports",
+			out:            `(?m)^{"purgeReportId":"[a-z0-9-]+","roomCount":1,"messageCount":1,"assetCount":0,"errorCount":0,"rooms":\[{"roomId":"retention-room-1","retentionDays":1,`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         13,
+			method:         "GET",
+			path:           "/messages/retention-message-1",
+			out:            ``,
+			httpStatusCode: 404,
+		},
+		{
+			testNo:         14,
+			method:         "GET",
+			path:           "/messages/retention-message-2",
+			out:            `(?m)^{"messageId":"retention-message-2",`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         15,
+			method:         "GET",
+			path:           "/messages/retention-message-3",
+			out:            `(?m)^{"messageId":"retention-message-3",`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         16,
+			method:         "GET",
+			path:           "/messages/retention-message-4",
+			out:            `(?m)^{"messageId":"retention-message-4",`,
+			httpStatusCode: 200,
+		},
+		{
+			// The report is kept without the comment quoting the message
+			testNo:         17,
+			method:         "GET",
+			path:           "/admin/reports?limit=100",
+			out:            `(?m)"messageId":"retention-message-1",`,
+			notOut:         `it says expired`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         18,
+			method:         "GET",
+			path:           "/admin/heldMessages?limit=100",
+			out:            ``,
+			notOut:         `retention-message-1`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         19,
+			method:         "GET",
+			path:           "/admin/webhooks/retention-webhook/deliveries?limit=100",
+			out:            `(?m)"messageId":"retention-message-2",`,
+			notOut:         `retention-message-1`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         20,
+			method:         "DELETE",
+			path:           "/admin/webhooks/retention-webhook",
+			out:            ``,
+			httpStatusCode: 204,
+		},
+	})
+}
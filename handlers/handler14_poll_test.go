@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/swagchat/chat-api/datastore"
+	"github.com/swagchat/chat-api/models"
+)
+
+// expirePoll moves the deadline of the poll to the past, as if it had passed.
+func expirePoll(t *testing.T, messageId string) {
+	dRes := datastore.GetProvider().SelectPoll(messageId)
+	if dRes.ProblemDetail != nil || dRes.Data == nil {
+		t.Fatalf("the poll of %s could not be selected: %v", messageId, dRes.ProblemDetail)
+	}
+	poll := dRes.Data.(*models.Poll)
+	poll.Deadline = time.Now().Unix() - 1
+	if dRes := datastore.GetProvider().UpdatePoll(poll); dRes.ProblemDetail != nil {
+		t.Fatalf("the poll of %s could not be updated: %v", messageId, dRes.ProblemDetail)
+	}
+}
+
+func TestPoll(t *testing.T) {
+	ts := httptest.NewServer(Mux)
+	defer ts.Close()
+
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         1,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "poll-user-1", "name": "poll user 1"}`,
+			out:            `(?m)^{"userId":"poll-user-1",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         2,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "poll-user-2", "name": "poll user 2"}`,
+			out:            `(?m)^{"userId":"poll-user-2",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         3,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "poll-user-3", "name": "poll user 3"}`,
+			out:            `(?m)^{"userId":"poll-user-3",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         4,
+			method:         "POST",
+			path:           "/rooms",
+			in:             `{"roomId": "poll-room-1", "userId": "poll-user-1", "name": "poll room", "type": 3, "userIds": ["poll-user-2"]}`,
+			out:            `(?m)^{"roomId":"poll-room-1",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo: 5,
+			method: "POST",
+			path:   "/messages",
+			in: `
+				{
+					"messages": [
+						{"messageId": "poll-message-1", "roomId": "poll-room-1", "userId": "poll-user-1", "type": "poll", "payload": {"question": "lunch?", "options": [{"text": "sushi"}, {"text": "ramen"}, {"optionId": "1", "text": "curry"}]}},
+						{"messageId": "poll-message-2", "roomId": "poll-room-1", "userId": "poll-user-1", "type": "poll", "payload": {"question": "when?", "options": [{"optionId": "noon", "text": "noon"}, {"optionId": "night", "text": "night"}], "isMultipleChoice": true, "isAnonymous": true, "deadline": "2100-01-01T00:00:00Z"}},
+						{"messageId": "poll-message-3", "roomId": "poll-room-1", "userId": "poll-user-1", "type": "text", "payload": {"text": "not a poll"}}
+					]
+				}
+			`,
+			out:            `(?m)^{"messageIds":\["poll-message-1","poll-message-2","poll-message-3"\]}$`,
+			httpStatusCode: 201,
+		},
+		{
+			// The options without an id are numbered around the ids already used
+			testNo:         6,
+			method:         "GET",
+			path:           "/messages/poll-message-1",
+			out:            `(?m)"options":\[{"optionId":"2","text":"sushi"},{"optionId":"3","text":"ramen"},{"optionId":"1","text":"curry"}\].*"poll":{"messageId":"poll-message-1","tallies":\[{"optionId":"2","count":0},{"optionId":"3","count":0},{"optionId":"1","count":0}\],"voterCount":0,"isClosed":false}`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         7,
+			method:         "POST",
+			path:           "/messages",
+			in:             `{"messages": [{"roomId": "poll-room-1", "userId": "poll-user-1", "type": "poll", "payload": {"question": "expired?", "options": [{"text": "yes"}, {"text": "no"}], "deadline": "2000-01-01T00:00:00Z"}}]}`,
+			out:            `(?m)"reason":"Poll deadline must be in the future."`,
+			httpStatusCode: 400,
+		},
+		{
+			testNo:         8,
+			method:         "POST",
+			path:           "/messages/poll-message-1/votes",
+			in:             `{"userId": "poll-user-1", "optionIds": ["2"]}`,
+			out:            `(?m)^{"messageId":"poll-message-1","tallies":\[{"optionId":"2","count":1,"userIds":\["poll-user-1"\]},{"optionId":"3","count":0},{"optionId":"1","count":0}\],"voterCount":1,"isClosed":false}$`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         9,
+			method:         "POST",
+			path:           "/messages/poll-message-1/votes",
+			in:             `{"userId": "poll-user-2", "optionIds": ["2"]}`,
+			out:            `(?m)"tallies":\[{"optionId":"2","count":2,"userIds":\["poll-user-1","poll-user-2"\]},`,
+			httpStatusCode: 200,
+		},
+		{
+			// A vote replaces the previous vote of the user
+			testNo:         10,
+			method:         "POST",
+			path:           "/messages/poll-message-1/votes",
+			in:             `{"userId": "poll-user-2", "optionIds": ["1"]}`,
+			out:            `(?m)"tallies":\[{"optionId":"2","count":1,"userIds":\["poll-user-1"\]},{"optionId":"3","count":0},{"optionId":"1","count":1,"userIds":\["poll-user-2"\]}\],"voterCount":2,`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         11,
+			method:         "POST",
+			path:           "/messages/poll-message-1/votes",
+			in:             `{"userId": "poll-user-1", "optionIds": ["2", "3"]}`,
+			out:            `(?m)"reason":"This poll accepts only one option."`,
+			httpStatusCode: 400,
+		},
+		{
+			testNo:         12,
+			method:         "POST",
+			path:           "/messages/poll-message-1/votes",
+			in:             `{"userId": "poll-user-1", "optionIds": ["9"]}`,
+			out:            `(?m)"reason":"optionIds contains an unknown option \[9\]."`,
+			httpStatusCode: 400,
+		},
+		{
+			testNo:         13,
+			method:         "POST",
+			path:           "/messages/poll-message-1/votes",
+			in:             `{"userId": "poll-user-3", "optionIds": ["2"]}`,
+			out:            `(?m)"title":"Only room members can vote. \(Create poll vote item\)"`,
+			httpStatusCode: 403,
+		},
+		{
+			testNo:         14,
+			method:         "POST",
+			path:           "/messages/poll-message-3/votes",
+			in:             `{"userId": "poll-user-1", "optionIds": ["2"]}`,
+			out:            `(?m)"reason":"messageId is invalid. The message is not a poll."`,
+			httpStatusCode: 400,
+		},
+		{
+			// An anonymous poll counts the votes without the voters
+			testNo:         15,
+			method:         "POST",
+			path:           "/messages/poll-message-2/votes",
+			in:             `{"userId": "poll-user-1", "optionIds": ["noon", "night", "noon"]}`,
+			out:            `(?m)^{"messageId":"poll-message-2","tallies":\[{"optionId":"noon","count":1},{"optionId":"night","count":1}\],"voterCount":1,"isClosed":false,"deadline":"2100-01-01T00:00:00Z"}$`,
+			httpStatusCode: 200,
+		},
+		{
+			// Every poll of the page has its result
+			testNo:         16,
+			method:         "GET",
+			path:           "/rooms/poll-room-1/messages",
+			out:            `(?m)"messageId":"poll-message-1",.*"poll":{"messageId":"poll-message-1","tallies":\[{"optionId":"2","count":1,.*"voterCount":2,.*"messageId":"poll-message-2",.*"poll":{"messageId":"poll-message-2",.*"voterCount":1,`,
+			notOut:         `"poll":{"messageId":"poll-message-3"`,
+			httpStatusCode: 200,
+		},
+	})
+
+	// A vote after the deadline is refused, and closes the poll
+	expirePoll(t, "poll-message-1")
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         17,
+			method:         "POST",
+			path:           "/messages/poll-message-1/votes",
+			in:             `{"userId": "poll-user-1", "optionIds": ["3"]}`,
+			out:            ``,
+			httpStatusCode: 409,
+		},
+	})
+
+	closed := regexp.MustCompile(`"poll":{"messageId":"poll-message-1","tallies":\[{"optionId":"2","count":1,.*"isClosed":true,"deadline":"[0-9T:-]+Z","closed":"[0-9T:-]+Z"}`)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		statusCode, data, err := adminRequest(ts, "GET", "/messages/poll-message-1", "")
+		if err != nil || statusCode != 200 {
+			t.Fatalf("GET message failed: %d %s %v", statusCode, data, err)
+		}
+		if closed.MatchString(data) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the poll was not closed: %s", data)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRoomUserDraft(t *testing.T) {
+	ts := httptest.NewServer(Mux)
+	defer ts.Close()
+
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         1,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "draft-user-1", "name": "draft user 1"}`,
+			out:            `(?m)^{"userId":"draft-user-1",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         2,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "draft-user-2", "name": "draft user 2"}`,
+			out:            `(?m)^{"userId":"draft-user-2",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         3,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "draft-user-3", "name": "draft user 3"}`,
+			out:            `(?m)^{"userId":"draft-user-3",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         4,
+			method:         "POST",
+			path:           "/rooms",
+			in:             `{"roomId": "draft-room-1", "userId": "draft-user-1", "name": "draft room", "type": 3, "userIds": ["draft-user-2"]}`,
+			out:            `(?m)^{"roomId":"draft-room-1",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         5,
+			method:         "GET",
+			path:           "/rooms/draft-room-1/users/draft-user-1/draft",
+			out:            `(?m)^{"roomId":"draft-room-1","userId":"draft-user-1","draft":"","modified":""}$`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         6,
+			method:         "PUT",
+			path:           "/rooms/draft-room-1/users/draft-user-1/draft",
+			in:             `{"draft": "see you"}`,
+			out:            `(?m)^{"roomId":"draft-room-1","userId":"draft-user-1","draft":"see you","modified":"[0-9T:-]+Z"}$`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         7,
+			method:         "PUT",
+			path:           "/rooms/draft-room-1/users/draft-user-2/draft",
+			in:             `{"draft": "thanks"}`,
+			out:            `(?m)^{"roomId":"draft-room-1","userId":"draft-user-2","draft":"thanks",`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         8,
+			method:         "PUT",
+			path:           "/rooms/draft-room-1/users/draft-user-1/draft",
+			in:             `{"draft": ""}`,
+			out:            `(?m)"reason":"draft is required, but it's empty. Use DELETE to clear the draft."`,
+			httpStatusCode: 400,
+		},
+		{
+			testNo:         9,
+			method:         "PUT",
+			path:           "/rooms/draft-room-1/users/draft-user-3/draft",
+			in:             `{"draft": "not a member"}`,
+			out:            ``,
+			httpStatusCode: 404,
+		},
+		{
+			testNo:         10,
+			method:         "GET",
+			path:           "/rooms/draft-room-1/users/draft-user-1/draft",
+			out:            `(?m)^{"roomId":"draft-room-1","userId":"draft-user-1","draft":"see you","modified":"[0-9T:-]+Z"}$`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         11,
+			method:         "POST",
+			path:           "/messages",
+			in:             `{"messages": [{"messageId": "draft-message-1", "roomId": "draft-room-1", "userId": "draft-user-1", "type": "text", "payload": {"text": "see you"}}]}`,
+			out:            `(?m)^{"messageIds":\["draft-message-1"\]}$`,
+			httpStatusCode: 201,
+		},
+		{
+			// Posting a message clears the draft of the sender only
+			testNo:         12,
+			method:         "GET",
+			path:           "/rooms/draft-room-1/users/draft-user-1/draft",
+			out:            `(?m)^{"roomId":"draft-room-1","userId":"draft-user-1","draft":"","modified":""}$`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         13,
+			method:         "GET",
+			path:           "/rooms/draft-room-1/users/draft-user-2/draft",
+			out:            `(?m)^{"roomId":"draft-room-1","userId":"draft-user-2","draft":"thanks",`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         14,
+			method:         "DELETE",
+			path:           "/rooms/draft-room-1/users/draft-user-2/draft",
+			out:            `^$`,
+			httpStatusCode: 204,
+		},
+		{
+			testNo:         15,
+			method:         "GET",
+			path:           "/rooms/draft-room-1/users/draft-user-2/draft",
+			out:            `(?m)^{"roomId":"draft-room-1","userId":"draft-user-2","draft":"","modified":""}$`,
+			httpStatusCode: 200,
+		},
+	})
+}
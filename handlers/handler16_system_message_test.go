@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSystemMessage(t *testing.T) {
+	ts := httptest.NewServer(Mux)
+	defer ts.Close()
+
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         1,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "system-user-1", "name": "system user 1"}`,
+			out:            `(?m)^{"userId":"system-user-1",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         2,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "system-user-2", "name": "system user 2"}`,
+			out:            `(?m)^{"userId":"system-user-2",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         3,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "system-user-3", "name": "system user 3"}`,
+			out:            `(?m)^{"userId":"system-user-3",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         4,
+			method:         "POST",
+			path:           "/rooms",
+			in:             `{"roomId": "system-room-1", "userId": "system-user-1", "name": "system room 1", "type": 3, "userIds": ["system-user-2"]}`,
+			out:            `(?m)^{"roomId":"system-room-1",.*"isSystemMessage":true,`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         5,
+			method:         "POST",
+			path:           "/rooms",
+			in:             `{"roomId": "system-room-2", "userId": "system-user-1", "name": "system room 2", "type": 3, "userIds": ["system-user-2"], "isSystemMessage": false}`,
+			out:            `(?m)^{"roomId":"system-room-2",.*"isSystemMessage":false,`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         6,
+			method:         "PUT",
+			path:           "/rooms/system-room-1/users",
+			in:             `{"userIds": ["system-user-3"]}`,
+			out:            `(?m)"userId":"system-user-3"`,
+			httpStatusCode: 200,
+		},
+		{
+			// The owner is not changed by the userId of the body
+			testNo:         7,
+			method:         "PUT",
+			path:           "/rooms/system-room-1",
+			in:             `{"userId": "system-user-2", "name": "renamed room"}`,
+			out:            `(?m)^{"roomId":"system-room-1","userId":"system-user-1","name":"renamed room",`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         8,
+			method:         "DELETE",
+			path:           "/rooms/system-room-1/users",
+			in:             `{"userIds": ["system-user-3"]}`,
+			out:            ``,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         9,
+			method:         "GET",
+			path:           "/rooms/system-room-1/messages",
+			out:            `(?m)"roomId":"system-room-1","userId":"system-user-1","type":"system","payload":{"action":"userJoin","userIds":\["system-user-3"\]}`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         10,
+			method:         "GET",
+			path:           "/rooms/system-room-1/messages",
+			out:            `(?m)"type":"system","payload":{"action":"roomRename","oldValue":"system room 1","newValue":"renamed room"}`,
+			notOut:         `"action":"ownerTransfer"`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         11,
+			method:         "GET",
+			path:           "/rooms/system-room-1/messages",
+			out:            `(?m)"type":"system","payload":{"action":"userLeft","userIds":\["system-user-3"\]}.*"allCount":3}$`,
+			httpStatusCode: 200,
+		},
+		{
+			// System messages are not counted as unread
+			testNo:         12,
+			method:         "GET",
+			path:           "/users/system-user-2",
+			out:            `(?m)^{"userId":"system-user-2",.*"unreadCount":0,`,
+			httpStatusCode: 200,
+		},
+		{
+			// A room opted out of system messages keeps no history of its changes
+			testNo:         13,
+			method:         "PUT",
+			path:           "/rooms/system-room-2",
+			in:             `{"name": "renamed room 2"}`,
+			out:            `(?m)^{"roomId":"system-room-2",.*"name":"renamed room 2",`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         14,
+			method:         "GET",
+			path:           "/rooms/system-room-2/messages",
+			out:            `(?m)^{"messages":\[\],"allCount":0}$`,
+			httpStatusCode: 200,
+		},
+	})
+}
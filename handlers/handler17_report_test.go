@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+// postReport creates a report and returns its id.
+func postReport(t *testing.T, ts *httptest.Server, path, in string) string {
+	statusCode, data, err := adminRequest(ts, "POST", path, in)
+	if err != nil || statusCode != 201 {
+		t.Fatalf("POST %s failed: %d %s %v", path, statusCode, data, err)
+	}
+	var report struct {
+		ReportId string `json:"reportId"`
+	}
+	if err := json.Unmarshal([]byte(data), &report); err != nil {
+		t.Fatalf("Error by json.Unmarshal(): %v", err)
+	}
+	return report.ReportId
+}
+
+func TestReportTriage(t *testing.T) {
+	ts := httptest.NewServer(Mux)
+	defer ts.Close()
+
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         1,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "report-user-1", "name": "report user 1"}`,
+			out:            `(?m)^{"userId":"report-user-1",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         2,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "report-user-2", "name": "report user 2"}`,
+			out:            `(?m)^{"userId":"report-user-2",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         3,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "report-user-3", "name": "report user 3"}`,
+			out:            `(?m)^{"userId":"report-user-3",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         4,
+			method:         "POST",
+			path:           "/rooms",
+			in:             `{"roomId": "report-room-1", "userId": "report-user-1", "name": "report room", "type": 3, "userIds": ["report-user-2"]}`,
+			out:            `(?m)^{"roomId":"report-room-1",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         5,
+			method:         "POST",
+			path:           "/messages",
+			in:             `{"messages": [{"messageId": "report-message-1", "roomId": "report-room-1", "userId": "report-user-2", "type": "text", "payload": {"text": "buy now"}}]}`,
+			out:            `(?m)^{"messageIds":\["report-message-1"\]}$`,
+			httpStatusCode: 201,
+		},
+		{
+			// Only the users of the room can report its messages
+			testNo:         6,
+			method:         "POST",
+			path:           "/messages/report-message-1/reports",
+			in:             `{"reporterUserId": "report-user-3", "reasonCode": "spam"}`,
+			out:            `(?m)^{"title":"You do not have permission to report this message.","status":403,`,
+			httpStatusCode: 403,
+		},
+		{
+			testNo:         7,
+			method:         "POST",
+			path:           "/messages/report-message-1/reports",
+			in:             `{"reporterUserId": "report-user-2", "reasonCode": "spam"}`,
+			out:            `(?m)"reason":"Users can not report themselves."`,
+			httpStatusCode: 400,
+		},
+		{
+			testNo:         8,
+			method:         "POST",
+			path:           "/messages/report-message-1/reports",
+			in:             `{"reporterUserId": "report-user-1", "reasonCode": "boring"}`,
+			out:            `(?m)"name":"reasonCode"`,
+			httpStatusCode: 400,
+		},
+		{
+			testNo:         9,
+			method:         "POST",
+			path:           "/users/report-user-3/reports",
+			in:             `{"reporterUserId": "report-user-1", "roomId": "report-room-1", "reasonCode": "harassment"}`,
+			out:            `(?m)"reason":"roomId is invalid. Reported user is not a user of this room."`,
+			httpStatusCode: 400,
+		},
+	})
+
+	messageReportId := postReport(t, ts, "/messages/report-message-1/reports", `{"reporterUserId": "report-user-1", "reasonCode": "spam", "comment": "an ad"}`)
+	userReportId1 := postReport(t, ts, "/users/report-user-2/reports", `{"reporterUserId": "report-user-1", "roomId": "report-room-1", "reasonCode": "harassment"}`)
+	userReportId2 := postReport(t, ts, "/users/report-user-2/reports", `{"reporterUserId": "report-user-3", "reasonCode": "other"}`)
+
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         10,
+			method:         "GET",
+			path:           "/admin/reports/" + messageReportId,
+			out:            `(?m)^{"reportId":"` + messageReportId + `","targetType":"message","messageId":"report-message-1","roomId":"report-room-1","targetUserId":"report-user-2","reporterUserId":"report-user-1","reasonCode":"spam","comment":"an ad","status":"open",`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         11,
+			method:         "POST",
+			path:           "/admin/reports/" + messageReportId + "/triage",
+			in:             `{"note": "looking into it"}`,
+			out:            `(?m)^{"reportId":"` + messageReportId + `",.*"status":"triaged","note":"looking into it",`,
+			httpStatusCode: 200,
+		},
+		{
+			// The open reports are listed by default, the others by status
+			testNo:         12,
+			method:         "GET",
+			path:           "/admin/reports?limit=100",
+			out:            `(?m)"reportId":"` + userReportId1 + `",.*"reportId":"` + userReportId2 + `"|"reportId":"` + userReportId2 + `",.*"reportId":"` + userReportId1 + `"`,
+			notOut:         messageReportId,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         13,
+			method:         "GET",
+			path:           "/admin/reports?status=triaged&limit=100",
+			out:            `(?m)"reportId":"` + messageReportId + `"`,
+			notOut:         userReportId1,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         14,
+			method:         "POST",
+			path:           "/admin/reports/" + userReportId1 + "/resolve",
+			in:             `{"action": "deleteMessage"}`,
+			out:            `(?m)"reason":"deleteMessage is only available for message reports."`,
+			httpStatusCode: 400,
+		},
+		{
+			testNo:         15,
+			method:         "POST",
+			path:           "/admin/reports/" + userReportId2 + "/resolve",
+			in:             `{"action": "removeFromRoom"}`,
+			out:            `(?m)"reason":"removeFromRoom needs a report with roomId."`,
+			httpStatusCode: 400,
+		},
+		{
+			// A triaged report can still be resolved, which runs the action
+			testNo:         16,
+			method:         "POST",
+			path:           "/admin/reports/" + messageReportId + "/resolve",
+			in:             `{"action": "deleteMessage", "note": "removed the ad"}`,
+			out:            `(?m)^{"reportId":"` + messageReportId + `",.*"status":"resolved","action":"deleteMessage","note":"removed the ad",`,
+			httpStatusCode: 200,
+		},
+		{
+			// A deleted message is not found anymore, as it is left out of the message list
+			testNo:         17,
+			method:         "GET",
+			path:           "/messages/report-message-1",
+			out:            ``,
+			httpStatusCode: 404,
+		},
+		{
+			testNo:         18,
+			method:         "GET",
+			path:           "/rooms/report-room-1/messages",
+			out:            `(?m)"allCount":0}$`,
+			notOut:         `report-message-1`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         19,
+			method:         "POST",
+			path:           "/admin/reports/" + userReportId1 + "/resolve",
+			in:             `{"action": "removeFromRoom"}`,
+			out:            `(?m)^{"reportId":"` + userReportId1 + `",.*"status":"resolved","action":"removeFromRoom",`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         20,
+			method:         "GET",
+			path:           "/rooms/report-room-1/users/report-user-2/draft",
+			out:            ``,
+			httpStatusCode: 404,
+		},
+		{
+			testNo:         21,
+			method:         "POST",
+			path:           "/admin/reports/" + userReportId2 + "/dismiss",
+			in:             `{"note": "no evidence"}`,
+			out:            `(?m)^{"reportId":"` + userReportId2 + `",.*"status":"dismissed","note":"no evidence",`,
+			httpStatusCode: 200,
+		},
+		{
+			// A closed report can not be triaged, resolved nor dismissed again
+			testNo:         22,
+			method:         "POST",
+			path:           "/admin/reports/" + userReportId2 + "/triage",
+			in:             `{}`,
+			out:            ``,
+			httpStatusCode: 409,
+		},
+		{
+			testNo:         23,
+			method:         "POST",
+			path:           "/admin/reports/" + messageReportId + "/dismiss",
+			in:             `{}`,
+			out:            ``,
+			httpStatusCode: 409,
+		},
+		{
+			testNo:         24,
+			method:         "POST",
+			path:           "/admin/reports/not-exist-report-id/triage",
+			in:             `{}`,
+			out:            ``,
+			httpStatusCode: 404,
+		},
+	})
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+// postIncomingWebhook creates an incoming webhook and returns its path relative to the api version.
+func postIncomingWebhook(t *testing.T, ts *httptest.Server, in string) string {
+	statusCode, data, err := adminRequest(ts, "POST", "/admin/incomingWebhooks", in)
+	if err != nil || statusCode != 201 {
+		t.Fatalf("POST incoming webhook failed: %d %s %v", statusCode, data, err)
+	}
+	var incomingWebhook struct {
+		Url string `json:"url"`
+	}
+	if err := json.Unmarshal([]byte(data), &incomingWebhook); err != nil {
+		t.Fatalf("Error by json.Unmarshal(): %v", err)
+	}
+	if !strings.HasPrefix(incomingWebhook.Url, "/"+utils.API_VERSION+"/hooks/") {
+		t.Fatalf("unexpected url: %s", incomingWebhook.Url)
+	}
+	return strings.TrimPrefix(incomingWebhook.Url, "/"+utils.API_VERSION)
+}
+
+func TestIncomingWebhookSecret(t *testing.T) {
+	ts := httptest.NewServer(Mux)
+	defer ts.Close()
+
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         1,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "hook-user-1", "name": "hook user 1"}`,
+			out:            `(?m)^{"userId":"hook-user-1",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         2,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "hook-user-2", "name": "hook user 2"}`,
+			out:            `(?m)^{"userId":"hook-user-2",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         3,
+			method:         "POST",
+			path:           "/rooms",
+			in:             `{"roomId": "hook-room-1", "userId": "hook-user-1", "name": "hook room", "type": 3, "userIds": ["hook-user-2"]}`,
+			out:            `(?m)^{"roomId":"hook-room-1",`,
+			httpStatusCode: 201,
+		},
+		{
+			// The hook user must be a user of the room
+			testNo:         4,
+			method:         "POST",
+			path:           "/admin/incomingWebhooks",
+			in:             `{"roomId": "hook-room-1", "userId": "not-exist-user-id"}`,
+			out:            `(?m)"name":"userId"`,
+			httpStatusCode: 400,
+		},
+	})
+
+	hookPath := postIncomingWebhook(t, ts, `{"incomingWebhookId": "hook-1", "name": "ci", "roomId": "hook-room-1", "userId": "hook-user-1"}`)
+	limitedPath := postIncomingWebhook(t, ts, `{"incomingWebhookId": "hook-2", "roomId": "hook-room-1", "userId": "hook-user-2", "rateLimit": 1}`)
+	secret := strings.TrimPrefix(hookPath, "/hooks/")
+
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         5,
+			method:         "POST",
+			path:           hookPath,
+			in:             `{"text": "build passed"}`,
+			out:            `(?m)^{"messageIds":\["[a-z0-9-]+"\]}$`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         6,
+			method:         "GET",
+			path:           "/rooms/hook-room-1/messages",
+			out:            `(?m)"roomId":"hook-room-1","userId":"hook-user-1","type":"text","payload":{"text":"build passed"}`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         7,
+			method:         "POST",
+			path:           hookPath,
+			in:             `{}`,
+			out:            `(?m)"reason":"text or attachments is required."`,
+			httpStatusCode: 400,
+		},
+		{
+			// An unknown secret, or one differing by a character, is rejected like a missing page
+			testNo:         8,
+			method:         "POST",
+			path:           "/hooks/" + strings.Repeat("0", len(secret)),
+			in:             `{"text": "guessed"}`,
+			out:            ``,
+			httpStatusCode: 404,
+		},
+		{
+			testNo:         9,
+			method:         "POST",
+			path:           "/hooks/" + secret[:len(secret)-1],
+			in:             `{"text": "truncated"}`,
+			out:            ``,
+			httpStatusCode: 404,
+		},
+		{
+			// The secret is only shown as the url
+			testNo:         10,
+			method:         "GET",
+			path:           "/admin/incomingWebhooks/hook-1",
+			out:            `(?m)^{"incomingWebhookId":"hook-1","name":"ci","roomId":"hook-room-1","userId":"hook-user-1","url":"/` + utils.API_VERSION + hookPath + `",`,
+			notOut:         `"secret"`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         11,
+			method:         "POST",
+			path:           limitedPath,
+			in:             `{"text": "first"}`,
+			out:            `(?m)^{"messageIds":\["[a-z0-9-]+"\]}$`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         12,
+			method:         "POST",
+			path:           limitedPath,
+			in:             `{"text": "second"}`,
+			out:            `(?m)^{"title":"Too many requests to the incoming webhook. Please try again later.","status":429,`,
+			httpStatusCode: 429,
+		},
+		{
+			testNo:         13,
+			method:         "DELETE",
+			path:           "/admin/incomingWebhooks/hook-1",
+			out:            `^$`,
+			httpStatusCode: 204,
+		},
+		{
+			// A revoked secret is rejected, and the hook is kept without its url
+			testNo:         14,
+			method:         "POST",
+			path:           hookPath,
+			in:             `{"text": "after revoke"}`,
+			out:            ``,
+			httpStatusCode: 404,
+		},
+		{
+			testNo:         15,
+			method:         "GET",
+			path:           "/admin/incomingWebhooks/hook-1",
+			out:            `(?m)^{"incomingWebhookId":"hook-1",.*"revoked":"[0-9T:-]+Z"}$`,
+			notOut:         `"url"`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         16,
+			method:         "DELETE",
+			path:           "/admin/incomingWebhooks/hook-1",
+			out:            ``,
+			httpStatusCode: 409,
+		},
+	})
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+// postUserAccessToken creates a user and returns its access token.
+func postUserAccessToken(t *testing.T, ts *httptest.Server, userId string) string {
+	statusCode, data, err := adminRequest(ts, "POST", "/users", `{"userId": "`+userId+`", "name": "`+userId+`"}`)
+	if err != nil || statusCode != 201 {
+		t.Fatalf("POST user failed: %d %s %v", statusCode, data, err)
+	}
+	var user struct {
+		AccessToken string `json:"accessToken"`
+	}
+	if err := json.Unmarshal([]byte(data), &user); err != nil {
+		t.Fatalf("Error by json.Unmarshal(): %v", err)
+	}
+	return user.AccessToken
+}
+
+// userRequest sends a request as the user. Without accessToken it is sent as a guest.
+func userRequest(ts *httptest.Server, method, path, userId, accessToken string) (int, http.Header, string, error) {
+	req, err := http.NewRequest(method, ts.URL+"/"+utils.API_VERSION+path, nil)
+	if err != nil {
+		return 0, nil, "", err
+	}
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		req.Header.Set(utils.HEADER_USER_ID, userId)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, nil, "", err
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return 0, nil, "", err
+	}
+	return res.StatusCode, res.Header, string(data), nil
+}
+
+// waitExportJob polls the export job until it has finished, and returns it.
+func waitExportJob(t *testing.T, ts *httptest.Server, data string) string {
+	var job struct {
+		ExportJobId string `json:"exportJobId"`
+		Status      string `json:"status"`
+	}
+	if err := json.Unmarshal([]byte(data), &job); err != nil || job.Status != "queued" {
+		t.Fatalf("the export was not queued: %s %v", data, err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		statusCode, data, err := adminRequest(ts, "GET", "/exports/"+job.ExportJobId, "")
+		if err != nil || statusCode != 200 {
+			t.Fatalf("GET export job failed: %d %s %v", statusCode, data, err)
+		}
+		if err := json.Unmarshal([]byte(data), &job); err != nil {
+			t.Fatalf("Error by json.Unmarshal(): %v", err)
+		}
+		if job.Status != "queued" && job.Status != "running" {
+			return data
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the export job did not finish: %s", data)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestExportRoom(t *testing.T) {
+	ts := httptest.NewServer(Mux)
+	defer ts.Close()
+
+	accessToken1 := postUserAccessToken(t, ts, "export-user-1")
+	postUserAccessToken(t, ts, "export-user-2")
+	accessToken3 := postUserAccessToken(t, ts, "export-user-3")
+
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         1,
+			method:         "POST",
+			path:           "/rooms",
+			in:             `{"roomId": "export-room-1", "userId": "export-user-1", "name": "export room", "type": 3, "userIds": ["export-user-2"]}`,
+			out:            `(?m)^{"roomId":"export-room-1",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo: 2,
+			method: "POST",
+			path:   "/messages",
+			in: `
+				{
+					"messages": [
+						{"messageId": "export-message-1", "roomId": "export-room-1", "userId": "export-user-1", "type": "text", "payload": {"text": "hello"}},
+						{"messageId": "export-message-2", "roomId": "export-room-1", "userId": "export-user-2", "type": "text", "payload": {"text": "oops"}},
+						{"messageId": "export-message-3", "roomId": "export-room-1", "userId": "export-user-2", "type": "text", "payload": {"text": "bye"}}
+					]
+				}
+			`,
+			out:            `(?m)^{"messageIds":\["export-message-1","export-message-2","export-message-3"\]}$`,
+			httpStatusCode: 201,
+		},
+	})
+
+	reportId := postReport(t, ts, "/messages/export-message-2/reports", `{"reporterUserId": "export-user-1", "reasonCode": "spam"}`)
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         3,
+			method:         "POST",
+			path:           "/admin/reports/" + reportId + "/resolve",
+			in:             `{"action": "deleteMessage"}`,
+			out:            `(?m)"status":"resolved","action":"deleteMessage"`,
+			httpStatusCode: 200,
+		},
+		{
+			// An export under the sync limit is streamed, with the deleted messages as tombstones for administrators
+			testNo:         4,
+			method:         "GET",
+			path:           "/rooms/export-room-1/export",
+			out:            `(?m)^{"roomId":"export-room-1","name":"export room","messages":\[{"messageId":"export-message-1","userId":"export-user-1","userName":"export-user-1","type":"text","payload":{"text":"hello"},.*{"messageId":"export-message-2",[^{}]*"deleted":"[0-9T:-]+Z"},{"messageId":"export-message-3",.*}\]}$`,
+			notOut:         `oops`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         5,
+			method:         "GET",
+			path:           "/rooms/export-room-1/export?format=csv",
+			out:            `(?m)^messageId,created,userId,userName,type,text,payload,deleted\nexport-message-1,[0-9T:-]+Z,export-user-1,export-user-1,text,hello,`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         6,
+			method:         "GET",
+			path:           "/rooms/export-room-1/export?format=pdf",
+			out:            `(?m)"reason":"format is invalid. Available values are json, csv and html."`,
+			httpStatusCode: 400,
+		},
+		{
+			testNo:         7,
+			method:         "GET",
+			path:           "/rooms/not-exist-room-id/export",
+			out:            ``,
+			httpStatusCode: 404,
+		},
+	})
+
+	// A member exports without the deleted messages, and other users and guests can not export
+	statusCode, header, data, err := userRequest(ts, "GET", "/rooms/export-room-1/export", "export-user-1", accessToken1)
+	if err != nil || statusCode != 200 || header.Get("Content-Disposition") != `attachment; filename="room-export-room-1.json"` {
+		t.Fatalf("the member could not export: %d %v %s %v", statusCode, header, data, err)
+	}
+	if !regexp.MustCompile(`"messageId":"export-message-1",.*"messageId":"export-message-3",`).MatchString(data) || regexp.MustCompile(`export-message-2`).MatchString(data) {
+		t.Fatalf("unexpected export of a member: %s", data)
+	}
+	if statusCode, _, data, err = userRequest(ts, "GET", "/rooms/export-room-1/export", "export-user-3", accessToken3); err != nil || statusCode != 403 {
+		t.Fatalf("a user out of the room could export: %d %s %v", statusCode, data, err)
+	}
+	if statusCode, _, data, err = userRequest(ts, "GET", "/rooms/export-room-1/export", "", ""); err != nil || statusCode != 403 {
+		t.Fatalf("a guest could export: %d %s %v", statusCode, data, err)
+	}
+
+	// async=true queues the export whatever its size
+	statusCode, header, data, err = userRequest(ts, "GET", "/rooms/export-room-1/export?async=true", "export-user-1", accessToken1)
+	if err != nil || statusCode != 202 {
+		t.Fatalf("the export was not accepted: %d %s %v", statusCode, data, err)
+	}
+	if !regexp.MustCompile(`^/`+utils.API_VERSION+`/exports/[a-z0-9-]+$`).MatchString(header.Get("Location")) {
+		t.Fatalf("unexpected location: %s", header.Get("Location"))
+	}
+	exportJobPath := header.Get("Location")[len("/"+utils.API_VERSION):]
+	if job := waitExportJob(t, ts, data); !regexp.MustCompile(`^{"exportJobId":"[a-z0-9-]+","roomId":"export-room-1","format":"json","includeDeleted":false,"status":"succeeded","url":"[^"]+export-[a-z0-9-]+\.json",`).MatchString(job) {
+		t.Fatalf("unexpected export job: %s", job)
+	}
+
+	// Only the user who requested the job can see it
+	if statusCode, _, data, err = userRequest(ts, "GET", exportJobPath, "export-user-1", accessToken1); err != nil || statusCode != 200 {
+		t.Fatalf("the requester could not get the job: %d %s %v", statusCode, data, err)
+	}
+	if statusCode, _, data, err = userRequest(ts, "GET", exportJobPath, "export-user-3", accessToken3); err != nil || statusCode != 404 {
+		t.Fatalf("another user could get the job: %d %s %v", statusCode, data, err)
+	}
+
+	// An export over the sync limit is queued
+	exportCfg := *utils.Cfg.Export
+	utils.Cfg.Export.SyncLimit = "2"
+	defer func() {
+		*utils.Cfg.Export = exportCfg
+	}()
+	statusCode, data, err = adminRequest(ts, "GET", "/rooms/export-room-1/export?format=csv", "")
+	if err != nil || statusCode != 202 {
+		t.Fatalf("the export over the sync limit was not queued: %d %s %v", statusCode, data, err)
+	}
+	if job := waitExportJob(t, ts, data); !regexp.MustCompile(`"format":"csv","includeDeleted":true,"status":"succeeded",`).MatchString(job) {
+		t.Fatalf("unexpected export job: %s", job)
+	}
+}
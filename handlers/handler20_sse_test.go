@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+type sseTestEvent struct {
+	id   string
+	data string
+}
+
+type sseTestStream struct {
+	res    *http.Response
+	reader *bufio.Reader
+}
+
+// openSseStream opens the event stream of the user, resuming after lastEventId if it is given.
+func openSseStream(t *testing.T, ts *httptest.Server, userId, accessToken, lastEventId string) *sseTestStream {
+	req, _ := http.NewRequest("GET", ts.URL+"/"+utils.API_VERSION+"/users/"+userId+"/events?accessToken="+url.QueryEscape(accessToken), nil)
+	if lastEventId != "" {
+		req.Header.Set("Last-Event-ID", lastEventId)
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("the event stream could not be opened: %v", err)
+	}
+	if res.StatusCode != 200 || res.Header.Get("Content-Type") != "text/event-stream" {
+		res.Body.Close()
+		t.Fatalf("unexpected event stream response: %d %s", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+	return &sseTestStream{
+		res:    res,
+		reader: bufio.NewReader(res.Body),
+	}
+}
+
+// next reads the next event, skipping the comment lines.
+func (s *sseTestStream) next(t *testing.T) *sseTestEvent {
+	e := &sseTestEvent{}
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("the event could not be read: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			if e.data != "" {
+				return e
+			}
+		case strings.HasPrefix(line, "id: "):
+			e.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			e.data += strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+// nextMessage reads events until a message event, and returns it.
+func (s *sseTestStream) nextMessage(t *testing.T) *sseTestEvent {
+	for {
+		if e := s.next(t); strings.Contains(e.data, `"eventName":"message"`) {
+			return e
+		}
+	}
+}
+
+func (s *sseTestStream) close() {
+	s.res.Body.Close()
+}
+
+func TestSseReplay(t *testing.T) {
+	ts := httptest.NewServer(Mux)
+	defer ts.Close()
+
+	rtmCfg := *utils.Cfg.Rtm
+	utils.Cfg.Rtm.Provider = "builtin"
+	defer func() {
+		*utils.Cfg.Rtm = rtmCfg
+	}()
+	// The stream is only routed when the hub is enabled on start
+	SetSseMux()
+
+	accessToken := postUserAccessToken(t, ts, "sse-user-1")
+	postUserAccessToken(t, ts, "sse-user-2")
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         1,
+			method:         "POST",
+			path:           "/rooms",
+			in:             `{"roomId": "sse-room-1", "userId": "sse-user-1", "name": "sse room", "type": 3, "userIds": ["sse-user-2"]}`,
+			out:            `(?m)^{"roomId":"sse-room-1",`,
+			httpStatusCode: 201,
+		},
+	})
+
+	if statusCode, _, data, err := userRequest(ts, "GET", "/users/sse-user-1/events?accessToken=invalid", "", ""); err != nil || statusCode != 401 {
+		t.Fatalf("the stream was opened without a valid access token: %d %s %v", statusCode, data, err)
+	}
+
+	postMessage := func(messageId string) {
+		statusCode, data, err := adminRequest(ts, "POST", "/messages", `{"messages": [{"messageId": "`+messageId+`", "roomId": "sse-room-1", "userId": "sse-user-2", "type": "text", "payload": {"text": "hi"}}]}`)
+		if err != nil || statusCode != 201 {
+			t.Fatalf("POST message failed: %d %s %v", statusCode, data, err)
+		}
+	}
+
+	stream := openSseStream(t, ts, "sse-user-1", accessToken, "")
+	postMessage("sse-message-1")
+	first := stream.nextMessage(t)
+	stream.close()
+	if first.id == "" || !strings.Contains(first.data, `"messageId":"sse-message-1"`) {
+		t.Fatalf("unexpected event: %+v", first)
+	}
+
+	// The events published while disconnected are replayed after Last-Event-ID, in order
+	postMessage("sse-message-2")
+	postMessage("sse-message-3")
+	time.Sleep(100 * time.Millisecond)
+	stream = openSseStream(t, ts, "sse-user-1", accessToken, first.id)
+	second := stream.nextMessage(t)
+	third := stream.nextMessage(t)
+	stream.close()
+	if !strings.Contains(second.data, `"messageId":"sse-message-2"`) || !strings.Contains(third.data, `"messageId":"sse-message-3"`) {
+		t.Fatalf("unexpected replay:\n%+v\n%+v", second, third)
+	}
+	if second.id == first.id || third.id == second.id {
+		t.Fatalf("the replayed events have the same ids: %s %s %s", first.id, second.id, third.id)
+	}
+
+	// An id of another process, or a malformed one, asks the client to resync instead
+	for _, lastEventId := range []string{"previous-epoch-1", "invalid"} {
+		stream = openSseStream(t, ts, "sse-user-1", accessToken, lastEventId)
+		e := stream.next(t)
+		stream.close()
+		if e.id != "" || !strings.Contains(e.data, `"eventName":"resync"`) {
+			t.Fatalf("%s was not answered with resync: %+v", lastEventId, e)
+		}
+	}
+}
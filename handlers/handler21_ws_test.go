@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/swagchat/chat-api/utils"
+)
+
+// dialWs connects to the WebSocket endpoint with the query and the headers, and returns the status code of the handshake.
+func dialWs(ts *httptest.Server, query string, header http.Header) (*websocket.Conn, int, error) {
+	wsUrl := "ws" + strings.TrimPrefix(ts.URL, "http") + "/" + utils.API_VERSION + "/ws"
+	if query != "" {
+		wsUrl += "?" + query
+	}
+	conn, res, err := websocket.DefaultDialer.Dial(wsUrl, header)
+	if res == nil {
+		return nil, 0, err
+	}
+	return conn, res.StatusCode, err
+}
+
+func TestServeWs(t *testing.T) {
+	ts := httptest.NewServer(Mux)
+	defer ts.Close()
+
+	rtmCfg := *utils.Cfg.Rtm
+	utils.Cfg.Rtm.Provider = "builtin"
+	defer func() {
+		*utils.Cfg.Rtm = rtmCfg
+	}()
+	// The endpoint is only routed when the hub is enabled on start
+	SetWsMux()
+
+	accessToken := postUserAccessToken(t, ts, "ws-user-1")
+	postUserAccessToken(t, ts, "ws-user-2")
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         1,
+			method:         "POST",
+			path:           "/rooms",
+			in:             `{"roomId": "ws-room-1", "userId": "ws-user-1", "name": "ws room", "type": 3, "userIds": ["ws-user-2"]}`,
+			out:            `(?m)^{"roomId":"ws-room-1",`,
+			httpStatusCode: 201,
+		},
+	})
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+accessToken)
+	header.Set(utils.HEADER_USER_ID, "ws-user-1")
+	testTable := []struct {
+		testNo         int
+		query          string
+		header         http.Header
+		httpStatusCode int
+	}{
+		{1, "", nil, http.StatusUnauthorized},
+		{2, "userId=ws-user-1", nil, http.StatusUnauthorized},
+		{3, "userId=ws-user-1&accessToken=invalid", nil, http.StatusUnauthorized},
+		// The access token of another user
+		{4, "userId=ws-user-2&accessToken=" + url.QueryEscape(accessToken), nil, http.StatusUnauthorized},
+		{5, "accessToken=" + url.QueryEscape(accessToken), nil, http.StatusUnauthorized},
+		// Browsers can not set headers on WebSocket, so the access token is given with the query
+		{6, "userId=ws-user-1&accessToken=" + url.QueryEscape(accessToken), nil, http.StatusSwitchingProtocols},
+		{7, "", header, http.StatusSwitchingProtocols},
+	}
+
+	for _, testRecord := range testTable {
+		conn, statusCode, err := dialWs(ts, testRecord.query, testRecord.header)
+		if statusCode != testRecord.httpStatusCode {
+			t.Fatalf("TestNo %d\nHTTP Status Code Failure\n[expected]%d\n[result  ]%d %v", testRecord.testNo, testRecord.httpStatusCode, statusCode, err)
+		}
+		if conn == nil {
+			continue
+		}
+
+		// The connection receives the events of the rooms of the user
+		messageId := "ws-message-" + strconv.Itoa(testRecord.testNo)
+		statusCode, data, err := adminRequest(ts, "POST", "/messages", `{"messages": [{"messageId": "`+messageId+`", "roomId": "ws-room-1", "userId": "ws-user-2", "type": "text", "payload": {"text": "hi"}}]}`)
+		if err != nil || statusCode != 201 {
+			t.Fatalf("TestNo %d\nPOST message failed: %d %s %v", testRecord.testNo, statusCode, data, err)
+		}
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("TestNo %d\nthe message event was not received: %v", testRecord.testNo, err)
+			}
+			if strings.Contains(string(message), `"eventName":"message"`) {
+				if !strings.Contains(string(message), `"messageId":"`+messageId+`"`) {
+					t.Fatalf("TestNo %d\nunexpected event: %s", testRecord.testNo, message)
+				}
+				break
+			}
+		}
+		conn.Close()
+	}
+}
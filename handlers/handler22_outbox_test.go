@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/swagchat/chat-api/datastore"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+// insertOutboxItem inserts an rtm item of an event to outbox-user with the status.
+func insertOutboxItem(t *testing.T, outboxItemId, status string, attempts int) {
+	event := models.NewEvent(models.EVENT_NAME_MESSAGE, "outbox-room", "outbox-user", nil)
+	event.Recipients = []string{"outbox-user"}
+	data, _ := json.Marshal(event)
+	item := &models.OutboxItem{
+		OutboxItemId: outboxItemId,
+		Kind:         models.OUTBOX_KIND_RTM,
+		RoomId:       "outbox-room",
+		MessageId:    "outbox-message",
+		Data:         data,
+		Status:       status,
+		Attempts:     attempts,
+		LastError:    "http status code[500]",
+	}
+	item.BeforeSave()
+	if dRes := datastore.GetProvider().InsertMessages(nil, []*models.OutboxItem{item}, nil); dRes.ProblemDetail != nil {
+		t.Fatalf("%s could not be inserted: %v", outboxItemId, dRes.ProblemDetail)
+	}
+}
+
+func TestOutboxItems(t *testing.T) {
+	ts := httptest.NewServer(Mux)
+	defer ts.Close()
+
+	// Requeued items are delivered without a provider
+	rtmCfg := *utils.Cfg.Rtm
+	utils.Cfg.Rtm.Provider = ""
+	defer func() {
+		*utils.Cfg.Rtm = rtmCfg
+	}()
+
+	insertOutboxItem(t, "outbox-item-pending", models.OUTBOX_ITEM_STATUS_PENDING, 1)
+	insertOutboxItem(t, "outbox-item-dead", models.OUTBOX_ITEM_STATUS_DEAD, 10)
+	insertOutboxItem(t, "outbox-item-succeeded", models.OUTBOX_ITEM_STATUS_SUCCEEDED, 1)
+
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         1,
+			method:         "GET",
+			path:           "/admin/outboxItems",
+			out:            `"outboxItemId":"outbox-item-dead",`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         2,
+			method:         "GET",
+			path:           "/admin/outboxItems?status=dead&kind=rtm",
+			out:            `(?m)^{"outboxItems":\[{"outboxItemId":"outbox-item-dead",.*"status":"dead","attempts":10,"lastError":"http status code\[500\]"`,
+			notOut:         `outbox-item-pending|outbox-item-succeeded`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         3,
+			method:         "GET",
+			path:           "/admin/outboxItems?status=dead&kind=push",
+			out:            `(?m)^{"outboxItems":\[\]}$`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         4,
+			method:         "GET",
+			path:           "/admin/outboxItems?status=unknown",
+			out:            `"name":"status"`,
+			httpStatusCode: 400,
+		},
+		{
+			testNo:         5,
+			method:         "GET",
+			path:           "/admin/outboxItems/outbox-item-pending",
+			out:            `(?m)^{"outboxItemId":"outbox-item-pending",.*"status":"pending","attempts":1,"nextAttempt":"`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         6,
+			method:         "GET",
+			path:           "/admin/outboxItems/unknown-outbox-item",
+			out:            "",
+			httpStatusCode: 404,
+		},
+		// A dead item is dispatched again from its first attempt
+		{
+			testNo:         7,
+			method:         "POST",
+			path:           "/admin/outboxItems/outbox-item-dead/requeue",
+			out:            `(?m)^{"outboxItemId":"outbox-item-dead",.*"status":"succeeded","attempts":1,"created"`,
+			notOut:         `lastError`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         8,
+			method:         "GET",
+			path:           "/admin/outboxItems/outbox-item-dead",
+			out:            `"status":"succeeded","attempts":1,`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         9,
+			method:         "POST",
+			path:           "/admin/outboxItems/outbox-item-pending/requeue",
+			out:            `"status":"succeeded","attempts":1,`,
+			httpStatusCode: 200,
+		},
+		// A delivered item can not be requeued
+		{
+			testNo:         10,
+			method:         "POST",
+			path:           "/admin/outboxItems/outbox-item-succeeded/requeue",
+			out:            "",
+			httpStatusCode: 409,
+		},
+		{
+			testNo:         11,
+			method:         "POST",
+			path:           "/admin/outboxItems/unknown-outbox-item/requeue",
+			out:            "",
+			httpStatusCode: 404,
+		},
+	})
+
+	// The outbox is for administrators only
+	if statusCode, _, data, err := userRequest(ts, "GET", "/admin/outboxItems", "", ""); err != nil || statusCode != 403 {
+		t.Fatalf("the outbox was listed without the API key: %d %s %v", statusCode, data, err)
+	}
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+// eventEnvelopeKeys are the keys of the Event schema, and whether each one is required.
+var eventEnvelopeKeys = map[string]bool{
+	"version":    true,
+	"eventId":    true,
+	"eventName":  true,
+	"roomId":     false,
+	"actorId":    false,
+	"recipients": true,
+	"payload":    true,
+	"timestamp":  true,
+}
+
+// nextEnvelope reads the events of the stream of userId up to the one named eventName, checks its envelope and returns it.
+func nextEnvelope(t *testing.T, stream *sseTestStream, userId, eventName string) *models.Event {
+	for {
+		e := stream.next(t)
+		var keys map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(e.data), &keys); err != nil {
+			t.Fatalf("the event is not an object: %s", e.data)
+		}
+		for key := range keys {
+			if _, ok := eventEnvelopeKeys[key]; !ok {
+				t.Fatalf("the event has the unknown key %s: %s", key, e.data)
+			}
+		}
+		for key, required := range eventEnvelopeKeys {
+			if _, ok := keys[key]; required && !ok {
+				t.Fatalf("the event has no %s: %s", key, e.data)
+			}
+		}
+
+		var event models.Event
+		if err := json.Unmarshal([]byte(e.data), &event); err != nil {
+			t.Fatalf("the event could not be decoded: %s", e.data)
+		}
+		if event.EventName != eventName {
+			continue
+		}
+		if event.Version != models.EVENT_VERSION || event.EventId == "" {
+			t.Fatalf("unexpected version or eventId: %s", e.data)
+		}
+		if _, err := time.Parse(time.RFC3339, event.Timestamp); err != nil {
+			t.Fatalf("the timestamp is not RFC 3339: %s", e.data)
+		}
+		if !utils.SearchStringValueInSlice(event.Recipients, userId) {
+			t.Fatalf("the recipients do not contain the user of the stream: %s", e.data)
+		}
+		return &event
+	}
+}
+
+func TestEventEnvelope(t *testing.T) {
+	ts := httptest.NewServer(Mux)
+	defer ts.Close()
+
+	rtmCfg := *utils.Cfg.Rtm
+	utils.Cfg.Rtm.Provider = "builtin"
+	defer func() {
+		*utils.Cfg.Rtm = rtmCfg
+	}()
+	// The stream is only routed when the hub is enabled on start
+	SetSseMux()
+
+	accessToken := postUserAccessToken(t, ts, "event-user-1")
+	postUserAccessToken(t, ts, "event-user-2")
+	postUserAccessToken(t, ts, "event-user-3")
+	stream := openSseStream(t, ts, "event-user-1", accessToken, "")
+	defer stream.close()
+
+	testTable := []struct {
+		testNo         int
+		method         string
+		path           string
+		in             string
+		httpStatusCode int
+		eventName      string
+		roomId         string
+		actorId        string
+		payload        string
+		recipients     []string
+	}{
+		{
+			1, "POST", "/rooms", `{"roomId": "event-room-1", "userId": "event-user-1", "name": "event room", "type": 3, "userIds": ["event-user-2"]}`, 201,
+			models.EVENT_NAME_ROOM_CREATE, "event-room-1", "event-user-1", `^{"roomId":"event-room-1",.*"name":"event room",`, []string{"event-user-1", "event-user-2"},
+		},
+		{
+			2, "PUT", "/rooms/event-room-1", `{"name": "event room renamed"}`, 200,
+			models.EVENT_NAME_ROOM_UPDATE, "event-room-1", "event-user-1", `^{"roomId":"event-room-1",.*"name":"event room renamed",`, []string{"event-user-1", "event-user-2"},
+		},
+		// The joined users receive the event as well
+		{
+			3, "PUT", "/rooms/event-room-1/users", `{"userIds": ["event-user-3"]}`, 200,
+			models.EVENT_NAME_USER_JOIN, "event-room-1", "event-user-1", `^{"userIds":\["event-user-3"\],"users":\[.*"userId":"event-user-3"`, []string{"event-user-1", "event-user-2", "event-user-3"},
+		},
+		{
+			4, "POST", "/messages", `{"messages": [{"messageId": "event-message-1", "roomId": "event-room-1", "userId": "event-user-2", "type": "text", "payload": {"text": "hi"}}]}`, 201,
+			models.EVENT_NAME_MESSAGE, "event-room-1", "event-user-2", `^{"messageId":"event-message-1",.*"payload":{"text":"hi"}`, []string{"event-user-1", "event-user-2", "event-user-3"},
+		},
+		// A read receipt is the new unread count of the user
+		{
+			5, "PUT", "/rooms/event-room-1/users/event-user-1", `{"unreadCount": 0}`, 200,
+			models.EVENT_NAME_MESSAGE_READ, "event-room-1", "event-user-1", `^{"roomId":"event-room-1","userId":"event-user-1",.*"unreadCount":0,`, []string{"event-user-1", "event-user-2", "event-user-3"},
+		},
+		// The users who left receive the event as well
+		{
+			6, "DELETE", "/rooms/event-room-1/users", `{"userIds": ["event-user-3"]}`, 200,
+			models.EVENT_NAME_USER_LEFT, "event-room-1", "event-user-1", `^{"userIds":\["event-user-3"\],"users":\[`, []string{"event-user-1", "event-user-2", "event-user-3"},
+		},
+		// A profile change is not an event of a room, and goes to the user and the users of their rooms
+		{
+			7, "PUT", "/users/event-user-2", `{"name": "event user 2 renamed"}`, 200,
+			models.EVENT_NAME_USER_UPDATE, "", "event-user-2", `^{"userId":"event-user-2","name":"event user 2 renamed",`, []string{"event-user-2", "event-user-1"},
+		},
+	}
+
+	eventIds := make(map[string]bool)
+	for _, testRecord := range testTable {
+		statusCode, data, err := adminRequest(ts, testRecord.method, testRecord.path, testRecord.in)
+		if err != nil || statusCode != testRecord.httpStatusCode {
+			t.Fatalf("TestNo %d\nHTTP Status Code Failure\n[expected]%d\n[result  ]%d %s %v", testRecord.testNo, testRecord.httpStatusCode, statusCode, data, err)
+		}
+
+		event := nextEnvelope(t, stream, "event-user-1", testRecord.eventName)
+		if event.RoomId != testRecord.roomId || event.ActorId != testRecord.actorId {
+			t.Fatalf("TestNo %d\nEvent Failure\n[expected]%s %s\n[result  ]%s %s", testRecord.testNo, testRecord.roomId, testRecord.actorId, event.RoomId, event.ActorId)
+		}
+		if !regexp.MustCompile(testRecord.payload).MatchString(string(event.Payload)) {
+			t.Fatalf("TestNo %d\nPayload Failure\n[expected]%s\n[result  ]%s", testRecord.testNo, testRecord.payload, event.Payload)
+		}
+		for _, userId := range testRecord.recipients {
+			if !utils.SearchStringValueInSlice(event.Recipients, userId) {
+				t.Fatalf("TestNo %d\nRecipients Failure\n[expected]%v\n[result  ]%v", testRecord.testNo, testRecord.recipients, event.Recipients)
+			}
+		}
+		if eventIds[event.EventId] {
+			t.Fatalf("TestNo %d\nthe eventId %s is not unique", testRecord.testNo, event.EventId)
+		}
+		eventIds[event.EventId] = true
+	}
+}
+
+func TestEventRecipients(t *testing.T) {
+	ts := httptest.NewServer(Mux)
+	defer ts.Close()
+
+	rtmCfg := *utils.Cfg.Rtm
+	utils.Cfg.Rtm.Provider = "builtin"
+	defer func() {
+		*utils.Cfg.Rtm = rtmCfg
+	}()
+	SetSseMux()
+
+	accessToken := postUserAccessToken(t, ts, "recipient-user-1")
+	postUserAccessToken(t, ts, "recipient-user-2")
+	postUserAccessToken(t, ts, "recipient-user-3")
+	stream := openSseStream(t, ts, "recipient-user-1", accessToken, "")
+	defer stream.close()
+
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         1,
+			method:         "POST",
+			path:           "/rooms",
+			in:             `{"roomId": "recipient-room-1", "userId": "recipient-user-1", "name": "recipient room", "type": 3, "userIds": ["recipient-user-2", "recipient-user-3"]}`,
+			out:            `"roomId":"recipient-room-1"`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         2,
+			method:         "PUT",
+			path:           "/rooms/recipient-room-1/users/recipient-user-2",
+			in:             `{"notify": "muted"}`,
+			out:            `"notify":"muted"`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         3,
+			method:         "PUT",
+			path:           "/users/recipient-user-3/blocks",
+			in:             `{"userIds": ["recipient-user-1"]}`,
+			out:            `"blockUsers":\["recipient-user-1"\]`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         4,
+			method:         "POST",
+			path:           "/messages",
+			in:             `{"messages": [{"messageId": "recipient-message-1", "roomId": "recipient-room-1", "userId": "recipient-user-1", "type": "text", "payload": {"text": "hi"}}]}`,
+			out:            `"messageIds":\["recipient-message-1"\]`,
+			httpStatusCode: 201,
+		},
+	})
+
+	// The muted user receives the message, and the user who blocked the sender does not
+	event := nextEnvelope(t, stream, "recipient-user-1", models.EVENT_NAME_MESSAGE)
+	if !utils.SearchStringValueInSlice(event.Recipients, "recipient-user-2") || utils.SearchStringValueInSlice(event.Recipients, "recipient-user-3") {
+		t.Fatalf("Recipients Failure\n[expected]%v\n[result  ]%v", []string{"recipient-user-1", "recipient-user-2"}, event.Recipients)
+	}
+}
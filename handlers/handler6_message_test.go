@@ -332,6 +332,106 @@ func TestPostMessages(t *testing.T) {
 	}
 }
 
+func TestPostMessagesAtomic(t *testing.T) {
+	ts := httptest.NewServer(Mux)
+	defer ts.Close()
+
+	testTable := []testRecord{
+		{
+			testNo: 1,
+			in: `
+				{
+					"messages" : [
+						{
+							"roomId": "custom-room-id-1",
+							"userId": "custom-user-id-1",
+							"type": "text",
+							"payload": {
+								"text": "Imported 1"
+							}
+						},
+						{
+							"roomId": "custom-room-id-1",
+							"userId": "custom-user-id-2",
+							"type": "text",
+							"payload": {
+								"text": "Imported 2"
+							}
+						}
+					]
+				}
+			`,
+			out:            `(?m)^{"messageIds":\["[a-z0-9-]+","[a-z0-9-]+"\]}$`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo: 2,
+			in: `
+				{
+					"messages" : [
+						{
+							"roomId": "custom-room-id-1",
+							"userId": "custom-user-id-1",
+							"type": "text",
+							"payload": {
+								"text": "Not imported"
+							}
+						},
+						{
+							"roomId": "not-exist-room-id",
+							"userId": "custom-user-id-1",
+							"type": "text",
+							"payload": {
+								"text": "Not imported"
+							}
+						},
+						{
+							"roomId": "custom-room-id-1",
+							"userId": "not-exist-user-id",
+							"type": "text",
+							"payload": {
+								"text": "Not imported"
+							}
+						}
+					]
+				}
+			`,
+			out:            `(?m)^{"errors":\[{"title":"Request parameter error. \(Create message item\)","status":400,"errorName":"invalid-param","invalidParams":\[{"name":"roomId","reason":"roomId is invalid. Not exist room."}\]},{"title":"Request parameter error. \(Create message item\)","status":400,"errorName":"invalid-param","invalidParams":\[{"name":"userId","reason":"userId is invalid. Not exist user."}\]}\]}$`,
+			httpStatusCode: 400,
+		},
+		{
+			testNo: 3,
+			in: `
+				{
+					"messages" : []
+				}
+			`,
+			out:            `(?m)^{"errors":\[{"title":"Request parameter error. \(Create message item\)","status":400,"errorName":"invalid-param","invalidParams":\[{"name":"messages","reason":"messages is required, but it's empty."}\]}\]}$`,
+			httpStatusCode: 400,
+		},
+	}
+
+	for _, testRecord := range testTable {
+		reader := strings.NewReader(testRecord.in)
+		req, _ := http.NewRequest("POST", ts.URL+"/"+utils.API_VERSION+"/messages?atomic=true", reader)
+		req.Header.Set("Content-Type", "application/json")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("TestNo %d\nhttp request failed: %v", testRecord.testNo, err)
+		}
+
+		if res.StatusCode != testRecord.httpStatusCode {
+			t.Fatalf("TestNo %d\nHTTP Status Code Failure\n[expected]%d\n[result  ]%d", testRecord.testNo, testRecord.httpStatusCode, res.StatusCode)
+		}
+
+		data, err := ioutil.ReadAll(res.Body)
+		r := regexp.MustCompile(testRecord.out)
+		if !r.MatchString(string(data)) {
+			t.Fatalf("TestNo %d\nResponse Body Failure\n[expected]%s\n[result  ]%s", testRecord.testNo, testRecord.out, string(data))
+		}
+	}
+}
+
 func TestGetMessage(t *testing.T) {
 	ts := httptest.NewServer(Mux)
 	defer ts.Close()
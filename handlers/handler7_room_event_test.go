@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+func TestPostRoomEvent(t *testing.T) {
+	ts := httptest.NewServer(Mux)
+	defer ts.Close()
+
+	testTable := []testRecord{
+		{
+			testNo: 1,
+			roomId: "custom-room-id-1",
+			in: `
+				{
+					"userId": "custom-user-id-1",
+					"eventName": "typingStart"
+				}
+			`,
+			out:            `^$`,
+			httpStatusCode: 204,
+		},
+		{
+			testNo: 2,
+			roomId: "custom-room-id-1",
+			in: `
+				{
+					"userId": "custom-user-id-2",
+					"eventName": "typingStop",
+					"payload": {
+						"messageId": "draft"
+					}
+				}
+			`,
+			out:            `^$`,
+			httpStatusCode: 204,
+		},
+		{
+			testNo: 3,
+			roomId: "custom-room-id-1",
+			in: `
+				{
+					"userId": "custom-user-id-1",
+					"eventName": "not-exist-event"
+				}
+			`,
+			out:            `(?m)^{"title":"Request parameter error. \(Create room event item\)","status":400,"errorName":"invalid-param","invalidParams":\[{"name":"eventName","reason":"eventName is invalid. Available values are typingStart, typingStop."}\]}$`,
+			httpStatusCode: 400,
+		},
+		{
+			testNo: 4,
+			roomId: "custom-room-id-1",
+			in: `
+				{
+					"userId": "custom-user-id-3",
+					"eventName": "typingStart"
+				}
+			`,
+			out:            `(?m)^{"title":"You do not have permission to send events to this room.","status":403,"errorName":"operation-not-permitted"}$`,
+			httpStatusCode: 403,
+		},
+		{
+			testNo: 5,
+			roomId: "not-exist-room-id",
+			in: `
+				{
+					"userId": "custom-user-id-1",
+					"eventName": "typingStart"
+				}
+			`,
+			out:            ``,
+			httpStatusCode: 404,
+		},
+		{
+			testNo: 6,
+			roomId: "custom-room-id-1",
+			in: `
+				json error
+			`,
+			out:            `(?m)^{"title":"Json parse error. \(Create room event item\)","status":400,"errorName":"invalid-json"}$`,
+			httpStatusCode: 400,
+		},
+	}
+
+	for _, testRecord := range testTable {
+		reader := strings.NewReader(testRecord.in)
+		req, _ := http.NewRequest("POST", ts.URL+"/"+utils.API_VERSION+"/rooms/"+testRecord.roomId+"/events", reader)
+		req.Header.Set("Content-Type", "application/json")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("TestNo %d\nhttp request failed: %v", testRecord.testNo, err)
+		}
+
+		if res.StatusCode != testRecord.httpStatusCode {
+			t.Fatalf("TestNo %d\nHTTP Status Code Failure\n[expected]%d\n[result  ]%d", testRecord.testNo, testRecord.httpStatusCode, res.StatusCode)
+		}
+
+		data, err := ioutil.ReadAll(res.Body)
+		r := regexp.MustCompile(testRecord.out)
+		if !r.MatchString(string(data)) {
+			t.Fatalf("TestNo %d\nResponse Body Failure\n[expected]%s\n[result  ]%s", testRecord.testNo, testRecord.out, string(data))
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/swagchat/chat-api/models"
+)
+
+func TestBotSecret(t *testing.T) {
+	ts := httptest.NewServer(Mux)
+	defer ts.Close()
+
+	testTable := []testRecord{
+		{
+			testNo: 1,
+			method: "POST",
+			path:   "/users",
+			in: `
+				{
+					"userId": "bot-secret-bot",
+					"name": "bot",
+					"isBot": true,
+					"botEndpoint": "http://localhost/bot"
+				}
+			`,
+			out:            `(?m)^{"userId":"bot-secret-bot",.*"isBot":true,"botEndpoint":"http://localhost/bot","botSecret":"[0-9a-f]{64}",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         2,
+			method:         "GET",
+			path:           "/users/bot-secret-bot",
+			out:            `(?m)^{"userId":"bot-secret-bot",.*"isBot":true,"botEndpoint":"http://localhost/bot",`,
+			notOut:         `botSecret`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo: 3,
+			method: "PUT",
+			path:   "/users/bot-secret-bot",
+			in: `
+				{
+					"name": "bot renamed"
+				}
+			`,
+			out:            `(?m)^{"userId":"bot-secret-bot","name":"bot renamed",.*"isBot":true,"botEndpoint":"http://localhost/bot",`,
+			notOut:         `botSecret`,
+			httpStatusCode: 200,
+		},
+	}
+	runTestTable(t, ts, testTable)
+}
+
+type botRequestStruct struct {
+	Type      string `json:"type"`
+	Command   string `json:"command"`
+	Text      string `json:"text"`
+	MessageId string `json:"messageId"`
+}
+
+func TestPostMessagesAtomicBot(t *testing.T) {
+	ts := httptest.NewServer(Mux)
+	defer ts.Close()
+
+	botRequests := make(chan *botRequestStruct, 10)
+	botServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var req botRequestStruct
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Errorf("Error by json.Unmarshal(): %v", err)
+		}
+		botRequests <- &req
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer botServer.Close()
+
+	testTable := []testRecord{
+		{
+			testNo:         1,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "atomic-bot-user", "name": "atomic user"}`,
+			out:            `(?m)^{"userId":"atomic-bot-user",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         2,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "atomic-bot", "name": "atomic bot", "isBot": true, "botEndpoint": "` + botServer.URL + `"}`,
+			out:            `(?m)^{"userId":"atomic-bot",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         3,
+			method:         "POST",
+			path:           "/rooms",
+			in:             `{"roomId": "atomic-bot-room", "userId": "atomic-bot-user", "name": "atomic room", "type": 3, "userIds": ["atomic-bot"]}`,
+			out:            `(?m)^{"roomId":"atomic-bot-room",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         4,
+			method:         "POST",
+			path:           "/rooms/atomic-bot-room/commands",
+			in:             `{"command": "echo", "botUserId": "atomic-bot"}`,
+			out:            `(?m)^{"commandId":"[a-z0-9-]+","roomId":"atomic-bot-room","command":"echo",`,
+			httpStatusCode: 201,
+		},
+		{
+			// A command in an atomic batch is handed to the bot instead of being stored, like without atomic
+			testNo: 5,
+			method: "POST",
+			path:   "/messages?atomic=true",
+			in: `
+				{
+					"messages": [
+						{"messageId": "atomic-bot-command", "roomId": "atomic-bot-room", "userId": "atomic-bot-user", "type": "text", "payload": {"text": "/echo hi"}},
+						{"messageId": "atomic-bot-mention", "roomId": "atomic-bot-room", "userId": "atomic-bot-user", "type": "text", "payload": {"text": "hello @atomic-bot"}}
+					]
+				}
+			`,
+			out:            `(?m)^{"messageIds":\["atomic-bot-mention"\],"commandMessageIds":\["atomic-bot-command"\]}$`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         6,
+			method:         "GET",
+			path:           "/messages/atomic-bot-command",
+			out:            ``,
+			httpStatusCode: 404,
+		},
+	}
+	runTestTable(t, ts, testTable)
+
+	// The bot is called for the command and for the mention of the committed message
+	received := make(map[string]*botRequestStruct)
+	for len(received) < 2 {
+		select {
+		case req := <-botRequests:
+			received[req.Type] = req
+		case <-time.After(5 * time.Second):
+			t.Fatalf("the bot was not called for both messages: %v", received)
+		}
+	}
+	if req := received[models.BOT_REQUEST_TYPE_COMMAND]; req.Command != "echo" || req.Text != "hi" || req.MessageId != "atomic-bot-command" {
+		t.Fatalf("unexpected command request: %+v", req)
+	}
+	if req := received[models.BOT_REQUEST_TYPE_MESSAGE]; req.MessageId != "atomic-bot-mention" {
+		t.Fatalf("unexpected message request: %+v", req)
+	}
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swagchat/chat-api/datastore"
+)
+
+type syncStruct struct {
+	Changes []json.RawMessage `json:"changes"`
+	Token   string            `json:"token"`
+	HasMore bool              `json:"hasMore"`
+}
+
+func getSync(t *testing.T, ts *httptest.Server, userId, since string) *syncStruct {
+	statusCode, data, err := adminRequest(ts, "GET", "/users/"+userId+"/sync?wait=0&since="+since, "")
+	if err != nil {
+		t.Fatalf("http request failed: %v", err)
+	}
+	if statusCode != 200 {
+		t.Fatalf("HTTP Status Code Failure\n[expected]%d\n[result  ]%d\n%s", 200, statusCode, data)
+	}
+	var sync syncStruct
+	if err := json.Unmarshal([]byte(data), &sync); err != nil {
+		t.Fatalf("Error by json.Unmarshal(): %v", err)
+	}
+	return &sync
+}
+
+func TestSyncInterleaving(t *testing.T) {
+	ts := httptest.NewServer(Mux)
+	defer ts.Close()
+
+	testTable := []testRecord{
+		{
+			testNo:         1,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "sync-user-1", "name": "sync user 1"}`,
+			out:            `(?m)^{"userId":"sync-user-1",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         2,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "sync-user-2", "name": "sync user 2"}`,
+			out:            `(?m)^{"userId":"sync-user-2",`,
+			httpStatusCode: 201,
+		},
+	}
+	for i := 1; i <= 4; i++ {
+		testTable = append(testTable, testRecord{
+			testNo:         2 + i,
+			method:         "POST",
+			path:           "/rooms",
+			in:             fmt.Sprintf(`{"roomId": "sync-room-%d", "userId": "sync-user-1", "name": "sync room", "type": 3, "userIds": ["sync-user-2"]}`, i),
+			out:            fmt.Sprintf(`(?m)^{"roomId":"sync-room-%d",`, i),
+			httpStatusCode: 201,
+		})
+	}
+	runTestTable(t, ts, testTable)
+
+	start := getSync(t, ts, "sync-user-2", "")
+	since := start.Token
+
+	// The messages of the rooms are recorded for sync-user-2 by concurrent requests
+	const messageCount = 40
+	var wg sync.WaitGroup
+	for i := 0; i < messageCount; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			in := fmt.Sprintf(`{"messages": [{"roomId": "sync-room-%d", "userId": "sync-user-1", "type": "text", "payload": {"text": "message %d"}}]}`, i%4+1, i)
+			if statusCode, data, err := adminRequest(ts, "POST", "/messages", in); err != nil || statusCode != 201 {
+				t.Errorf("POST /messages failed: %d %s %v", statusCode, data, err)
+			}
+		}(i)
+	}
+
+	// Every token reached while the messages are written covers exactly the changes returned so far
+	received := 0
+	deadline := time.Now().Add(10 * time.Second)
+	for received < messageCount && time.Now().Before(deadline) {
+		result := getSync(t, ts, "sync-user-2", since)
+		sinceSeq, _ := strconv.Atoi(since)
+		token, _ := strconv.Atoi(result.Token)
+		if token != sinceSeq+len(result.Changes) {
+			t.Fatalf("the token skipped changes\n[since]%s\n[token]%s\n[changes]%d", since, result.Token, len(result.Changes))
+		}
+		received += len(result.Changes)
+		since = result.Token
+	}
+	wg.Wait()
+	if received != messageCount {
+		t.Fatalf("%d of %d changes were synced", received, messageCount)
+	}
+}
+
+func TestSyncToken(t *testing.T) {
+	ts := httptest.NewServer(Mux)
+	defer ts.Close()
+
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         1,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "sync-user-3", "name": "sync user 3"}`,
+			out:            `(?m)^{"userId":"sync-user-3",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         2,
+			method:         "POST",
+			path:           "/users",
+			in:             `{"userId": "sync-user-4", "name": "sync user 4"}`,
+			out:            `(?m)^{"userId":"sync-user-4",`,
+			httpStatusCode: 201,
+		},
+		{
+			testNo:         3,
+			method:         "POST",
+			path:           "/rooms",
+			in:             `{"roomId": "sync-room-5", "userId": "sync-user-3", "name": "sync room", "type": 3, "userIds": ["sync-user-4"]}`,
+			out:            `(?m)^{"roomId":"sync-room-5",`,
+			httpStatusCode: 201,
+		},
+	})
+
+	// Without since only the current token is returned
+	start := getSync(t, ts, "sync-user-4", "")
+	if len(start.Changes) != 0 || start.Token == "" {
+		t.Fatalf("unexpected sync without since: %+v", start)
+	}
+
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         4,
+			method:         "POST",
+			path:           "/messages",
+			in:             `{"messages": [{"messageId": "sync-message-1", "roomId": "sync-room-5", "userId": "sync-user-3", "type": "text", "payload": {"text": "hello"}}]}`,
+			out:            `(?m)^{"messageIds":\["sync-message-1"\]}$`,
+			httpStatusCode: 201,
+		},
+	})
+
+	result := getSync(t, ts, "sync-user-4", start.Token)
+	startSeq, _ := strconv.Atoi(start.Token)
+	if token, _ := strconv.Atoi(result.Token); len(result.Changes) != 1 || token != startSeq+1 {
+		t.Fatalf("unexpected sync\n[since]%s\n[token]%s\n[changes]%d", start.Token, result.Token, len(result.Changes))
+	}
+	if !regexp.MustCompile(`"messageId":"sync-message-1"`).Match(result.Changes[0]) {
+		t.Fatalf("unexpected change: %s", result.Changes[0])
+	}
+	if again := getSync(t, ts, "sync-user-4", result.Token); len(again.Changes) != 0 || again.Token != result.Token {
+		t.Fatalf("the token did not cover the returned changes: %+v", again)
+	}
+
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         5,
+			method:         "GET",
+			path:           "/users/sync-user-4/sync?wait=0&since=abc",
+			out:            `(?m)"name":"since"`,
+			httpStatusCode: 400,
+		},
+		{
+			// A token ahead of the sequence of the user was not issued for the user
+			testNo:         6,
+			method:         "GET",
+			path:           fmt.Sprintf("/users/sync-user-4/sync?wait=0&since=%d", startSeq+100),
+			out:            `(?m)^{"title":"The sync token has expired. Fetch everything again and sync without since.","status":410`,
+			httpStatusCode: 410,
+		},
+	})
+
+	// Once the changes after a token are purged, the token has expired, while the latest one is still up to date
+	if dRes := datastore.GetProvider().DeleteChangeLogs(time.Now().Unix() + 1); dRes.ProblemDetail != nil {
+		t.Fatalf("the change log could not be purged: %s", dRes.ProblemDetail.Title)
+	}
+	runTestTable(t, ts, []testRecord{
+		{
+			testNo:         7,
+			method:         "GET",
+			path:           "/users/sync-user-4/sync?wait=0&since=" + start.Token,
+			out:            `(?m)^{"title":"The sync token has expired.`,
+			httpStatusCode: 410,
+		},
+		{
+			testNo:         8,
+			method:         "GET",
+			path:           "/users/sync-user-4/sync?wait=0&since=" + result.Token,
+			out:            `(?m)^{"changes":\[\],"token":"` + result.Token + `"`,
+			httpStatusCode: 200,
+		},
+		{
+			testNo:         9,
+			method:         "GET",
+			path:           "/users/not-exist-user-id/sync?wait=0",
+			out:            ``,
+			httpStatusCode: 404,
+		},
+	})
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/go-zoo/bone"
+	"github.com/swagchat/chat-api/services"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func SetHeldMessageMux() {
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/heldMessages"), colsHandler(adminHandler(GetHeldMessages)))
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/heldMessages/#heldMessageId^[a-z0-9-]$"), colsHandler(adminHandler(GetHeldMessage)))
+	Mux.PostFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/heldMessages/#heldMessageId^[a-z0-9-]$/approve"), colsHandler(adminHandler(ApproveHeldMessage)))
+	Mux.PostFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/heldMessages/#heldMessageId^[a-z0-9-]$/reject"), colsHandler(adminHandler(RejectHeldMessage)))
+}
+
+func GetHeldMessages(w http.ResponseWriter, r *http.Request) {
+	params, _ := url.ParseQuery(r.URL.RawQuery)
+	heldMessages, pd := services.GetHeldMessages(params)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", heldMessages)
+}
+
+func GetHeldMessage(w http.ResponseWriter, r *http.Request) {
+	heldMessageId := bone.GetValue(r, "heldMessageId")
+	heldMessage, pd := services.GetHeldMessage(heldMessageId)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	setLastModified(w, heldMessage.Modified)
+	respond(w, r, http.StatusOK, "application/json", heldMessage)
+}
+
+func ApproveHeldMessage(w http.ResponseWriter, r *http.Request) {
+	heldMessageId := bone.GetValue(r, "heldMessageId")
+	heldMessage, pd := services.ApproveHeldMessage(heldMessageId)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", heldMessage)
+}
+
+func RejectHeldMessage(w http.ResponseWriter, r *http.Request) {
+	heldMessageId := bone.GetValue(r, "heldMessageId")
+	heldMessage, pd := services.RejectHeldMessage(heldMessageId)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", heldMessage)
+}
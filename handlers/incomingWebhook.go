@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/go-zoo/bone"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/services"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func SetIncomingWebhookMux() {
+	Mux.PostFunc(utils.AppendStrings("/", utils.API_VERSION, "/hooks/#secret^[a-z0-9]$"), colsHandler(PostIncomingWebhookMessage))
+	Mux.PostFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/incomingWebhooks"), colsHandler(adminHandler(PostIncomingWebhook)))
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/incomingWebhooks"), colsHandler(adminHandler(GetIncomingWebhooks)))
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/incomingWebhooks/#incomingWebhookId^[a-z0-9-]$"), colsHandler(adminHandler(GetIncomingWebhook)))
+	Mux.PutFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/incomingWebhooks/#incomingWebhookId^[a-z0-9-]$"), colsHandler(adminHandler(PutIncomingWebhook)))
+	Mux.DeleteFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/incomingWebhooks/#incomingWebhookId^[a-z0-9-]$"), colsHandler(adminHandler(RevokeIncomingWebhook)))
+}
+
+func PostIncomingWebhookMessage(w http.ResponseWriter, r *http.Request) {
+	var post models.IncomingWebhookPayload
+	if err := decodeBody(r, &post); err != nil {
+		respondJsonDecodeError(w, r, "Create message item")
+		return
+	}
+
+	secret := bone.GetValue(r, "secret")
+	mRes, pd := services.PostIncomingWebhookMessage(secret, &post)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respondMessages(w, r, mRes)
+}
+
+func PostIncomingWebhook(w http.ResponseWriter, r *http.Request) {
+	var post models.IncomingWebhook
+	if err := decodeBody(r, &post); err != nil {
+		respondJsonDecodeError(w, r, "Create incoming webhook item")
+		return
+	}
+
+	incomingWebhook, pd := services.PostIncomingWebhook(&post)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusCreated, "application/json", incomingWebhook)
+}
+
+func GetIncomingWebhooks(w http.ResponseWriter, r *http.Request) {
+	params, _ := url.ParseQuery(r.URL.RawQuery)
+	incomingWebhooks, pd := services.GetIncomingWebhooks(params)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", incomingWebhooks)
+}
+
+func GetIncomingWebhook(w http.ResponseWriter, r *http.Request) {
+	incomingWebhookId := bone.GetValue(r, "incomingWebhookId")
+	incomingWebhook, pd := services.GetIncomingWebhook(incomingWebhookId)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	setLastModified(w, incomingWebhook.Modified)
+	respond(w, r, http.StatusOK, "application/json", incomingWebhook)
+}
+
+func PutIncomingWebhook(w http.ResponseWriter, r *http.Request) {
+	var put models.IncomingWebhook
+	if err := decodeBody(r, &put); err != nil {
+		respondJsonDecodeError(w, r, "Update incoming webhook item")
+		return
+	}
+
+	put.IncomingWebhookId = bone.GetValue(r, "incomingWebhookId")
+	incomingWebhook, pd := services.PutIncomingWebhook(&put)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", incomingWebhook)
+}
+
+func RevokeIncomingWebhook(w http.ResponseWriter, r *http.Request) {
+	incomingWebhookId := bone.GetValue(r, "incomingWebhookId")
+	pd := services.RevokeIncomingWebhook(incomingWebhookId)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusNoContent, "", nil)
+}
@@ -12,6 +12,8 @@ import (
 func SetMessageMux() {
 	Mux.PostFunc(utils.AppendStrings("/", utils.API_VERSION, "/messages"), colsHandler(PostMessages))
 	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/messages/#messageId^[a-z0-9-]$"), colsHandler(GetMessage))
+	Mux.PutFunc(utils.AppendStrings("/", utils.API_VERSION, "/messages/#messageId^[a-z0-9-]$"), colsHandler(PutMessage))
+	Mux.PostFunc(utils.AppendStrings("/", utils.API_VERSION, "/messages/#messageId^[a-z0-9-]$/votes"), colsHandler(PostPollVote))
 }
 
 func PostMessages(w http.ResponseWriter, r *http.Request) {
@@ -21,8 +23,21 @@ func PostMessages(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	mRes := services.PostMessage(&post)
+	var mRes *models.ResponseMessages
+	if r.URL.Query().Get("atomic") == "true" {
+		mRes = services.PostMessageAtomic(&post)
+	} else {
+		mRes = services.PostMessage(&post)
+	}
+	respondMessages(w, r, mRes)
+}
+
+func respondMessages(w http.ResponseWriter, r *http.Request, mRes *models.ResponseMessages) {
 	if len(mRes.MessageIds) == 0 {
+		if len(mRes.HeldMessageIds) > 0 || len(mRes.CommandMessageIds) > 0 {
+			respond(w, r, http.StatusAccepted, "application/json", mRes)
+			return
+		}
 		respond(w, r, mRes.Errors[0].Status, "application/json", mRes)
 		return
 	}
@@ -41,3 +56,42 @@ func GetMessage(w http.ResponseWriter, r *http.Request) {
 	setLastModified(w, message.Modified)
 	respond(w, r, http.StatusOK, "application/json", message)
 }
+
+func PutMessage(w http.ResponseWriter, r *http.Request) {
+	var put models.Message
+	if err := decodeBody(r, &put); err != nil {
+		respondJsonDecodeError(w, r, "Update message item")
+		return
+	}
+
+	put.MessageId = bone.GetValue(r, "messageId")
+	message, heldMessage, pd := services.PutMessage(&put)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+	if heldMessage != nil {
+		respond(w, r, http.StatusAccepted, "application/json", heldMessage)
+		return
+	}
+
+	setLastModified(w, message.Modified)
+	respond(w, r, http.StatusOK, "application/json", message)
+}
+
+func PostPollVote(w http.ResponseWriter, r *http.Request) {
+	var post models.RequestPollVote
+	if err := decodeBody(r, &post); err != nil {
+		respondJsonDecodeError(w, r, "Create poll vote item")
+		return
+	}
+
+	messageId := bone.GetValue(r, "messageId")
+	pollResult, pd := services.PostPollVote(messageId, &post)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", pollResult)
+}
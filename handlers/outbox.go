@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/go-zoo/bone"
+	"github.com/swagchat/chat-api/services"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func SetOutboxMux() {
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/outboxItems"), colsHandler(adminHandler(GetOutboxItems)))
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/outboxItems/#outboxItemId^[a-z0-9-]$"), colsHandler(adminHandler(GetOutboxItem)))
+	Mux.PostFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/outboxItems/#outboxItemId^[a-z0-9-]$/requeue"), colsHandler(adminHandler(RequeueOutboxItem)))
+}
+
+func GetOutboxItems(w http.ResponseWriter, r *http.Request) {
+	params, _ := url.ParseQuery(r.URL.RawQuery)
+	items, pd := services.GetOutboxItems(params)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", items)
+}
+
+func GetOutboxItem(w http.ResponseWriter, r *http.Request) {
+	outboxItemId := bone.GetValue(r, "outboxItemId")
+	item, pd := services.GetOutboxItem(outboxItemId)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", item)
+}
+
+func RequeueOutboxItem(w http.ResponseWriter, r *http.Request) {
+	outboxItemId := bone.GetValue(r, "outboxItemId")
+	item, pd := services.RequeueOutboxItem(outboxItemId)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", item)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/go-zoo/bone"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/services"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func SetReportMux() {
+	Mux.PostFunc(utils.AppendStrings("/", utils.API_VERSION, "/messages/#messageId^[a-z0-9-]$/reports"), colsHandler(PostMessageReport))
+	Mux.PostFunc(utils.AppendStrings("/", utils.API_VERSION, "/users/#userId^[a-z0-9-]$/reports"), colsHandler(PostUserReport))
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/reports"), colsHandler(adminHandler(GetReports)))
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/reports/#reportId^[a-z0-9-]$"), colsHandler(adminHandler(GetReport)))
+	Mux.PostFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/reports/#reportId^[a-z0-9-]$/triage"), colsHandler(adminHandler(TriageReport)))
+	Mux.PostFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/reports/#reportId^[a-z0-9-]$/resolve"), colsHandler(adminHandler(ResolveReport)))
+	Mux.PostFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/reports/#reportId^[a-z0-9-]$/dismiss"), colsHandler(adminHandler(DismissReport)))
+}
+
+func PostMessageReport(w http.ResponseWriter, r *http.Request) {
+	var post models.Report
+	if err := decodeBody(r, &post); err != nil {
+		respondJsonDecodeError(w, r, "Create report item")
+		return
+	}
+
+	post.MessageId = bone.GetValue(r, "messageId")
+	report, pd := services.PostMessageReport(&post)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusCreated, "application/json", report)
+}
+
+func PostUserReport(w http.ResponseWriter, r *http.Request) {
+	var post models.Report
+	if err := decodeBody(r, &post); err != nil {
+		respondJsonDecodeError(w, r, "Create report item")
+		return
+	}
+
+	post.TargetUserId = bone.GetValue(r, "userId")
+	report, pd := services.PostUserReport(&post)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusCreated, "application/json", report)
+}
+
+func GetReports(w http.ResponseWriter, r *http.Request) {
+	params, _ := url.ParseQuery(r.URL.RawQuery)
+	reports, pd := services.GetReports(params)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", reports)
+}
+
+func GetReport(w http.ResponseWriter, r *http.Request) {
+	reportId := bone.GetValue(r, "reportId")
+	report, pd := services.GetReport(reportId)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	setLastModified(w, report.Modified)
+	respond(w, r, http.StatusOK, "application/json", report)
+}
+
+func TriageReport(w http.ResponseWriter, r *http.Request) {
+	updateReport(w, r, "Triage report item", services.TriageReport)
+}
+
+func ResolveReport(w http.ResponseWriter, r *http.Request) {
+	updateReport(w, r, "Resolve report item", services.ResolveReport)
+}
+
+func DismissReport(w http.ResponseWriter, r *http.Request) {
+	updateReport(w, r, "Dismiss report item", services.DismissReport)
+}
+
+func updateReport(w http.ResponseWriter, r *http.Request, title string, fn func(string, *models.ReportResolution) (*models.Report, *models.ProblemDetail)) {
+	var put models.ReportResolution
+	if err := decodeBody(r, &put); err != nil {
+		respondJsonDecodeError(w, r, title)
+		return
+	}
+
+	reportId := bone.GetValue(r, "reportId")
+	report, pd := fn(reportId, &put)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", report)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/go-zoo/bone"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/services"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func SetRetentionMux() {
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/rooms/#roomId^[a-z0-9-]$/retention"), colsHandler(adminHandler(GetRoomRetention)))
+	Mux.PutFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/rooms/#roomId^[a-z0-9-]$/retention"), colsHandler(adminHandler(PutRoomRetention)))
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/users/#userId^[a-z0-9-]$/legalHold"), colsHandler(adminHandler(GetUserLegalHold)))
+	Mux.PutFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/users/#userId^[a-z0-9-]$/legalHold"), colsHandler(adminHandler(PutUserLegalHold)))
+	Mux.PostFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/purgeReports"), colsHandler(adminHandler(PostPurge)))
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/purgeReports"), colsHandler(adminHandler(GetPurgeReports)))
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/purgeReports/#purgeReportId^[a-z0-9-]$"), colsHandler(adminHandler(GetPurgeReport)))
+}
+
+func GetRoomRetention(w http.ResponseWriter, r *http.Request) {
+	roomId := bone.GetValue(r, "roomId")
+	roomRetention, pd := services.GetRoomRetention(roomId)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", roomRetention)
+}
+
+func PutRoomRetention(w http.ResponseWriter, r *http.Request) {
+	var put models.RoomRetention
+	if err := decodeBody(r, &put); err != nil {
+		respondJsonDecodeError(w, r, "Update room retention")
+		return
+	}
+
+	roomId := bone.GetValue(r, "roomId")
+	roomRetention, pd := services.PutRoomRetention(roomId, &put)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", roomRetention)
+}
+
+func GetUserLegalHold(w http.ResponseWriter, r *http.Request) {
+	userId := bone.GetValue(r, "userId")
+	userLegalHold, pd := services.GetUserLegalHold(userId)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", userLegalHold)
+}
+
+func PutUserLegalHold(w http.ResponseWriter, r *http.Request) {
+	var put models.UserLegalHold
+	if err := decodeBody(r, &put); err != nil {
+		respondJsonDecodeError(w, r, "Update user legal hold")
+		return
+	}
+
+	userId := bone.GetValue(r, "userId")
+	userLegalHold, pd := services.PutUserLegalHold(userId, &put)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", userLegalHold)
+}
+
+func PostPurge(w http.ResponseWriter, r *http.Request) {
+	purgeReport, pd := services.PostPurge()
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusCreated, "application/json", purgeReport)
+}
+
+func GetPurgeReports(w http.ResponseWriter, r *http.Request) {
+	params, _ := url.ParseQuery(r.URL.RawQuery)
+	purgeReports, pd := services.GetPurgeReports(params)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", purgeReports)
+}
+
+func GetPurgeReport(w http.ResponseWriter, r *http.Request) {
+	purgeReportId := bone.GetValue(r, "purgeReportId")
+	purgeReport, pd := services.GetPurgeReport(purgeReportId)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", purgeReport)
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-zoo/bone"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/services"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func SetRoomEventMux() {
+	Mux.PostFunc(utils.AppendStrings("/", utils.API_VERSION, "/rooms/#roomId^[a-z0-9-]$/events"), colsHandler(PostRoomEvent))
+}
+
+func PostRoomEvent(w http.ResponseWriter, r *http.Request) {
+	var post models.RoomEvent
+	if err := decodeBody(r, &post); err != nil {
+		respondJsonDecodeError(w, r, "Create room event item")
+		return
+	}
+
+	post.RoomId = bone.GetValue(r, "roomId")
+	pd := services.PostRoomEvent(&post)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusNoContent, "", nil)
+}
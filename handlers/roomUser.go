@@ -13,6 +13,9 @@ func SetRoomUserMux() {
 	Mux.PutFunc(utils.AppendStrings("/", utils.API_VERSION, "/rooms/#roomId^[a-z0-9-]$/users"), colsHandler(PutRoomUsers))
 	Mux.PutFunc(utils.AppendStrings("/", utils.API_VERSION, "/rooms/#roomId^[a-z0-9-]$/users/#userId^[a-z0-9-]$"), colsHandler(PutRoomUser))
 	Mux.DeleteFunc(utils.AppendStrings("/", utils.API_VERSION, "/rooms/#roomId^[a-z0-9-]$/users"), colsHandler(DeleteRoomUsers))
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/rooms/#roomId^[a-z0-9-]$/users/#userId^[a-z0-9-]$/draft"), colsHandler(GetRoomUserDraft))
+	Mux.PutFunc(utils.AppendStrings("/", utils.API_VERSION, "/rooms/#roomId^[a-z0-9-]$/users/#userId^[a-z0-9-]$/draft"), colsHandler(PutRoomUserDraft))
+	Mux.DeleteFunc(utils.AppendStrings("/", utils.API_VERSION, "/rooms/#roomId^[a-z0-9-]$/users/#userId^[a-z0-9-]$/draft"), colsHandler(DeleteRoomUserDraft))
 }
 
 func PutRoomUsers(w http.ResponseWriter, r *http.Request) {
@@ -67,3 +70,46 @@ func DeleteRoomUsers(w http.ResponseWriter, r *http.Request) {
 
 	respond(w, r, http.StatusOK, "application/json", roomUsers)
 }
+
+func GetRoomUserDraft(w http.ResponseWriter, r *http.Request) {
+	roomId := bone.GetValue(r, "roomId")
+	userId := bone.GetValue(r, "userId")
+	draft, pd := services.GetRoomUserDraft(roomId, userId)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", draft)
+}
+
+func PutRoomUserDraft(w http.ResponseWriter, r *http.Request) {
+	var put models.RoomUserDraft
+	if err := decodeBody(r, &put); err != nil {
+		respondJsonDecodeError(w, r, "Update room's user draft item")
+		return
+	}
+
+	put.RoomId = bone.GetValue(r, "roomId")
+	put.UserId = bone.GetValue(r, "userId")
+	draft, pd := services.PutRoomUserDraft(&put)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	setLastModified(w, draft.Modified)
+	respond(w, r, http.StatusOK, "application/json", draft)
+}
+
+func DeleteRoomUserDraft(w http.ResponseWriter, r *http.Request) {
+	roomId := bone.GetValue(r, "roomId")
+	userId := bone.GetValue(r, "userId")
+	pd := services.DeleteRoomUserDraft(roomId, userId)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusNoContent, "", nil)
+}
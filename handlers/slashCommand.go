@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-zoo/bone"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/services"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func SetSlashCommandMux() {
+	Mux.PostFunc(utils.AppendStrings("/", utils.API_VERSION, "/rooms/#roomId^[a-z0-9-]$/commands"), colsHandler(PostSlashCommand))
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/rooms/#roomId^[a-z0-9-]$/commands"), colsHandler(GetSlashCommands))
+	Mux.DeleteFunc(utils.AppendStrings("/", utils.API_VERSION, "/rooms/#roomId^[a-z0-9-]$/commands/#commandId^[a-z0-9-]$"), colsHandler(DeleteSlashCommand))
+}
+
+func PostSlashCommand(w http.ResponseWriter, r *http.Request) {
+	var post models.SlashCommand
+	if err := decodeBody(r, &post); err != nil {
+		respondJsonDecodeError(w, r, "Create command item")
+		return
+	}
+
+	post.RoomId = bone.GetValue(r, "roomId")
+	slashCommand, pd := services.PostSlashCommand(&post)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusCreated, "application/json", slashCommand)
+}
+
+func GetSlashCommands(w http.ResponseWriter, r *http.Request) {
+	roomId := bone.GetValue(r, "roomId")
+	slashCommands, pd := services.GetSlashCommands(roomId)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", slashCommands)
+}
+
+func DeleteSlashCommand(w http.ResponseWriter, r *http.Request) {
+	roomId := bone.GetValue(r, "roomId")
+	commandId := bone.GetValue(r, "commandId")
+	pd := services.DeleteSlashCommand(roomId, commandId)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusNoContent, "", nil)
+}
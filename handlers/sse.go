@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-zoo/bone"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/rtm"
+	"github.com/swagchat/chat-api/services"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func SetSseMux() {
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/users/#userId^[a-z0-9-]$/events"), colsHandler(aclHandler(GetUserEvents)))
+}
+
+// GetUserEvents streams the events of every room the user belongs to as Server-Sent Events.
+func GetUserEvents(w http.ResponseWriter, r *http.Request) {
+	userId := bone.GetValue(r, "userId")
+	if !isStreamUser(r, userId) {
+		respondErr(w, r, http.StatusUnauthorized, &models.ProblemDetail{
+			Title:     "User authentication is required. (Stream user events)",
+			Status:    http.StatusUnauthorized,
+			ErrorName: models.ERROR_NAME_OPERATION_NOT_PERMITTED,
+		})
+		return
+	}
+
+	roomIds, pd := services.GetUserRoomIds(userId)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	lastEventId := r.Header.Get("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = r.URL.Query().Get("lastEventId")
+	}
+	switch err := rtm.GetHub().ServeSse(w, r, userId, roomIds, lastEventId); err {
+	case nil:
+	case rtm.ErrHubDraining:
+		respondErr(w, r, http.StatusServiceUnavailable, &models.ProblemDetail{
+			Title:     "Server is shutting down. (Stream user events)",
+			Status:    http.StatusServiceUnavailable,
+			ErrorName: "service-unavailable",
+		})
+	default:
+		respondErr(w, r, http.StatusInternalServerError, &models.ProblemDetail{
+			Title:     "Event stream error. (Stream user events)",
+			Status:    http.StatusInternalServerError,
+			ErrorName: "event-stream-error",
+			Detail:    err.Error(),
+		})
+	}
+}
@@ -18,6 +18,9 @@ func SetUserMux() {
 	Mux.PutFunc(utils.AppendStrings("/", utils.API_VERSION, "/users/#userId^[a-z0-9-]$"), colsHandler(PutUser))
 	Mux.DeleteFunc(utils.AppendStrings("/", utils.API_VERSION, "/users/#userId^[a-z0-9-]$"), colsHandler(DeleteUser))
 	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/users/#userId^[a-z0-9-]$/unreadCount"), colsHandler(GetUserUnreadCount))
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/users/#userId^[a-z0-9-]$/presence"), colsHandler(GetPresence))
+	Mux.PutFunc(utils.AppendStrings("/", utils.API_VERSION, "/users/#userId^[a-z0-9-]$/presence"), colsHandler(PutPresence))
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/users/#userId^[a-z0-9-]$/sync"), colsHandler(GetSync))
 }
 
 func PostUser(w http.ResponseWriter, r *http.Request) {
@@ -96,3 +99,42 @@ func GetUserUnreadCount(w http.ResponseWriter, r *http.Request) {
 
 	respond(w, r, http.StatusOK, "application/json", userUnreadCount)
 }
+
+func GetSync(w http.ResponseWriter, r *http.Request) {
+	userId := bone.GetValue(r, "userId")
+	sync, pd := services.GetSync(r.Context(), userId, r.URL.Query())
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", sync)
+}
+
+func GetPresence(w http.ResponseWriter, r *http.Request) {
+	userId := bone.GetValue(r, "userId")
+	presence, pd := services.GetPresence(userId)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", presence)
+}
+
+func PutPresence(w http.ResponseWriter, r *http.Request) {
+	var put models.Presence
+	if err := decodeBody(r, &put); err != nil {
+		respondJsonDecodeError(w, r, "Update presence item")
+		return
+	}
+
+	put.UserId = bone.GetValue(r, "userId")
+	presence, pd := services.PutPresence(&put)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", presence)
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/go-zoo/bone"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/services"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func SetWebhookMux() {
+	Mux.PostFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/webhooks"), colsHandler(adminHandler(PostWebhook)))
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/webhooks"), colsHandler(adminHandler(GetWebhooks)))
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/webhooks/#webhookId^[a-z0-9-]$"), colsHandler(adminHandler(GetWebhook)))
+	Mux.PutFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/webhooks/#webhookId^[a-z0-9-]$"), colsHandler(adminHandler(PutWebhook)))
+	Mux.DeleteFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/webhooks/#webhookId^[a-z0-9-]$"), colsHandler(adminHandler(DeleteWebhook)))
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/webhooks/#webhookId^[a-z0-9-]$/deliveries"), colsHandler(adminHandler(GetWebhookDeliveriesOfWebhook)))
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/webhookDeliveries"), colsHandler(adminHandler(GetWebhookDeliveries)))
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/webhookDeliveries/#deliveryId^[a-z0-9-]$"), colsHandler(adminHandler(GetWebhookDelivery)))
+	Mux.PostFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/webhookDeliveries/#deliveryId^[a-z0-9-]$/replay"), colsHandler(adminHandler(ReplayWebhookDelivery)))
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/admin/webhookDeadLetters"), colsHandler(adminHandler(GetWebhookDeadLetters)))
+}
+
+func PostWebhook(w http.ResponseWriter, r *http.Request) {
+	var post models.Webhook
+	if err := decodeBody(r, &post); err != nil {
+		respondJsonDecodeError(w, r, "Create webhook item")
+		return
+	}
+
+	webhook, pd := services.PostWebhook(&post)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusCreated, "application/json", webhook)
+}
+
+func GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, pd := services.GetWebhooks()
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", webhooks)
+}
+
+func GetWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookId := bone.GetValue(r, "webhookId")
+	webhook, pd := services.GetWebhook(webhookId)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	setLastModified(w, webhook.Modified)
+	respond(w, r, http.StatusOK, "application/json", webhook)
+}
+
+func PutWebhook(w http.ResponseWriter, r *http.Request) {
+	var put models.Webhook
+	if err := decodeBody(r, &put); err != nil {
+		respondJsonDecodeError(w, r, "Update webhook item")
+		return
+	}
+
+	put.WebhookId = bone.GetValue(r, "webhookId")
+	webhook, pd := services.PutWebhook(&put)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", webhook)
+}
+
+func DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookId := bone.GetValue(r, "webhookId")
+	pd := services.DeleteWebhook(webhookId)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusNoContent, "", nil)
+}
+
+func GetWebhookDeliveriesOfWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookId := bone.GetValue(r, "webhookId")
+	params, _ := url.ParseQuery(r.URL.RawQuery)
+	deliveries, pd := services.GetWebhookDeliveries(webhookId, params)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", deliveries)
+}
+
+func GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	params, _ := url.ParseQuery(r.URL.RawQuery)
+	deliveries, pd := services.GetWebhookDeliveries("", params)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", deliveries)
+}
+
+func GetWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	deliveryId := bone.GetValue(r, "deliveryId")
+	delivery, pd := services.GetWebhookDelivery(deliveryId)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	setLastModified(w, delivery.Modified)
+	respond(w, r, http.StatusOK, "application/json", delivery)
+}
+
+func ReplayWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	deliveryId := bone.GetValue(r, "deliveryId")
+	delivery, pd := services.ReplayWebhookDelivery(deliveryId)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", delivery)
+}
+
+func GetWebhookDeadLetters(w http.ResponseWriter, r *http.Request) {
+	params, _ := url.ParseQuery(r.URL.RawQuery)
+	deliveries, pd := services.GetWebhookDeadLetters(params)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	respond(w, r, http.StatusOK, "application/json", deliveries)
+}
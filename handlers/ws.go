@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/rtm"
+	"github.com/swagchat/chat-api/services"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func SetWsMux() {
+	Mux.GetFunc(utils.AppendStrings("/", utils.API_VERSION, "/ws"), colsHandler(aclHandler(ServeWs)))
+}
+
+// ServeWs upgrades to a WebSocket connection that receives the events of every room the user belongs to.
+// Without the user headers, the user is given with the userId and accessToken query parameters.
+func ServeWs(w http.ResponseWriter, r *http.Request) {
+	userId := r.Header.Get(utils.HEADER_USER_ID)
+	if userId == "" {
+		userId = r.URL.Query().Get("userId")
+	}
+	if !isStreamUser(r, userId) {
+		respondErr(w, r, http.StatusUnauthorized, &models.ProblemDetail{
+			Title:     "User authentication is required. (Connect WebSocket)",
+			Status:    http.StatusUnauthorized,
+			ErrorName: models.ERROR_NAME_OPERATION_NOT_PERMITTED,
+		})
+		return
+	}
+
+	roomIds, pd := services.GetUserRoomIds(userId)
+	if pd != nil {
+		respondErr(w, r, pd.Status, pd)
+		return
+	}
+
+	switch err := rtm.GetHub().ServeWs(w, r, userId, roomIds); err {
+	case nil:
+	case rtm.ErrWsBadHandshake:
+		respondErr(w, r, http.StatusBadRequest, &models.ProblemDetail{
+			Title:     "WebSocket handshake error. (Connect WebSocket)",
+			Status:    http.StatusBadRequest,
+			ErrorName: models.ERROR_NAME_INVALID_PARAM,
+		})
+	case rtm.ErrHubDraining:
+		respondErr(w, r, http.StatusServiceUnavailable, &models.ProblemDetail{
+			Title:     "Server is shutting down. (Connect WebSocket)",
+			Status:    http.StatusServiceUnavailable,
+			ErrorName: "service-unavailable",
+		})
+	default:
+		respondErr(w, r, http.StatusInternalServerError, &models.ProblemDetail{
+			Title:     "WebSocket upgrade error. (Connect WebSocket)",
+			Status:    http.StatusInternalServerError,
+			ErrorName: "websocket-error",
+			Detail:    err.Error(),
+		})
+	}
+}
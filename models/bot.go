@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+const (
+	BOT_REQUEST_TYPE_COMMAND = "command"
+	BOT_REQUEST_TYPE_MESSAGE = "message"
+
+	BOT_RESPONSE_TYPE_IN_ROOM   = "inRoom"
+	BOT_RESPONSE_TYPE_EPHEMERAL = "ephemeral"
+)
+
+// BotRequest is posted to the bot endpoint.
+// For commands Text holds the arguments that follow the command name.
+type BotRequest struct {
+	Type      string   `json:"type"`
+	CommandId string   `json:"commandId,omitempty"`
+	Command   string   `json:"command,omitempty"`
+	Text      string   `json:"text"`
+	RoomId    string   `json:"roomId"`
+	UserId    string   `json:"userId"`
+	BotUserId string   `json:"botUserId"`
+	MessageId string   `json:"messageId"`
+	Message   *Message `json:"message,omitempty"`
+}
+
+// BotResponse is the optional body a bot answers with.
+// An empty text and no attachments means the bot does not reply.
+type BotResponse struct {
+	ResponseType string               `json:"responseType"`
+	Text         string               `json:"text"`
+	Attachments  []*MessageAttachment `json:"attachments,omitempty"`
+}
+
+func (br *BotResponse) IsEmpty() bool {
+	return br.Text == "" && len(br.Attachments) == 0
+}
+
+// EphemeralMessage is shown only to UserId and is never persisted.
+type EphemeralMessage struct {
+	RoomId       string         `json:"roomId"`
+	UserId       string         `json:"userId"`
+	SenderUserId string         `json:"senderUserId"`
+	Type         string         `json:"type"`
+	Payload      utils.JSONText `json:"payload"`
+	Created      string         `json:"created"`
+}
+
+func NewEphemeralMessage(m *Message, userId string) *EphemeralMessage {
+	l, _ := time.LoadLocation("Etc/GMT")
+	return &EphemeralMessage{
+		RoomId:       m.RoomId,
+		UserId:       userId,
+		SenderUserId: m.UserId,
+		Type:         m.Type,
+		Payload:      m.Payload,
+		Created:      time.Now().In(l).Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"encoding/json"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+// syncEventNames are the events kept in the change log of their recipients.
+// Typing, presence and ephemeral messages only matter while they happen.
+var syncEventNames = []string{
+	EVENT_NAME_MESSAGE,
+	EVENT_NAME_MESSAGE_EDIT,
+	EVENT_NAME_MESSAGE_DELETE,
+	EVENT_NAME_MESSAGE_READ,
+	EVENT_NAME_POLL_UPDATE,
+	EVENT_NAME_POLL_CLOSE,
+	EVENT_NAME_USER_JOIN,
+	EVENT_NAME_USER_LEFT,
+	EVENT_NAME_USER_UPDATE,
+	EVENT_NAME_ROOM_CREATE,
+	EVENT_NAME_ROOM_UPDATE,
+	EVENT_NAME_ROOM_DELETE,
+}
+
+func IsSyncEvent(eventName string) bool {
+	return utils.SearchStringValueInSlice(syncEventNames, eventName)
+}
+
+// ChangeLog is an event as seen by one of its recipients. Its seq is the number in the sequence of the user the sync token points to.
+type ChangeLog struct {
+	Id        uint64         `json:"-" db:"id"`
+	Seq       uint64         `json:"-" db:"seq,notnull"`
+	UserId    string         `json:"userId" db:"user_id,notnull"`
+	EventName string         `json:"eventName" db:"event_name,notnull"`
+	RoomId    string         `json:"roomId" db:"room_id,notnull"`
+	Event     utils.JSONText `json:"event" db:"event"`
+	Created   int64          `json:"created" db:"created,notnull"`
+}
+
+// ChangeLogSeq is the last number given to a change of the user.
+type ChangeLogSeq struct {
+	Id     uint64 `json:"-" db:"id"`
+	UserId string `json:"userId" db:"user_id,notnull"`
+	Seq    uint64 `json:"seq" db:"seq,notnull"`
+}
+
+// NewChangeLogs returns the change log entries of the event, one for each recipient.
+func NewChangeLogs(event *Event, created int64) ([]*ChangeLog, error) {
+	// The recipients are left out so that a user does not learn who else received the event
+	e := *event
+	e.Recipients = nil
+	eventBytes, err := json.Marshal(&e)
+	if err != nil {
+		return nil, err
+	}
+
+	changeLogs := make([]*ChangeLog, 0, len(event.Recipients))
+	for _, userId := range event.Recipients {
+		changeLogs = append(changeLogs, &ChangeLog{
+			UserId:    userId,
+			EventName: event.EventName,
+			RoomId:    event.RoomId,
+			Event:     utils.JSONText(eventBytes),
+			Created:   created,
+		})
+	}
+	return changeLogs, nil
+}
+
+// Sync is the response of a sync request. Token is passed as since to the next request.
+// HasMore is true when the changes were cut at the limit and the next request returns the rest immediately.
+type Sync struct {
+	Changes []utils.JSONText `json:"changes"`
+	Token   string           `json:"token"`
+	HasMore bool             `json:"hasMore"`
+}
@@ -1,10 +1,14 @@
 package models
 
-import "net/http"
+import (
+	"net/http"
+	"net/url"
+)
 
 const (
 	PLATFORM_IOS = iota + 1
 	PLATFORM_ANDROID
+	PLATFORM_WEB
 	end
 )
 
@@ -17,6 +21,20 @@ type Device struct {
 	Platform             int    `json:"platform,omitempty" db:"platform,notnull"`
 	Token                string `json:"token,omitempty" db:"token,notnull"`
 	NotificationDeviceId string `json:"notificationDeviceId,omitempty" db:"notification_device_id"`
+
+	// The keys of the push subscription of a web device, whose endpoint is the token
+	P256dh       string               `json:"-" db:"p256dh,notnull"`
+	Auth         string               `json:"-" db:"auth,notnull"`
+	Subscription *WebPushSubscription `json:"subscription,omitempty" db:"-"`
+}
+
+// WebPushSubscription is the PushSubscription of a browser, as serialized by PushSubscription.toJSON().
+type WebPushSubscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
 }
 
 func IsValidDevicePlatform(platform int) bool {
@@ -32,12 +50,16 @@ func (d *Device) IsValid() *ProblemDetail {
 			InvalidParams: []InvalidParam{
 				InvalidParam{
 					Name:   "device.platform",
-					Reason: "platform is invalid. Currently only 1(iOS) and 2(Android) are supported.",
+					Reason: "platform is invalid. Currently only 1(iOS), 2(Android) and 3(Web) are supported.",
 				},
 			},
 		}
 	}
 
+	if d.Platform == PLATFORM_WEB {
+		return d.isValidWebPush()
+	}
+
 	if d.Token == "" {
 		return &ProblemDetail{
 			Title:     "Request parameter error. (Create device item)",
@@ -54,3 +76,41 @@ func (d *Device) IsValid() *ProblemDetail {
 
 	return nil
 }
+
+// isValidWebPush also sets the token and the keys of a valid web device from its subscription.
+func (d *Device) isValidWebPush() *ProblemDetail {
+	invalidParams := []InvalidParam{}
+	if d.Subscription == nil {
+		invalidParams = append(invalidParams, InvalidParam{
+			Name:   "subscription",
+			Reason: "subscription is required for a web device, but it's empty.",
+		})
+	} else {
+		endpoint, err := url.Parse(d.Subscription.Endpoint)
+		if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+			invalidParams = append(invalidParams, InvalidParam{
+				Name:   "subscription.endpoint",
+				Reason: "endpoint must be an https URL.",
+			})
+		}
+		if d.Subscription.Keys.P256dh == "" || d.Subscription.Keys.Auth == "" {
+			invalidParams = append(invalidParams, InvalidParam{
+				Name:   "subscription.keys",
+				Reason: "keys must have p256dh and auth.",
+			})
+		}
+	}
+	if len(invalidParams) > 0 {
+		return &ProblemDetail{
+			Title:         "Request parameter error. (Create device item)",
+			Status:        http.StatusBadRequest,
+			ErrorName:     ERROR_NAME_INVALID_PARAM,
+			InvalidParams: invalidParams,
+		}
+	}
+
+	d.Token = d.Subscription.Endpoint
+	d.P256dh = d.Subscription.Keys.P256dh
+	d.Auth = d.Subscription.Keys.Auth
+	return nil
+}
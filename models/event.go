@@ -0,0 +1,69 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+// EVENT_VERSION is the version of the Event schema. It is raised on incompatible changes.
+const EVENT_VERSION = 1
+
+// Event is the envelope of every event published to the realtime messaging provider.
+// It is delivered to Recipients. Unless they are set beforehand, they are resolved from the users of RoomId when the event is published.
+type Event struct {
+	Version    int            `json:"version"`
+	EventId    string         `json:"eventId"`
+	EventName  string         `json:"eventName"`
+	RoomId     string         `json:"roomId,omitempty"`
+	ActorId    string         `json:"actorId,omitempty"`
+	Recipients []string       `json:"recipients,omitempty"`
+	Payload    utils.JSONText `json:"payload"`
+	Timestamp  string         `json:"timestamp"`
+}
+
+// blockableEventNames are the events of a user that are not sent to the users who blocked them.
+var blockableEventNames = []string{
+	EVENT_NAME_MESSAGE,
+	EVENT_NAME_MESSAGE_EDIT,
+	EVENT_NAME_MESSAGE_DELETE,
+	EVENT_NAME_POLL_UPDATE,
+	EVENT_NAME_TYPING_START,
+	EVENT_NAME_TYPING_STOP,
+}
+
+// EventMembers is the payload of the userJoin and userLeft events.
+type EventMembers struct {
+	UserIds []string       `json:"userIds"`
+	Users   []*UserForRoom `json:"users"`
+}
+
+func IsBlockableEvent(eventName string) bool {
+	return utils.SearchStringValueInSlice(blockableEventNames, eventName)
+}
+
+func NewEvent(eventName, roomId, actorId string, payload interface{}) *Event {
+	l, _ := time.LoadLocation("Etc/GMT")
+	event := &Event{
+		Version:   EVENT_VERSION,
+		EventId:   utils.CreateUuid(),
+		EventName: eventName,
+		RoomId:    roomId,
+		ActorId:   actorId,
+		Payload:   utils.JSONText("{}"),
+		Timestamp: time.Now().In(l).Format(time.RFC3339),
+	}
+	switch p := payload.(type) {
+	case nil:
+	case utils.JSONText:
+		if len(p) > 0 {
+			event.Payload = p
+		}
+	default:
+		if data, err := json.Marshal(p); err == nil {
+			event.Payload = utils.JSONText(data)
+		}
+	}
+	return event
+}
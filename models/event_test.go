@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+func TestNewEvent(t *testing.T) {
+	testTable := []struct {
+		testNo  int
+		roomId  string
+		payload interface{}
+		out     string
+	}{
+		{1, "room1", nil, `{}`},
+		{2, "room1", utils.JSONText(`{"text":"hi"}`), `{"text":"hi"}`},
+		{3, "room1", utils.JSONText(""), `{}`},
+		{4, "", &EventMembers{UserIds: []string{"user2"}}, `{"userIds":["user2"],"users":null}`},
+	}
+
+	for _, testRecord := range testTable {
+		event := NewEvent(EVENT_NAME_USER_JOIN, testRecord.roomId, "user1", testRecord.payload)
+		data, err := json.Marshal(event)
+		if err != nil {
+			t.Fatalf("TestNo %d\nError by json.Marshal(): %v", testRecord.testNo, err)
+		}
+
+		var envelope map[string]json.RawMessage
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			t.Fatalf("TestNo %d\nError by json.Unmarshal(): %v", testRecord.testNo, err)
+		}
+		if string(envelope["version"]) != "1" || string(envelope["eventName"]) != `"userJoin"` || string(envelope["actorId"]) != `"user1"` {
+			t.Fatalf("TestNo %d\nEnvelope Failure\n[result]%s", testRecord.testNo, data)
+		}
+		if string(envelope["payload"]) != testRecord.out {
+			t.Fatalf("TestNo %d\nPayload Failure\n[expected]%s\n[result  ]%s", testRecord.testNo, testRecord.out, envelope["payload"])
+		}
+		// The recipients are resolved on publish, and an event without a room has no roomId
+		if _, ok := envelope["recipients"]; ok {
+			t.Fatalf("TestNo %d\nthe recipients were set: %s", testRecord.testNo, data)
+		}
+		if _, ok := envelope["roomId"]; ok != (testRecord.roomId != "") {
+			t.Fatalf("TestNo %d\nRoomId Failure\n[result]%s", testRecord.testNo, data)
+		}
+		if timestamp, err := time.Parse(time.RFC3339, event.Timestamp); err != nil || timestamp.Location() != time.UTC {
+			t.Fatalf("TestNo %d\nthe timestamp is not RFC 3339 in UTC: %s", testRecord.testNo, event.Timestamp)
+		}
+	}
+
+	if NewEvent(EVENT_NAME_MESSAGE, "room1", "user1", nil).EventId == NewEvent(EVENT_NAME_MESSAGE, "room1", "user1", nil).EventId {
+		t.Fatalf("the eventIds are not unique")
+	}
+}
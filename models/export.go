@@ -0,0 +1,201 @@
+package models
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+const (
+	EXPORT_FORMAT_JSON = "json"
+	EXPORT_FORMAT_CSV  = "csv"
+	EXPORT_FORMAT_HTML = "html"
+
+	EXPORT_JOB_STATUS_QUEUED    = "queued"
+	EXPORT_JOB_STATUS_RUNNING   = "running"
+	EXPORT_JOB_STATUS_SUCCEEDED = "succeeded"
+	EXPORT_JOB_STATUS_FAILED    = "failed"
+)
+
+// ExportMessage is a message row of a room export with the sender's name resolved.
+// Deleted messages are only exported for administrators, as tombstones without payload.
+type ExportMessage struct {
+	Id        uint64         `json:"-" db:"id"`
+	MessageId string         `json:"messageId" db:"message_id"`
+	RoomId    string         `json:"roomId" db:"room_id"`
+	UserId    string         `json:"userId" db:"user_id"`
+	UserName  string         `json:"userName" db:"user_name"`
+	Type      string         `json:"type" db:"type"`
+	Payload   utils.JSONText `json:"payload" db:"payload"`
+	Created   int64          `json:"created" db:"created"`
+	Modified  int64          `json:"modified" db:"modified"`
+	Deleted   int64          `json:"deleted,omitempty" db:"deleted"`
+}
+
+func (em *ExportMessage) MarshalJSON() ([]byte, error) {
+	l, _ := time.LoadLocation("Etc/GMT")
+	deleted := ""
+	if em.IsDeleted() {
+		deleted = time.Unix(em.Deleted, 0).In(l).Format(time.RFC3339)
+	}
+	return json.Marshal(&struct {
+		MessageId string         `json:"messageId"`
+		UserId    string         `json:"userId"`
+		UserName  string         `json:"userName"`
+		Type      string         `json:"type"`
+		Payload   utils.JSONText `json:"payload,omitempty"`
+		Created   string         `json:"created"`
+		Modified  string         `json:"modified"`
+		Deleted   string         `json:"deleted,omitempty"`
+	}{
+		MessageId: em.MessageId,
+		UserId:    em.UserId,
+		UserName:  em.UserName,
+		Type:      em.Type,
+		Payload:   em.Payload,
+		Created:   time.Unix(em.Created, 0).In(l).Format(time.RFC3339),
+		Modified:  time.Unix(em.Modified, 0).In(l).Format(time.RFC3339),
+		Deleted:   deleted,
+	})
+}
+
+func (em *ExportMessage) IsDeleted() bool {
+	return em.Deleted != 0
+}
+
+// Text returns the text of a text message, or the poll question of a poll message.
+func (em *ExportMessage) Text() string {
+	if em.IsDeleted() {
+		return ""
+	}
+	switch em.Type {
+	case MESSAGE_TYPE_TEXT, MESSAGE_TYPE_SYSTEM:
+		var payloadText PayloadText
+		json.Unmarshal(em.Payload, &payloadText)
+		return payloadText.Text
+	case MESSAGE_TYPE_POLL:
+		var payloadPoll PayloadPoll
+		json.Unmarshal(em.Payload, &payloadPoll)
+		return payloadPoll.Question
+	}
+	return ""
+}
+
+type ExportJob struct {
+	Id             uint64 `json:"-" db:"id"`
+	ExportJobId    string `json:"exportJobId" db:"export_job_id,notnull"`
+	RoomId         string `json:"roomId" db:"room_id,notnull"`
+	Format         string `json:"format" db:"format,notnull"`
+	From           int64  `json:"from" db:"from_time,notnull"`
+	To             int64  `json:"to" db:"to_time,notnull"`
+	IncludeDeleted bool   `json:"includeDeleted" db:"include_deleted,notnull"`
+	RequestedBy    string `json:"-" db:"requested_by"`
+	Status         string `json:"status" db:"status,notnull"`
+	Url            string `json:"url,omitempty" db:"url"`
+	Error          string `json:"error,omitempty" db:"error"`
+	Created        int64  `json:"created" db:"created,notnull"`
+	Modified       int64  `json:"modified" db:"modified,notnull"`
+}
+
+func (ej *ExportJob) MarshalJSON() ([]byte, error) {
+	l, _ := time.LoadLocation("Etc/GMT")
+	from := ""
+	if ej.From != 0 {
+		from = time.Unix(ej.From, 0).In(l).Format(time.RFC3339)
+	}
+	to := ""
+	if ej.To != 0 {
+		to = time.Unix(ej.To, 0).In(l).Format(time.RFC3339)
+	}
+	return json.Marshal(&struct {
+		ExportJobId    string `json:"exportJobId"`
+		RoomId         string `json:"roomId"`
+		Format         string `json:"format"`
+		From           string `json:"from,omitempty"`
+		To             string `json:"to,omitempty"`
+		IncludeDeleted bool   `json:"includeDeleted"`
+		Status         string `json:"status"`
+		Url            string `json:"url,omitempty"`
+		Error          string `json:"error,omitempty"`
+		Created        string `json:"created"`
+		Modified       string `json:"modified"`
+	}{
+		ExportJobId:    ej.ExportJobId,
+		RoomId:         ej.RoomId,
+		Format:         ej.Format,
+		From:           from,
+		To:             to,
+		IncludeDeleted: ej.IncludeDeleted,
+		Status:         ej.Status,
+		Url:            ej.Url,
+		Error:          ej.Error,
+		Created:        time.Unix(ej.Created, 0).In(l).Format(time.RFC3339),
+		Modified:       time.Unix(ej.Modified, 0).In(l).Format(time.RFC3339),
+	})
+}
+
+func (ej *ExportJob) IsValid() *ProblemDetail {
+	switch ej.Format {
+	case EXPORT_FORMAT_JSON, EXPORT_FORMAT_CSV, EXPORT_FORMAT_HTML:
+	default:
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Export room messages)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "format",
+					Reason: "format is invalid. Available values are json, csv and html.",
+				},
+			},
+		}
+	}
+
+	if ej.To != 0 && ej.From > ej.To {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Export room messages)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "to",
+					Reason: "to must be later than from.",
+				},
+			},
+		}
+	}
+
+	return nil
+}
+
+func (ej *ExportJob) BeforeSave() {
+	if ej.ExportJobId == "" {
+		ej.ExportJobId = utils.CreateUuid()
+	}
+
+	if ej.Status == "" {
+		ej.Status = EXPORT_JOB_STATUS_QUEUED
+	}
+
+	nowTimestamp := time.Now().Unix()
+	if ej.Created == 0 {
+		ej.Created = nowTimestamp
+	}
+	ej.Modified = nowTimestamp
+}
+
+func (ej *ExportJob) ContentType() string {
+	switch ej.Format {
+	case EXPORT_FORMAT_CSV:
+		return "text/csv; charset=utf-8"
+	case EXPORT_FORMAT_HTML:
+		return "text/html; charset=utf-8"
+	}
+	return "application/json"
+}
+
+func (ej *ExportJob) FileName() string {
+	return utils.AppendStrings("room-", ej.RoomId, ".", ej.Format)
+}
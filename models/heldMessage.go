@@ -0,0 +1,93 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+const (
+	HELD_MESSAGE_KIND_POST = "post"
+	HELD_MESSAGE_KIND_EDIT = "edit"
+
+	HELD_MESSAGE_STATUS_HELD     = "held"
+	HELD_MESSAGE_STATUS_APPROVED = "approved"
+	HELD_MESSAGE_STATUS_REJECTED = "rejected"
+)
+
+type HeldMessages struct {
+	HeldMessages []*HeldMessage `json:"heldMessages"`
+}
+
+type HeldMessage struct {
+	Id            uint64         `json:"-" db:"id"`
+	HeldMessageId string         `json:"heldMessageId" db:"held_message_id,notnull"`
+	MessageId     string         `json:"messageId" db:"message_id,notnull"`
+	RoomId        string         `json:"roomId" db:"room_id,notnull"`
+	UserId        string         `json:"userId" db:"user_id,notnull"`
+	Type          string         `json:"type" db:"type"`
+	Payload       utils.JSONText `json:"payload" db:"payload"`
+	Kind          string         `json:"kind" db:"kind,notnull"`
+	Reason        string         `json:"reason,omitempty" db:"reason"`
+	Status        string         `json:"status" db:"status,notnull"`
+	Created       int64          `json:"created" db:"created,notnull"`
+	Modified      int64          `json:"modified" db:"modified,notnull"`
+}
+
+func (hm *HeldMessage) MarshalJSON() ([]byte, error) {
+	l, _ := time.LoadLocation("Etc/GMT")
+	return json.Marshal(&struct {
+		HeldMessageId string         `json:"heldMessageId"`
+		MessageId     string         `json:"messageId"`
+		RoomId        string         `json:"roomId"`
+		UserId        string         `json:"userId"`
+		Type          string         `json:"type"`
+		Payload       utils.JSONText `json:"payload"`
+		Kind          string         `json:"kind"`
+		Reason        string         `json:"reason,omitempty"`
+		Status        string         `json:"status"`
+		Created       string         `json:"created"`
+		Modified      string         `json:"modified"`
+	}{
+		HeldMessageId: hm.HeldMessageId,
+		MessageId:     hm.MessageId,
+		RoomId:        hm.RoomId,
+		UserId:        hm.UserId,
+		Type:          hm.Type,
+		Payload:       hm.Payload,
+		Kind:          hm.Kind,
+		Reason:        hm.Reason,
+		Status:        hm.Status,
+		Created:       time.Unix(hm.Created, 0).In(l).Format(time.RFC3339),
+		Modified:      time.Unix(hm.Modified, 0).In(l).Format(time.RFC3339),
+	})
+}
+
+func (hm *HeldMessage) BeforeSave() {
+	if hm.HeldMessageId == "" {
+		hm.HeldMessageId = utils.CreateUuid()
+	}
+
+	if hm.Status == "" {
+		hm.Status = HELD_MESSAGE_STATUS_HELD
+	}
+
+	nowTimestamp := time.Now().Unix()
+	if hm.Created == 0 {
+		hm.Created = nowTimestamp
+	}
+	hm.Modified = nowTimestamp
+}
+
+func NewHeldMessage(m *Message, kind, reason string) *HeldMessage {
+	return &HeldMessage{
+		MessageId: m.MessageId,
+		RoomId:    m.RoomId,
+		UserId:    m.UserId,
+		Type:      m.Type,
+		Payload:   m.Payload,
+		Kind:      kind,
+		Reason:    reason,
+	}
+}
@@ -0,0 +1,204 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+const incomingWebhookMaxAttachments = 20
+
+type IncomingWebhooks struct {
+	IncomingWebhooks []*IncomingWebhook `json:"incomingWebhooks"`
+}
+
+type IncomingWebhook struct {
+	Id                uint64 `json:"-" db:"id"`
+	IncomingWebhookId string `json:"incomingWebhookId" db:"incoming_webhook_id,notnull"`
+	Name              string `json:"name" db:"name"`
+	RoomId            string `json:"roomId" db:"room_id,notnull"`
+	UserId            string `json:"userId" db:"user_id,notnull"`
+	Secret            string `json:"-" db:"secret,notnull"`
+	RateLimit         int    `json:"rateLimit,omitempty" db:"rate_limit,notnull"`
+	Created           int64  `json:"created" db:"created,notnull"`
+	Modified          int64  `json:"modified" db:"modified,notnull"`
+	Revoked           int64  `json:"-" db:"revoked,notnull"`
+}
+
+func (iw *IncomingWebhook) MarshalJSON() ([]byte, error) {
+	l, _ := time.LoadLocation("Etc/GMT")
+	revoked := ""
+	if iw.Revoked != 0 {
+		revoked = time.Unix(iw.Revoked, 0).In(l).Format(time.RFC3339)
+	}
+	url := ""
+	if iw.Revoked == 0 {
+		url = iw.Url()
+	}
+	return json.Marshal(&struct {
+		IncomingWebhookId string `json:"incomingWebhookId"`
+		Name              string `json:"name,omitempty"`
+		RoomId            string `json:"roomId"`
+		UserId            string `json:"userId"`
+		Url               string `json:"url,omitempty"`
+		RateLimit         int    `json:"rateLimit,omitempty"`
+		Created           string `json:"created"`
+		Modified          string `json:"modified"`
+		Revoked           string `json:"revoked,omitempty"`
+	}{
+		IncomingWebhookId: iw.IncomingWebhookId,
+		Name:              iw.Name,
+		RoomId:            iw.RoomId,
+		UserId:            iw.UserId,
+		Url:               url,
+		RateLimit:         iw.RateLimit,
+		Created:           time.Unix(iw.Created, 0).In(l).Format(time.RFC3339),
+		Modified:          time.Unix(iw.Modified, 0).In(l).Format(time.RFC3339),
+		Revoked:           revoked,
+	})
+}
+
+// Url is the path, relative to the api host, that accepts posts for this hook.
+func (iw *IncomingWebhook) Url() string {
+	return utils.AppendStrings("/", utils.API_VERSION, "/hooks/", iw.Secret)
+}
+
+func (iw *IncomingWebhook) IsValid() *ProblemDetail {
+	if iw.IncomingWebhookId != "" && !utils.IsValidId(iw.IncomingWebhookId) {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Create incoming webhook item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "incomingWebhookId",
+					Reason: "incomingWebhookId is invalid. Available characters are alphabets, numbers and hyphens.",
+				},
+			},
+		}
+	}
+
+	if iw.RoomId == "" || !utils.IsValidId(iw.RoomId) {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Create incoming webhook item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "roomId",
+					Reason: "roomId is required, but it's empty or invalid.",
+				},
+			},
+		}
+	}
+
+	if iw.UserId == "" || !utils.IsValidId(iw.UserId) {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Create incoming webhook item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "userId",
+					Reason: "userId is required, but it's empty or invalid.",
+				},
+			},
+		}
+	}
+
+	if iw.RateLimit < 0 {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Create incoming webhook item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "rateLimit",
+					Reason: "rateLimit must be zero or greater.",
+				},
+			},
+		}
+	}
+
+	return nil
+}
+
+func (iw *IncomingWebhook) BeforeSave() {
+	if iw.IncomingWebhookId == "" {
+		iw.IncomingWebhookId = utils.CreateUuid()
+	}
+
+	if iw.Secret == "" {
+		iw.GenerateSecret()
+	}
+
+	nowTimestamp := time.Now().Unix()
+	if iw.Created == 0 {
+		iw.Created = nowTimestamp
+	}
+	iw.Modified = nowTimestamp
+}
+
+func (iw *IncomingWebhook) GenerateSecret() {
+	b := make([]byte, 24)
+	rand.Read(b)
+	iw.Secret = hex.EncodeToString(b)
+}
+
+func (iw *IncomingWebhook) Put(put *IncomingWebhook) {
+	if put.Name != "" {
+		iw.Name = put.Name
+	}
+	if put.UserId != "" {
+		iw.UserId = put.UserId
+	}
+	if put.RateLimit != 0 {
+		iw.RateLimit = put.RateLimit
+	}
+}
+
+// IncomingWebhookPayload is the body accepted by POST /hooks/{secret}.
+type IncomingWebhookPayload struct {
+	Text        string               `json:"text"`
+	Attachments []*MessageAttachment `json:"attachments,omitempty"`
+}
+
+func (p *IncomingWebhookPayload) IsValid() *ProblemDetail {
+	if p.Text == "" && len(p.Attachments) == 0 {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Create message item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "text",
+					Reason: "text or attachments is required.",
+				},
+			},
+		}
+	}
+
+	if len(p.Attachments) > incomingWebhookMaxAttachments {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Create message item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "attachments",
+					Reason: "attachments can contain up to 20 items.",
+				},
+			},
+		}
+	}
+
+	return nil
+}
+
+func (p *IncomingWebhookPayload) Message(iw *IncomingWebhook) *Message {
+	return NewAttachmentMessage(iw.RoomId, iw.UserId, p.Text, p.Attachments)
+}
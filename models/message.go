@@ -9,8 +9,17 @@ import (
 )
 
 const (
-	MESSAGE_TYPE_TEXT  = "text"
-	MESSAGE_TYPE_IMAGE = "image"
+	MESSAGE_TYPE_TEXT   = "text"
+	MESSAGE_TYPE_IMAGE  = "image"
+	MESSAGE_TYPE_SYSTEM = "system"
+	MESSAGE_TYPE_POLL   = "poll"
+)
+
+const (
+	SYSTEM_ACTION_USER_JOIN    = "userJoin"
+	SYSTEM_ACTION_USER_LEFT    = "userLeft"
+	SYSTEM_ACTION_ROOM_RENAME  = "roomRename"
+	SYSTEM_ACTION_ROOM_PICTURE = "roomPictureChange"
 )
 
 type Messages struct {
@@ -24,11 +33,12 @@ type Message struct {
 	RoomId    string         `json:"roomId" db:"room_id,notnull"`
 	UserId    string         `json:"userId" db:"user_id,notnull"`
 	Type      string         `json:"type,omitempty" db:"type"`
-	EventName string         `json:"eventName,omitempty" db:"-"`
 	Payload   utils.JSONText `json:"payload" db:"payload"`
 	Created   int64          `json:"created" db:"created,notnull"`
 	Modified  int64          `json:"modified" db:"modified,notnull"`
 	Deleted   int64          `json:"-" db:"deleted,notnull"`
+
+	Poll *PollResult `json:"poll,omitempty" db:"-"`
 }
 
 func (m *Message) MarshalJSON() ([]byte, error) {
@@ -38,31 +48,50 @@ func (m *Message) MarshalJSON() ([]byte, error) {
 		RoomId    string         `json:"roomId"`
 		UserId    string         `json:"userId"`
 		Type      string         `json:"type"`
-		EventName string         `json:"eventName,omitempty"`
 		Payload   utils.JSONText `json:"payload"`
 		Created   string         `json:"created"`
 		Modified  string         `json:"modified"`
+		Poll      *PollResult    `json:"poll,omitempty"`
 	}{
 		MessageId: m.MessageId,
 		RoomId:    m.RoomId,
 		UserId:    m.UserId,
 		Type:      m.Type,
-		EventName: m.EventName,
 		Payload:   m.Payload,
 		Created:   time.Unix(m.Created, 0).In(l).Format(time.RFC3339),
 		Modified:  time.Unix(m.Modified, 0).In(l).Format(time.RFC3339),
+		Poll:      m.Poll,
 	})
 }
 
+// ResponseMessages is the result of POST /messages.
+// CommandMessageIds are messages that invoked a slash command. They are handed to the bot instead of being stored.
 type ResponseMessages struct {
-	MessageIds []string         `json:"messageIds,omitempty"`
-	Errors     []*ProblemDetail `json:"errors,omitempty"`
+	MessageIds        []string         `json:"messageIds,omitempty"`
+	HeldMessageIds    []string         `json:"heldMessageIds,omitempty"`
+	CommandMessageIds []string         `json:"commandMessageIds,omitempty"`
+	Errors            []*ProblemDetail `json:"errors,omitempty"`
 }
 
 type PayloadText struct {
 	Text string `json:"text"`
 }
 
+// MessageAttachment is a rich link block carried in the payload of a text message.
+// It is posted by integrations such as incoming webhooks and bots.
+type MessageAttachment struct {
+	Title     string `json:"title,omitempty"`
+	TitleLink string `json:"titleLink,omitempty"`
+	Text      string `json:"text,omitempty"`
+	ImageUrl  string `json:"imageUrl,omitempty"`
+	Color     string `json:"color,omitempty"`
+}
+
+type PayloadAttachment struct {
+	Text        string               `json:"text"`
+	Attachments []*MessageAttachment `json:"attachments,omitempty"`
+}
+
 type PayloadImage struct {
 	Mime         string `json:"mime"`
 	SourceUrl    string `json:"sourceUrl"`
@@ -76,6 +105,13 @@ type PayloadLocation struct {
 	Longitude float64 `json:"longitude"`
 }
 
+type PayloadSystem struct {
+	Action   string   `json:"action"`
+	UserIds  []string `json:"userIds,omitempty"`
+	OldValue string   `json:"oldValue,omitempty"`
+	NewValue string   `json:"newValue,omitempty"`
+}
+
 type PayloadUsers struct {
 	Users []string `json:"users"`
 }
@@ -95,6 +131,20 @@ func (m *Message) IsValid() *ProblemDetail {
 		}
 	}
 
+	if m.Type == MESSAGE_TYPE_SYSTEM {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Create message item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "type",
+					Reason: "System type is reserved and can not be posted.",
+				},
+			},
+		}
+	}
+
 	if m.Payload == nil {
 		return &ProblemDetail{
 			Title:     "Request parameter error. (Create message item)",
@@ -145,9 +195,43 @@ func (m *Message) IsValid() *ProblemDetail {
 		}
 	}
 
+	if m.Type == MESSAGE_TYPE_POLL {
+		var pp PayloadPoll
+		if err := json.Unmarshal(m.Payload, &pp); err != nil {
+			return pollPayloadProblemDetail("Poll type needs question and options.")
+		}
+		if pd := pp.IsValid(); pd != nil {
+			return pd
+		}
+	}
+
 	return nil
 }
 
+func (m *Message) Put(put *Message) {
+	if put.Payload != nil {
+		m.Payload = put.Payload
+	}
+}
+
+// LastMessageText returns the text shown as the room's last message.
+func (m *Message) LastMessageText() string {
+	switch m.Type {
+	case MESSAGE_TYPE_TEXT:
+		var payloadText PayloadText
+		json.Unmarshal(m.Payload, &payloadText)
+		return payloadText.Text
+	case MESSAGE_TYPE_IMAGE:
+		return "画像を受信しました"
+	case MESSAGE_TYPE_POLL:
+		var payloadPoll PayloadPoll
+		json.Unmarshal(m.Payload, &payloadPoll)
+		return payloadPoll.Question
+	default:
+		return "メッセージを受信しました"
+	}
+}
+
 func (m *Message) BeforeSave() {
 	if m.MessageId == "" {
 		m.MessageId = utils.CreateUuid()
@@ -159,3 +243,34 @@ func (m *Message) BeforeSave() {
 	}
 	m.Modified = nowTimestamp
 }
+
+// NewAttachmentMessage builds a text message with attachments.
+// When text is empty, the attachment titles are used so that the message is never blank in clients that ignore attachments.
+func NewAttachmentMessage(roomId, userId, text string, attachments []*MessageAttachment) *Message {
+	if text == "" {
+		for _, a := range attachments {
+			t := a.Title
+			if t == "" {
+				t = a.Text
+			}
+			if t == "" {
+				continue
+			}
+			if text != "" {
+				text = utils.AppendStrings(text, "\n")
+			}
+			text = utils.AppendStrings(text, t)
+		}
+	}
+
+	payload, _ := json.Marshal(&PayloadAttachment{
+		Text:        text,
+		Attachments: attachments,
+	})
+	return &Message{
+		RoomId:  roomId,
+		UserId:  userId,
+		Type:    MESSAGE_TYPE_TEXT,
+		Payload: utils.JSONText(payload),
+	}
+}
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+const (
+	OUTBOX_KIND_RTM  = "rtm"
+	OUTBOX_KIND_PUSH = "push"
+
+	OUTBOX_ITEM_STATUS_PENDING   = "pending"
+	OUTBOX_ITEM_STATUS_SUCCEEDED = "succeeded"
+	OUTBOX_ITEM_STATUS_DEAD      = "dead"
+)
+
+type OutboxItems struct {
+	OutboxItems []*OutboxItem `json:"outboxItems"`
+}
+
+// OutboxItem is a real-time event or a push notification of a message.
+// It is inserted in the same transaction as the message, so that it is delivered even if the first attempt fails.
+type OutboxItem struct {
+	Id           uint64         `json:"-" db:"id"`
+	OutboxItemId string         `json:"outboxItemId" db:"outbox_item_id,notnull"`
+	Kind         string         `json:"kind" db:"kind,notnull"`
+	RoomId       string         `json:"roomId" db:"room_id,notnull"`
+	MessageId    string         `json:"messageId" db:"message_id,notnull"`
+	Data         utils.JSONText `json:"data" db:"data"`
+	Status       string         `json:"status" db:"status,notnull"`
+	Attempts     int            `json:"attempts" db:"attempts,notnull"`
+	NextAttempt  int64          `json:"nextAttempt" db:"next_attempt,notnull"`
+	LastError    string         `json:"lastError,omitempty" db:"last_error"`
+	Created      int64          `json:"created" db:"created,notnull"`
+	Modified     int64          `json:"modified" db:"modified,notnull"`
+}
+
+// OutboxPush is the data of a push item.
+// UserIds are its recipients, and an item without them is pushed to everyone subscribed to the room.
+type OutboxPush struct {
+	NotificationTopicId string   `json:"notificationTopicId"`
+	Text                string   `json:"text"`
+	Badge               int      `json:"badge"`
+	UserIds             []string `json:"userIds,omitempty"`
+}
+
+func (oi *OutboxItem) MarshalJSON() ([]byte, error) {
+	l, _ := time.LoadLocation("Etc/GMT")
+	nextAttempt := ""
+	if oi.Status == OUTBOX_ITEM_STATUS_PENDING {
+		nextAttempt = time.Unix(oi.NextAttempt, 0).In(l).Format(time.RFC3339)
+	}
+	return json.Marshal(&struct {
+		OutboxItemId string         `json:"outboxItemId"`
+		Kind         string         `json:"kind"`
+		RoomId       string         `json:"roomId"`
+		MessageId    string         `json:"messageId"`
+		Data         utils.JSONText `json:"data"`
+		Status       string         `json:"status"`
+		Attempts     int            `json:"attempts"`
+		NextAttempt  string         `json:"nextAttempt,omitempty"`
+		LastError    string         `json:"lastError,omitempty"`
+		Created      string         `json:"created"`
+		Modified     string         `json:"modified"`
+	}{
+		OutboxItemId: oi.OutboxItemId,
+		Kind:         oi.Kind,
+		RoomId:       oi.RoomId,
+		MessageId:    oi.MessageId,
+		Data:         oi.Data,
+		Status:       oi.Status,
+		Attempts:     oi.Attempts,
+		NextAttempt:  nextAttempt,
+		LastError:    oi.LastError,
+		Created:      time.Unix(oi.Created, 0).In(l).Format(time.RFC3339),
+		Modified:     time.Unix(oi.Modified, 0).In(l).Format(time.RFC3339),
+	})
+}
+
+func (oi *OutboxItem) BeforeSave() {
+	if oi.OutboxItemId == "" {
+		oi.OutboxItemId = utils.CreateUuid()
+	}
+
+	if oi.Status == "" {
+		oi.Status = OUTBOX_ITEM_STATUS_PENDING
+	}
+
+	nowTimestamp := time.Now().Unix()
+	if oi.Created == 0 {
+		oi.Created = nowTimestamp
+	}
+	oi.Modified = nowTimestamp
+}
+
+func (oi *OutboxItem) Event() (*Event, error) {
+	var event Event
+	if err := json.Unmarshal(oi.Data, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (oi *OutboxItem) Push() (*OutboxPush, error) {
+	var push OutboxPush
+	if err := json.Unmarshal(oi.Data, &push); err != nil {
+		return nil, err
+	}
+	return &push, nil
+}
+
+// NewOutboxRtmItem returns an item publishing the message event.
+func NewOutboxRtmItem(message *Message, eventName string) *OutboxItem {
+	data, _ := json.Marshal(NewEvent(eventName, message.RoomId, message.UserId, message))
+	return &OutboxItem{
+		Kind:      OUTBOX_KIND_RTM,
+		RoomId:    message.RoomId,
+		MessageId: message.MessageId,
+		Data:      utils.JSONText(data),
+	}
+}
+
+// NewOutboxPushItem returns an item sending a push notification to the room.
+func NewOutboxPushItem(room *Room, messageId string, push *OutboxPush) *OutboxItem {
+	push.NotificationTopicId = room.NotificationTopicId
+	data, _ := json.Marshal(push)
+	return &OutboxItem{
+		Kind:      OUTBOX_KIND_PUSH,
+		RoomId:    room.RoomId,
+		MessageId: messageId,
+		Data:      utils.JSONText(data),
+	}
+}
+
+func IsValidOutboxParams(status, kind string) *ProblemDetail {
+	invalidParams := []InvalidParam{}
+	if status != "" && status != OUTBOX_ITEM_STATUS_PENDING && status != OUTBOX_ITEM_STATUS_SUCCEEDED && status != OUTBOX_ITEM_STATUS_DEAD {
+		invalidParams = append(invalidParams, InvalidParam{
+			Name:   "status",
+			Reason: "status must be pending, succeeded or dead.",
+		})
+	}
+	if kind != "" && kind != OUTBOX_KIND_RTM && kind != OUTBOX_KIND_PUSH {
+		invalidParams = append(invalidParams, InvalidParam{
+			Name:   "kind",
+			Reason: "kind must be rtm or push.",
+		})
+	}
+	if len(invalidParams) > 0 {
+		return &ProblemDetail{
+			Title:         "Request parameter error. (Get outbox items)",
+			Status:        http.StatusBadRequest,
+			ErrorName:     ERROR_NAME_INVALID_PARAM,
+			InvalidParams: invalidParams,
+		}
+	}
+	return nil
+}
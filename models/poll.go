@@ -0,0 +1,248 @@
+package models
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+const pollMaxOptions = 20
+
+type PayloadPoll struct {
+	Question         string        `json:"question"`
+	Options          []*PollOption `json:"options"`
+	IsMultipleChoice bool          `json:"isMultipleChoice"`
+	IsAnonymous      bool          `json:"isAnonymous"`
+	// RFC3339. Empty means the poll never closes automatically
+	Deadline string `json:"deadline,omitempty"`
+}
+
+type PollOption struct {
+	OptionId string `json:"optionId"`
+	Text     string `json:"text"`
+}
+
+func (pp *PayloadPoll) IsValid() *ProblemDetail {
+	if pp.Question == "" {
+		return pollPayloadProblemDetail("Poll type needs question.")
+	}
+
+	if len(pp.Options) < 2 || len(pp.Options) > pollMaxOptions {
+		return pollPayloadProblemDetail("Poll type needs from 2 to 20 options.")
+	}
+
+	optionIds := make(map[string]bool)
+	for _, option := range pp.Options {
+		if option == nil || option.Text == "" {
+			return pollPayloadProblemDetail("Poll options need text.")
+		}
+		if option.OptionId == "" {
+			continue
+		}
+		if optionIds[option.OptionId] {
+			return pollPayloadProblemDetail("Poll option ids must be unique.")
+		}
+		optionIds[option.OptionId] = true
+	}
+
+	if pp.Deadline != "" {
+		deadline, err := time.Parse(time.RFC3339, pp.Deadline)
+		if err != nil {
+			return pollPayloadProblemDetail("Poll deadline must be RFC3339.")
+		}
+		if !deadline.After(time.Now()) {
+			return pollPayloadProblemDetail("Poll deadline must be in the future.")
+		}
+	}
+
+	return nil
+}
+
+// Normalize numbers options that have no id, starting from 1.
+func (pp *PayloadPoll) Normalize() {
+	used := make(map[string]bool)
+	for _, option := range pp.Options {
+		used[option.OptionId] = true
+	}
+	next := 1
+	for _, option := range pp.Options {
+		if option.OptionId != "" {
+			continue
+		}
+		for used[strconv.Itoa(next)] {
+			next++
+		}
+		option.OptionId = strconv.Itoa(next)
+		used[option.OptionId] = true
+	}
+}
+
+func (pp *PayloadPoll) HasOption(optionId string) bool {
+	for _, option := range pp.Options {
+		if option.OptionId == optionId {
+			return true
+		}
+	}
+	return false
+}
+
+func pollPayloadProblemDetail(reason string) *ProblemDetail {
+	return &ProblemDetail{
+		Title:     "Request parameter error. (Create message item)",
+		Status:    http.StatusBadRequest,
+		ErrorName: ERROR_NAME_INVALID_PARAM,
+		InvalidParams: []InvalidParam{
+			InvalidParam{
+				Name:   "payload",
+				Reason: reason,
+			},
+		},
+	}
+}
+
+// Poll keeps the state of a poll message that must be queried, such as its deadline.
+// The question and options live in the message payload.
+type Poll struct {
+	Id               uint64 `json:"-" db:"id"`
+	MessageId        string `json:"messageId" db:"message_id,notnull"`
+	RoomId           string `json:"roomId" db:"room_id,notnull"`
+	IsMultipleChoice bool   `json:"isMultipleChoice" db:"is_multiple_choice,notnull"`
+	IsAnonymous      bool   `json:"isAnonymous" db:"is_anonymous,notnull"`
+	Deadline         int64  `json:"deadline" db:"deadline,notnull"`
+	Closed           int64  `json:"closed" db:"closed,notnull"`
+	Created          int64  `json:"created" db:"created,notnull"`
+	Modified         int64  `json:"modified" db:"modified,notnull"`
+}
+
+func NewPoll(m *Message) *Poll {
+	var pp PayloadPoll
+	json.Unmarshal(m.Payload, &pp)
+	var deadline int64
+	if t, err := time.Parse(time.RFC3339, pp.Deadline); err == nil {
+		deadline = t.Unix()
+	}
+	nowTimestamp := time.Now().Unix()
+	return &Poll{
+		MessageId:        m.MessageId,
+		RoomId:           m.RoomId,
+		IsMultipleChoice: pp.IsMultipleChoice,
+		IsAnonymous:      pp.IsAnonymous,
+		Deadline:         deadline,
+		Created:          nowTimestamp,
+		Modified:         nowTimestamp,
+	}
+}
+
+func (p *Poll) IsClosed() bool {
+	return p.Closed != 0 || (p.Deadline != 0 && p.Deadline <= time.Now().Unix())
+}
+
+type PollVote struct {
+	Id        uint64 `json:"-" db:"id"`
+	MessageId string `json:"messageId" db:"message_id,notnull"`
+	OptionId  string `json:"optionId" db:"option_id,notnull"`
+	UserId    string `json:"userId" db:"user_id,notnull"`
+	Created   int64  `json:"created" db:"created,notnull"`
+}
+
+// RequestPollVote replaces every vote the user has cast on the poll.
+type RequestPollVote struct {
+	UserId    string   `json:"userId"`
+	OptionIds []string `json:"optionIds"`
+}
+
+func (rpv *RequestPollVote) IsValid(poll *Poll, pp *PayloadPoll) *ProblemDetail {
+	if rpv.UserId == "" || !utils.IsValidId(rpv.UserId) {
+		return pollVoteProblemDetail("userId", "userId is required, but it's empty or invalid.")
+	}
+
+	rpv.OptionIds = utils.RemoveDuplicate(rpv.OptionIds)
+	if len(rpv.OptionIds) == 0 {
+		return pollVoteProblemDetail("optionIds", "optionIds is required, but it's empty.")
+	}
+	if !poll.IsMultipleChoice && len(rpv.OptionIds) > 1 {
+		return pollVoteProblemDetail("optionIds", "This poll accepts only one option.")
+	}
+	for _, optionId := range rpv.OptionIds {
+		if !pp.HasOption(optionId) {
+			return pollVoteProblemDetail("optionIds", utils.AppendStrings("optionIds contains an unknown option [", optionId, "]."))
+		}
+	}
+
+	return nil
+}
+
+func pollVoteProblemDetail(name, reason string) *ProblemDetail {
+	return &ProblemDetail{
+		Title:     "Request parameter error. (Create poll vote item)",
+		Status:    http.StatusBadRequest,
+		ErrorName: ERROR_NAME_INVALID_PARAM,
+		InvalidParams: []InvalidParam{
+			InvalidParam{
+				Name:   name,
+				Reason: reason,
+			},
+		},
+	}
+}
+
+// PollResult is returned with poll messages and published when votes change.
+type PollResult struct {
+	MessageId  string       `json:"messageId"`
+	Tallies    []*PollTally `json:"tallies"`
+	VoterCount int          `json:"voterCount"`
+	IsClosed   bool         `json:"isClosed"`
+	Deadline   string       `json:"deadline,omitempty"`
+	Closed     string       `json:"closed,omitempty"`
+}
+
+type PollTally struct {
+	OptionId string `json:"optionId"`
+	Count    int    `json:"count"`
+	// Omitted for anonymous polls and options without votes
+	UserIds []string `json:"userIds,omitempty"`
+}
+
+func NewPollResult(poll *Poll, pp *PayloadPoll, votes []*PollVote) *PollResult {
+	l, _ := time.LoadLocation("Etc/GMT")
+	tallies := make([]*PollTally, 0, len(pp.Options))
+	index := make(map[string]*PollTally)
+	for _, option := range pp.Options {
+		tally := &PollTally{OptionId: option.OptionId}
+		if !poll.IsAnonymous {
+			tally.UserIds = make([]string, 0)
+		}
+		tallies = append(tallies, tally)
+		index[option.OptionId] = tally
+	}
+
+	voters := make(map[string]bool)
+	for _, vote := range votes {
+		tally, ok := index[vote.OptionId]
+		if !ok {
+			continue
+		}
+		tally.Count++
+		if !poll.IsAnonymous {
+			tally.UserIds = append(tally.UserIds, vote.UserId)
+		}
+		voters[vote.UserId] = true
+	}
+
+	pr := &PollResult{
+		MessageId:  poll.MessageId,
+		Tallies:    tallies,
+		VoterCount: len(voters),
+		IsClosed:   poll.IsClosed(),
+	}
+	if poll.Deadline != 0 {
+		pr.Deadline = time.Unix(poll.Deadline, 0).In(l).Format(time.RFC3339)
+	}
+	if poll.Closed != 0 {
+		pr.Closed = time.Unix(poll.Closed, 0).In(l).Format(time.RFC3339)
+	}
+	return pr
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	PRESENCE_STATUS_ONLINE  = "online"
+	PRESENCE_STATUS_AWAY    = "away"
+	PRESENCE_STATUS_OFFLINE = "offline"
+
+	presenceStatusTextMaxLength = 140
+)
+
+// Presence is whether a user is online. Online and away are kept by real-time connections and
+// PUT /users/{userId}/presence, and turn offline when neither is seen for the presence timeout.
+type Presence struct {
+	Id         uint64 `json:"-" db:"id"`
+	UserId     string `json:"userId" db:"user_id,notnull"`
+	Status     string `json:"status" db:"status,notnull"`
+	StatusText string `json:"statusText" db:"status_text,notnull"`
+	LastSeen   int64  `json:"lastSeen" db:"last_seen,notnull"`
+	Created    int64  `json:"created" db:"created,notnull"`
+	Modified   int64  `json:"modified" db:"modified,notnull"`
+}
+
+func (p *Presence) MarshalJSON() ([]byte, error) {
+	l, _ := time.LoadLocation("Etc/GMT")
+	lastSeen := ""
+	if p.LastSeen != 0 {
+		lastSeen = time.Unix(p.LastSeen, 0).In(l).Format(time.RFC3339)
+	}
+	return json.Marshal(&struct {
+		UserId     string `json:"userId"`
+		Status     string `json:"status"`
+		StatusText string `json:"statusText,omitempty"`
+		LastSeen   string `json:"lastSeen,omitempty"`
+	}{
+		UserId:     p.UserId,
+		Status:     p.Status,
+		StatusText: p.StatusText,
+		LastSeen:   lastSeen,
+	})
+}
+
+func (p *Presence) IsValid() *ProblemDetail {
+	invalidParams := []InvalidParam{}
+	if p.Status != PRESENCE_STATUS_ONLINE && p.Status != PRESENCE_STATUS_AWAY {
+		invalidParams = append(invalidParams, InvalidParam{
+			Name:   "status",
+			Reason: "status must be online or away.",
+		})
+	}
+	if utf8.RuneCountInString(p.StatusText) > presenceStatusTextMaxLength {
+		invalidParams = append(invalidParams, InvalidParam{
+			Name:   "statusText",
+			Reason: "statusText must be 140 characters or less.",
+		})
+	}
+	if len(invalidParams) > 0 {
+		return &ProblemDetail{
+			Title:         "Request parameter error. (Update presence item)",
+			Status:        http.StatusBadRequest,
+			ErrorName:     ERROR_NAME_INVALID_PARAM,
+			InvalidParams: invalidParams,
+		}
+	}
+	return nil
+}
+
+func (p *Presence) BeforeSave() {
+	nowTimestamp := time.Now().Unix()
+	if p.Created == 0 {
+		p.Created = nowTimestamp
+	}
+	p.Modified = nowTimestamp
+}
+
+// NewOfflinePresence is the presence of a user who has never been seen.
+func NewOfflinePresence(userId string) *Presence {
+	return &Presence{
+		UserId: userId,
+		Status: PRESENCE_STATUS_OFFLINE,
+	}
+}
@@ -6,6 +6,8 @@ const (
 	ERROR_NAME_DATABASE_ERROR          = "database-error"
 	ERROR_NAME_NOTIFICATION_ERROR      = "notification-error"
 	ERROR_NAME_OPERATION_NOT_PERMITTED = "operation-not-permitted"
+	ERROR_NAME_TOO_MANY_REQUESTS       = "too-many-requests"
+	ERROR_NAME_CONTENT_REJECTED        = "content-rejected"
 )
 
 type ProblemDetail struct {
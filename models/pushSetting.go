@@ -0,0 +1,105 @@
+package models
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var quietHoursTimeRegexp = regexp.MustCompile(`^([01][0-9]|2[0-3]):([0-5][0-9])$`)
+
+// QuietHours is the time of day when a user is not pushed messages, such as 22:00 to 07:00 in Asia/Tokyo.
+// Without a timezone it is in UTC.
+type QuietHours struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+func (qh *QuietHours) IsValid() []InvalidParam {
+	invalidParams := []InvalidParam{}
+	if qh.Start == "" && qh.End == "" {
+		return invalidParams
+	}
+	if !quietHoursTimeRegexp.MatchString(qh.Start) {
+		invalidParams = append(invalidParams, InvalidParam{
+			Name:   "quietHours.start",
+			Reason: "start must be a time of day such as 22:00.",
+		})
+	}
+	if !quietHoursTimeRegexp.MatchString(qh.End) {
+		invalidParams = append(invalidParams, InvalidParam{
+			Name:   "quietHours.end",
+			Reason: "end must be a time of day such as 07:00.",
+		})
+	}
+	if _, err := time.LoadLocation(qh.Timezone); err != nil {
+		invalidParams = append(invalidParams, InvalidParam{
+			Name:   "quietHours.timezone",
+			Reason: "timezone must be a name of the tz database such as Asia/Tokyo.",
+		})
+	}
+	return invalidParams
+}
+
+// Contains returns whether t is in the quiet hours. The quiet hours may span midnight.
+func (qh *QuietHours) Contains(t time.Time) bool {
+	start, ok := quietHoursMinutes(qh.Start)
+	if !ok {
+		return false
+	}
+	end, ok := quietHoursMinutes(qh.End)
+	if !ok || start == end {
+		return false
+	}
+	location, err := time.LoadLocation(qh.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	local := t.In(location)
+	minutes := local.Hour()*60 + local.Minute()
+	if start < end {
+		return start <= minutes && minutes < end
+	}
+	return minutes >= start || minutes < end
+}
+
+func quietHoursMinutes(s string) (int, bool) {
+	match := quietHoursTimeRegexp.FindStringSubmatch(s)
+	if match == nil {
+		return 0, false
+	}
+	hour, _ := strconv.Atoi(match[1])
+	minute, _ := strconv.Atoi(match[2])
+	return hour*60 + minute, true
+}
+
+// PushSetting is what decides whether a user of a room is pushed a new message.
+type PushSetting struct {
+	UserId             string `db:"user_id"`
+	Notify             string `db:"notify"`
+	MutedUntil         int64  `db:"muted_until"`
+	QuietHoursStart    string `db:"quiet_hours_start"`
+	QuietHoursEnd      string `db:"quiet_hours_end"`
+	QuietHoursTimezone string `db:"quiet_hours_timezone"`
+}
+
+// IsPushed returns whether the user is pushed a message at now, mentioned is whether the message mentions the user.
+func (ps *PushSetting) IsPushed(mentioned bool, now time.Time) bool {
+	switch ps.Notify {
+	case NOTIFY_MUTED:
+		if ps.MutedUntil == 0 || now.Unix() < ps.MutedUntil {
+			return false
+		}
+	case NOTIFY_MENTIONS:
+		if !mentioned {
+			return false
+		}
+	}
+	quietHours := &QuietHours{
+		Start:    ps.QuietHoursStart,
+		End:      ps.QuietHoursEnd,
+		Timezone: ps.QuietHoursTimezone,
+	}
+	return !quietHours.Contains(now)
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuietHoursContains(t *testing.T) {
+	testTable := []struct {
+		testNo     int
+		quietHours QuietHours
+		time       string
+		contains   bool
+	}{
+		{1, QuietHours{Start: "09:00", End: "17:00"}, "2026-10-19T08:59:00Z", false},
+		{2, QuietHours{Start: "09:00", End: "17:00"}, "2026-10-19T09:00:00Z", true},
+		{3, QuietHours{Start: "09:00", End: "17:00"}, "2026-10-19T17:00:00Z", false},
+		// Across midnight
+		{4, QuietHours{Start: "22:00", End: "07:00"}, "2026-10-19T21:59:00Z", false},
+		{5, QuietHours{Start: "22:00", End: "07:00"}, "2026-10-19T23:30:00Z", true},
+		{6, QuietHours{Start: "22:00", End: "07:00"}, "2026-10-19T00:00:00Z", true},
+		{7, QuietHours{Start: "22:00", End: "07:00"}, "2026-10-19T06:59:00Z", true},
+		{8, QuietHours{Start: "22:00", End: "07:00"}, "2026-10-19T07:00:00Z", false},
+		// In the timezone, whatever the zone of the time
+		{9, QuietHours{Start: "22:00", End: "07:00", Timezone: "Asia/Tokyo"}, "2026-10-19T13:30:00Z", true},
+		{10, QuietHours{Start: "22:00", End: "07:00", Timezone: "Asia/Tokyo"}, "2026-10-19T23:30:00Z", false},
+		{11, QuietHours{Start: "22:00", End: "07:00", Timezone: "Asia/Tokyo"}, "2026-10-19T22:30:00+09:00", true},
+		{12, QuietHours{Start: "22:00", End: "07:00", Timezone: "America/New_York"}, "2026-07-01T02:30:00Z", true},
+		{13, QuietHours{Start: "22:00", End: "07:00", Timezone: "America/New_York"}, "2026-12-01T02:30:00Z", false},
+		// Incomplete quiet hours contain nothing
+		{14, QuietHours{}, "2026-10-19T00:00:00Z", false},
+		{15, QuietHours{Start: "22:00"}, "2026-10-19T23:00:00Z", false},
+		{16, QuietHours{Start: "22:00", End: "22:00"}, "2026-10-19T22:00:00Z", false},
+	}
+
+	for _, testRecord := range testTable {
+		tm, err := time.Parse(time.RFC3339, testRecord.time)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if contains := testRecord.quietHours.Contains(tm); contains != testRecord.contains {
+			t.Fatalf("TestNo %d\n[expected]%t\n[result  ]%t", testRecord.testNo, testRecord.contains, contains)
+		}
+	}
+}
+
+func TestPushSettingIsPushed(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		testNo      int
+		pushSetting PushSetting
+		mentioned   bool
+		pushed      bool
+	}{
+		{1, PushSetting{Notify: NOTIFY_ALL}, false, true},
+		{2, PushSetting{Notify: ""}, false, true},
+		{3, PushSetting{Notify: NOTIFY_MENTIONS}, false, false},
+		{4, PushSetting{Notify: NOTIFY_MENTIONS}, true, true},
+		// Muted without mutedUntil is muted until unmuted, even when mentioned
+		{5, PushSetting{Notify: NOTIFY_MUTED}, true, false},
+		{6, PushSetting{Notify: NOTIFY_MUTED, MutedUntil: now.Unix() + 1}, false, false},
+		{7, PushSetting{Notify: NOTIFY_MUTED, MutedUntil: now.Unix()}, false, true},
+		// The quiet hours apply to every setting
+		{8, PushSetting{Notify: NOTIFY_ALL, QuietHoursStart: "11:00", QuietHoursEnd: "13:00"}, false, false},
+		{9, PushSetting{Notify: NOTIFY_MENTIONS, QuietHoursStart: "11:00", QuietHoursEnd: "13:00"}, true, false},
+		{10, PushSetting{Notify: NOTIFY_ALL, QuietHoursStart: "11:00", QuietHoursEnd: "13:00", QuietHoursTimezone: "Asia/Tokyo"}, false, true},
+		{11, PushSetting{Notify: NOTIFY_MUTED, MutedUntil: now.Unix() - 1, QuietHoursStart: "11:00", QuietHoursEnd: "13:00"}, false, false},
+	}
+
+	for _, testRecord := range testTable {
+		if pushed := testRecord.pushSetting.IsPushed(testRecord.mentioned, now); pushed != testRecord.pushed {
+			t.Fatalf("TestNo %d\n[expected]%t\n[result  ]%t", testRecord.testNo, testRecord.pushed, pushed)
+		}
+	}
+}
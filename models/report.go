@@ -0,0 +1,184 @@
+package models
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+const (
+	REPORT_TARGET_MESSAGE = "message"
+	REPORT_TARGET_USER    = "user"
+
+	REPORT_STATUS_OPEN      = "open"
+	REPORT_STATUS_TRIAGED   = "triaged"
+	REPORT_STATUS_RESOLVED  = "resolved"
+	REPORT_STATUS_DISMISSED = "dismissed"
+
+	REPORT_ACTION_NONE             = ""
+	REPORT_ACTION_DELETE_MESSAGE   = "deleteMessage"
+	REPORT_ACTION_REMOVE_FROM_ROOM = "removeFromRoom"
+	REPORT_ACTION_DEACTIVATE_USER  = "deactivateUser"
+)
+
+var reportReasonCodes = []string{
+	"spam",
+	"harassment",
+	"hate",
+	"sexual",
+	"violence",
+	"other",
+}
+
+type Reports struct {
+	Reports []*Report `json:"reports"`
+}
+
+type Report struct {
+	Id             uint64 `json:"-" db:"id"`
+	ReportId       string `json:"reportId" db:"report_id,notnull"`
+	TargetType     string `json:"targetType" db:"target_type,notnull"`
+	MessageId      string `json:"messageId,omitempty" db:"message_id"`
+	RoomId         string `json:"roomId,omitempty" db:"room_id"`
+	TargetUserId   string `json:"targetUserId" db:"target_user_id,notnull"`
+	ReporterUserId string `json:"reporterUserId" db:"reporter_user_id,notnull"`
+	ReasonCode     string `json:"reasonCode" db:"reason_code,notnull"`
+	Comment        string `json:"comment,omitempty" db:"comment"`
+	Status         string `json:"status" db:"status,notnull"`
+	Action         string `json:"action,omitempty" db:"action"`
+	Note           string `json:"note,omitempty" db:"note"`
+	Created        int64  `json:"created" db:"created,notnull"`
+	Modified       int64  `json:"modified" db:"modified,notnull"`
+}
+
+type ReportResolution struct {
+	Action string `json:"action,omitempty"`
+	Note   string `json:"note,omitempty"`
+}
+
+func (r *Report) MarshalJSON() ([]byte, error) {
+	l, _ := time.LoadLocation("Etc/GMT")
+	return json.Marshal(&struct {
+		ReportId       string `json:"reportId"`
+		TargetType     string `json:"targetType"`
+		MessageId      string `json:"messageId,omitempty"`
+		RoomId         string `json:"roomId,omitempty"`
+		TargetUserId   string `json:"targetUserId"`
+		ReporterUserId string `json:"reporterUserId"`
+		ReasonCode     string `json:"reasonCode"`
+		Comment        string `json:"comment,omitempty"`
+		Status         string `json:"status"`
+		Action         string `json:"action,omitempty"`
+		Note           string `json:"note,omitempty"`
+		Created        string `json:"created"`
+		Modified       string `json:"modified"`
+	}{
+		ReportId:       r.ReportId,
+		TargetType:     r.TargetType,
+		MessageId:      r.MessageId,
+		RoomId:         r.RoomId,
+		TargetUserId:   r.TargetUserId,
+		ReporterUserId: r.ReporterUserId,
+		ReasonCode:     r.ReasonCode,
+		Comment:        r.Comment,
+		Status:         r.Status,
+		Action:         r.Action,
+		Note:           r.Note,
+		Created:        time.Unix(r.Created, 0).In(l).Format(time.RFC3339),
+		Modified:       time.Unix(r.Modified, 0).In(l).Format(time.RFC3339),
+	})
+}
+
+func (r *Report) IsValid() *ProblemDetail {
+	if r.ReporterUserId == "" || !utils.IsValidId(r.ReporterUserId) {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Create report item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "reporterUserId",
+					Reason: "reporterUserId is required, but it's empty or invalid.",
+				},
+			},
+		}
+	}
+
+	if r.ReporterUserId == r.TargetUserId {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Create report item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "reporterUserId",
+					Reason: "Users can not report themselves.",
+				},
+			},
+		}
+	}
+
+	if !utils.SearchStringValueInSlice(reportReasonCodes, r.ReasonCode) {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Create report item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "reasonCode",
+					Reason: "reasonCode is invalid. Available values are spam, harassment, hate, sexual, violence, other.",
+				},
+			},
+		}
+	}
+
+	return nil
+}
+
+func (r *Report) BeforeSave() {
+	if r.ReportId == "" {
+		r.ReportId = utils.CreateUuid()
+	}
+
+	if r.Status == "" {
+		r.Status = REPORT_STATUS_OPEN
+	}
+
+	nowTimestamp := time.Now().Unix()
+	if r.Created == 0 {
+		r.Created = nowTimestamp
+	}
+	r.Modified = nowTimestamp
+}
+
+func (r *Report) IsClosed() bool {
+	return r.Status == REPORT_STATUS_RESOLVED || r.Status == REPORT_STATUS_DISMISSED
+}
+
+func (rr *ReportResolution) IsValid(r *Report) *ProblemDetail {
+	invalidParam := InvalidParam{Name: "action"}
+	switch rr.Action {
+	case REPORT_ACTION_NONE, REPORT_ACTION_DEACTIVATE_USER:
+		return nil
+	case REPORT_ACTION_DELETE_MESSAGE:
+		if r.MessageId != "" {
+			return nil
+		}
+		invalidParam.Reason = "deleteMessage is only available for message reports."
+	case REPORT_ACTION_REMOVE_FROM_ROOM:
+		if r.RoomId != "" {
+			return nil
+		}
+		invalidParam.Reason = "removeFromRoom needs a report with roomId."
+	default:
+		invalidParam.Reason = "action is invalid. Available values are deleteMessage, removeFromRoom, deactivateUser."
+	}
+	return &ProblemDetail{
+		Title:         "Request parameter error. (Resolve report item)",
+		Status:        http.StatusBadRequest,
+		ErrorName:     ERROR_NAME_INVALID_PARAM,
+		InvalidParams: []InvalidParam{invalidParam},
+	}
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+// RoomRetention is the retention setting of a room.
+// RetentionDays overrides the global setting. 0 keeps messages forever and -1 removes the override.
+type RoomRetention struct {
+	RoomId                 string `json:"roomId"`
+	RetentionDays          *int64 `json:"retentionDays"`
+	EffectiveRetentionDays int64  `json:"effectiveRetentionDays"`
+	LegalHold              *bool  `json:"legalHold,omitempty"`
+}
+
+func (rr *RoomRetention) IsValid() *ProblemDetail {
+	if rr.RetentionDays != nil && *rr.RetentionDays < -1 {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Update room retention)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "retentionDays",
+					Reason: "retentionDays must be 0 or more, or -1 to use the global setting.",
+				},
+			},
+		}
+	}
+	return nil
+}
+
+func NewRoomRetention(room *Room, globalDays int64) *RoomRetention {
+	legalHold := room.LegalHold
+	return &RoomRetention{
+		RoomId:                 room.RoomId,
+		RetentionDays:          room.RetentionDays,
+		EffectiveRetentionDays: room.EffectiveRetentionDays(globalDays),
+		LegalHold:              &legalHold,
+	}
+}
+
+type UserLegalHold struct {
+	UserId    string `json:"userId"`
+	LegalHold *bool  `json:"legalHold"`
+}
+
+func (ulh *UserLegalHold) IsValid() *ProblemDetail {
+	if ulh.LegalHold == nil {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Update user legal hold)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "legalHold",
+					Reason: "legalHold is required, but it's empty.",
+				},
+			},
+		}
+	}
+	return nil
+}
+
+type PurgeReports struct {
+	PurgeReports []*PurgeReport `json:"purgeReports"`
+}
+
+// PurgeReport is the result of one purger run.
+type PurgeReport struct {
+	Id            uint64         `json:"-" db:"id"`
+	PurgeReportId string         `json:"purgeReportId" db:"purge_report_id,notnull"`
+	RoomCount     int64          `json:"roomCount" db:"room_count,notnull"`
+	MessageCount  int64          `json:"messageCount" db:"message_count,notnull"`
+	AssetCount    int64          `json:"assetCount" db:"asset_count,notnull"`
+	ErrorCount    int64          `json:"errorCount" db:"error_count,notnull"`
+	Rooms         utils.JSONText `json:"rooms" db:"rooms"`
+	Started       int64          `json:"started" db:"started,notnull"`
+	Finished      int64          `json:"finished" db:"finished,notnull"`
+
+	RoomReports []*PurgeRoomReport `json:"-" db:"-"`
+}
+
+type PurgeRoomReport struct {
+	RoomId        string   `json:"roomId"`
+	RetentionDays int64    `json:"retentionDays"`
+	Cutoff        string   `json:"cutoff"`
+	MessageCount  int64    `json:"messageCount"`
+	AssetCount    int64    `json:"assetCount"`
+	Errors        []string `json:"errors,omitempty"`
+}
+
+func (pr *PurgeReport) MarshalJSON() ([]byte, error) {
+	l, _ := time.LoadLocation("Etc/GMT")
+	return json.Marshal(&struct {
+		PurgeReportId string         `json:"purgeReportId"`
+		RoomCount     int64          `json:"roomCount"`
+		MessageCount  int64          `json:"messageCount"`
+		AssetCount    int64          `json:"assetCount"`
+		ErrorCount    int64          `json:"errorCount"`
+		Rooms         utils.JSONText `json:"rooms"`
+		Started       string         `json:"started"`
+		Finished      string         `json:"finished"`
+	}{
+		PurgeReportId: pr.PurgeReportId,
+		RoomCount:     pr.RoomCount,
+		MessageCount:  pr.MessageCount,
+		AssetCount:    pr.AssetCount,
+		ErrorCount:    pr.ErrorCount,
+		Rooms:         pr.Rooms,
+		Started:       time.Unix(pr.Started, 0).In(l).Format(time.RFC3339),
+		Finished:      time.Unix(pr.Finished, 0).In(l).Format(time.RFC3339),
+	})
+}
+
+func (pr *PurgeReport) Add(roomReport *PurgeRoomReport) {
+	pr.RoomReports = append(pr.RoomReports, roomReport)
+	pr.RoomCount++
+	pr.MessageCount += roomReport.MessageCount
+	pr.AssetCount += roomReport.AssetCount
+	pr.ErrorCount += int64(len(roomReport.Errors))
+}
+
+func (pr *PurgeReport) BeforeSave() {
+	if pr.PurgeReportId == "" {
+		pr.PurgeReportId = utils.CreateUuid()
+	}
+
+	roomReports := pr.RoomReports
+	if roomReports == nil {
+		roomReports = []*PurgeRoomReport{}
+	}
+	pr.Rooms, _ = json.Marshal(roomReports)
+
+	if pr.Finished == 0 {
+		pr.Finished = time.Now().Unix()
+	}
+}
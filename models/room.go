@@ -56,6 +56,9 @@ type Room struct {
 	NotificationTopicId   string         `json:"notificationTopicId,omitempty" db:"notification_topic_id"`
 	IsCanLeft             *bool          `json:"isCanLeft,omitempty" db:"is_can_left,notnull"`
 	IsShowUsers           *bool          `json:"isShowUsers,omitempty" db:"is_show_users,notnull"`
+	IsSystemMessage       *bool          `json:"isSystemMessage,omitempty" db:"is_system_message,notnull"`
+	RetentionDays         *int64         `json:"-" db:"retention_days"`
+	LegalHold             bool           `json:"-" db:"legal_hold,notnull"`
 	Created               int64          `json:"created" db:"created,notnull"`
 	Modified              int64          `json:"modified" db:"modified,notnull"`
 	Deleted               int64          `json:"-" db:"deleted,notnull"`
@@ -81,6 +84,8 @@ type UserForRoom struct {
 	RuMetaData    utils.JSONText `json:"ruMetaData" db:"ru_meta_data"`
 	RuCreated     int64          `json:"ruCreated" db:"ru_created"`
 	RuModified    int64          `json:"ruModified" db:"ru_modified"`
+
+	Presence *Presence `json:"presence,omitempty" db:"-"`
 }
 
 func (r *Room) MarshalJSON() ([]byte, error) {
@@ -108,6 +113,7 @@ func (r *Room) MarshalJSON() ([]byte, error) {
 		NotificationTopicId   string         `json:"notificationTopicId,omitempty"`
 		IsCanLeft             *bool          `json:"isCanLeft,omitempty"`
 		IsShowUsers           *bool          `json:"isShowUsers,omitempty"`
+		IsSystemMessage       *bool          `json:"isSystemMessage,omitempty"`
 		Created               string         `json:"created"`
 		Modified              string         `json:"modified"`
 		Users                 []*UserForRoom `json:"users,omitempty"`
@@ -125,6 +131,7 @@ func (r *Room) MarshalJSON() ([]byte, error) {
 		MessageCount:       r.MessageCount,
 		IsCanLeft:          r.IsCanLeft,
 		IsShowUsers:        r.IsShowUsers,
+		IsSystemMessage:    r.IsSystemMessage,
 		Created:            time.Unix(r.Created, 0).In(l).Format(time.RFC3339),
 		Modified:           time.Unix(r.Modified, 0).In(l).Format(time.RFC3339),
 		Users:              r.Users,
@@ -147,6 +154,7 @@ func (ufr *UserForRoom) MarshalJSON() ([]byte, error) {
 		RuMetaData     utils.JSONText `json:"ruMetaData"`
 		RuCreated      string         `json:"ruCreated"`
 		RuModified     string         `json:"ruModified"`
+		Presence       *Presence      `json:"presence,omitempty"`
 	}{
 		UserId:         ufr.UserId,
 		Name:           ufr.Name,
@@ -161,6 +169,7 @@ func (ufr *UserForRoom) MarshalJSON() ([]byte, error) {
 		RuMetaData:     ufr.RuMetaData,
 		RuCreated:      time.Unix(ufr.RuCreated, 0).In(l).Format(time.RFC3339),
 		RuModified:     time.Unix(ufr.RuModified, 0).In(l).Format(time.RFC3339),
+		Presence:       ufr.Presence,
 	})
 }
 
@@ -271,6 +280,11 @@ func (r *Room) BeforeSave() {
 		r.IsShowUsers = &isShowUsers
 	}
 
+	if r.IsSystemMessage == nil {
+		isSystemMessage := true
+		r.IsSystemMessage = &isSystemMessage
+	}
+
 	nowTimestamp := time.Now().Unix()
 	if r.Created == 0 {
 		r.Created = nowTimestamp
@@ -278,6 +292,28 @@ func (r *Room) BeforeSave() {
 	r.Modified = nowTimestamp
 }
 
+// EffectiveRetentionDays returns the days to keep messages of the room. 0 keeps them forever.
+func (r *Room) EffectiveRetentionDays(globalDays int64) int64 {
+	if r.RetentionDays != nil {
+		return *r.RetentionDays
+	}
+	return globalDays
+}
+
+func (r *Room) PutRetention(put *RoomRetention) {
+	if put.RetentionDays != nil {
+		if *put.RetentionDays < 0 {
+			r.RetentionDays = nil
+		} else {
+			retentionDays := *put.RetentionDays
+			r.RetentionDays = &retentionDays
+		}
+	}
+	if put.LegalHold != nil {
+		r.LegalHold = *put.LegalHold
+	}
+}
+
 func (r *Room) Put(put *Room) *ProblemDetail {
 	if put.Name != "" {
 		r.Name = put.Name
@@ -297,6 +333,9 @@ func (r *Room) Put(put *Room) *ProblemDetail {
 	if put.IsShowUsers != nil {
 		r.IsShowUsers = put.IsShowUsers
 	}
+	if put.IsSystemMessage != nil {
+		r.IsSystemMessage = put.IsSystemMessage
+	}
 	if put.Type != nil {
 		if *r.Type == ONE_ON_ONE && *put.Type != ONE_ON_ONE {
 			return &ProblemDetail{
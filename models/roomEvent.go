@@ -0,0 +1,71 @@
+package models
+
+import (
+	"net/http"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+const (
+	EVENT_NAME_MESSAGE           = "message"
+	EVENT_NAME_MESSAGE_EDIT      = "messageEdit"
+	EVENT_NAME_MESSAGE_DELETE    = "messageDelete"
+	EVENT_NAME_EPHEMERAL_MESSAGE = "ephemeralMessage"
+	EVENT_NAME_POLL_UPDATE       = "pollUpdate"
+	EVENT_NAME_POLL_CLOSE        = "pollClose"
+	EVENT_NAME_USER_JOIN         = "userJoin"
+	EVENT_NAME_USER_LEFT         = "userLeft"
+	EVENT_NAME_USER_UPDATE       = "userUpdate"
+	EVENT_NAME_PRESENCE          = "presence"
+	EVENT_NAME_ROOM_CREATE       = "roomCreate"
+	EVENT_NAME_ROOM_UPDATE       = "roomUpdate"
+	EVENT_NAME_ROOM_DELETE       = "roomDelete"
+	EVENT_NAME_MESSAGE_READ      = "messageRead"
+	EVENT_NAME_RESYNC            = "resync"
+	EVENT_NAME_TYPING_START      = "typingStart"
+	EVENT_NAME_TYPING_STOP       = "typingStop"
+)
+
+var roomEventNames = []string{
+	EVENT_NAME_TYPING_START,
+	EVENT_NAME_TYPING_STOP,
+}
+
+type RoomEvent struct {
+	RoomId    string         `json:"roomId"`
+	UserId    string         `json:"userId"`
+	EventName string         `json:"eventName"`
+	Payload   utils.JSONText `json:"payload,omitempty"`
+}
+
+func (re *RoomEvent) IsValid() *ProblemDetail {
+	if re.UserId == "" || !utils.IsValidId(re.UserId) {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Create room event item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "userId",
+					Reason: "userId is required, but it's empty or invalid.",
+				},
+			},
+		}
+	}
+
+	if !utils.SearchStringValueInSlice(roomEventNames, re.EventName) {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Create room event item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "eventName",
+					Reason: "eventName is invalid. Available values are typingStart, typingStop.",
+				},
+			},
+		}
+	}
+
+	return nil
+}
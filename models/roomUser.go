@@ -9,22 +9,45 @@ import (
 	"github.com/swagchat/chat-api/utils"
 )
 
+const (
+	NOTIFY_ALL      = "all"
+	NOTIFY_MENTIONS = "mentions"
+	NOTIFY_MUTED    = "muted"
+)
+
 type RoomUser struct {
-	RoomId      string         `json:"roomId" db:"room_id,notnull"`
-	UserId      string         `json:"userId" db:"user_id,notnull"`
-	UnreadCount *int64         `json:"unreadCount" db:"unread_count"`
-	MetaData    utils.JSONText `json:"metaData" db:"meta_data"`
-	Created     int64          `json:"created" db:"created,notnull"`
-	Modified    int64          `json:"modified" db:"modified,notnull"`
+	RoomId       string         `json:"roomId" db:"room_id,notnull"`
+	UserId       string         `json:"userId" db:"user_id,notnull"`
+	UnreadCount  *int64         `json:"unreadCount" db:"unread_count"`
+	MetaData     utils.JSONText `json:"metaData" db:"meta_data"`
+	Draft        string         `json:"-" db:"draft"`
+	DraftUpdated int64          `json:"-" db:"draft_updated,notnull"`
+	Created      int64          `json:"created" db:"created,notnull"`
+	Modified     int64          `json:"modified" db:"modified,notnull"`
+
+	// Notify is which messages of the room are pushed to the user, all when empty.
+	// A muted room with MutedUntil is pushed again from that unix time
+	Notify     string `json:"notify,omitempty" db:"notify,notnull"`
+	MutedUntil int64  `json:"mutedUntil,omitempty" db:"muted_until,notnull"`
 }
 
 func (ru *RoomUser) MarshalJSON() ([]byte, error) {
 	l, _ := time.LoadLocation("Etc/GMT")
+	notify := ru.Notify
+	if notify == "" {
+		notify = NOTIFY_ALL
+	}
+	mutedUntil := ""
+	if ru.Notify == NOTIFY_MUTED && ru.MutedUntil != 0 {
+		mutedUntil = time.Unix(ru.MutedUntil, 0).In(l).Format(time.RFC3339)
+	}
 	return json.Marshal(&struct {
 		RoomId      string         `json:"roomId"`
 		UserId      string         `json:"userId"`
 		UnreadCount *int64         `json:"unreadCount"`
 		MetaData    utils.JSONText `json:"metaData"`
+		Notify      string         `json:"notify"`
+		MutedUntil  string         `json:"mutedUntil,omitempty"`
 		Created     string         `json:"created"`
 		Modified    string         `json:"modified"`
 	}{
@@ -32,6 +55,8 @@ func (ru *RoomUser) MarshalJSON() ([]byte, error) {
 		UserId:      ru.UserId,
 		UnreadCount: ru.UnreadCount,
 		MetaData:    ru.MetaData,
+		Notify:      notify,
+		MutedUntil:  mutedUntil,
 		Created:     time.Unix(ru.Created, 0).In(l).Format(time.RFC3339),
 		Modified:    time.Unix(ru.Modified, 0).In(l).Format(time.RFC3339),
 	})
@@ -66,6 +91,34 @@ func (ru *RoomUser) IsValid() *ProblemDetail {
 		}
 	}
 
+	if ru.Notify != "" && ru.Notify != NOTIFY_ALL && ru.Notify != NOTIFY_MENTIONS && ru.Notify != NOTIFY_MUTED {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Create room user item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "notify",
+					Reason: "notify must be all, mentions or muted.",
+				},
+			},
+		}
+	}
+
+	if ru.MutedUntil < 0 || (ru.MutedUntil != 0 && ru.Notify != NOTIFY_MUTED) {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Create room user item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "mutedUntil",
+					Reason: "mutedUntil must be a unix time, and notify must be muted with it.",
+				},
+			},
+		}
+	}
+
 	return nil
 }
 
@@ -84,6 +137,57 @@ func (ru *RoomUser) Put(put *RoomUser) {
 	if put.MetaData != nil {
 		ru.MetaData = put.MetaData
 	}
+	// A new notify setting replaces the time of a previous mute
+	if put.Notify != "" {
+		ru.Notify = put.Notify
+		ru.MutedUntil = put.MutedUntil
+	} else if put.MutedUntil != 0 {
+		ru.MutedUntil = put.MutedUntil
+	}
+}
+
+type RoomUserDraft struct {
+	RoomId   string `json:"roomId"`
+	UserId   string `json:"userId"`
+	Draft    string `json:"draft"`
+	Modified int64  `json:"modified"`
+}
+
+func (rud *RoomUserDraft) MarshalJSON() ([]byte, error) {
+	l, _ := time.LoadLocation("Etc/GMT")
+	modified := ""
+	if rud.Modified != 0 {
+		modified = time.Unix(rud.Modified, 0).In(l).Format(time.RFC3339)
+	}
+	return json.Marshal(&struct {
+		RoomId   string `json:"roomId"`
+		UserId   string `json:"userId"`
+		Draft    string `json:"draft"`
+		Modified string `json:"modified"`
+	}{
+		RoomId:   rud.RoomId,
+		UserId:   rud.UserId,
+		Draft:    rud.Draft,
+		Modified: modified,
+	})
+}
+
+func (rud *RoomUserDraft) IsValid() *ProblemDetail {
+	if rud.Draft == "" {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Update room's user draft item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "draft",
+					Reason: "draft is required, but it's empty. Use DELETE to clear the draft.",
+				},
+			},
+		}
+	}
+
+	return nil
 }
 
 type ErrorRoomUser struct {
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+var slashCommandRegexp = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+type SlashCommands struct {
+	SlashCommands []*SlashCommand `json:"commands"`
+}
+
+type SlashCommand struct {
+	Id          uint64 `json:"-" db:"id"`
+	CommandId   string `json:"commandId" db:"command_id,notnull"`
+	RoomId      string `json:"roomId" db:"room_id,notnull"`
+	Command     string `json:"command" db:"command,notnull"`
+	BotUserId   string `json:"botUserId" db:"bot_user_id,notnull"`
+	Description string `json:"description,omitempty" db:"description"`
+	Created     int64  `json:"created" db:"created,notnull"`
+	Modified    int64  `json:"modified" db:"modified,notnull"`
+	Deleted     int64  `json:"-" db:"deleted,notnull"`
+}
+
+func (sc *SlashCommand) MarshalJSON() ([]byte, error) {
+	l, _ := time.LoadLocation("Etc/GMT")
+	return json.Marshal(&struct {
+		CommandId   string `json:"commandId"`
+		RoomId      string `json:"roomId"`
+		Command     string `json:"command"`
+		BotUserId   string `json:"botUserId"`
+		Description string `json:"description,omitempty"`
+		Created     string `json:"created"`
+		Modified    string `json:"modified"`
+	}{
+		CommandId:   sc.CommandId,
+		RoomId:      sc.RoomId,
+		Command:     sc.Command,
+		BotUserId:   sc.BotUserId,
+		Description: sc.Description,
+		Created:     time.Unix(sc.Created, 0).In(l).Format(time.RFC3339),
+		Modified:    time.Unix(sc.Modified, 0).In(l).Format(time.RFC3339),
+	})
+}
+
+func (sc *SlashCommand) IsValid() *ProblemDetail {
+	sc.Command = strings.TrimPrefix(sc.Command, "/")
+	if !slashCommandRegexp.MatchString(sc.Command) {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Create command item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "command",
+					Reason: "command is invalid. Available characters are lowercase alphabets, numbers, hyphens and underscores, up to 32 characters.",
+				},
+			},
+		}
+	}
+
+	if sc.BotUserId == "" || !utils.IsValidId(sc.BotUserId) {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Create command item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "botUserId",
+					Reason: "botUserId is required, but it's empty or invalid.",
+				},
+			},
+		}
+	}
+
+	return nil
+}
+
+func (sc *SlashCommand) BeforeSave() {
+	if sc.CommandId == "" {
+		sc.CommandId = utils.CreateUuid()
+	}
+
+	nowTimestamp := time.Now().Unix()
+	if sc.Created == 0 {
+		sc.Created = nowTimestamp
+	}
+	sc.Modified = nowTimestamp
+}
+
+// ParseSlashCommand splits "/command args" into its command name and arguments.
+// ok is false when the text is not shaped like a slash command.
+func ParseSlashCommand(text string) (command, args string, ok bool) {
+	if !strings.HasPrefix(text, "/") {
+		return "", "", false
+	}
+	fields := strings.SplitN(strings.TrimPrefix(text, "/"), " ", 2)
+	if !slashCommandRegexp.MatchString(fields[0]) {
+		return "", "", false
+	}
+	if len(fields) == 2 {
+		args = strings.TrimSpace(fields[1])
+	}
+	return fields[0], args, true
+}
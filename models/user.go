@@ -1,8 +1,11 @@
 package models
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/swagchat/chat-api/utils"
@@ -24,13 +27,24 @@ type User struct {
 	IsCanBlock     *bool          `json:"isCanBlock,omitempty" db:"is_can_block,notnull"`
 	IsShowUsers    *bool          `json:"isShowUsers,omitempty" db:"is_show_users,notnull"`
 	AccessToken    string         `json:"accessToken,omitempty" db:"access_token"`
+	IsBot          *bool          `json:"isBot,omitempty" db:"is_bot,notnull"`
+	BotEndpoint    string         `json:"botEndpoint,omitempty" db:"bot_endpoint"`
+	BotSecret      string         `json:"botSecret,omitempty" db:"bot_secret"`
+	LegalHold      bool           `json:"-" db:"legal_hold,notnull"`
 	Created        int64          `json:"created,omitempty" db:"created,notnull"`
 	Modified       int64          `json:"modified,omitempty" db:"modified,notnull"`
 	Deleted        int64          `json:"-" db:"deleted,notnull"`
 
-	Rooms   []*RoomForUser `json:"rooms,omitempty" db:"-"`
-	Devices []*Device      `json:"devices,omitempty" db:"-"`
-	Blocks  []string       `json:"blocks,omitempty" db:"-"`
+	// The columns of QuietHours
+	QuietHoursStart    string `json:"-" db:"quiet_hours_start,notnull"`
+	QuietHoursEnd      string `json:"-" db:"quiet_hours_end,notnull"`
+	QuietHoursTimezone string `json:"-" db:"quiet_hours_timezone,notnull"`
+
+	Rooms      []*RoomForUser `json:"rooms,omitempty" db:"-"`
+	Devices    []*Device      `json:"devices,omitempty" db:"-"`
+	Blocks     []string       `json:"blocks,omitempty" db:"-"`
+	Presence   *Presence      `json:"presence,omitempty" db:"-"`
+	QuietHours *QuietHours    `json:"quietHours,omitempty" db:"-"`
 }
 
 type UserMini struct {
@@ -59,10 +73,12 @@ type RoomForUser struct {
 	Users []*UserMini `json:"users" db:"-"`
 
 	// from RoomUser
-	RuUnreadCount int64          `json:"ruUnreadCount" db:"ru_unread_count"`
-	RuMetaData    utils.JSONText `json:"ruMetaData" db:"ru_meta_data"`
-	RuCreated     int64          `json:"ruCreated" db:"ru_created"`
-	RuModified    int64          `json:"ruModified" db:"ru_modified"`
+	RuUnreadCount  int64          `json:"ruUnreadCount" db:"ru_unread_count"`
+	RuMetaData     utils.JSONText `json:"ruMetaData" db:"ru_meta_data"`
+	RuDraft        string         `json:"ruDraft,omitempty" db:"ru_draft"`
+	RuDraftUpdated int64          `json:"ruDraftUpdated,omitempty" db:"ru_draft_updated"`
+	RuCreated      int64          `json:"ruCreated" db:"ru_created"`
+	RuModified     int64          `json:"ruModified" db:"ru_modified"`
 }
 
 type UserUnreadCount struct {
@@ -71,6 +87,14 @@ type UserUnreadCount struct {
 
 func (u *User) MarshalJSON() ([]byte, error) {
 	l, _ := time.LoadLocation("Etc/GMT")
+	var quietHours *QuietHours
+	if u.QuietHoursStart != "" {
+		quietHours = &QuietHours{
+			Start:    u.QuietHoursStart,
+			End:      u.QuietHoursEnd,
+			Timezone: u.QuietHoursTimezone,
+		}
+	}
 	return json.Marshal(&struct {
 		UserId         string         `json:"userId"`
 		Name           string         `json:"name"`
@@ -82,11 +106,16 @@ func (u *User) MarshalJSON() ([]byte, error) {
 		IsCanBlock     *bool          `json:"isCanBlock,omitempty"`
 		IsShowUsers    *bool          `json:"isShowUsers,omitempty"`
 		AccessToken    string         `json:"accessToken,omitempty"`
+		IsBot          *bool          `json:"isBot,omitempty"`
+		BotEndpoint    string         `json:"botEndpoint,omitempty"`
+		BotSecret      string         `json:"botSecret,omitempty"`
 		Created        string         `json:"created"`
 		Modified       string         `json:"modified"`
 		Rooms          []*RoomForUser `json:"rooms,omitempty"`
 		Devices        []*Device      `json:"devices,omitempty"`
 		Blocks         []string       `json:"blocks,omitempty"`
+		Presence       *Presence      `json:"presence,omitempty"`
+		QuietHours     *QuietHours    `json:"quietHours,omitempty"`
 	}{
 		UserId:         u.UserId,
 		Name:           u.Name,
@@ -98,11 +127,16 @@ func (u *User) MarshalJSON() ([]byte, error) {
 		IsCanBlock:     u.IsCanBlock,
 		IsShowUsers:    u.IsShowUsers,
 		AccessToken:    u.AccessToken,
+		IsBot:          u.IsBot,
+		BotEndpoint:    u.BotEndpoint,
+		BotSecret:      u.BotSecret,
 		Created:        time.Unix(u.Created, 0).In(l).Format(time.RFC3339),
 		Modified:       time.Unix(u.Modified, 0).In(l).Format(time.RFC3339),
 		Rooms:          u.Rooms,
 		Devices:        u.Devices,
 		Blocks:         u.Blocks,
+		Presence:       u.Presence,
+		QuietHours:     quietHours,
 	})
 }
 
@@ -112,6 +146,10 @@ func (rfu *RoomForUser) MarshalJSON() ([]byte, error) {
 	if rfu.LastMessageUpdated != 0 {
 		lmu = time.Unix(rfu.LastMessageUpdated, 0).In(l).Format(time.RFC3339)
 	}
+	rdu := ""
+	if rfu.RuDraftUpdated != 0 {
+		rdu = time.Unix(rfu.RuDraftUpdated, 0).In(l).Format(time.RFC3339)
+	}
 	return json.Marshal(&struct {
 		RoomId             string         `json:"roomId"`
 		UserId             string         `json:"userId"`
@@ -128,6 +166,8 @@ func (rfu *RoomForUser) MarshalJSON() ([]byte, error) {
 		Users              []*UserMini    `json:"users"`
 		RuUnreadCount      int64          `json:"ruUnreadCount"`
 		RuMetaData         utils.JSONText `json:"ruMetaData"`
+		RuDraft            string         `json:"ruDraft,omitempty"`
+		RuDraftUpdated     string         `json:"ruDraftUpdated,omitempty"`
 		RuCreated          string         `json:"ruCreated"`
 		RuModified         string         `json:"ruModified"`
 	}{
@@ -146,6 +186,8 @@ func (rfu *RoomForUser) MarshalJSON() ([]byte, error) {
 		Users:              rfu.Users,
 		RuUnreadCount:      rfu.RuUnreadCount,
 		RuMetaData:         rfu.RuMetaData,
+		RuDraft:            rfu.RuDraft,
+		RuDraftUpdated:     rdu,
 		RuCreated:          time.Unix(rfu.RuCreated, 0).In(l).Format(time.RFC3339),
 		RuModified:         time.Unix(rfu.RuModified, 0).In(l).Format(time.RFC3339),
 	})
@@ -180,6 +222,34 @@ func (u *User) IsValid() *ProblemDetail {
 		}
 	}
 
+	if u.IsBotUser() {
+		endpoint, err := url.Parse(u.BotEndpoint)
+		if u.BotEndpoint == "" || err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return &ProblemDetail{
+				Title:     "Request parameter error. (Create user item)",
+				Status:    http.StatusBadRequest,
+				ErrorName: ERROR_NAME_INVALID_PARAM,
+				InvalidParams: []InvalidParam{
+					InvalidParam{
+						Name:   "botEndpoint",
+						Reason: "botEndpoint is required for bot users, but it's empty or invalid.",
+					},
+				},
+			}
+		}
+	}
+
+	if u.QuietHours != nil {
+		if invalidParams := u.QuietHours.IsValid(); len(invalidParams) > 0 {
+			return &ProblemDetail{
+				Title:         "Request parameter error. (Create user item)",
+				Status:        http.StatusBadRequest,
+				ErrorName:     ERROR_NAME_INVALID_PARAM,
+				InvalidParams: invalidParams,
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -212,6 +282,27 @@ func (u *User) BeforeSave() {
 		u.UnreadCount = &unreadCount
 	}
 
+	if u.IsBot == nil {
+		isBot := false
+		u.IsBot = &isBot
+	}
+
+	if *u.IsBot && u.BotSecret == "" {
+		b := make([]byte, 32)
+		rand.Read(b)
+		u.BotSecret = hex.EncodeToString(b)
+	}
+
+	// Empty quiet hours turn them off
+	if u.QuietHours != nil {
+		u.QuietHoursStart = u.QuietHours.Start
+		u.QuietHoursEnd = u.QuietHours.End
+		u.QuietHoursTimezone = u.QuietHours.Timezone
+		if u.QuietHoursStart == "" {
+			u.QuietHoursTimezone = ""
+		}
+	}
+
 	nowTimestamp := time.Now().Unix()
 	if u.Created == 0 {
 		u.Created = nowTimestamp
@@ -219,6 +310,21 @@ func (u *User) BeforeSave() {
 	u.Modified = nowTimestamp
 }
 
+// Profile returns the part of the user shown to the users of the same rooms.
+func (u *User) Profile() *User {
+	return &User{
+		UserId:         u.UserId,
+		Name:           u.Name,
+		PictureUrl:     u.PictureUrl,
+		InformationUrl: u.InformationUrl,
+		MetaData:       u.MetaData,
+		IsPublic:       u.IsPublic,
+		IsBot:          u.IsBot,
+		Created:        u.Created,
+		Modified:       u.Modified,
+	}
+}
+
 func (u *User) Put(put *User) {
 	if put.Name != "" {
 		u.Name = put.Name
@@ -241,4 +347,17 @@ func (u *User) Put(put *User) {
 	if put.IsCanBlock != nil {
 		u.IsCanBlock = put.IsCanBlock
 	}
+	if put.IsBot != nil {
+		u.IsBot = put.IsBot
+	}
+	if put.BotEndpoint != "" {
+		u.BotEndpoint = put.BotEndpoint
+	}
+	if put.QuietHours != nil {
+		u.QuietHours = put.QuietHours
+	}
+}
+
+func (u *User) IsBotUser() bool {
+	return u.IsBot != nil && *u.IsBot
 }
@@ -0,0 +1,290 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+const (
+	WEBHOOK_EVENT_ALL             = "*"
+	WEBHOOK_EVENT_MESSAGE_CREATED = "messageCreated"
+	WEBHOOK_EVENT_ROOM_CREATED    = "roomCreated"
+	WEBHOOK_EVENT_ROOM_DELETED    = "roomDeleted"
+	WEBHOOK_EVENT_USER_JOINED     = "userJoined"
+	WEBHOOK_EVENT_USER_LEFT       = "userLeft"
+
+	WEBHOOK_DELIVERY_STATUS_PENDING   = "pending"
+	WEBHOOK_DELIVERY_STATUS_SUCCEEDED = "succeeded"
+	WEBHOOK_DELIVERY_STATUS_DEAD      = "dead"
+)
+
+var webhookEvents = []string{
+	WEBHOOK_EVENT_ALL,
+	WEBHOOK_EVENT_MESSAGE_CREATED,
+	WEBHOOK_EVENT_ROOM_CREATED,
+	WEBHOOK_EVENT_ROOM_DELETED,
+	WEBHOOK_EVENT_USER_JOINED,
+	WEBHOOK_EVENT_USER_LEFT,
+}
+
+type Webhooks struct {
+	Webhooks []*Webhook `json:"webhooks"`
+}
+
+type Webhook struct {
+	Id         uint64   `json:"-" db:"id"`
+	WebhookId  string   `json:"webhookId" db:"webhook_id,notnull"`
+	Url        string   `json:"url" db:"url,notnull"`
+	Secret     string   `json:"secret" db:"secret,notnull"`
+	Events     []string `json:"events" db:"-"`
+	EventsText string   `json:"-" db:"events,notnull"`
+	IsActive   *bool    `json:"isActive,omitempty" db:"is_active,notnull"`
+	Created    int64    `json:"created" db:"created,notnull"`
+	Modified   int64    `json:"modified" db:"modified,notnull"`
+	Deleted    int64    `json:"-" db:"deleted,notnull"`
+}
+
+func (wh *Webhook) MarshalJSON() ([]byte, error) {
+	l, _ := time.LoadLocation("Etc/GMT")
+	return json.Marshal(&struct {
+		WebhookId string   `json:"webhookId"`
+		Url       string   `json:"url"`
+		Secret    string   `json:"secret"`
+		Events    []string `json:"events"`
+		IsActive  *bool    `json:"isActive"`
+		Created   string   `json:"created"`
+		Modified  string   `json:"modified"`
+	}{
+		WebhookId: wh.WebhookId,
+		Url:       wh.Url,
+		Secret:    wh.Secret,
+		Events:    wh.Events,
+		IsActive:  wh.IsActive,
+		Created:   time.Unix(wh.Created, 0).In(l).Format(time.RFC3339),
+		Modified:  time.Unix(wh.Modified, 0).In(l).Format(time.RFC3339),
+	})
+}
+
+func (wh *Webhook) IsValid() *ProblemDetail {
+	if wh.WebhookId != "" && !utils.IsValidId(wh.WebhookId) {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Create webhook item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "webhookId",
+					Reason: "webhookId is invalid. Available characters are alphabets, numbers and hyphens.",
+				},
+			},
+		}
+	}
+
+	u, err := url.Parse(wh.Url)
+	if wh.Url == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Create webhook item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "url",
+					Reason: "url is required, but it's empty or invalid.",
+				},
+			},
+		}
+	}
+
+	if len(wh.Events) == 0 {
+		return &ProblemDetail{
+			Title:     "Request parameter error. (Create webhook item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []InvalidParam{
+				InvalidParam{
+					Name:   "events",
+					Reason: "events is required, but it's empty.",
+				},
+			},
+		}
+	}
+
+	for _, event := range wh.Events {
+		if !utils.SearchStringValueInSlice(webhookEvents, event) {
+			return &ProblemDetail{
+				Title:     "Request parameter error. (Create webhook item)",
+				Status:    http.StatusBadRequest,
+				ErrorName: ERROR_NAME_INVALID_PARAM,
+				InvalidParams: []InvalidParam{
+					InvalidParam{
+						Name:   "events",
+						Reason: utils.AppendStrings("events contains an invalid value [", event, "]. Available values are ", strings.Join(webhookEvents, ", "), "."),
+					},
+				},
+			}
+		}
+	}
+
+	return nil
+}
+
+func (wh *Webhook) BeforeSave() {
+	if wh.WebhookId == "" {
+		wh.WebhookId = utils.CreateUuid()
+	}
+
+	if wh.Secret == "" {
+		b := make([]byte, 32)
+		rand.Read(b)
+		wh.Secret = hex.EncodeToString(b)
+	}
+
+	if wh.IsActive == nil {
+		isActive := true
+		wh.IsActive = &isActive
+	}
+
+	wh.EventsText = strings.Join(wh.Events, ",")
+
+	nowTimestamp := time.Now().Unix()
+	if wh.Created == 0 {
+		wh.Created = nowTimestamp
+	}
+	wh.Modified = nowTimestamp
+}
+
+// AfterLoad restores Events from the comma separated column. The datastore calls it after selecting.
+func (wh *Webhook) AfterLoad() {
+	if wh.EventsText == "" {
+		wh.Events = []string{}
+		return
+	}
+	wh.Events = strings.Split(wh.EventsText, ",")
+}
+
+func (wh *Webhook) Put(put *Webhook) {
+	if put.Url != "" {
+		wh.Url = put.Url
+	}
+	if put.Secret != "" {
+		wh.Secret = put.Secret
+	}
+	if put.Events != nil {
+		wh.Events = put.Events
+	}
+	if put.IsActive != nil {
+		wh.IsActive = put.IsActive
+	}
+}
+
+func (wh *Webhook) IsSubscribed(eventName string) bool {
+	if wh.IsActive != nil && !*wh.IsActive {
+		return false
+	}
+	for _, event := range wh.Events {
+		if event == WEBHOOK_EVENT_ALL || event == eventName {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookDeliveries struct {
+	WebhookDeliveries []*WebhookDelivery `json:"webhookDeliveries"`
+}
+
+type WebhookDelivery struct {
+	Id             uint64         `json:"-" db:"id"`
+	DeliveryId     string         `json:"deliveryId" db:"delivery_id,notnull"`
+	WebhookId      string         `json:"webhookId" db:"webhook_id,notnull"`
+	EventName      string         `json:"eventName" db:"event_name,notnull"`
+	MessageId      string         `json:"-" db:"message_id,notnull"`
+	Data           utils.JSONText `json:"data" db:"data"`
+	Status         string         `json:"status" db:"status,notnull"`
+	Attempts       int            `json:"attempts" db:"attempts,notnull"`
+	NextAttempt    int64          `json:"nextAttempt" db:"next_attempt,notnull"`
+	LastStatusCode int            `json:"lastStatusCode,omitempty" db:"last_status_code,notnull"`
+	LastError      string         `json:"lastError,omitempty" db:"last_error"`
+	Created        int64          `json:"created" db:"created,notnull"`
+	Modified       int64          `json:"modified" db:"modified,notnull"`
+}
+
+func (wd *WebhookDelivery) MarshalJSON() ([]byte, error) {
+	l, _ := time.LoadLocation("Etc/GMT")
+	nextAttempt := ""
+	if wd.Status == WEBHOOK_DELIVERY_STATUS_PENDING {
+		nextAttempt = time.Unix(wd.NextAttempt, 0).In(l).Format(time.RFC3339)
+	}
+	return json.Marshal(&struct {
+		DeliveryId     string         `json:"deliveryId"`
+		WebhookId      string         `json:"webhookId"`
+		EventName      string         `json:"eventName"`
+		Data           utils.JSONText `json:"data"`
+		Status         string         `json:"status"`
+		Attempts       int            `json:"attempts"`
+		NextAttempt    string         `json:"nextAttempt,omitempty"`
+		LastStatusCode int            `json:"lastStatusCode,omitempty"`
+		LastError      string         `json:"lastError,omitempty"`
+		Created        string         `json:"created"`
+		Modified       string         `json:"modified"`
+	}{
+		DeliveryId:     wd.DeliveryId,
+		WebhookId:      wd.WebhookId,
+		EventName:      wd.EventName,
+		Data:           wd.Data,
+		Status:         wd.Status,
+		Attempts:       wd.Attempts,
+		NextAttempt:    nextAttempt,
+		LastStatusCode: wd.LastStatusCode,
+		LastError:      wd.LastError,
+		Created:        time.Unix(wd.Created, 0).In(l).Format(time.RFC3339),
+		Modified:       time.Unix(wd.Modified, 0).In(l).Format(time.RFC3339),
+	})
+}
+
+func (wd *WebhookDelivery) BeforeSave() {
+	if wd.DeliveryId == "" {
+		wd.DeliveryId = utils.CreateUuid()
+	}
+
+	if wd.Status == "" {
+		wd.Status = WEBHOOK_DELIVERY_STATUS_PENDING
+	}
+
+	nowTimestamp := time.Now().Unix()
+	if wd.Created == 0 {
+		wd.Created = nowTimestamp
+	}
+	wd.Modified = nowTimestamp
+}
+
+// Body is the signed request body sent to the webhook url.
+// It is rebuilt from the stored delivery so that every attempt sends identical bytes.
+func (wd *WebhookDelivery) Body() ([]byte, error) {
+	l, _ := time.LoadLocation("Etc/GMT")
+	return json.Marshal(&struct {
+		DeliveryId string         `json:"deliveryId"`
+		WebhookId  string         `json:"webhookId"`
+		EventName  string         `json:"eventName"`
+		Created    string         `json:"created"`
+		Data       utils.JSONText `json:"data"`
+	}{
+		DeliveryId: wd.DeliveryId,
+		WebhookId:  wd.WebhookId,
+		EventName:  wd.EventName,
+		Created:    time.Unix(wd.Created, 0).In(l).Format(time.RFC3339),
+		Data:       wd.Data,
+	})
+}
+
+type WebhookRoomUsers struct {
+	RoomId  string   `json:"roomId"`
+	UserIds []string `json:"userIds"`
+}
@@ -0,0 +1,102 @@
+package moderation
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+const (
+	ACTION_ALLOW  = "allow"
+	ACTION_REJECT = "reject"
+	ACTION_MASK   = "mask"
+	ACTION_HOLD   = "hold"
+)
+
+type Result struct {
+	Action        string
+	Payload       utils.JSONText
+	Reason        string
+	ProblemDetail *models.ProblemDetail
+}
+
+type Hook interface {
+	Moderate(*models.Message) (*Result, error)
+}
+
+func GetHooks() []Hook {
+	hooks := make([]Hook, 0)
+	for _, name := range strings.Split(utils.Cfg.Moderation.Hooks, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "wordFilter":
+			hooks = append(hooks, &WordFilterHook{})
+		case "webhook":
+			hooks = append(hooks, &WebhookHook{})
+		default:
+			utils.AppLogger.Error("",
+				zap.String("msg", "utils.Cfg.Moderation.Hooks is incorrect"),
+			)
+			os.Exit(0)
+		}
+	}
+	return hooks
+}
+
+// Moderate runs the message through every configured hook in order.
+// A mask rewrites the payload and moves on to the next hook, reject and hold stop the pipeline.
+// A hook that fails is logged and skipped so that an unavailable moderator does not stop the chat.
+func Moderate(m *models.Message) *Result {
+	result := &Result{
+		Action:  ACTION_ALLOW,
+		Payload: m.Payload,
+	}
+	for _, hook := range GetHooks() {
+		target := *m
+		target.Payload = result.Payload
+		res, err := hook.Moderate(&target)
+		if err != nil {
+			utils.AppLogger.Error("",
+				zap.String("msg", err.Error()),
+			)
+			continue
+		}
+		switch res.Action {
+		case ACTION_MASK:
+			result.Action = ACTION_MASK
+			result.Payload = res.Payload
+			result.Reason = res.Reason
+		case ACTION_REJECT:
+			if res.ProblemDetail == nil {
+				res.ProblemDetail = rejectProblemDetail(res.Reason)
+			}
+			return res
+		case ACTION_HOLD:
+			res.Payload = result.Payload
+			return res
+		}
+	}
+	return result
+}
+
+func rejectProblemDetail(reason string) *models.ProblemDetail {
+	if reason == "" {
+		reason = "The content is not allowed."
+	}
+	return &models.ProblemDetail{
+		Title:     "Request parameter error. (Create message item)",
+		Status:    http.StatusBadRequest,
+		ErrorName: models.ERROR_NAME_CONTENT_REJECTED,
+		InvalidParams: []models.InvalidParam{
+			models.InvalidParam{
+				Name:   "payload",
+				Reason: reason,
+			},
+		},
+	}
+}
@@ -0,0 +1,87 @@
+package moderation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+// moderationServer answers the moderation webhook with the response of the text it is posted.
+func moderationServer(t *testing.T, responses map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var m struct {
+			Payload models.PayloadText `json:"payload"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			t.Errorf("the message could not be decoded: %v", err)
+		}
+		response, ok := responses[m.Payload.Text]
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(response))
+	}))
+}
+
+func TestModerate(t *testing.T) {
+	server := moderationServer(t, map[string]string{
+		"good morning":    `{"action":"allow"}`,
+		"go to ****":      `{"action":"allow"}`,
+		"buy now":         `{"action":"reject","reason":"Advertising is not allowed."}`,
+		"visit ****.com":  `{"action":"hold","reason":"Links are reviewed."}`,
+		"my address":      `{"action":"mask","payload":{"text":"my *******"},"reason":"Personal information."}`,
+		"unknown action":  `{"action":"delete"}`,
+		"mask no payload": `{"action":"mask"}`,
+	})
+	defer server.Close()
+	defer setupModeration(utils.Moderation{
+		Hooks:            "wordFilter, webhook",
+		WordFilterWords:  "hell,spam",
+		WordFilterAction: ACTION_MASK,
+		WebhookEndpoint:  server.URL,
+		WebhookTimeout:   "3",
+	})()
+
+	testTable := []struct {
+		testNo  int
+		text    string
+		action  string
+		payload string
+		reason  string
+	}{
+		{1, "good morning", ACTION_ALLOW, `{"text":"good morning"}`, ""},
+		// The webhook is posted the payload masked by the word filter
+		{2, "go to hell", ACTION_MASK, `{"text":"go to ****"}`, "The text contains words that are not allowed."},
+		{3, "buy now", ACTION_REJECT, "", "Advertising is not allowed."},
+		// A held message keeps the payload masked so far
+		{4, "visit spam.com", ACTION_HOLD, `{"text":"visit ****.com"}`, "Links are reviewed."},
+		{5, "my address", ACTION_MASK, `{"text":"my *******"}`, "Personal information."},
+		// A failing hook is skipped
+		{6, "unknown action", ACTION_ALLOW, `{"text":"unknown action"}`, ""},
+		{7, "mask no payload", ACTION_ALLOW, `{"text":"mask no payload"}`, ""},
+		{8, "server error", ACTION_ALLOW, `{"text":"server error"}`, ""},
+	}
+
+	for _, testRecord := range testTable {
+		res := Moderate(textMessage(testRecord.text))
+		if res.Action != testRecord.action {
+			t.Fatalf("TestNo %d\nAction Failure\n[expected]%s\n[result  ]%s", testRecord.testNo, testRecord.action, res.Action)
+		}
+		if string(res.Payload) != testRecord.payload {
+			t.Fatalf("TestNo %d\nPayload Failure\n[expected]%s\n[result  ]%s", testRecord.testNo, testRecord.payload, res.Payload)
+		}
+		if res.Reason != testRecord.reason {
+			t.Fatalf("TestNo %d\nReason Failure\n[expected]%s\n[result  ]%s", testRecord.testNo, testRecord.reason, res.Reason)
+		}
+		if res.Action == ACTION_REJECT {
+			if res.ProblemDetail == nil || res.ProblemDetail.ErrorName != models.ERROR_NAME_CONTENT_REJECTED {
+				t.Fatalf("TestNo %d\nthe rejection has no problem detail: %v", testRecord.testNo, res.ProblemDetail)
+			}
+		}
+	}
+}
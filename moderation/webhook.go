@@ -0,0 +1,62 @@
+package moderation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+// WebhookHook posts the message to utils.Cfg.Moderation.WebhookEndpoint.
+// The endpoint answers with {"action": "allow|reject|mask|hold", "payload": {...}, "reason": "..."}.
+type WebhookHook struct{}
+
+type webhookResponse struct {
+	Action  string         `json:"action"`
+	Payload utils.JSONText `json:"payload,omitempty"`
+	Reason  string         `json:"reason,omitempty"`
+}
+
+func (hook WebhookHook) Moderate(m *models.Message) (*Result, error) {
+	input, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := strconv.Atoi(utils.Cfg.Moderation.WebhookTimeout)
+	if err != nil {
+		timeout = 3
+	}
+	client := &http.Client{
+		Timeout: time.Duration(timeout) * time.Second,
+	}
+	resp, err := client.Post(utils.Cfg.Moderation.WebhookEndpoint, "application/json", bytes.NewBuffer(input))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(utils.AppendStrings("moderation webhook http status code[", strconv.Itoa(resp.StatusCode), "]"))
+	}
+
+	var wr webhookResponse
+	if err := json.NewDecoder(resp.Body).Decode(&wr); err != nil {
+		return nil, err
+	}
+	switch wr.Action {
+	case ACTION_ALLOW, ACTION_REJECT, ACTION_HOLD:
+		return &Result{Action: wr.Action, Reason: wr.Reason}, nil
+	case ACTION_MASK:
+		if wr.Payload == nil {
+			return nil, errors.New("moderation webhook returned mask without payload")
+		}
+		return &Result{Action: wr.Action, Payload: wr.Payload, Reason: wr.Reason}, nil
+	default:
+		return nil, errors.New(utils.AppendStrings("moderation webhook returned unknown action[", wr.Action, "]"))
+	}
+}
@@ -0,0 +1,113 @@
+package moderation
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+var (
+	wordFilterPatterns     []*regexp.Regexp
+	wordFilterPatternsOnce sync.Once
+)
+
+// WordFilterHook matches text payloads against a word list and regular expressions
+// and applies utils.Cfg.Moderation.WordFilterAction when something matches.
+type WordFilterHook struct{}
+
+func (hook WordFilterHook) Moderate(m *models.Message) (*Result, error) {
+	if m.Type != models.MESSAGE_TYPE_TEXT {
+		return &Result{Action: ACTION_ALLOW}, nil
+	}
+
+	var pt models.PayloadText
+	if err := json.Unmarshal(m.Payload, &pt); err != nil {
+		return nil, err
+	}
+
+	masked := pt.Text
+	isMatched := false
+	for _, pattern := range getWordFilterPatterns() {
+		if pattern.MatchString(masked) {
+			isMatched = true
+			masked = pattern.ReplaceAllStringFunc(masked, func(s string) string {
+				return strings.Repeat("*", utf8.RuneCountInString(s))
+			})
+		}
+	}
+	if !isMatched {
+		return &Result{Action: ACTION_ALLOW}, nil
+	}
+
+	reason := "The text contains words that are not allowed."
+	switch utils.Cfg.Moderation.WordFilterAction {
+	case ACTION_REJECT:
+		return &Result{Action: ACTION_REJECT, Reason: reason}, nil
+	case ACTION_HOLD:
+		return &Result{Action: ACTION_HOLD, Reason: reason}, nil
+	default:
+		// Only the text is replaced, the other fields such as attachments are kept as they are
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(m.Payload, &fields); err != nil {
+			return nil, err
+		}
+		text, err := json.Marshal(masked)
+		if err != nil {
+			return nil, err
+		}
+		fields["text"] = text
+		payload, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		return &Result{Action: ACTION_MASK, Payload: utils.JSONText(payload), Reason: reason}, nil
+	}
+}
+
+func getWordFilterPatterns() []*regexp.Regexp {
+	wordFilterPatternsOnce.Do(func() {
+		wordFilterPatterns = make([]*regexp.Regexp, 0)
+		for _, word := range strings.Split(utils.Cfg.Moderation.WordFilterWords, ",") {
+			word = strings.TrimSpace(word)
+			if word == "" {
+				continue
+			}
+			wordFilterPatterns = append(wordFilterPatterns, regexp.MustCompile(utils.AppendStrings("(?i)", regexp.QuoteMeta(word))))
+		}
+
+		if utils.Cfg.Moderation.WordFilterPatternsPath == "" {
+			return
+		}
+		buf, err := ioutil.ReadFile(utils.Cfg.Moderation.WordFilterPatternsPath)
+		if err != nil {
+			utils.AppLogger.Error("",
+				zap.String("msg", err.Error()),
+			)
+			return
+		}
+		for _, line := range strings.Split(string(buf), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			pattern, err := regexp.Compile(line)
+			if err != nil {
+				utils.AppLogger.Error("",
+					zap.String("msg", err.Error()),
+					zap.String("pattern", line),
+				)
+				continue
+			}
+			wordFilterPatterns = append(wordFilterPatterns, pattern)
+		}
+	})
+	return wordFilterPatterns
+}
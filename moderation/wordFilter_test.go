@@ -0,0 +1,82 @@
+package moderation
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+// setupModeration replaces the moderation config, and returns the function restoring it.
+func setupModeration(moderation utils.Moderation) func() {
+	cfg := *utils.Cfg.Moderation
+	*utils.Cfg.Moderation = moderation
+	wordFilterPatternsOnce = sync.Once{}
+	return func() {
+		*utils.Cfg.Moderation = cfg
+		wordFilterPatternsOnce = sync.Once{}
+	}
+}
+
+func textMessage(text string) *models.Message {
+	return &models.Message{
+		Type:    models.MESSAGE_TYPE_TEXT,
+		Payload: utils.JSONText(`{"text":"` + text + `"}`),
+	}
+}
+
+func TestWordFilterHook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "moderation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	patternsPath := filepath.Join(dir, "patterns.txt")
+	patterns := "# phone numbers\n[0-9]{3}-[0-9]{4}\n\n[invalid\n"
+	if err := ioutil.WriteFile(patternsPath, []byte(patterns), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	testTable := []struct {
+		testNo  int
+		action  string
+		message *models.Message
+		result  string
+		payload string
+	}{
+		{1, ACTION_MASK, textMessage("good morning"), ACTION_ALLOW, ""},
+		{2, ACTION_MASK, textMessage("Go to HELL, spam!"), ACTION_MASK, `{"text":"Go to ****, ****!"}`},
+		{3, ACTION_MASK, textMessage("call 555-1234"), ACTION_MASK, `{"text":"call ********"}`},
+		{4, ACTION_MASK, textMessage("日本語の禁止"), ACTION_MASK, `{"text":"日本語の**"}`},
+		{5, ACTION_REJECT, textMessage("spam"), ACTION_REJECT, ""},
+		{6, ACTION_HOLD, textMessage("spam"), ACTION_HOLD, ""},
+		{7, ACTION_MASK, &models.Message{Type: models.MESSAGE_TYPE_IMAGE, Payload: utils.JSONText(`{"text":"spam"}`)}, ACTION_ALLOW, ""},
+		{8, ACTION_MASK, &models.Message{Type: models.MESSAGE_TYPE_TEXT, Payload: utils.JSONText(`{"text":"spam","attachments":[{"title":"spam"}],"custom":{"id":12345678901234567890}}`)}, ACTION_MASK, `{"attachments":[{"title":"spam"}],"custom":{"id":12345678901234567890},"text":"****"}`},
+	}
+
+	for _, testRecord := range testTable {
+		restore := setupModeration(utils.Moderation{
+			WordFilterWords:        "hell, spam,,禁止",
+			WordFilterPatternsPath: patternsPath,
+			WordFilterAction:       testRecord.action,
+		})
+		res, err := WordFilterHook{}.Moderate(testRecord.message)
+		restore()
+		if err != nil {
+			t.Fatalf("TestNo %d\nModerate failed: %v", testRecord.testNo, err)
+		}
+		if res.Action != testRecord.result {
+			t.Fatalf("TestNo %d\nAction Failure\n[expected]%s\n[result  ]%s", testRecord.testNo, testRecord.result, res.Action)
+		}
+		if string(res.Payload) != testRecord.payload {
+			t.Fatalf("TestNo %d\nPayload Failure\n[expected]%s\n[result  ]%s", testRecord.testNo, testRecord.payload, res.Payload)
+		}
+		if res.Action != ACTION_ALLOW && res.Reason == "" {
+			t.Fatalf("TestNo %d\nthe reason is missing", testRecord.testNo)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package notification
+
+// [APNs Provider API] https://developer.apple.com/documentation/usernotifications/setting_up_a_remote_notification_server/sending_notification_requests_to_apns
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+const (
+	APNS_HOST_PRODUCTION = "https://api.push.apple.com"
+	APNS_HOST_SANDBOX    = "https://api.sandbox.push.apple.com"
+
+	// apnsTokenLifetime is shorter than the hour APNs accepts a token for.
+	// APNs also rejects tokens refreshed more often than every 20 minutes.
+	apnsTokenLifetime = 50 * time.Minute
+)
+
+// ApnsProvider sends pushes to iOS devices with the APNs HTTP/2 API and token-based authentication.
+type ApnsProvider struct {
+	tokenTopics
+	keyPath  string
+	keyId    string
+	teamId   string
+	topic    string
+	endpoint string
+}
+
+// apnsClient is shared by the providers so that the HTTP/2 connection and the token are reused
+var apnsClient = struct {
+	sync.Mutex
+	client   *http.Client
+	keyPath  string
+	key      *ecdsa.PrivateKey
+	token    string
+	issuedAt time.Time
+}{
+	client: newApnsHttpClient(nil),
+}
+
+// newApnsHttpClient returns a client speaking HTTP/2, as APNs does not accept HTTP/1.1.
+func newApnsHttpClient(tlsConfig *tls.Config) *http.Client {
+	transport := &http.Transport{
+		TLSClientConfig: tlsConfig,
+	}
+	http2.ConfigureTransport(transport)
+	return &http.Client{
+		Transport: transport,
+	}
+}
+
+type apnsErrorResponse struct {
+	Reason string `json:"reason"`
+}
+
+// Publish sends the push to every iOS device subscribed to the room.
+func (provider ApnsProvider) Publish(ctx context.Context, notificationTopicId, roomId string, messageInfo *MessageInfo) NotificationChannel {
+	nc := make(NotificationChannel, 1)
+	defer close(nc)
+	nc <- publishToRoomTokens(ctx, "APNs", roomId, models.PLATFORM_IOS, messageInfo.UserIds, func(ctx context.Context, token string) error {
+		return provider.sendToken(ctx, token, roomId, messageInfo)
+	})
+	return nc
+}
+
+// newApnsPush returns the payload of the push. A push without text updates the badge in the background with a low priority.
+func newApnsPush(roomId string, messageInfo *MessageInfo) (*iosPushWrapper, string, string) {
+	badge := messageInfo.Badge
+	push := iosPush{
+		Badge:    &badge,
+		ThreadId: roomId,
+		RoomId:   roomId,
+	}
+	if messageInfo.Text == "" {
+		contentAvailable := 1
+		push.ContentAvailable = &contentAvailable
+		return &iosPushWrapper{APS: push}, "background", "5"
+	}
+	mutableContent := 1
+	push.Alert = messageInfo.Text
+	push.Sound = "default"
+	push.MutableContent = &mutableContent
+	return &iosPushWrapper{APS: push}, "alert", "10"
+}
+
+func (provider ApnsProvider) sendToken(ctx context.Context, deviceToken, roomId string, messageInfo *MessageInfo) error {
+	token, err := provider.authToken()
+	if err != nil {
+		return err
+	}
+
+	push, pushType, priority := newApnsPush(roomId, messageInfo)
+	body, err := json.Marshal(push)
+	if err != nil {
+		return err
+	}
+	url := utils.AppendStrings(provider.endpoint, "/3/device/", deviceToken)
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("authorization", utils.AppendStrings("bearer ", token))
+	req.Header.Set("apns-topic", provider.topic)
+	req.Header.Set("apns-push-type", pushType)
+	req.Header.Set("apns-priority", priority)
+	req.Header.Set("apns-collapse-id", roomId)
+	res, err := apnsClient.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	resBody, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+	if res.StatusCode == http.StatusGone {
+		return &unregisteredTokenError{token: deviceToken}
+	}
+
+	var apnsErr apnsErrorResponse
+	json.Unmarshal(resBody, &apnsErr)
+	return errors.New(utils.AppendStrings("http status ", strconv.Itoa(res.StatusCode), " ", apnsErr.Reason))
+}
+
+// authToken returns the provider authentication token, signed again when it is about to expire.
+func (provider ApnsProvider) authToken() (string, error) {
+	apnsClient.Lock()
+	defer apnsClient.Unlock()
+	if apnsClient.key == nil || apnsClient.keyPath != provider.keyPath {
+		key, err := loadApnsKey(provider.keyPath)
+		if err != nil {
+			return "", err
+		}
+		apnsClient.keyPath = provider.keyPath
+		apnsClient.key = key
+		apnsClient.token = ""
+	}
+	if apnsClient.token != "" && time.Since(apnsClient.issuedAt) < apnsTokenLifetime {
+		return apnsClient.token, nil
+	}
+
+	issuedAt := time.Now()
+	token, err := signApnsToken(apnsClient.key, provider.keyId, provider.teamId, issuedAt)
+	if err != nil {
+		return "", err
+	}
+	apnsClient.token = token
+	apnsClient.issuedAt = issuedAt
+	return token, nil
+}
+
+// loadApnsKey reads the .p8 signing key downloaded from the Apple Developer account.
+func loadApnsKey(keyPath string) (*ecdsa.PrivateKey, error) {
+	data, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("the APNs key is not PEM encoded")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the APNs key is not an ECDSA key")
+	}
+	return ecdsaKey, nil
+}
+
+// signApnsToken returns the provider authentication token.
+func signApnsToken(key *ecdsa.PrivateKey, keyId, teamId string, issuedAt time.Time) (string, error) {
+	return signJwtES256(key, map[string]interface{}{
+		"alg": "ES256",
+		"kid": keyId,
+	}, map[string]interface{}{
+		"iss": teamId,
+		"iat": issuedAt.Unix(),
+	})
+}
@@ -5,6 +5,7 @@ package notification
 import (
 	"context"
 	"encoding/json"
+	"strings"
 
 	"go.uber.org/zap"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/swagchat/chat-api/datastore"
 	"github.com/swagchat/chat-api/models"
 	"github.com/swagchat/chat-api/utils"
 )
@@ -45,6 +47,7 @@ type iosPush struct {
 	Badge            *int   `json:"badge,omitempty"`
 	Sound            string `json:"sound,omitempty"`
 	ContentAvailable *int   `json:"content-available,omitempty"`
+	MutableContent   *int   `json:"mutable-content,omitempty"`
 	Category         string `json:"category,omitempty"`
 	ThreadId         string `json:"thread-id,omitempty"`
 	RoomId           string `json:"roomId,omitempty"`
@@ -238,6 +241,7 @@ func (provider AwsSnsProvider) Publish(ctx context.Context, notificationTopicId,
 	if err != nil {
 		result.ProblemDetail = createProblemDetail("An error occurred while publishing.", err)
 		nc <- result
+		return nc
 	}
 	wrapper.APNS = string(b[:])
 	wrapper.APNSSandbox = wrapper.APNS
@@ -252,15 +256,23 @@ func (provider AwsSnsProvider) Publish(ctx context.Context, notificationTopicId,
 	if err != nil {
 		result.ProblemDetail = createProblemDetail("An error occurred while publishing.", err)
 		nc <- result
+		return nc
 	}
 	wrapper.GCM = string(b[:])
 	pushData, err := json.Marshal(wrapper)
 	if err != nil {
 		result.ProblemDetail = createProblemDetail("An error occurred while publishing.", err)
 		nc <- result
+		return nc
 	}
 	message := string(pushData[:])
 
+	// The topic reaches everyone in the room, and each recipient is reached through the endpoints of their devices
+	if messageInfo.UserIds != nil {
+		nc <- provider.publishToUsers(ctx, client, roomId, message, messageInfo.UserIds)
+		return nc
+	}
+
 	params := &sns.PublishInput{
 		Message:          aws.String(message),
 		MessageStructure: aws.String("json"),
@@ -268,18 +280,49 @@ func (provider AwsSnsProvider) Publish(ctx context.Context, notificationTopicId,
 		TopicArn:         aws.String(notificationTopicId),
 	}
 	res, err := client.Publish(params)
+	if err != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while publishing.", err)
+		nc <- result
+		return nc
+	}
 	utils.AppLogger.Info("",
 		zap.String("msg", "[Amazon SNS]Publish message."),
 		zap.String("topicArn", notificationTopicId),
 		zap.String("message", message),
 		zap.String("response", res.String()),
 	)
+	result.Data = res
 	nc <- result
+	return nc
+}
 
-	select {
-	case <-ctx.Done():
-		return nc
-	case <-nc:
-		return nc
+// publishToUsers publishes the message to the endpoints of the users' devices.
+// Like publishToTokens, it fails only when no endpoint could be reached.
+func (provider AwsSnsProvider) publishToUsers(ctx context.Context, client *sns.SNS, roomId, message string, userIds []string) NotificationResult {
+	endpoints := make([]string, 0)
+	for _, userId := range userIds {
+		dRes := datastore.GetProvider().SelectDevicesByUserId(userId)
+		if dRes.ProblemDetail != nil {
+			return NotificationResult{ProblemDetail: dRes.ProblemDetail}
+		}
+		for _, device := range dRes.Data.([]*models.Device) {
+			if provider.ownsId(device.NotificationDeviceId) {
+				endpoints = append(endpoints, device.NotificationDeviceId)
+			}
+		}
 	}
+	return publishToTokens(ctx, "Amazon SNS", roomId, endpoints, func(ctx context.Context, endpoint string) error {
+		_, err := client.PublishWithContext(ctx, &sns.PublishInput{
+			Message:          aws.String(message),
+			MessageStructure: aws.String("json"),
+			Subject:          aws.String("subject"),
+			TargetArn:        aws.String(endpoint),
+		})
+		return err
+	})
+}
+
+// ownsId tells the ARNs of SNS from the device tokens of the other providers.
+func (provider AwsSnsProvider) ownsId(id string) bool {
+	return strings.HasPrefix(id, "arn:")
 }
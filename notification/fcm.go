@@ -0,0 +1,206 @@
+package notification
+
+// [FCM HTTP v1 API] https://firebase.google.com/docs/reference/fcm/rest/v1/projects.messages
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// FcmProvider sends pushes to Android and web devices with FCM HTTP v1.
+// FCM topics are not used, a room is reached through the registration tokens of its subscriptions.
+type FcmProvider struct {
+	tokenTopics
+	credentialPath string
+	projectId      string
+	endpoint       string
+}
+
+// fcmClient is shared by the providers so that the OAuth token is reused until it expires
+var fcmClient struct {
+	sync.Mutex
+	credentialPath string
+	client         *http.Client
+	projectId      string
+}
+
+type fcmRequest struct {
+	Message *fcmMessage `json:"message"`
+}
+
+type fcmMessage struct {
+	Token        string            `json:"token"`
+	Notification *fcmNotification  `json:"notification,omitempty"`
+	Data         map[string]string `json:"data,omitempty"`
+	Android      *fcmAndroidConfig `json:"android,omitempty"`
+	Webpush      *fcmWebpushConfig `json:"webpush,omitempty"`
+}
+
+type fcmNotification struct {
+	Body string `json:"body,omitempty"`
+}
+
+type fcmAndroidConfig struct {
+	CollapseKey  string                  `json:"collapse_key,omitempty"`
+	Priority     string                  `json:"priority,omitempty"`
+	Notification *fcmAndroidNotification `json:"notification,omitempty"`
+}
+
+type fcmAndroidNotification struct {
+	Tag               string `json:"tag,omitempty"`
+	NotificationCount *int   `json:"notification_count,omitempty"`
+}
+
+type fcmWebpushConfig struct {
+	Headers      map[string]string       `json:"headers,omitempty"`
+	Notification *fcmWebpushNotification `json:"notification,omitempty"`
+}
+
+type fcmWebpushNotification struct {
+	Tag      string `json:"tag,omitempty"`
+	Renotify bool   `json:"renotify,omitempty"`
+}
+
+type fcmErrorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+		Details []struct {
+			Type      string `json:"@type"`
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+func (provider FcmProvider) Publish(ctx context.Context, notificationTopicId, roomId string, messageInfo *MessageInfo) NotificationChannel {
+	nc := make(NotificationChannel, 1)
+	defer close(nc)
+	nc <- publishToRoomTokens(ctx, "FCM", roomId, 0, messageInfo.UserIds, func(ctx context.Context, token string) error {
+		return provider.sendToken(ctx, token, roomId, messageInfo)
+	})
+	return nc
+}
+
+func (provider FcmProvider) sendToken(ctx context.Context, token, roomId string, messageInfo *MessageInfo) error {
+	return provider.send(ctx, newFcmMessage(token, roomId, messageInfo))
+}
+
+func newFcmMessage(token, roomId string, messageInfo *MessageInfo) *fcmMessage {
+	badge := messageInfo.Badge
+	return &fcmMessage{
+		Token: token,
+		Notification: &fcmNotification{
+			Body: messageInfo.Text,
+		},
+		Data: map[string]string{
+			"roomId":  roomId,
+			"message": messageInfo.Text,
+			"badge":   strconv.Itoa(badge),
+		},
+		Android: &fcmAndroidConfig{
+			CollapseKey: roomId,
+			Priority:    "high",
+			Notification: &fcmAndroidNotification{
+				Tag:               roomId,
+				NotificationCount: &badge,
+			},
+		},
+		Webpush: &fcmWebpushConfig{
+			Headers: map[string]string{
+				"Urgency": "high",
+			},
+			Notification: &fcmWebpushNotification{
+				Tag:      roomId,
+				Renotify: true,
+			},
+		},
+	}
+}
+
+func (provider FcmProvider) send(ctx context.Context, message *fcmMessage) error {
+	client, projectId, err := provider.client()
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(&fcmRequest{Message: message})
+	if err != nil {
+		return err
+	}
+	url := utils.AppendStrings(provider.endpoint, "/v1/projects/", projectId, "/messages:send")
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	resBody, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var fcmErr fcmErrorResponse
+	if err := json.Unmarshal(resBody, &fcmErr); err != nil {
+		return errors.New(utils.AppendStrings("http status ", strconv.Itoa(res.StatusCode)))
+	}
+	for _, detail := range fcmErr.Error.Details {
+		if detail.ErrorCode == "UNREGISTERED" {
+			return &unregisteredTokenError{token: message.Token}
+		}
+	}
+	return errors.New(utils.AppendStrings(fcmErr.Error.Status, " ", fcmErr.Error.Message))
+}
+
+// client returns the http client authorized with the service account, and the project to send to.
+func (provider FcmProvider) client() (*http.Client, string, error) {
+	fcmClient.Lock()
+	defer fcmClient.Unlock()
+	if fcmClient.client != nil && fcmClient.credentialPath == provider.credentialPath {
+		return fcmClient.client, provider.projectIdOr(fcmClient.projectId), nil
+	}
+
+	data, err := ioutil.ReadFile(provider.credentialPath)
+	if err != nil {
+		return nil, "", err
+	}
+	conf, err := google.JWTConfigFromJSON(data, fcmScope)
+	if err != nil {
+		return nil, "", err
+	}
+	var credential struct {
+		ProjectId string `json:"project_id"`
+	}
+	if err := json.Unmarshal(data, &credential); err != nil {
+		return nil, "", err
+	}
+
+	fcmClient.credentialPath = provider.credentialPath
+	fcmClient.client = oauth2.NewClient(context.Background(), conf.TokenSource(context.Background()))
+	fcmClient.projectId = credential.ProjectId
+	return fcmClient.client, provider.projectIdOr(fcmClient.projectId), nil
+}
+
+func (provider FcmProvider) projectIdOr(projectId string) string {
+	if provider.projectId != "" {
+		return provider.projectId
+	}
+	return projectId
+}
@@ -0,0 +1,126 @@
+package notification
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// mockFcmServer serves the OAuth token endpoint and the send endpoint of FCM.
+// A message to the token "unregistered" fails as FCM does for an uninstalled app.
+func mockFcmServer() (*httptest.Server, func() []map[string]interface{}) {
+	var mu sync.Mutex
+	var messages []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if err := r.ParseForm(); err != nil || r.Form.Get("assertion") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"test-token","token_type":"Bearer","expires_in":3600}`))
+		case "/v1/projects/test-project/messages:send":
+			if r.Header.Get("Authorization") != "Bearer test-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			var req map[string]map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if req["message"]["token"] == "unregistered" {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error":{"code":404,"message":"Requested entity was not found.","status":"NOT_FOUND","details":[{"@type":"type.googleapis.com/google.firebase.fcm.v1.FcmError","errorCode":"UNREGISTERED"}]}}`))
+				return
+			}
+			mu.Lock()
+			messages = append(messages, req["message"])
+			mu.Unlock()
+			w.Write([]byte(`{"name":"projects/test-project/messages/1"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server, func() []map[string]interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		return messages
+	}
+}
+
+func writeServiceAccount(t *testing.T, dir, tokenUri string) string {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPem := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+	credential, _ := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "test-project",
+		"private_key_id": "test-key",
+		"private_key":    string(keyPem),
+		"client_email":   "chat-api@test-project.iam.gserviceaccount.com",
+		"token_uri":      tokenUri,
+	})
+	path := filepath.Join(dir, "credential.json")
+	if err := ioutil.WriteFile(path, credential, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFcmProviderSend(t *testing.T) {
+	server, messages := mockFcmServer()
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "fcm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	provider := FcmProvider{
+		credentialPath: writeServiceAccount(t, dir, server.URL+"/token"),
+		endpoint:       server.URL,
+	}
+
+	messageInfo := &MessageInfo{Text: "hello", Badge: 3}
+	if err := provider.send(context.Background(), newFcmMessage("device1", "room1", messageInfo)); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	sent := messages()
+	if len(sent) != 1 {
+		t.Fatalf("%d messages were sent", len(sent))
+	}
+	data := sent[0]["data"].(map[string]interface{})
+	if data["roomId"] != "room1" || data["message"] != "hello" || data["badge"] != "3" {
+		t.Fatalf("unexpected data payload: %v", data)
+	}
+	android := sent[0]["android"].(map[string]interface{})
+	if android["collapse_key"] != "room1" {
+		t.Fatalf("unexpected collapse key: %v", android["collapse_key"])
+	}
+
+	err = provider.send(context.Background(), newFcmMessage("unregistered", "room1", messageInfo))
+	if unregistered, ok := err.(*unregisteredTokenError); !ok || unregistered.token != "unregistered" {
+		t.Fatalf("an unregistered token was not reported: %v", err)
+	}
+
+	provider.projectId = "other-project"
+	if err := provider.send(context.Background(), newFcmMessage("device1", "room1", messageInfo)); err == nil {
+		t.Fatalf("the configured project was not used")
+	}
+}
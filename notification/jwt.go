@@ -0,0 +1,43 @@
+package notification
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+// signJwtES256 returns a JWT signed with ES256, as APNs and VAPID require.
+func signJwtES256(key *ecdsa.PrivateKey, header, claims map[string]interface{}) (string, error) {
+	headerBytes, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsBytes, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := utils.AppendStrings(
+		base64.RawURLEncoding.EncodeToString(headerBytes), ".",
+		base64.RawURLEncoding.EncodeToString(claimsBytes))
+
+	hash := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+	if err != nil {
+		return "", err
+	}
+	// The signature is r and s as 32 byte big-endian integers
+	signature := make([]byte, 64)
+	copyPadded(signature[:32], r)
+	copyPadded(signature[32:], s)
+	return utils.AppendStrings(signingInput, ".", base64.RawURLEncoding.EncodeToString(signature)), nil
+}
+
+func copyPadded(dst []byte, n *big.Int) {
+	b := n.Bytes()
+	copy(dst[len(dst)-len(b):], b)
+}
@@ -14,6 +14,9 @@ import (
 type MessageInfo struct {
 	Text  string
 	Badge int
+
+	// UserIds are the users to push to, nil for everyone subscribed to the room
+	UserIds []string
 }
 
 type NotificationResult struct {
@@ -33,9 +36,28 @@ type Provider interface {
 	Publish(context.Context, string, string, *MessageInfo) NotificationChannel
 }
 
+// GetProvider returns the provider of the config.
+// When a platform has its own provider, it returns a RoutingProvider that falls back to Provider for the other platforms.
 func GetProvider() Provider {
+	routes := make(map[int]string)
+	if utils.Cfg.Notification.IosProvider != "" {
+		routes[models.PLATFORM_IOS] = utils.Cfg.Notification.IosProvider
+	}
+	if utils.Cfg.Notification.AndroidProvider != "" {
+		routes[models.PLATFORM_ANDROID] = utils.Cfg.Notification.AndroidProvider
+	}
+	if utils.Cfg.Notification.WebProvider != "" {
+		routes[models.PLATFORM_WEB] = utils.Cfg.Notification.WebProvider
+	}
+	if len(routes) == 0 {
+		return newProvider(utils.Cfg.Notification.Provider)
+	}
+	return newRoutingProvider(routes, utils.Cfg.Notification.Provider)
+}
+
+func newProvider(name string) Provider {
 	var provider Provider
-	switch utils.Cfg.Notification.Provider {
+	switch name {
 	case "awsSns":
 		provider = &AwsSnsProvider{
 			region:                utils.Cfg.Notification.AwsRegion,
@@ -45,12 +67,44 @@ func GetProvider() Provider {
 			applicationArnIos:     utils.Cfg.Notification.AwsApplicationArnIos,
 			applicationArnAndroid: utils.Cfg.Notification.AwsApplicationArnAndroid,
 		}
+	case "fcm":
+		provider = &FcmProvider{
+			tokenTopics:    tokenTopics{roomTopicNamePrefix: utils.Cfg.Notification.RoomTopicNamePrefix},
+			credentialPath: utils.Cfg.Notification.FcmCredentialPath,
+			projectId:      utils.Cfg.Notification.FcmProjectId,
+			endpoint:       utils.Cfg.Notification.FcmEndpoint,
+		}
+	case "apns":
+		provider = &ApnsProvider{
+			tokenTopics: tokenTopics{roomTopicNamePrefix: utils.Cfg.Notification.RoomTopicNamePrefix},
+			keyPath:     utils.Cfg.Notification.ApnsKeyPath,
+			keyId:       utils.Cfg.Notification.ApnsKeyId,
+			teamId:      utils.Cfg.Notification.ApnsTeamId,
+			topic:       utils.Cfg.Notification.ApnsTopic,
+			endpoint:    apnsEndpoint(),
+		}
+	case "webPush":
+		provider = &WebPushProvider{
+			tokenTopics:     tokenTopics{roomTopicNamePrefix: utils.Cfg.Notification.RoomTopicNamePrefix},
+			vapidPrivateKey: utils.Cfg.Notification.WebPushVapidPrivateKey,
+			vapidSubject:    utils.Cfg.Notification.WebPushVapidSubject,
+		}
 	default:
 		provider = &NotUseProvider{}
 	}
 	return provider
 }
 
+func apnsEndpoint() string {
+	if utils.Cfg.Notification.ApnsEndpoint != "" {
+		return utils.Cfg.Notification.ApnsEndpoint
+	}
+	if utils.Cfg.Notification.ApnsEnvironment == "sandbox" {
+		return APNS_HOST_SANDBOX
+	}
+	return APNS_HOST_PRODUCTION
+}
+
 func createProblemDetail(title string, err error) *models.ProblemDetail {
 	return &models.ProblemDetail{
 		Title:     title,
@@ -0,0 +1,195 @@
+package notification
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/swagchat/chat-api/datastore"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+// RoutingProvider sends to each device through the provider of its platform, so that platforms can move between providers one at a time.
+// The room topics are kept by the topic-based provider among the routes, such as SNS, and its subscriptions are reached by publishing to the topic.
+// The other subscriptions hold device tokens, and are pushed to one by one through the token-based provider of their platform.
+type RoutingProvider struct {
+	tokenTopics
+	routes    map[int]string
+	fallback  string
+	providers map[string]Provider
+}
+
+// tokenSender is a provider that pushes to a device token directly.
+type tokenSender interface {
+	sendToken(ctx context.Context, token, roomId string, messageInfo *MessageInfo) error
+}
+
+// idOwner is a provider whose ids can be told apart from device tokens.
+type idOwner interface {
+	ownsId(id string) bool
+}
+
+func newRoutingProvider(routes map[int]string, fallback string) *RoutingProvider {
+	provider := &RoutingProvider{
+		tokenTopics: tokenTopics{roomTopicNamePrefix: utils.Cfg.Notification.RoomTopicNamePrefix},
+		routes:      routes,
+		fallback:    fallback,
+		providers:   make(map[string]Provider),
+	}
+	provider.providers[fallback] = newProvider(fallback)
+	for _, name := range routes {
+		if _, ok := provider.providers[name]; !ok {
+			provider.providers[name] = newProvider(name)
+		}
+	}
+	return provider
+}
+
+// route returns the name of the provider of the platform.
+func (provider RoutingProvider) route(platform int) string {
+	if name, ok := provider.routes[platform]; ok {
+		return name
+	}
+	return provider.fallback
+}
+
+// owner returns the provider of the id, or nil for a device token.
+func (provider RoutingProvider) owner(id string) Provider {
+	for _, p := range provider.providers {
+		if owner, ok := p.(idOwner); ok && owner.ownsId(id) {
+			return p
+		}
+	}
+	return nil
+}
+
+// topicProvider returns the provider keeping the room topics, if any.
+func (provider RoutingProvider) topicProvider() Provider {
+	for _, p := range provider.providers {
+		if _, ok := p.(idOwner); ok {
+			return p
+		}
+	}
+	return nil
+}
+
+func (provider RoutingProvider) CreateTopic(roomId string) NotificationChannel {
+	if p := provider.topicProvider(); p != nil {
+		return p.CreateTopic(roomId)
+	}
+	return provider.tokenTopics.CreateTopic(roomId)
+}
+
+func (provider RoutingProvider) DeleteTopic(notificationTopicId string) NotificationChannel {
+	if p := provider.owner(notificationTopicId); p != nil {
+		return p.DeleteTopic(notificationTopicId)
+	}
+	return provider.tokenTopics.DeleteTopic(notificationTopicId)
+}
+
+func (provider RoutingProvider) CreateEndpoint(userId string, platform int, deviceToken string) NotificationChannel {
+	return provider.providers[provider.route(platform)].CreateEndpoint(userId, platform, deviceToken)
+}
+
+func (provider RoutingProvider) DeleteEndpoint(notificationDeviceId string) NotificationChannel {
+	if p := provider.owner(notificationDeviceId); p != nil {
+		return p.DeleteEndpoint(notificationDeviceId)
+	}
+	return provider.tokenTopics.DeleteEndpoint(notificationDeviceId)
+}
+
+func (provider RoutingProvider) Subscribe(notificationTopicId string, notificationDeviceId string) NotificationChannel {
+	if p := provider.owner(notificationDeviceId); p != nil {
+		return p.Subscribe(notificationTopicId, notificationDeviceId)
+	}
+	return provider.tokenTopics.Subscribe(notificationTopicId, notificationDeviceId)
+}
+
+func (provider RoutingProvider) Unsubscribe(notificationSubscribeId string) NotificationChannel {
+	if p := provider.owner(notificationSubscribeId); p != nil {
+		return p.Unsubscribe(notificationSubscribeId)
+	}
+	return provider.tokenTopics.Unsubscribe(notificationSubscribeId)
+}
+
+// Publish publishes to the topic of the room, and pushes to each device token subscribed to the room.
+// It fails only when no part of the room could be reached, so that a retry does not repeat the push to the others.
+func (provider RoutingProvider) Publish(ctx context.Context, notificationTopicId, roomId string, messageInfo *MessageInfo) NotificationChannel {
+	nc := make(NotificationChannel, 1)
+	defer close(nc)
+
+	results := make([]NotificationResult, 0)
+	if p := provider.owner(notificationTopicId); p != nil {
+		results = append(results, <-p.Publish(ctx, notificationTopicId, roomId, messageInfo))
+	}
+
+	tokens, pd := provider.selectRoutedTokens(roomId, messageInfo.UserIds)
+	if pd != nil {
+		results = append(results, NotificationResult{ProblemDetail: pd})
+	}
+	for name, routedTokens := range tokens {
+		sender := provider.providers[name].(tokenSender)
+		results = append(results, publishToTokens(ctx, name, roomId, routedTokens, func(ctx context.Context, token string) error {
+			return sender.sendToken(ctx, token, roomId, messageInfo)
+		}))
+	}
+
+	nc <- combineResults(results)
+	return nc
+}
+
+// combineResults returns the first successful result, and logs the failed ones as they will not be retried.
+// Without a successful one it returns the first failure.
+func combineResults(results []NotificationResult) NotificationResult {
+	var failed []NotificationResult
+	for _, result := range results {
+		if result.ProblemDetail != nil {
+			failed = append(failed, result)
+		}
+	}
+	if len(failed) == len(results) {
+		if len(failed) == 0 {
+			return NotificationResult{}
+		}
+		return failed[0]
+	}
+
+	for _, result := range failed {
+		utils.AppLogger.Error("",
+			zap.String("msg", "[Routing]Publish error."),
+			zap.String("title", result.ProblemDetail.Title),
+			zap.String("detail", result.ProblemDetail.Detail),
+		)
+	}
+	for _, result := range results {
+		if result.ProblemDetail == nil {
+			return result
+		}
+	}
+	return NotificationResult{}
+}
+
+// selectRoutedTokens returns the device tokens subscribed to the room, by the name of the token-based provider of their platform.
+// The subscriptions of a topic-based provider, and those of a platform without a token-based provider, are left out.
+func (provider RoutingProvider) selectRoutedTokens(roomId string, userIds []string) (map[string][]string, *models.ProblemDetail) {
+	dRes := datastore.GetProvider().SelectSubscriptionsByRoomId(roomId)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	tokens := make(map[string][]string)
+	for _, subscription := range dRes.Data.([]*models.Subscription) {
+		token := subscription.NotificationSubscriptionId
+		if provider.owner(token) != nil || !isRecipient(userIds, subscription.UserId) {
+			continue
+		}
+		name := provider.route(subscription.Platform)
+		if _, ok := provider.providers[name].(tokenSender); !ok {
+			continue
+		}
+		if !utils.SearchStringValueInSlice(tokens[name], token) {
+			tokens[name] = append(tokens[name], token)
+		}
+	}
+	return tokens, nil
+}
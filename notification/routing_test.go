@@ -0,0 +1,58 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/swagchat/chat-api/models"
+)
+
+func TestRoutingProviderRoutes(t *testing.T) {
+	provider := newRoutingProvider(map[int]string{
+		models.PLATFORM_IOS: "apns",
+		models.PLATFORM_WEB: "webPush",
+	}, "awsSns")
+
+	if provider.route(models.PLATFORM_IOS) != "apns" || provider.route(models.PLATFORM_ANDROID) != "awsSns" {
+		t.Fatalf("unexpected routes: ios %s, android %s", provider.route(models.PLATFORM_IOS), provider.route(models.PLATFORM_ANDROID))
+	}
+	if _, ok := provider.owner("arn:aws:sns:ap-northeast-1:123456789012:endpoint/GCM/app/1").(*AwsSnsProvider); !ok {
+		t.Fatalf("an SNS endpoint was not routed to SNS")
+	}
+	if provider.owner("device-token") != nil {
+		t.Fatalf("a device token was routed to a topic-based provider")
+	}
+	if _, ok := provider.topicProvider().(*AwsSnsProvider); !ok {
+		t.Fatalf("the room topics are not kept by SNS")
+	}
+
+	provider = newRoutingProvider(map[int]string{
+		models.PLATFORM_IOS:     "apns",
+		models.PLATFORM_ANDROID: "fcm",
+	}, "")
+	if provider.topicProvider() != nil {
+		t.Fatalf("a topic-based provider was found without SNS")
+	}
+	nRes := <-provider.CreateTopic("room1")
+	if topic := *nRes.Data.(*string); topic != provider.roomTopicNamePrefix+"room1" {
+		t.Fatalf("unexpected room topic: %s", topic)
+	}
+	nRes = <-provider.Subscribe("room1", "device-token")
+	if subscription := *nRes.Data.(*string); subscription != "device-token" {
+		t.Fatalf("unexpected subscription: %s", subscription)
+	}
+}
+
+func TestCombineResults(t *testing.T) {
+	failed := NotificationResult{ProblemDetail: &models.ProblemDetail{Title: "failed"}}
+	sent := NotificationResult{Data: 1}
+
+	if result := combineResults([]NotificationResult{failed, sent}); result.ProblemDetail != nil {
+		t.Fatalf("a partly successful publish failed")
+	}
+	if result := combineResults([]NotificationResult{failed, failed}); result.ProblemDetail == nil {
+		t.Fatalf("a publish reaching nothing succeeded")
+	}
+	if result := combineResults(nil); result.ProblemDetail != nil {
+		t.Fatalf("a publish to nothing failed")
+	}
+}
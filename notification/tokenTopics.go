@@ -0,0 +1,184 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.uber.org/zap"
+
+	"github.com/swagchat/chat-api/datastore"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+// tokenTopics emulates topics for the providers that push to device tokens directly.
+// A room topic is the room itself, and a subscription row holds the token of a device in the room.
+type tokenTopics struct {
+	roomTopicNamePrefix string
+}
+
+func (tt tokenTopics) CreateTopic(roomId string) NotificationChannel {
+	nc := make(NotificationChannel, 1)
+	defer close(nc)
+	topic := utils.AppendStrings(tt.roomTopicNamePrefix, roomId)
+	nc <- NotificationResult{
+		Data: &topic,
+	}
+	return nc
+}
+
+func (tt tokenTopics) DeleteTopic(notificationTopicId string) NotificationChannel {
+	nc := make(NotificationChannel, 1)
+	defer close(nc)
+	nc <- NotificationResult{}
+	return nc
+}
+
+func (tt tokenTopics) CreateEndpoint(userId string, platform int, deviceToken string) NotificationChannel {
+	nc := make(NotificationChannel, 1)
+	defer close(nc)
+	nc <- NotificationResult{
+		Data: &deviceToken,
+	}
+	return nc
+}
+
+func (tt tokenTopics) DeleteEndpoint(notificationDeviceId string) NotificationChannel {
+	nc := make(NotificationChannel, 1)
+	defer close(nc)
+	nc <- NotificationResult{}
+	return nc
+}
+
+func (tt tokenTopics) Subscribe(notificationTopicId string, notificationDeviceId string) NotificationChannel {
+	nc := make(NotificationChannel, 1)
+	defer close(nc)
+	nc <- NotificationResult{
+		Data: &notificationDeviceId,
+	}
+	return nc
+}
+
+func (tt tokenTopics) Unsubscribe(notificationSubscribeId string) NotificationChannel {
+	nc := make(NotificationChannel, 1)
+	defer close(nc)
+	nc <- NotificationResult{}
+	return nc
+}
+
+// unregisteredTokenError is returned for a device token the push service no longer accepts
+type unregisteredTokenError struct {
+	token string
+}
+
+func (e *unregisteredTokenError) Error() string {
+	return utils.AppendStrings("device token is unregistered [", e.token, "]")
+}
+
+// publishToRoomTokens sends the push to every device of the platform subscribed to the room, 0 for every platform.
+// With userIds it is sent to the devices of those users only.
+// It fails only when no device could be reached, so that a retry does not repeat the push to the others.
+// The devices of unregistered tokens are removed.
+func publishToRoomTokens(ctx context.Context, providerName, roomId string, platform int, userIds []string, send func(ctx context.Context, token string) error) NotificationResult {
+	tokens, pd := selectRoomTokens(roomId, platform, userIds)
+	if pd != nil {
+		return NotificationResult{
+			ProblemDetail: pd,
+		}
+	}
+	return publishToTokens(ctx, providerName, roomId, tokens, send)
+}
+
+// publishToTokens sends the push to each of the device tokens, with the same result as publishToRoomTokens.
+func publishToTokens(ctx context.Context, providerName, roomId string, tokens []string, send func(ctx context.Context, token string) error) NotificationResult {
+	result := NotificationResult{}
+	sent := 0
+	var lastErr error
+	for _, token := range tokens {
+		err := send(ctx, token)
+		if err == nil {
+			sent++
+			continue
+		}
+		if unregistered, ok := err.(*unregisteredTokenError); ok {
+			removeDevicesByToken(unregistered.token)
+			continue
+		}
+		lastErr = err
+		utils.AppLogger.Error("",
+			zap.String("msg", utils.AppendStrings("[", providerName, "]Publish error.")),
+			zap.String("roomId", roomId),
+			zap.String("err", err.Error()),
+		)
+	}
+	if sent == 0 && lastErr != nil {
+		result.ProblemDetail = createProblemDetail("An error occurred while publishing.", lastErr)
+		return result
+	}
+
+	utils.AppLogger.Info("",
+		zap.String("msg", utils.AppendStrings("[", providerName, "]Publish message.")),
+		zap.String("roomId", roomId),
+		zap.Int("sent", sent),
+	)
+	result.Data = sent
+	return result
+}
+
+// selectRoomTokens returns the device tokens subscribed to the room. Platform 0 matches every platform, and nil userIds every user.
+func selectRoomTokens(roomId string, platform int, userIds []string) ([]string, *models.ProblemDetail) {
+	dRes := datastore.GetProvider().SelectSubscriptionsByRoomId(roomId)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	tokens := make([]string, 0)
+	for _, subscription := range dRes.Data.([]*models.Subscription) {
+		if platform != 0 && subscription.Platform != platform {
+			continue
+		}
+		if !isRecipient(userIds, subscription.UserId) {
+			continue
+		}
+		if !utils.SearchStringValueInSlice(tokens, subscription.NotificationSubscriptionId) {
+			tokens = append(tokens, subscription.NotificationSubscriptionId)
+		}
+	}
+	return tokens, nil
+}
+
+// isRecipient returns whether the push of userIds is sent to the user, nil userIds for everyone.
+func isRecipient(userIds []string, userId string) bool {
+	return userIds == nil || utils.SearchStringValueInSlice(userIds, userId)
+}
+
+// removeDevicesByToken deletes the devices of a token the push service no longer accepts, and their subscriptions.
+func removeDevicesByToken(token string) {
+	dp := datastore.GetProvider()
+	dRes := dp.SelectDevicesByToken(token)
+	if dRes.ProblemDetail != nil {
+		logProblemDetail(dRes.ProblemDetail)
+		return
+	}
+	for _, device := range dRes.Data.([]*models.Device) {
+		if dRes := dp.DeleteDevice(device.UserId, device.Platform); dRes.ProblemDetail != nil {
+			logProblemDetail(dRes.ProblemDetail)
+			continue
+		}
+		if dRes := dp.DeleteSubscriptionsByUserIdAndPlatform(device.UserId, device.Platform); dRes.ProblemDetail != nil {
+			logProblemDetail(dRes.ProblemDetail)
+			continue
+		}
+		utils.AppLogger.Info("",
+			zap.String("msg", "Removed an unregistered device."),
+			zap.String("userId", device.UserId),
+			zap.Int("platform", device.Platform),
+		)
+	}
+}
+
+func logProblemDetail(pd *models.ProblemDetail) {
+	pdBytes, _ := json.Marshal(pd)
+	utils.AppLogger.Error("",
+		zap.String("problemDetail", string(pdBytes)),
+	)
+}
@@ -0,0 +1,305 @@
+package notification
+
+// [Message Encryption for Web Push] https://tools.ietf.org/html/rfc8291
+// [VAPID] https://tools.ietf.org/html/rfc8292
+// [Generic Event Delivery Using HTTP Push] https://tools.ietf.org/html/rfc8030
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/swagchat/chat-api/datastore"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+const (
+	// webPushTTL is how long the push service keeps a push for an offline browser
+	webPushTTL = "86400"
+
+	webPushRecordSize = 4096
+
+	// vapidTokenLifetime is half of the 24 hours a push service accepts a token for.
+	// A token is signed again when less than vapidTokenRenewal of it remains.
+	vapidTokenLifetime = 12 * time.Hour
+	vapidTokenRenewal  = time.Hour
+)
+
+// WebPushProvider sends pushes to the push services of browsers, encrypted with the keys of their subscriptions.
+type WebPushProvider struct {
+	tokenTopics
+	vapidPrivateKey string
+	vapidSubject    string
+}
+
+// webPushClient is shared by the providers so that the VAPID tokens are reused for each push service
+var webPushClient = struct {
+	sync.Mutex
+	client     *http.Client
+	privateKey string
+	key        *ecdsa.PrivateKey
+	tokens     map[string]vapidToken
+}{
+	client: &http.Client{
+		Timeout: 30 * time.Second,
+	},
+}
+
+type vapidToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+type webPushPayload struct {
+	RoomId  string `json:"roomId"`
+	Message string `json:"message,omitempty"`
+	Badge   int    `json:"badge"`
+}
+
+// Publish sends the push to every web device subscribed to the room.
+func (provider WebPushProvider) Publish(ctx context.Context, notificationTopicId, roomId string, messageInfo *MessageInfo) NotificationChannel {
+	nc := make(NotificationChannel, 1)
+	defer close(nc)
+	nc <- publishToRoomTokens(ctx, "WebPush", roomId, models.PLATFORM_WEB, messageInfo.UserIds, func(ctx context.Context, endpoint string) error {
+		return provider.sendToken(ctx, endpoint, roomId, messageInfo)
+	})
+	return nc
+}
+
+func (provider WebPushProvider) sendToken(ctx context.Context, endpoint, roomId string, messageInfo *MessageInfo) error {
+	device, err := selectWebDevice(endpoint)
+	if err != nil {
+		return err
+	}
+	return provider.send(ctx, device, roomId, messageInfo)
+}
+
+// selectWebDevice returns the device holding the keys of the subscription endpoint.
+func selectWebDevice(endpoint string) (*models.Device, error) {
+	dRes := datastore.GetProvider().SelectDevicesByToken(endpoint)
+	if dRes.ProblemDetail != nil {
+		return nil, errors.New(dRes.ProblemDetail.Title)
+	}
+	for _, device := range dRes.Data.([]*models.Device) {
+		if device.Platform == models.PLATFORM_WEB {
+			return device, nil
+		}
+	}
+	return nil, errors.New(utils.AppendStrings("web device is not found [", endpoint, "]"))
+}
+
+func (provider WebPushProvider) send(ctx context.Context, device *models.Device, roomId string, messageInfo *MessageInfo) error {
+	payload, err := json.Marshal(&webPushPayload{
+		RoomId:  roomId,
+		Message: messageInfo.Text,
+		Badge:   messageInfo.Badge,
+	})
+	if err != nil {
+		return err
+	}
+	body, err := encryptWebPush(payload, device.P256dh, device.Auth)
+	if err != nil {
+		return err
+	}
+	authorization, err := provider.authorization(device.Token)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", device.Token, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", webPushTTL)
+	if messageInfo.Text == "" {
+		req.Header.Set("Urgency", "normal")
+	} else {
+		req.Header.Set("Urgency", "high")
+	}
+	// A newer push replaces the one of the same room still waiting in the push service
+	if topic := strings.Replace(roomId, "-", "", -1); len(topic) <= 32 {
+		req.Header.Set("Topic", topic)
+	}
+	res, err := webPushClient.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	resBody, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+	// The subscription has expired or the user has revoked the permission
+	if res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone {
+		return &unregisteredTokenError{token: device.Token}
+	}
+	return errors.New(utils.AppendStrings("http status ", strconv.Itoa(res.StatusCode), " ", string(resBody)))
+}
+
+// authorization returns the VAPID authorization header for the push service of the endpoint.
+func (provider WebPushProvider) authorization(endpoint string) (string, error) {
+	endpointUrl, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	audience := utils.AppendStrings(endpointUrl.Scheme, "://", endpointUrl.Host)
+
+	webPushClient.Lock()
+	defer webPushClient.Unlock()
+	if webPushClient.key == nil || webPushClient.privateKey != provider.vapidPrivateKey {
+		key, err := parseVapidKey(provider.vapidPrivateKey)
+		if err != nil {
+			return "", err
+		}
+		webPushClient.privateKey = provider.vapidPrivateKey
+		webPushClient.key = key
+		webPushClient.tokens = make(map[string]vapidToken)
+	}
+	publicKey := elliptic.Marshal(elliptic.P256(), webPushClient.key.X, webPushClient.key.Y)
+
+	token, ok := webPushClient.tokens[audience]
+	if !ok || token.expiresAt.Sub(time.Now()) < vapidTokenRenewal {
+		expiresAt := time.Now().Add(vapidTokenLifetime)
+		signed, err := signVapidToken(webPushClient.key, audience, provider.vapidSubject, expiresAt)
+		if err != nil {
+			return "", err
+		}
+		token = vapidToken{token: signed, expiresAt: expiresAt}
+		webPushClient.tokens[audience] = token
+	}
+	return utils.AppendStrings("vapid t=", token.token, ", k=", base64.RawURLEncoding.EncodeToString(publicKey)), nil
+}
+
+// parseVapidKey reads the private key of the application server, as generated by the web-push libraries.
+func parseVapidKey(privateKey string) (*ecdsa.PrivateKey, error) {
+	if privateKey == "" {
+		return nil, errors.New("the VAPID private key is not configured")
+	}
+	d, err := decodeBase64Url(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	curve := elliptic.P256()
+	if len(d) != 32 {
+		return nil, errors.New("the VAPID private key must be 32 bytes")
+	}
+	key := &ecdsa.PrivateKey{D: new(big.Int).SetBytes(d)}
+	if key.D.Sign() == 0 || key.D.Cmp(curve.Params().N) >= 0 {
+		return nil, errors.New("the VAPID private key is out of range")
+	}
+	key.PublicKey.Curve = curve
+	key.PublicKey.X, key.PublicKey.Y = curve.ScalarBaseMult(d)
+	return key, nil
+}
+
+// signVapidToken returns the token identifying the application server to the push service of the audience.
+func signVapidToken(key *ecdsa.PrivateKey, audience, subject string, expiresAt time.Time) (string, error) {
+	return signJwtES256(key, map[string]interface{}{
+		"typ": "JWT",
+		"alg": "ES256",
+	}, map[string]interface{}{
+		"aud": audience,
+		"exp": expiresAt.Unix(),
+		"sub": subject,
+	})
+}
+
+// encryptWebPush encrypts the payload for the subscription keys as a single aes128gcm record.
+func encryptWebPush(payload []byte, p256dh, auth string) ([]byte, error) {
+	uaPublicBytes, err := decodeBase64Url(p256dh)
+	if err != nil {
+		return nil, err
+	}
+	authSecret, err := decodeBase64Url(auth)
+	if err != nil {
+		return nil, err
+	}
+	curve := elliptic.P256()
+	uaX, uaY := elliptic.Unmarshal(curve, uaPublicBytes)
+	if uaX == nil || !curve.IsOnCurve(uaX, uaY) {
+		return nil, errors.New("the p256dh key is not a point on P-256")
+	}
+	asPrivate, asX, asY, err := elliptic.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublicBytes := elliptic.Marshal(curve, asX, asY)
+	ecdhSecret := p256SharedSecret(uaX, uaY, asPrivate)
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	keyInfo := utils.AppendStrings("WebPush: info\x00", string(uaPublicBytes), string(asPublicBytes))
+	ikm := hkdfSha256(ecdhSecret, authSecret, keyInfo, 32)
+	cek := hkdfSha256(ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	nonce := hkdfSha256(ikm, salt, "Content-Encoding: nonce\x00", 12)
+
+	// The payload is followed by the delimiter of the last record, and the tag of AES-GCM
+	if len(payload)+1+16 > webPushRecordSize {
+		return nil, errors.New("the payload is too large for a web push")
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// The header holds the salt, the record size and the public key of the application server
+	header := make([]byte, 16+4+1, 16+4+1+len(asPublicBytes))
+	copy(header, salt)
+	binary.BigEndian.PutUint32(header[16:20], webPushRecordSize)
+	header[20] = byte(len(asPublicBytes))
+	header = append(header, asPublicBytes...)
+	return gcm.Seal(header, nonce, append(payload, 0x02), nil), nil
+}
+
+// p256SharedSecret returns the x coordinate of the ECDH shared point as 32 bytes.
+func p256SharedSecret(x, y *big.Int, private []byte) []byte {
+	sx, _ := elliptic.P256().ScalarMult(x, y, private)
+	secret := make([]byte, 32)
+	copyPadded(secret, sx)
+	return secret
+}
+
+// hkdfSha256 derives length bytes of key from the secret with HKDF-SHA256, length being at most 32.
+func hkdfSha256(secret, salt []byte, info string, length int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(secret)
+	prk := extract.Sum(nil)
+
+	expand := hmac.New(sha256.New, prk)
+	expand.Write([]byte(info))
+	expand.Write([]byte{0x01})
+	return expand.Sum(nil)[:length]
+}
+
+// decodeBase64Url accepts the keys of a subscription with or without padding.
+func decodeBase64Url(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,163 @@
+package notification
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/swagchat/chat-api/models"
+)
+
+func TestHkdfSha256(t *testing.T) {
+	// Test case 1 of RFC 5869, of which the first 32 bytes are derived
+	secret, _ := hex.DecodeString("0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b")
+	salt, _ := hex.DecodeString("000102030405060708090a0b0c")
+	info, _ := hex.DecodeString("f0f1f2f3f4f5f6f7f8f9")
+	okm := hex.EncodeToString(hkdfSha256(secret, salt, string(info), 32))
+	if okm != "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf" {
+		t.Fatalf("unexpected okm: %s", okm)
+	}
+}
+
+// decryptWebPush decrypts the body as a browser does with the private key of its subscription.
+func decryptWebPush(t *testing.T, body []byte, uaPrivate []byte, authSecret []byte) []byte {
+	curve := elliptic.P256()
+	salt := body[:16]
+	if rs := binary.BigEndian.Uint32(body[16:20]); rs != webPushRecordSize {
+		t.Fatalf("unexpected record size: %d", rs)
+	}
+	idlen := int(body[20])
+	asPublic := body[21 : 21+idlen]
+	asX, asY := elliptic.Unmarshal(curve, asPublic)
+	if asX == nil {
+		t.Fatal("the key of the application server is malformed")
+	}
+	ecdhSecret := p256SharedSecret(asX, asY, uaPrivate)
+	uaX, uaY := curve.ScalarBaseMult(uaPrivate)
+	keyInfo := "WebPush: info\x00" + string(elliptic.Marshal(curve, uaX, uaY)) + string(asPublic)
+	ikm := hkdfSha256(ecdhSecret, authSecret, keyInfo, 32)
+	cek := hkdfSha256(ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	nonce := hkdfSha256(ikm, salt, "Content-Encoding: nonce\x00", 12)
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	plaintext, err := gcm.Open(nil, nonce, body[21+idlen:], nil)
+	if err != nil {
+		t.Fatalf("the push could not be decrypted: %v", err)
+	}
+	if plaintext[len(plaintext)-1] != 0x02 {
+		t.Fatalf("the last record delimiter is missing")
+	}
+	return plaintext[:len(plaintext)-1]
+}
+
+func verifyVapidAuthorization(t *testing.T, authorization, audience string, vapidKey *ecdsa.PrivateKey) {
+	var token, publicKey string
+	for _, param := range strings.Split(strings.TrimPrefix(authorization, "vapid "), ",") {
+		param = strings.TrimSpace(param)
+		if strings.HasPrefix(param, "t=") {
+			token = param[2:]
+		} else if strings.HasPrefix(param, "k=") {
+			publicKey = param[2:]
+		}
+	}
+	expectedKey := elliptic.Marshal(elliptic.P256(), vapidKey.X, vapidKey.Y)
+	if publicKey != base64.RawURLEncoding.EncodeToString(expectedKey) {
+		t.Fatalf("unexpected public key: %s", publicKey)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("malformed token: %s", token)
+	}
+	var claims map[string]interface{}
+	claimsBytes, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	json.Unmarshal(claimsBytes, &claims)
+	if claims["aud"] != audience || claims["sub"] != "mailto:admin@example.com" {
+		t.Fatalf("unexpected claims: %v", claims)
+	}
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(&vapidKey.PublicKey, hash[:], r, s) {
+		t.Fatalf("the token signature is invalid")
+	}
+}
+
+func TestWebPushProviderSend(t *testing.T) {
+	uaPrivate, uaX, uaY, err := elliptic.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authSecret := make([]byte, 16)
+	rand.Read(authSecret)
+	vapidKey, _, _, err := elliptic.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := make(chan *http.Request, 10)
+	bodies := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests <- r
+		bodies <- body
+		if strings.HasSuffix(r.URL.Path, "/expired") {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	provider := WebPushProvider{
+		vapidPrivateKey: base64.RawURLEncoding.EncodeToString(vapidKey),
+		vapidSubject:    "mailto:admin@example.com",
+	}
+	device := &models.Device{
+		Platform: models.PLATFORM_WEB,
+		Token:    server.URL + "/push/subscription1",
+		P256dh:   base64.RawURLEncoding.EncodeToString(elliptic.Marshal(elliptic.P256(), uaX, uaY)),
+		Auth:     base64.URLEncoding.EncodeToString(authSecret),
+	}
+	if err := provider.send(context.Background(), device, "room-1", &MessageInfo{Text: "hello", Badge: 2}); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	r, body := <-requests, <-bodies
+	if r.Header.Get("Content-Encoding") != "aes128gcm" || r.Header.Get("TTL") == "" ||
+		r.Header.Get("Urgency") != "high" || r.Header.Get("Topic") != "room1" {
+		t.Fatalf("unexpected headers: %v", r.Header)
+	}
+	ecdsaVapidKey, _ := parseVapidKey(provider.vapidPrivateKey)
+	verifyVapidAuthorization(t, r.Header.Get("Authorization"), server.URL, ecdsaVapidKey)
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(decryptWebPush(t, body, uaPrivate, authSecret), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload["roomId"] != "room-1" || payload["message"] != "hello" || payload["badge"] != float64(2) {
+		t.Fatalf("unexpected payload: %v", payload)
+	}
+
+	device.Token = server.URL + "/push/expired"
+	err = provider.send(context.Background(), device, "room-1", &MessageInfo{Text: "hello"})
+	<-requests
+	<-bodies
+	if unregistered, ok := err.(*unregisteredTokenError); !ok || unregistered.token != device.Token {
+		t.Fatalf("an expired subscription was not reported: %v", err)
+	}
+}
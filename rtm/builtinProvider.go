@@ -0,0 +1,19 @@
+package rtm
+
+import "encoding/json"
+
+// BuiltinProvider delivers events to the WebSocket connections of this process.
+type BuiltinProvider struct{}
+
+func (provider BuiltinProvider) Init() error {
+	return nil
+}
+
+func (provider BuiltinProvider) PublishMessage(mi *MessagingInfo) error {
+	data, err := json.Marshal(mi.Event)
+	if err != nil {
+		return err
+	}
+	GetHub().Publish(data)
+	return nil
+}
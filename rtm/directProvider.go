@@ -1,12 +1,7 @@
 package rtm
 
 import (
-	"bytes"
 	"encoding/json"
-	"errors"
-	"io/ioutil"
-	"net/http"
-	"strconv"
 
 	"github.com/swagchat/chat-api/utils"
 )
@@ -18,18 +13,14 @@ func (provider DirectProvider) Init() error {
 }
 
 func (provider DirectProvider) PublishMessage(mi *MessagingInfo) error {
-	rawIn := json.RawMessage(mi.Message)
-	input, err := rawIn.MarshalJSON()
-	resp, err := http.Post(utils.AppendStrings(utils.Cfg.Rtm.DirectEndpoint, "/message"), "application/json", bytes.NewBuffer(input))
+	input, err := json.Marshal(mi.Event)
 	if err != nil {
 		return err
 	}
-	_, err = ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return err
+	if utils.Cfg.Rtm.ChannelMode != CHANNEL_MODE_USER {
+		return postEvent(utils.AppendStrings(utils.Cfg.Rtm.DirectEndpoint, "/message"), input)
 	}
-	if resp.StatusCode != http.StatusOK {
-		return errors.New(utils.AppendStrings("http status code[", strconv.Itoa(resp.StatusCode), "]"))
-	}
-	return nil
+	return postEventPerRecipient(mi.Event, input, func(userId string) string {
+		return utils.AppendStrings(utils.Cfg.Rtm.DirectEndpoint, "/users/", userId, "/message")
+	})
 }
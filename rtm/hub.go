@@ -0,0 +1,551 @@
+package rtm
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrHubDraining = errors.New("rtm: server is shutting down")
+
+	hub     *Hub
+	hubOnce sync.Once
+)
+
+// Hub keeps the WebSocket connections and Server-Sent Events streams of this process and routes events to them.
+// A connection receives events of the rooms its user belongs to.
+type Hub struct {
+	mu       sync.RWMutex
+	users    map[string]map[*client]struct{}
+	rooms    map[string]map[*client]struct{}
+	draining bool
+	wg       sync.WaitGroup
+
+	// epoch tells the event ids of this process from those of a previous one.
+	// It also identifies this process in the hub commands relayed to other instances.
+	epoch  string
+	seq    uint64
+	replay *replayBuffer
+
+	// relay sends membership changes to the other instances. It is nil for a single instance
+	relay func(data []byte)
+
+	// presence is told when the first connection of a user opens and when the last one closes
+	presence func(userId string, connected bool)
+}
+
+// hubCommand is a membership change relayed between instances.
+type hubCommand struct {
+	Origin  string   `json:"origin"`
+	Op      string   `json:"op"`
+	RoomId  string   `json:"roomId,omitempty"`
+	UserIds []string `json:"userIds,omitempty"`
+	Reason  string   `json:"reason,omitempty"`
+}
+
+const (
+	hubOpJoin           = "join"
+	hubOpLeave          = "leave"
+	hubOpRemoveRoom     = "removeRoom"
+	hubOpDisconnectUser = "disconnectUser"
+)
+
+// client is a WebSocket connection or a Server-Sent Events stream.
+type client struct {
+	hub    *Hub
+	userId string
+	send   chan *hubEvent
+
+	// forceClose interrupts a client that does not finish draining in time
+	forceClose func()
+
+	// rooms is guarded by hub.mu
+	rooms map[string]struct{}
+
+	closeOnce   sync.Once
+	closing     chan struct{}
+	closeCode   int
+	closeReason string
+}
+
+// routing is the part of an event the hub needs to find its receivers.
+type routing struct {
+	RoomId     string   `json:"roomId"`
+	EventName  string   `json:"eventName"`
+	Recipients []string `json:"recipients"`
+}
+
+type hubEvent struct {
+	id   string
+	seq  uint64
+	data []byte
+	routing
+}
+
+func GetHub() *Hub {
+	hubOnce.Do(func() {
+		hub = newHub()
+	})
+	return hub
+}
+
+func newHub() *Hub {
+	replaySize, _ := strconv.Atoi(utils.Cfg.Rtm.SseReplayBufferSize)
+	h := &Hub{
+		users:  make(map[string]map[*client]struct{}),
+		rooms:  make(map[string]map[*client]struct{}),
+		epoch:  strconv.FormatInt(time.Now().UnixNano(), 36),
+		replay: newReplayBuffer(replaySize),
+	}
+	if utils.Cfg.Rtm.Provider == "redis" {
+		h.relay = publishRedisHubCommand
+	}
+	return h
+}
+
+// HubEnabled reports whether this process serves real-time connections itself.
+func HubEnabled() bool {
+	return utils.Cfg.Rtm.Provider == "builtin" || utils.Cfg.Rtm.Provider == "redis"
+}
+
+// ServeWs upgrades the request and serves the connection until it is closed.
+// An error is returned only when the request was not upgraded, so that the caller can still respond.
+func (h *Hub) ServeWs(w http.ResponseWriter, r *http.Request, userId string, roomIds []string) error {
+	h.mu.RLock()
+	draining := h.draining
+	h.mu.RUnlock()
+	if draining {
+		return ErrHubDraining
+	}
+
+	conn, err := upgradeWs(w, r)
+	if err != nil {
+		return err
+	}
+
+	c := h.newClient(userId)
+	c.forceClose = func() {
+		conn.close()
+	}
+	h.wg.Add(1)
+	defer h.wg.Done()
+	if _, ok := h.register(c, roomIds, ""); !ok {
+		conn.writeClose(wsCloseGoingAway, "server is shutting down")
+		conn.close()
+		return nil
+	}
+	defer h.unregister(c)
+
+	writeDone := make(chan struct{})
+	go func() {
+		c.writePump(conn)
+		close(writeDone)
+	}()
+	c.readPump(conn)
+	<-writeDone
+	conn.close()
+	return nil
+}
+
+// Join subscribes the connections of the users to the room.
+func (h *Hub) Join(roomId string, userIds []string) {
+	h.join(roomId, userIds)
+	h.relayCommand(&hubCommand{Op: hubOpJoin, RoomId: roomId, UserIds: userIds})
+}
+
+// Leave unsubscribes the connections of the users from the room.
+func (h *Hub) Leave(roomId string, userIds []string) {
+	h.leave(roomId, userIds)
+	h.relayCommand(&hubCommand{Op: hubOpLeave, RoomId: roomId, UserIds: userIds})
+}
+
+// RemoveRoom unsubscribes every connection from the room.
+func (h *Hub) RemoveRoom(roomId string) {
+	h.removeRoom(roomId)
+	h.relayCommand(&hubCommand{Op: hubOpRemoveRoom, RoomId: roomId})
+}
+
+// DisconnectUser closes all connections of the user.
+func (h *Hub) DisconnectUser(userId string, reason string) {
+	h.disconnectUser(userId, reason)
+	h.relayCommand(&hubCommand{Op: hubOpDisconnectUser, UserIds: []string{userId}, Reason: reason})
+}
+
+func (h *Hub) join(roomId string, userIds []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, userId := range userIds {
+		for c := range h.users[userId] {
+			h.subscribeLocked(c, roomId)
+		}
+	}
+}
+
+func (h *Hub) leave(roomId string, userIds []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, userId := range userIds {
+		for c := range h.users[userId] {
+			h.unsubscribeLocked(c, roomId)
+		}
+	}
+}
+
+func (h *Hub) removeRoom(roomId string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.rooms[roomId] {
+		h.unsubscribeLocked(c, roomId)
+	}
+}
+
+func (h *Hub) disconnectUser(userId string, reason string) {
+	h.mu.RLock()
+	clients := make([]*client, 0, len(h.users[userId]))
+	for c := range h.users[userId] {
+		clients = append(clients, c)
+	}
+	h.mu.RUnlock()
+	for _, c := range clients {
+		c.stop(wsClosePolicyViolation, reason)
+	}
+}
+
+func (h *Hub) relayCommand(cmd *hubCommand) {
+	if h.relay == nil {
+		return
+	}
+	cmd.Origin = h.epoch
+	data, _ := json.Marshal(cmd)
+	h.relay(data)
+}
+
+// applyCommand applies a membership change relayed from another instance.
+func (h *Hub) applyCommand(data []byte) {
+	var cmd hubCommand
+	if err := json.Unmarshal(data, &cmd); err != nil || cmd.Origin == h.epoch {
+		return
+	}
+	switch cmd.Op {
+	case hubOpJoin:
+		h.join(cmd.RoomId, cmd.UserIds)
+	case hubOpLeave:
+		h.leave(cmd.RoomId, cmd.UserIds)
+	case hubOpRemoveRoom:
+		h.removeRoom(cmd.RoomId)
+	case hubOpDisconnectUser:
+		for _, userId := range cmd.UserIds {
+			h.disconnectUser(userId, cmd.Reason)
+		}
+	}
+}
+
+// Publish sends the event to the connections subscribed to its room, restricted to its recipients when they are set.
+// An event without a room is sent to the connections of its recipients.
+func (h *Hub) Publish(event []byte) {
+	var ro routing
+	if err := json.Unmarshal(event, &ro); err != nil || (ro.RoomId == "" && len(ro.Recipients) == 0) {
+		return
+	}
+
+	// The id is assigned under the lock so that queues and the replay buffer keep the same order
+	h.mu.Lock()
+	h.seq++
+	e := &hubEvent{
+		id:      utils.AppendStrings(h.epoch, "-", strconv.FormatUint(h.seq, 10)),
+		seq:     h.seq,
+		data:    event,
+		routing: ro,
+	}
+	h.replay.add(e)
+	clients := make([]*client, 0)
+	if ro.RoomId == "" {
+		for _, userId := range ro.Recipients {
+			for c := range h.users[userId] {
+				clients = append(clients, c)
+			}
+		}
+	} else {
+		for c := range h.rooms[ro.RoomId] {
+			if e.isFor(c) {
+				clients = append(clients, c)
+			}
+		}
+	}
+	h.mu.Unlock()
+
+	for _, c := range clients {
+		c.enqueue(e)
+	}
+}
+
+// Drain stops accepting connections and closes the open ones after flushing their queued events.
+// Connections that are still open after the timeout are closed forcibly.
+func (h *Hub) Drain(timeout time.Duration) {
+	h.mu.Lock()
+	h.draining = true
+	clients := make([]*client, 0)
+	for _, userClients := range h.users {
+		for c := range userClients {
+			clients = append(clients, c)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, c := range clients {
+		c.stop(wsCloseGoingAway, "server is shutting down")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		for _, c := range clients {
+			if c.forceClose != nil {
+				c.forceClose()
+			}
+		}
+	}
+	utils.AppLogger.Info("",
+		zap.String("msg", "Real-time connections drained."),
+		zap.Int("count", len(clients)),
+	)
+}
+
+func (h *Hub) newClient(userId string) *client {
+	bufferSize, _ := strconv.Atoi(utils.Cfg.Rtm.WsSendBufferSize)
+	if bufferSize <= 0 {
+		bufferSize = 256
+	}
+	return &client{
+		hub:     h,
+		userId:  userId,
+		send:    make(chan *hubEvent, bufferSize),
+		rooms:   make(map[string]struct{}),
+		closing: make(chan struct{}),
+	}
+}
+
+// register adds the client to the hub. When lastEventId is not empty, it also returns the buffered
+// events after that id which the client would have received. They are collected under the same lock,
+// so that no event is lost or sent twice between the replay and the queue.
+func (h *Hub) register(c *client, roomIds []string, lastEventId string) ([]*hubEvent, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.draining {
+		return nil, false
+	}
+	if h.users[c.userId] == nil {
+		h.users[c.userId] = make(map[*client]struct{})
+		if h.presence != nil {
+			go h.presence(c.userId, true)
+		}
+	}
+	h.users[c.userId][c] = struct{}{}
+	for _, roomId := range roomIds {
+		h.subscribeLocked(c, roomId)
+	}
+	if lastEventId == "" {
+		return nil, true
+	}
+	return h.replayLocked(c, lastEventId), true
+}
+
+// replayLocked returns the buffered events after lastEventId for the client.
+// When the events after lastEventId are no longer buffered, or the id comes from a previous process,
+// a single resync event is returned instead so that the client fetches the current state again.
+func (h *Hub) replayLocked(c *client, lastEventId string) []*hubEvent {
+	after, ok := h.parseEventId(lastEventId)
+	if !ok || (after < h.seq && (h.replay.oldest() == 0 || h.replay.oldest() > after+1)) {
+		data, _ := json.Marshal(models.NewEvent(models.EVENT_NAME_RESYNC, "", "", nil))
+		return []*hubEvent{&hubEvent{
+			data: data,
+		}}
+	}
+
+	var events []*hubEvent
+	for _, e := range h.replay.since(after) {
+		if _, ok := c.rooms[e.RoomId]; (ok || e.RoomId == "") && e.isFor(c) {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+func (h *Hub) parseEventId(eventId string) (uint64, bool) {
+	i := strings.LastIndex(eventId, "-")
+	if i < 0 || eventId[:i] != h.epoch {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(eventId[i+1:], 10, 64)
+	if err != nil || seq > h.seq {
+		return 0, false
+	}
+	return seq, true
+}
+
+func (h *Hub) unregister(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for roomId := range c.rooms {
+		h.unsubscribeLocked(c, roomId)
+	}
+	delete(h.users[c.userId], c)
+	if len(h.users[c.userId]) == 0 {
+		delete(h.users, c.userId)
+		if h.presence != nil {
+			go h.presence(c.userId, false)
+		}
+	}
+}
+
+// SetPresenceListener sets the function told about users coming online and going offline on this process.
+func (h *Hub) SetPresenceListener(listener func(userId string, connected bool)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.presence = listener
+}
+
+// ConnectedUserIds returns the users who have a connection to this process.
+func (h *Hub) ConnectedUserIds() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	userIds := make([]string, 0, len(h.users))
+	for userId := range h.users {
+		userIds = append(userIds, userId)
+	}
+	return userIds
+}
+
+func (h *Hub) subscribeLocked(c *client, roomId string) {
+	if h.rooms[roomId] == nil {
+		h.rooms[roomId] = make(map[*client]struct{})
+	}
+	h.rooms[roomId][c] = struct{}{}
+	c.rooms[roomId] = struct{}{}
+}
+
+func (h *Hub) unsubscribeLocked(c *client, roomId string) {
+	delete(h.rooms[roomId], c)
+	if len(h.rooms[roomId]) == 0 {
+		delete(h.rooms, roomId)
+	}
+	delete(c.rooms, roomId)
+}
+
+func (e *hubEvent) isFor(c *client) bool {
+	return len(e.Recipients) == 0 || utils.SearchStringValueInSlice(e.Recipients, c.userId)
+}
+
+// enqueue never blocks the publisher. A connection whose queue is full is closed.
+func (c *client) enqueue(e *hubEvent) {
+	select {
+	case <-c.closing:
+		return
+	default:
+	}
+	select {
+	case c.send <- e:
+	default:
+		c.stop(wsClosePolicyViolation, "too slow to receive events")
+	}
+}
+
+// stop makes writePump send a close frame with the code.
+func (c *client) stop(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.closeCode = code
+		c.closeReason = reason
+		close(c.closing)
+	})
+}
+
+func (c *client) readPump(conn *wsConn) {
+	pongWait := 2 * wsPingInterval()
+	conn.setReadDeadline(time.Now().Add(pongWait))
+	conn.onControl(func() {
+		conn.setReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		// Clients have nothing to send but control frames; data messages are ignored.
+		if _, err := conn.readMessage(); err != nil {
+			// A protocol error is answered with its close frame by gorilla/websocket itself
+			if err == websocket.ErrReadLimit {
+				c.stop(wsCloseTooBig, "")
+			} else {
+				// Either the client closed the connection, or it is gone and a close frame can not be delivered
+				c.stop(wsCloseNormal, "")
+			}
+			return
+		}
+		conn.setReadDeadline(time.Now().Add(pongWait))
+	}
+}
+
+func (c *client) writePump(conn *wsConn) {
+	ticker := time.NewTicker(wsPingInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case e := <-c.send:
+			if err := conn.writeText(e.data); err != nil {
+				c.stop(wsCloseNormal, "")
+				conn.close()
+				return
+			}
+		case <-ticker.C:
+			if err := conn.writePing(); err != nil {
+				c.stop(wsCloseNormal, "")
+				conn.close()
+				return
+			}
+		case <-c.closing:
+			if c.closeCode == wsCloseGoingAway {
+				c.flush(func(e *hubEvent) error {
+					return conn.writeText(e.data)
+				})
+			}
+			conn.writeClose(c.closeCode, c.closeReason)
+			// Give the client a moment to answer the close frame before readPump gives up
+			conn.setReadDeadline(time.Now().Add(wsWriteTimeout))
+			return
+		}
+	}
+}
+
+// flush writes the events left in the queue.
+func (c *client) flush(write func(e *hubEvent) error) {
+	for {
+		select {
+		case e := <-c.send:
+			if err := write(e); err != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func wsPingInterval() time.Duration {
+	interval, _ := strconv.Atoi(utils.Cfg.Rtm.WsPingInterval)
+	if interval <= 0 {
+		interval = 30
+	}
+	return time.Duration(interval) * time.Second
+}
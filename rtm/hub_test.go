@@ -0,0 +1,334 @@
+package rtm
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/swagchat/chat-api/utils"
+)
+
+// setupWsConfig sets the config of WebSocket connections, and returns the function restoring it.
+func setupWsConfig(pingInterval, sendBufferSize string) func() {
+	rtmCfg := *utils.Cfg.Rtm
+	utils.Cfg.Rtm.WsPingInterval = pingInterval
+	utils.Cfg.Rtm.WsSendBufferSize = sendBufferSize
+	return func() {
+		*utils.Cfg.Rtm = rtmCfg
+	}
+}
+
+func testHub() *Hub {
+	h := newHub()
+	h.relay = nil
+	return h
+}
+
+type wsTestFrame struct {
+	opcode  int
+	payload string
+	code    int
+}
+
+type wsTestClient struct {
+	conn     *websocket.Conn
+	raw      net.Conn
+	frames   chan wsTestFrame
+	readOnce sync.Once
+	done     chan error
+	served   bool
+}
+
+// connectWs serves a connection of the user over a pipe, and returns the client end once the user is registered.
+func connectWs(t *testing.T, h *Hub, userId string, roomIds []string) *wsTestClient {
+	c := &wsTestClient{
+		frames: make(chan wsTestFrame, 256),
+		done:   make(chan error, 1),
+	}
+	c.conn, c.raw = dialPipeWs(t, func(w http.ResponseWriter, r *http.Request) {
+		c.done <- h.ServeWs(w, r, userId, roomIds)
+	})
+
+	deadline := time.Now().Add(time.Second)
+	for !utils.SearchStringValueInSlice(h.ConnectedUserIds(), userId) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s was not registered", userId)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return c
+}
+
+// read starts reading the frames sent by the server. Until then the server is blocked writing to the pipe.
+// The control frames are only recorded, so that the tests answer them.
+func (c *wsTestClient) read() {
+	c.readOnce.Do(func() {
+		c.conn.SetPingHandler(func(appData string) error {
+			c.frames <- wsTestFrame{opcode: websocket.PingMessage, payload: appData}
+			return nil
+		})
+		c.conn.SetPongHandler(func(appData string) error {
+			c.frames <- wsTestFrame{opcode: websocket.PongMessage, payload: appData}
+			return nil
+		})
+		c.conn.SetCloseHandler(func(code int, text string) error {
+			c.frames <- wsTestFrame{opcode: websocket.CloseMessage, code: code}
+			return nil
+		})
+		go func() {
+			defer close(c.frames)
+			for {
+				opcode, payload, err := c.conn.ReadMessage()
+				if err != nil {
+					// Keeps the server from blocking on the frames written after the close frame
+					io.Copy(ioutil.Discard, c.raw)
+					return
+				}
+				c.frames <- wsTestFrame{opcode: opcode, payload: string(payload)}
+			}
+		}()
+	})
+}
+
+func (c *wsTestClient) next(t *testing.T) wsTestFrame {
+	c.read()
+	select {
+	case frame, ok := <-c.frames:
+		if !ok {
+			t.Fatalf("the connection was closed")
+		}
+		return frame
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no frame was received")
+	}
+	return wsTestFrame{}
+}
+
+func (c *wsTestClient) expectFrame(t *testing.T, opcode int, payload string) {
+	if frame := c.next(t); frame.opcode != opcode || frame.payload != payload {
+		t.Fatalf("Frame Failure\n[expected]%d %s\n[result  ]%d %s", opcode, payload, frame.opcode, frame.payload)
+	}
+}
+
+// expectClose reads the frames up to the close frame, and returns its code and the data messages before it.
+func (c *wsTestClient) expectClose(t *testing.T) (int, []string) {
+	var messages []string
+	for {
+		frame := c.next(t)
+		switch frame.opcode {
+		case websocket.TextMessage:
+			messages = append(messages, frame.payload)
+		case websocket.CloseMessage:
+			return frame.code, messages
+		}
+	}
+}
+
+func (c *wsTestClient) writeControl(t *testing.T, opcode int, payload string) {
+	if err := c.conn.WriteControl(opcode, []byte(payload), time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("the frame could not be written: %v", err)
+	}
+}
+
+func (c *wsTestClient) writeClose(t *testing.T) {
+	c.writeControl(t, websocket.CloseMessage, string(websocket.FormatCloseMessage(wsCloseNormal, "")))
+}
+
+// close closes the client end and waits for the connection to be served to the end.
+func (c *wsTestClient) close(t *testing.T) {
+	c.raw.Close()
+	c.wait(t)
+}
+
+// wait waits for ServeWs to return.
+func (c *wsTestClient) wait(t *testing.T) {
+	if c.served {
+		return
+	}
+	select {
+	case err := <-c.done:
+		c.served = true
+		if err != nil {
+			t.Fatalf("ServeWs failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the connection was not closed")
+	}
+}
+
+func TestHubPublish(t *testing.T) {
+	defer setupWsConfig("30", "256")()
+	h := testHub()
+
+	c1 := connectWs(t, h, "hub-user-1", []string{"hub-room-1"})
+	defer c1.close(t)
+	c2 := connectWs(t, h, "hub-user-2", []string{"hub-room-1"})
+	defer c2.close(t)
+	c3 := connectWs(t, h, "hub-user-3", []string{"hub-room-2"})
+	defer c3.close(t)
+
+	event1 := `{"roomId":"hub-room-1","eventName":"message"}`
+	event2 := `{"roomId":"hub-room-1","eventName":"message","recipients":["hub-user-2"]}`
+	event3 := `{"roomId":"hub-room-2","eventName":"message"}`
+	event4 := `{"eventName":"userUpdate","recipients":["hub-user-1","hub-user-3"]}`
+	for _, event := range []string{event1, event2, event3, event4, `invalid`, `{"eventName":"message"}`} {
+		h.Publish([]byte(event))
+	}
+
+	// Each connection receives the events of its rooms for its user, in order
+	c1.expectFrame(t, websocket.TextMessage, event1)
+	c1.expectFrame(t, websocket.TextMessage, event4)
+	c2.expectFrame(t, websocket.TextMessage, event1)
+	c2.expectFrame(t, websocket.TextMessage, event2)
+	c3.expectFrame(t, websocket.TextMessage, event3)
+	c3.expectFrame(t, websocket.TextMessage, event4)
+
+	// Joining and leaving change the rooms of the connections of the user
+	h.join("hub-room-2", []string{"hub-user-1"})
+	h.leave("hub-room-1", []string{"hub-user-2"})
+	event5 := `{"roomId":"hub-room-2","eventName":"message","recipients":["hub-user-1","hub-user-2"]}`
+	event6 := `{"roomId":"hub-room-1","eventName":"message"}`
+	h.Publish([]byte(event5))
+	h.Publish([]byte(event6))
+	c1.expectFrame(t, websocket.TextMessage, event5)
+	c1.expectFrame(t, websocket.TextMessage, event6)
+
+	// A client closing the connection is answered with a close frame and unregistered
+	c2.writeClose(t)
+	if code, _ := c2.expectClose(t); code != wsCloseNormal {
+		t.Fatalf("unexpected close code: %d", code)
+	}
+	c2.wait(t)
+	if utils.SearchStringValueInSlice(h.ConnectedUserIds(), "hub-user-2") {
+		t.Fatalf("hub-user-2 is still registered")
+	}
+}
+
+func TestHubPingPong(t *testing.T) {
+	defer setupWsConfig("1", "256")()
+	h := testHub()
+
+	c := connectWs(t, h, "hub-user-1", []string{"hub-room-1"})
+	defer c.close(t)
+
+	// A ping of the client is answered with its payload
+	c.writeControl(t, websocket.PingMessage, "are you there")
+	c.expectFrame(t, websocket.PongMessage, "are you there")
+
+	// The server pings the client every WsPingInterval
+	c.expectFrame(t, websocket.PingMessage, "")
+	c.writeControl(t, websocket.PongMessage, "")
+
+	// A protocol error closes the connection with its code
+	unmasked := []byte{0x80 | websocket.TextMessage, 0x02, 'h', 'i'}
+	if _, err := c.raw.Write(unmasked); err != nil {
+		t.Fatalf("the frame could not be written: %v", err)
+	}
+	if code, _ := c.expectClose(t); code != wsCloseProtocolError {
+		t.Fatalf("unexpected close code: %d", code)
+	}
+}
+
+func TestHubSlowConsumer(t *testing.T) {
+	defer setupWsConfig("30", "1")()
+	h := testHub()
+
+	c := connectWs(t, h, "hub-user-1", []string{"hub-room-1"})
+	defer c.close(t)
+
+	// The client reads nothing, so its queue overflows and it is closed without blocking the publisher
+	published := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			h.Publish([]byte(`{"roomId":"hub-room-1","eventName":"message"}`))
+		}
+		close(published)
+	}()
+	select {
+	case <-published:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Publish was blocked by a slow consumer")
+	}
+
+	code, messages := c.expectClose(t)
+	if code != wsClosePolicyViolation {
+		t.Fatalf("unexpected close code: %d", code)
+	}
+	if len(messages) > 2 {
+		t.Fatalf("%d events were sent past the queue of 1", len(messages))
+	}
+	c.writeClose(t)
+	c.wait(t)
+
+	// Enqueueing to a stopped client does nothing
+	stopped := h.newClient("hub-user-2")
+	stopped.stop(wsCloseNormal, "")
+	stopped.enqueue(&hubEvent{})
+	stopped.enqueue(&hubEvent{})
+	if len(stopped.send) != 0 {
+		t.Fatalf("an event was queued to a stopped client")
+	}
+}
+
+func TestHubDrain(t *testing.T) {
+	defer setupWsConfig("30", "256")()
+	h := testHub()
+
+	c1 := connectWs(t, h, "hub-user-1", []string{"hub-room-1"})
+	defer c1.close(t)
+	c2 := connectWs(t, h, "hub-user-2", []string{"hub-room-1"})
+	defer c2.close(t)
+
+	events := []string{
+		`{"roomId":"hub-room-1","eventName":"message","recipients":["hub-user-1"],"n":1}`,
+		`{"roomId":"hub-room-1","eventName":"message","recipients":["hub-user-1"],"n":2}`,
+		`{"roomId":"hub-room-1","eventName":"message","recipients":["hub-user-1"],"n":3}`,
+	}
+	for _, event := range events {
+		h.Publish([]byte(event))
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		h.Drain(500 * time.Millisecond)
+		close(drained)
+	}()
+
+	// The queued events are flushed before the close frame
+	code, messages := c1.expectClose(t)
+	if code != wsCloseGoingAway {
+		t.Fatalf("unexpected close code: %d", code)
+	}
+	if len(messages) != len(events) {
+		t.Fatalf("%d of %d queued events were flushed", len(messages), len(events))
+	}
+	for i := range events {
+		if messages[i] != events[i] {
+			t.Fatalf("Event Failure\n[expected]%s\n[result  ]%s", events[i], messages[i])
+		}
+	}
+	c1.writeClose(t)
+	c1.wait(t)
+
+	// A client not answering the close frame is closed when the timeout expires
+	if code, _ := c2.expectClose(t); code != wsCloseGoingAway {
+		t.Fatalf("unexpected close code: %d", code)
+	}
+	select {
+	case <-drained:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Drain did not return")
+	}
+	c2.wait(t)
+
+	// No connection is accepted while draining
+	if err := h.ServeWs(httptest.NewRecorder(), wsRequest("dGhlIHNhbXBsZSBub25jZQ=="), "hub-user-3", nil); err != ErrHubDraining {
+		t.Fatalf("a connection was accepted while draining: %v", err)
+	}
+}
@@ -1,12 +1,7 @@
 package rtm
 
 import (
-	"bytes"
 	"encoding/json"
-	"errors"
-	"io/ioutil"
-	"net/http"
-	"strconv"
 
 	"github.com/swagchat/chat-api/utils"
 )
@@ -18,19 +13,27 @@ func (provider NsqProvider) Init() error {
 }
 
 func (provider NsqProvider) PublishMessage(mi *MessagingInfo) error {
-	rawIn := json.RawMessage(mi.Message)
-	input, err := rawIn.MarshalJSON()
-	url := utils.AppendStrings(utils.Cfg.Rtm.QueEndpoint, "/pub?topic=", utils.Cfg.Rtm.QueTopic)
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(input))
+	input, err := json.Marshal(mi.Event)
 	if err != nil {
 		return err
 	}
-	_, err = ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return err
+	if utils.Cfg.Rtm.ChannelMode != CHANNEL_MODE_USER {
+		return postEvent(nsqPubUrl(utils.Cfg.Rtm.QueTopic), input)
 	}
-	if resp.StatusCode != http.StatusOK {
-		return errors.New(utils.AppendStrings("http status code[", strconv.Itoa(resp.StatusCode), "]"))
+	return postEventPerRecipient(mi.Event, input, func(userId string) string {
+		return nsqPubUrl(nsqUserTopic(userId))
+	})
+}
+
+func nsqPubUrl(topic string) string {
+	return utils.AppendStrings(utils.Cfg.Rtm.QueEndpoint, "/pub?topic=", topic)
+}
+
+// nsqUserTopic is the topic of a user in user channel mode.
+// Topic names of nsq can not contain ":", so user:{id} is written user.{id}, after QueTopic when it is set.
+func nsqUserTopic(userId string) string {
+	if utils.Cfg.Rtm.QueTopic == "" {
+		return utils.AppendStrings("user.", userId)
 	}
-	return nil
+	return utils.AppendStrings(utils.Cfg.Rtm.QueTopic, ".user.", userId)
 }
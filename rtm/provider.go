@@ -1,14 +1,23 @@
 package rtm
 
 import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
 	"os"
+	"strconv"
 
+	"github.com/swagchat/chat-api/models"
 	"github.com/swagchat/chat-api/utils"
 	"go.uber.org/zap"
 )
 
+// CHANNEL_MODE_USER makes the direct and nsq providers publish each event to a channel per recipient.
+const CHANNEL_MODE_USER = "user"
+
 type MessagingInfo struct {
-	Message string
+	Event *models.Event
 }
 
 type Provider interface {
@@ -25,6 +34,10 @@ func GetMessagingProvider() Provider {
 		provider = &DirectProvider{}
 	case "nsq":
 		provider = &NsqProvider{}
+	case "builtin":
+		provider = &BuiltinProvider{}
+	case "redis":
+		provider = &RedisProvider{}
 	default:
 		utils.AppLogger.Error("",
 			zap.String("msg", "utils.Cfg.Rtm.Provider is incorrect"),
@@ -33,3 +46,30 @@ func GetMessagingProvider() Provider {
 	}
 	return provider
 }
+
+func postEvent(url string, input []byte) error {
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(input))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(utils.AppendStrings("http status code[", strconv.Itoa(resp.StatusCode), "]"))
+	}
+	return nil
+}
+
+// postEventPerRecipient posts the event to the channel of every recipient, and returns the last error.
+func postEventPerRecipient(event *models.Event, input []byte, channelUrl func(userId string) string) error {
+	var lastErr error
+	for _, userId := range event.Recipients {
+		if err := postEvent(channelUrl(userId), input); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
@@ -0,0 +1,90 @@
+package rtm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func recordingServer() (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.RequestURI())
+		mu.Unlock()
+		if strings.Contains(r.URL.RequestURI(), "fail") {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		sort.Strings(requests)
+		return requests
+	}
+}
+
+// setupChannelMode returns the function restoring the config.
+func setupChannelMode(mode string) func() {
+	rtmCfg := *utils.Cfg.Rtm
+	utils.Cfg.Rtm.ChannelMode = mode
+	return func() {
+		*utils.Cfg.Rtm = rtmCfg
+	}
+}
+
+func TestDirectProviderChannelMode(t *testing.T) {
+	server, requests := recordingServer()
+	defer server.Close()
+	defer setupChannelMode("room")()
+	utils.Cfg.Rtm.DirectEndpoint = server.URL
+
+	event := models.NewEvent(models.EVENT_NAME_MESSAGE, "room1", "user1", nil)
+	event.Recipients = []string{"user1", "user2"}
+	if err := (DirectProvider{}).PublishMessage(&MessagingInfo{Event: event}); err != nil {
+		t.Fatalf("PublishMessage failed: %v", err)
+	}
+	if got := strings.Join(requests(), ","); got != "/message" {
+		t.Fatalf("room mode posted to %s", got)
+	}
+
+	utils.Cfg.Rtm.ChannelMode = CHANNEL_MODE_USER
+	if err := (DirectProvider{}).PublishMessage(&MessagingInfo{Event: event}); err != nil {
+		t.Fatalf("PublishMessage failed: %v", err)
+	}
+	if got := strings.Join(requests(), ","); got != "/message,/users/user1/message,/users/user2/message" {
+		t.Fatalf("user mode posted to %s", got)
+	}
+
+	event.Recipients = []string{"fail", "user3"}
+	if err := (DirectProvider{}).PublishMessage(&MessagingInfo{Event: event}); err == nil {
+		t.Fatalf("an error of a recipient was not returned")
+	}
+	if got := requests(); got[len(got)-1] != "/users/user3/message" {
+		t.Fatalf("recipients after an error were skipped: %v", got)
+	}
+}
+
+func TestNsqProviderChannelMode(t *testing.T) {
+	server, requests := recordingServer()
+	defer server.Close()
+	defer setupChannelMode(CHANNEL_MODE_USER)()
+	utils.Cfg.Rtm.QueEndpoint = server.URL
+	utils.Cfg.Rtm.QueTopic = "swagchat"
+
+	event := models.NewEvent(models.EVENT_NAME_USER_UPDATE, "", "user1", nil)
+	event.Recipients = []string{"user1", "user2"}
+	if err := (NsqProvider{}).PublishMessage(&MessagingInfo{Event: event}); err != nil {
+		t.Fatalf("PublishMessage failed: %v", err)
+	}
+	if got := strings.Join(requests(), ","); got != "/pub?topic=swagchat.user.user1,/pub?topic=swagchat.user.user2" {
+		t.Fatalf("user mode published to %s", got)
+	}
+}
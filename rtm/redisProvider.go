@@ -0,0 +1,208 @@
+package rtm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/swagchat/chat-api/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	redisDialTimeout    = 5 * time.Second
+	redisCommandTimeout = 5 * time.Second
+)
+
+// RedisProvider publishes events to a redis channel per room, and events without a room to the users channel.
+// Every API instance subscribes with RunRedisSubscriber and delivers them to its own connections.
+type RedisProvider struct{}
+
+var (
+	redisPoolInstance *redis.Pool
+	redisPoolOnce     sync.Once
+)
+
+// getRedisPool limits the open connections to RedisPoolSize and keeps the idle ones for reuse.
+func getRedisPool() *redis.Pool {
+	redisPoolOnce.Do(func() {
+		poolSize, _ := strconv.Atoi(utils.Cfg.Rtm.RedisPoolSize)
+		if poolSize <= 0 {
+			poolSize = 10
+		}
+		redisPoolInstance = &redis.Pool{
+			MaxIdle:   poolSize,
+			MaxActive: poolSize,
+			Wait:      true,
+			Dial:      dialRedis,
+		}
+	})
+	return redisPoolInstance
+}
+
+func dialRedis() (redis.Conn, error) {
+	database, _ := strconv.Atoi(utils.Cfg.Rtm.RedisDatabase)
+	return redis.Dial("tcp", utils.Cfg.Rtm.RedisEndpoint,
+		redis.DialConnectTimeout(redisDialTimeout),
+		redis.DialReadTimeout(redisCommandTimeout),
+		redis.DialWriteTimeout(redisCommandTimeout),
+		redis.DialPassword(utils.Cfg.Rtm.RedisPassword),
+		redis.DialDatabase(database),
+	)
+}
+
+// redisDo runs a command on a pooled connection.
+func redisDo(command string, args ...interface{}) (interface{}, error) {
+	c := getRedisPool().Get()
+	defer c.Close()
+	return c.Do(command, args...)
+}
+
+func (provider RedisProvider) Init() error {
+	_, err := redisDo("PING")
+	return err
+}
+
+func (provider RedisProvider) PublishMessage(mi *MessagingInfo) error {
+	channel := redisUsersChannel()
+	if mi.Event.RoomId != "" {
+		channel = redisRoomChannel(mi.Event.RoomId)
+	} else if len(mi.Event.Recipients) == 0 {
+		return errors.New("rtm: roomId or recipients are required to publish an event")
+	}
+	data, err := json.Marshal(mi.Event)
+	if err != nil {
+		return err
+	}
+	_, err = redisDo("PUBLISH", channel, data)
+	return err
+}
+
+// RunRedisSubscriber delivers the events published by every instance to the hub of this process until ctx is done.
+// It reconnects with backoff when the subscription is lost.
+func RunRedisSubscriber(ctx context.Context) {
+	if utils.Cfg.Rtm.Provider != "redis" {
+		return
+	}
+
+	backoff := time.Second
+	for {
+		subscribed, err := subscribeRedis(ctx, GetHub())
+		if ctx.Err() != nil {
+			return
+		}
+		if subscribed {
+			backoff = time.Second
+		}
+		utils.AppLogger.Error("",
+			zap.String("msg", "Redis subscription error."),
+			zap.String("err", err.Error()),
+		)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+// subscribeRedis receives messages until the connection fails. subscribed tells whether the subscription was made.
+// The connection is pinged every RedisPingInterval, and given up when nothing, not even a pong, arrives for twice as long,
+// so that a connection dropped without a FIN or RST is noticed.
+func subscribeRedis(ctx context.Context, h *Hub) (subscribed bool, err error) {
+	conn, err := dialRedis()
+	if err != nil {
+		return false, err
+	}
+	c := redis.PubSubConn{Conn: conn}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.Close()
+		case <-done:
+		}
+	}()
+	defer c.Close()
+
+	hubChannel := redisHubChannel()
+	if err := c.PSubscribe(redisRoomChannel("*")); err != nil {
+		return false, err
+	}
+	if err := c.Subscribe(hubChannel, redisUsersChannel()); err != nil {
+		return false, err
+	}
+
+	// From here on only the pinger writes to the connection
+	pingInterval := redisPingInterval()
+	go func() {
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := c.Ping(""); err != nil {
+					c.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		switch v := c.ReceiveWithTimeout(2 * pingInterval).(type) {
+		case error:
+			return subscribed, v
+		case redis.Subscription:
+			subscribed = true
+		case redis.PMessage:
+			h.Publish(v.Data)
+		case redis.Message:
+			if v.Channel == hubChannel {
+				h.applyCommand(v.Data)
+			} else {
+				h.Publish(v.Data)
+			}
+		}
+	}
+}
+
+func redisPingInterval() time.Duration {
+	interval, _ := strconv.Atoi(utils.Cfg.Rtm.RedisPingInterval)
+	if interval <= 0 {
+		interval = 30
+	}
+	return time.Duration(interval) * time.Second
+}
+
+// publishRedisHubCommand tells the other instances about a membership change made on this one.
+func publishRedisHubCommand(data []byte) {
+	if _, err := redisDo("PUBLISH", redisHubChannel(), data); err != nil {
+		utils.AppLogger.Error("",
+			zap.String("msg", "Redis publish hub command error."),
+			zap.String("err", err.Error()),
+		)
+	}
+}
+
+func redisRoomChannel(roomId string) string {
+	return utils.AppendStrings(utils.Cfg.Rtm.RedisChannelPrefix, "room:", roomId)
+}
+
+func redisHubChannel() string {
+	return utils.AppendStrings(utils.Cfg.Rtm.RedisChannelPrefix, "hub")
+}
+
+func redisUsersChannel() string {
+	return utils.AppendStrings(utils.Cfg.Rtm.RedisChannelPrefix, "users")
+}
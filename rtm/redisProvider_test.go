@@ -0,0 +1,428 @@
+package rtm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+// fakeRedis is an in-process stand-in of a redis server supporting the commands used by the provider.
+type fakeRedis struct {
+	listener net.Listener
+
+	mu          sync.Mutex
+	conns       int
+	commands    []string
+	subscribers map[*fakeRedisConn][]string
+	// stalled makes the server read commands without replying, like a peer gone without closing the connection
+	stalled bool
+}
+
+type fakeRedisConn struct {
+	mu     sync.Mutex
+	writer *bufio.Writer
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	fr := &fakeRedis{
+		listener:    listener,
+		subscribers: make(map[*fakeRedisConn][]string),
+	}
+	go fr.serve()
+	return fr
+}
+
+func (fr *fakeRedis) addr() string {
+	return fr.listener.Addr().String()
+}
+
+func (fr *fakeRedis) close() {
+	fr.listener.Close()
+}
+
+func (fr *fakeRedis) serve() {
+	for {
+		conn, err := fr.listener.Accept()
+		if err != nil {
+			return
+		}
+		fr.mu.Lock()
+		fr.conns++
+		fr.mu.Unlock()
+		go fr.handle(conn)
+	}
+}
+
+func (fr *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	fc := &fakeRedisConn{writer: bufio.NewWriter(conn)}
+	defer func() {
+		fr.mu.Lock()
+		delete(fr.subscribers, fc)
+		fr.mu.Unlock()
+	}()
+
+	for {
+		args, err := readFakeRedisCommand(reader)
+		if err != nil {
+			return
+		}
+		fr.mu.Lock()
+		fr.commands = append(fr.commands, strings.TrimSpace(strings.Join(args, " ")))
+		stalled := fr.stalled
+		_, subscribed := fr.subscribers[fc]
+		fr.mu.Unlock()
+		if stalled {
+			continue
+		}
+
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			if subscribed {
+				fc.write("*2\r\n$4\r\npong\r\n$0\r\n\r\n")
+			} else {
+				fc.write("+PONG\r\n")
+			}
+		case "AUTH", "SELECT":
+			fc.write("+OK\r\n")
+		case "SUBSCRIBE", "PSUBSCRIBE":
+			// Each channel is answered with its own reply
+			kind := strings.ToLower(args[0])
+			for _, channel := range args[1:] {
+				fr.mu.Lock()
+				fr.subscribers[fc] = append(fr.subscribers[fc], channel)
+				count := len(fr.subscribers[fc])
+				fr.mu.Unlock()
+				fc.write(fmt.Sprintf("*3\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n:%d\r\n", len(kind), kind, len(channel), channel, count))
+			}
+		case "PUBLISH":
+			fc.write(fmt.Sprintf(":%d\r\n", fr.publish(args[1], args[2])))
+		default:
+			fc.write("-ERR unknown command\r\n")
+		}
+	}
+}
+
+func (fr *fakeRedis) publish(channel, message string) int {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	receivers := 0
+	for fc, patterns := range fr.subscribers {
+		for _, pattern := range patterns {
+			switch {
+			case pattern == channel:
+				fc.write(fmt.Sprintf("*3\r\n$7\r\nmessage\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(channel), channel, len(message), message))
+			case strings.HasSuffix(pattern, "*") && strings.HasPrefix(channel, strings.TrimSuffix(pattern, "*")):
+				fc.write(fmt.Sprintf("*4\r\n$8\r\npmessage\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(pattern), pattern, len(channel), channel, len(message), message))
+			default:
+				continue
+			}
+			receivers++
+		}
+	}
+	return receivers
+}
+
+func (fr *fakeRedis) stall() {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.stalled = true
+}
+
+func (fr *fakeRedis) commandCount(command string) int {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	count := 0
+	for _, c := range fr.commands {
+		if c == command {
+			count++
+		}
+	}
+	return count
+}
+
+func (fr *fakeRedis) subscriberCount() int {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	count := 0
+	for _, patterns := range fr.subscribers {
+		count += len(patterns)
+	}
+	return count
+}
+
+func (fr *fakeRedis) connCount() int {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	return fr.conns
+}
+
+func (fr *fakeRedis) hasCommand(command string) bool {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	for _, c := range fr.commands {
+		if c == command {
+			return true
+		}
+	}
+	return false
+}
+
+func (fc *fakeRedisConn) write(reply string) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.writer.WriteString(reply)
+	fc.writer.Flush()
+}
+
+func readFakeRedisCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil || line[0] != '*' || n == 0 {
+		return nil, errors.New("fake redis: protocol error")
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err = reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		length, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, length+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:length])
+	}
+	return args, nil
+}
+
+// setupRedisConfig points the provider at the fake server, and returns the function restoring the config.
+func setupRedisConfig(fr *fakeRedis) func() {
+	rtmCfg := *utils.Cfg.Rtm
+	utils.Cfg.Rtm.Provider = "redis"
+	utils.Cfg.Rtm.RedisEndpoint = fr.addr()
+	utils.Cfg.Rtm.RedisPassword = "secret"
+	utils.Cfg.Rtm.RedisDatabase = "2"
+	utils.Cfg.Rtm.RedisPoolSize = "2"
+	utils.Cfg.Rtm.RedisChannelPrefix = "test:"
+	redisPoolOnce = sync.Once{}
+	redisPoolInstance = nil
+	return func() {
+		*utils.Cfg.Rtm = rtmCfg
+		redisPoolOnce = sync.Once{}
+		redisPoolInstance = nil
+	}
+}
+
+// startRedisSubscriber returns the function stopping the subscriber.
+func startRedisSubscriber(t *testing.T, fr *fakeRedis, h *Hub) context.CancelFunc {
+	ctx, cancel := context.WithCancel(context.Background())
+	before := fr.subscriberCount()
+	go subscribeRedis(ctx, h)
+	waitFor(t, "subscription", func() bool {
+		return fr.subscriberCount() == before+3
+	})
+	return cancel
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRedisProviderPublish(t *testing.T) {
+	fr := newFakeRedis(t)
+	defer fr.close()
+	defer setupRedisConfig(fr)()
+
+	h := newHub()
+	defer startRedisSubscriber(t, fr, h)()
+	c := h.newClient("user1")
+	h.register(c, []string{"room1"}, "")
+
+	provider := GetMessagingProvider()
+	if err := provider.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if !fr.hasCommand("AUTH secret") || !fr.hasCommand("SELECT 2") {
+		t.Fatalf("AUTH and SELECT were not sent: %v", fr.commands)
+	}
+
+	other := models.NewEvent(models.EVENT_NAME_MESSAGE, "room2", "user2", nil)
+	event := models.NewEvent(models.EVENT_NAME_MESSAGE, "room1", "user2", utils.JSONText(`{"text":"hello"}`))
+	hidden := models.NewEvent(models.EVENT_NAME_EPHEMERAL_MESSAGE, "room1", "user2", nil)
+	hidden.Recipients = []string{"user2"}
+	profile := models.NewEvent(models.EVENT_NAME_USER_UPDATE, "", "user2", nil)
+	profile.Recipients = []string{"user1", "user2"}
+	for _, e := range []*models.Event{other, event, hidden, profile} {
+		if err := provider.PublishMessage(&MessagingInfo{Event: e}); err != nil {
+			t.Fatalf("PublishMessage failed: %v", err)
+		}
+	}
+	data, _ := json.Marshal(event)
+	if !fr.hasCommand("PUBLISH test:room:room1 " + string(data)) {
+		t.Fatalf("event was not published to the room channel: %v", fr.commands)
+	}
+
+	for _, want := range []*models.Event{event, profile} {
+		select {
+		case e := <-c.send:
+			var got models.Event
+			if err := json.Unmarshal(e.data, &got); err != nil || got.EventId != want.EventId {
+				t.Fatalf("unexpected event %s, want %s", e.data, want.EventId)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("event %s was not delivered", want.EventName)
+		}
+	}
+	select {
+	case e := <-c.send:
+		t.Fatalf("event of another room or recipient was delivered: %s", e.data)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := provider.PublishMessage(&MessagingInfo{Event: models.NewEvent(models.EVENT_NAME_MESSAGE, "", "", nil)}); err == nil {
+		t.Fatalf("an event without roomId nor recipients was published")
+	}
+}
+
+func TestRedisHubCommand(t *testing.T) {
+	fr := newFakeRedis(t)
+	defer fr.close()
+	defer setupRedisConfig(fr)()
+
+	h1 := newHub()
+	h2 := newHub()
+	h2.epoch = h1.epoch + "2"
+	defer startRedisSubscriber(t, fr, h1)()
+	defer startRedisSubscriber(t, fr, h2)()
+	c := h2.newClient("user1")
+	h2.register(c, nil, "")
+
+	subscribed := func() bool {
+		h2.mu.RLock()
+		defer h2.mu.RUnlock()
+		_, ok := c.rooms["room1"]
+		return ok
+	}
+	h1.Join("room1", []string{"user1"})
+	waitFor(t, "join on the other instance", subscribed)
+	h1.Leave("room1", []string{"user1"})
+	waitFor(t, "leave on the other instance", func() bool {
+		return !subscribed()
+	})
+	h1.DisconnectUser("user1", "user deleted")
+	waitFor(t, "disconnect on the other instance", func() bool {
+		select {
+		case <-c.closing:
+			return c.closeReason == "user deleted"
+		default:
+			return false
+		}
+	})
+}
+
+func TestRedisSubscriberPing(t *testing.T) {
+	fr := newFakeRedis(t)
+	defer fr.close()
+	defer setupRedisConfig(fr)()
+	utils.Cfg.Rtm.RedisPingInterval = "1"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	type result struct {
+		subscribed bool
+		err        error
+	}
+	done := make(chan result, 1)
+	go func() {
+		subscribed, err := subscribeRedis(ctx, newHub())
+		done <- result{subscribed, err}
+	}()
+	waitFor(t, "subscription", func() bool {
+		return fr.subscriberCount() == 3
+	})
+
+	// The pongs keep the subscription alive past the read deadline
+	select {
+	case r := <-done:
+		t.Fatalf("the subscription ended while the server was answering: %v", r.err)
+	case <-time.After(2500 * time.Millisecond):
+	}
+	if n := fr.commandCount("PING"); n < 2 {
+		t.Fatalf("the subscription was pinged %d times", n)
+	}
+
+	// A server answering nothing is given up, so that RunRedisSubscriber reconnects
+	fr.stall()
+	select {
+	case r := <-done:
+		if netErr, ok := r.err.(net.Error); !r.subscribed || !ok || !netErr.Timeout() {
+			t.Fatalf("unexpected end of the subscription: %v %v", r.subscribed, r.err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the silent connection was not given up")
+	}
+}
+
+func TestRedisPool(t *testing.T) {
+	fr := newFakeRedis(t)
+	defer fr.close()
+	defer setupRedisConfig(fr)()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := redisDo("PING"); err != nil {
+				t.Errorf("PING failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := fr.connCount(); n > 2 {
+		t.Fatalf("pool opened %d connections, want at most 2", n)
+	}
+
+	_, err := redisDo("UNKNOWN")
+	if _, ok := err.(redis.Error); !ok {
+		t.Fatalf("want redis.Error, got %v", err)
+	}
+	if _, err := redisDo("PING"); err != nil {
+		t.Fatalf("PING after an error reply failed: %v", err)
+	}
+	if n := fr.connCount(); n > 2 {
+		t.Fatalf("connection was not reused after an error reply, %d connections", n)
+	}
+}
@@ -0,0 +1,55 @@
+package rtm
+
+// replayBuffer keeps the latest events in a ring. It is guarded by hub.mu.
+type replayBuffer struct {
+	events []*hubEvent
+	next   int
+	count  int
+}
+
+func newReplayBuffer(size int) *replayBuffer {
+	if size < 0 {
+		size = 0
+	}
+	return &replayBuffer{
+		events: make([]*hubEvent, size),
+	}
+}
+
+func (rb *replayBuffer) add(e *hubEvent) {
+	if len(rb.events) == 0 {
+		return
+	}
+	rb.events[rb.next] = e
+	rb.next = (rb.next + 1) % len(rb.events)
+	if rb.count < len(rb.events) {
+		rb.count++
+	}
+}
+
+// oldest returns the sequence number of the oldest buffered event, or 0 if the buffer is empty.
+func (rb *replayBuffer) oldest() uint64 {
+	if rb.count == 0 {
+		return 0
+	}
+	return rb.at(0).seq
+}
+
+// since returns the buffered events with a sequence number greater than seq, oldest first.
+func (rb *replayBuffer) since(seq uint64) []*hubEvent {
+	var events []*hubEvent
+	for i := 0; i < rb.count; i++ {
+		if e := rb.at(i); e.seq > seq {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+func (rb *replayBuffer) at(i int) *hubEvent {
+	start := rb.next - rb.count
+	if start < 0 {
+		start += len(rb.events)
+	}
+	return rb.events[(start+i)%len(rb.events)]
+}
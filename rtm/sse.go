@@ -0,0 +1,95 @@
+package rtm
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"time"
+)
+
+var ErrSseNotSupported = errors.New("rtm: streaming is not supported")
+
+// ServeSse streams the events of the user's rooms as Server-Sent Events until the client goes away.
+// The buffered events after lastEventId are sent first.
+// An error is returned only when nothing has been written yet, so that the caller can still respond.
+func (h *Hub) ServeSse(w http.ResponseWriter, r *http.Request, userId string, roomIds []string, lastEventId string) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return ErrSseNotSupported
+	}
+
+	c := h.newClient(userId)
+	h.wg.Add(1)
+	defer h.wg.Done()
+	replay, ok := h.register(c, roomIds, lastEventId)
+	if !ok {
+		return ErrHubDraining
+	}
+	defer h.unregister(c)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Keeps reverse proxies such as nginx from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	write := func(e *hubEvent) error {
+		if _, err := w.Write(sseEvent(e)); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+	for _, e := range replay {
+		if err := write(e); err != nil {
+			c.stop(wsCloseNormal, "")
+			return nil
+		}
+	}
+
+	ticker := time.NewTicker(wsPingInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case e := <-c.send:
+			if err := write(e); err != nil {
+				c.stop(wsCloseNormal, "")
+				return nil
+			}
+		case <-ticker.C:
+			// A comment line keeps proxies from timing out an idle stream
+			if _, err := w.Write([]byte(":ping\n\n")); err != nil {
+				c.stop(wsCloseNormal, "")
+				return nil
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			c.stop(wsCloseNormal, "")
+			return nil
+		case <-c.closing:
+			// EventSource reconnects by itself with Last-Event-ID, so the stream just ends here
+			if c.closeCode == wsCloseGoingAway {
+				c.flush(write)
+			}
+			return nil
+		}
+	}
+}
+
+func sseEvent(e *hubEvent) []byte {
+	var buf bytes.Buffer
+	if e.id != "" {
+		buf.WriteString("id: ")
+		buf.WriteString(e.id)
+		buf.WriteByte('\n')
+	}
+	for _, line := range bytes.Split(e.data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
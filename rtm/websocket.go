@@ -0,0 +1,105 @@
+package rtm
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Thin wrapper of gorilla/websocket used by the builtin provider.
+
+const (
+	wsCloseNormal          = websocket.CloseNormalClosure
+	wsCloseGoingAway       = websocket.CloseGoingAway
+	wsCloseProtocolError   = websocket.CloseProtocolError
+	wsClosePolicyViolation = websocket.ClosePolicyViolation
+	wsCloseTooBig          = websocket.CloseMessageTooBig
+
+	wsMaxMessageSize   = 64 * 1024
+	wsMaxControlLength = 125
+	wsWriteTimeout     = 10 * time.Second
+)
+
+var ErrWsBadHandshake = errors.New("websocket: bad handshake")
+
+var wsUpgrader = websocket.Upgrader{
+	// Clients authenticate with the headers or the access token, not with cookies, so every origin is allowed
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+	// The handler responds to a bad handshake itself
+	Error: func(w http.ResponseWriter, r *http.Request, status int, reason error) {},
+}
+
+// wsConn writes from a single goroutine, writePump, except for the control frames.
+type wsConn struct {
+	conn *websocket.Conn
+}
+
+// upgradeWs performs the opening handshake and takes over the connection.
+func upgradeWs(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		if _, ok := err.(websocket.HandshakeError); ok {
+			return nil, ErrWsBadHandshake
+		}
+		return nil, err
+	}
+	conn.SetReadLimit(wsMaxMessageSize)
+	// writePump answers with its own close frame
+	conn.SetCloseHandler(func(code int, text string) error {
+		return nil
+	})
+	return &wsConn{
+		conn: conn,
+	}, nil
+}
+
+// onControl sets the function called on every ping and pong. A ping is answered with its payload.
+func (c *wsConn) onControl(f func()) {
+	c.conn.SetPingHandler(func(appData string) error {
+		f()
+		err := c.conn.WriteControl(websocket.PongMessage, []byte(appData), time.Now().Add(wsWriteTimeout))
+		if err == websocket.ErrCloseSent {
+			return nil
+		}
+		return err
+	})
+	c.conn.SetPongHandler(func(appData string) error {
+		f()
+		return nil
+	})
+}
+
+// readMessage returns the next data message. The control frames are handled while reading.
+func (c *wsConn) readMessage() ([]byte, error) {
+	_, message, err := c.conn.ReadMessage()
+	return message, err
+}
+
+func (c *wsConn) writeText(data []byte) error {
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+func (c *wsConn) writePing() error {
+	return c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout))
+}
+
+// writeClose does nothing once a close frame was sent, such as on a protocol error.
+func (c *wsConn) writeClose(code int, reason string) error {
+	if len(reason) > wsMaxControlLength-2 {
+		reason = reason[:wsMaxControlLength-2]
+	}
+	return c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(wsWriteTimeout))
+}
+
+func (c *wsConn) setReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *wsConn) close() error {
+	return c.conn.Close()
+}
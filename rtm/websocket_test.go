@@ -0,0 +1,185 @@
+package rtm
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// pipeResponseWriter hands the server end of a net.Pipe to upgradeWs.
+type pipeResponseWriter struct {
+	*httptest.ResponseRecorder
+	conn net.Conn
+}
+
+func (w *pipeResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.conn, bufio.NewReadWriter(bufio.NewReader(w.conn), bufio.NewWriter(w.conn)), nil
+}
+
+func wsRequest(key string) *http.Request {
+	r, _ := http.NewRequest("GET", "/ws", nil)
+	r.Header.Set("Connection", "keep-alive, Upgrade")
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Sec-WebSocket-Version", "13")
+	r.Header.Set("Sec-WebSocket-Key", key)
+	return r
+}
+
+// dialPipeWs connects a client over a pipe, and serves the handshake request read from it with serve.
+// The client end of the pipe is returned as well, to write raw frames.
+func dialPipeWs(t *testing.T, serve func(w http.ResponseWriter, r *http.Request)) (*websocket.Conn, net.Conn) {
+	server, client := net.Pipe()
+	go func() {
+		r, err := http.ReadRequest(bufio.NewReader(server))
+		if err != nil {
+			server.Close()
+			return
+		}
+		serve(&pipeResponseWriter{httptest.NewRecorder(), server}, r)
+	}()
+	conn, _, err := websocket.NewClient(client, &url.URL{Scheme: "ws", Host: "localhost", Path: "/ws"}, nil, 1024, 1024)
+	if err != nil {
+		t.Fatalf("the client could not connect: %v", err)
+	}
+	return conn, client
+}
+
+func TestUpgradeWs(t *testing.T) {
+	testTable := []struct {
+		testNo int
+		modify func(r *http.Request)
+	}{
+		{1, func(r *http.Request) { r.Method = "POST" }},
+		{2, func(r *http.Request) { r.Header.Set("Connection", "keep-alive") }},
+		{3, func(r *http.Request) { r.Header.Del("Upgrade") }},
+		{4, func(r *http.Request) { r.Header.Set("Sec-WebSocket-Version", "8") }},
+		{5, func(r *http.Request) { r.Header.Del("Sec-WebSocket-Key") }},
+	}
+	for _, testRecord := range testTable {
+		r := wsRequest("dGhlIHNhbXBsZSBub25jZQ==")
+		testRecord.modify(r)
+		w := httptest.NewRecorder()
+		if _, err := upgradeWs(w, r); err != ErrWsBadHandshake {
+			t.Fatalf("TestNo %d\nthe bad handshake was accepted: %v", testRecord.testNo, err)
+		}
+		// The handler responds to a bad handshake itself
+		if w.Body.Len() != 0 {
+			t.Fatalf("TestNo %d\nthe bad handshake was responded: %s", testRecord.testNo, w.Body.String())
+		}
+	}
+
+	// Browsers connect from the origin of the application, not of the API
+	r := wsRequest("dGhlIHNhbXBsZSBub25jZQ==")
+	r.Header.Set("Origin", "https://app.example.com")
+	server, client := net.Pipe()
+	defer client.Close()
+	done := make(chan error, 1)
+	go func() {
+		conn, err := upgradeWs(&pipeResponseWriter{httptest.NewRecorder(), server}, r)
+		if err == nil {
+			conn.close()
+		}
+		done <- err
+	}()
+	res, err := http.ReadResponse(bufio.NewReader(client), nil)
+	if err != nil {
+		t.Fatalf("the response could not be read: %v", err)
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("unexpected status code: %d", res.StatusCode)
+	}
+	// The accept key of the sample handshake of RFC 6455
+	if accept := res.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected accept key: %s", accept)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("the upgrade failed: %v", err)
+	}
+}
+
+// serveWsConn upgrades the connection and returns what is read from it, closing it with the reason after the first message.
+func serveWsConn(t *testing.T, reason string) (*websocket.Conn, chan string, chan error) {
+	messages := make(chan string, 1)
+	errs := make(chan error, 1)
+	conn, _ := dialPipeWs(t, func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgradeWs(w, r)
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer c.close()
+		c.onControl(func() {})
+		message, err := c.readMessage()
+		if err != nil {
+			errs <- err
+			return
+		}
+		messages <- string(message)
+		c.writeClose(wsClosePolicyViolation, reason)
+		_, err = c.readMessage()
+		errs <- err
+	})
+	return conn, messages, errs
+}
+
+func TestWsConn(t *testing.T) {
+	conn, messages, errs := serveWsConn(t, strings.Repeat("a", 200))
+	defer conn.Close()
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("hello")); err != nil {
+		t.Fatalf("the message could not be written: %v", err)
+	}
+	select {
+	case message := <-messages:
+		if message != "hello" {
+			t.Fatalf("unexpected message: %s", message)
+		}
+	case err := <-errs:
+		t.Fatalf("the message was not read: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the message was not read")
+	}
+
+	// The reason of the close frame is cut to fit in a control frame
+	_, _, err := conn.ReadMessage()
+	closeErr, ok := err.(*websocket.CloseError)
+	if !ok || closeErr.Code != wsClosePolicyViolation || len(closeErr.Text) != wsMaxControlLength-2 {
+		t.Fatalf("unexpected close frame: %v", err)
+	}
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(wsCloseNormal, ""), time.Now().Add(time.Second))
+	select {
+	case err := <-errs:
+		if _, ok := err.(*websocket.CloseError); !ok {
+			t.Fatalf("the close frame was not read: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the close frame was not read")
+	}
+
+	// A message over wsMaxMessageSize is not read, and the connection is closed with its code
+	conn, _, errs = serveWsConn(t, "")
+	defer conn.Close()
+	closed := make(chan error, 1)
+	go func() {
+		_, _, err := conn.ReadMessage()
+		closed <- err
+	}()
+	go conn.WriteMessage(websocket.BinaryMessage, make([]byte, wsMaxMessageSize+1))
+	select {
+	case err := <-errs:
+		if err != websocket.ErrReadLimit {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the message too big was read")
+	}
+	if closeErr, ok := (<-closed).(*websocket.CloseError); !ok || closeErr.Code != wsCloseTooBig {
+		t.Fatalf("the close frame was not received: %v", closeErr)
+	}
+}
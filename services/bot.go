@@ -0,0 +1,328 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/swagchat/chat-api/datastore"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+const (
+	botHeaderTimestamp = "X-SwagChat-Bot-Timestamp"
+	botHeaderSignature = "X-SwagChat-Bot-Signature"
+)
+
+var mentionRegexp = regexp.MustCompile(`@([A-Za-z0-9-]+)`)
+
+func PostSlashCommand(post *models.SlashCommand) (*models.SlashCommand, *models.ProblemDetail) {
+	if pd := post.IsValid(); pd != nil {
+		return nil, pd
+	}
+
+	if _, pd := selectRoom(post.RoomId); pd != nil {
+		return nil, pd
+	}
+
+	bot, pd := selectUser(post.BotUserId)
+	if pd != nil || !bot.IsBotUser() {
+		return nil, &models.ProblemDetail{
+			Title:     "Request parameter error. (Create command item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: models.ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []models.InvalidParam{
+				models.InvalidParam{
+					Name:   "botUserId",
+					Reason: "botUserId is invalid. Not exist bot user.",
+				},
+			},
+		}
+	}
+
+	if _, pd := selectRoomUser(post.RoomId, post.BotUserId); pd != nil {
+		return nil, &models.ProblemDetail{
+			Title:     "Request parameter error. (Create command item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: models.ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []models.InvalidParam{
+				models.InvalidParam{
+					Name:   "botUserId",
+					Reason: "botUserId is invalid. The bot is not a member of the room.",
+				},
+			},
+		}
+	}
+
+	slashCommands, pd := selectSlashCommands(post.RoomId)
+	if pd != nil {
+		return nil, pd
+	}
+	for _, sc := range slashCommands {
+		if sc.Command == post.Command {
+			return nil, &models.ProblemDetail{
+				Status: http.StatusConflict,
+			}
+		}
+	}
+
+	post.BeforeSave()
+	dRes := datastore.GetProvider().InsertSlashCommand(post)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	return dRes.Data.(*models.SlashCommand), nil
+}
+
+func GetSlashCommands(roomId string) (*models.SlashCommands, *models.ProblemDetail) {
+	if _, pd := selectRoom(roomId); pd != nil {
+		return nil, pd
+	}
+
+	slashCommands, pd := selectSlashCommands(roomId)
+	if pd != nil {
+		return nil, pd
+	}
+	return &models.SlashCommands{
+		SlashCommands: slashCommands,
+	}, nil
+}
+
+func DeleteSlashCommand(roomId, commandId string) *models.ProblemDetail {
+	dRes := datastore.GetProvider().SelectSlashCommand(commandId)
+	if dRes.ProblemDetail != nil {
+		return dRes.ProblemDetail
+	}
+	if dRes.Data == nil || dRes.Data.(*models.SlashCommand).RoomId != roomId {
+		return &models.ProblemDetail{
+			Status: http.StatusNotFound,
+		}
+	}
+	slashCommand := dRes.Data.(*models.SlashCommand)
+
+	slashCommand.Deleted = time.Now().Unix()
+	dRes = datastore.GetProvider().UpdateSlashCommand(slashCommand)
+	if dRes.ProblemDetail != nil {
+		return dRes.ProblemDetail
+	}
+	return nil
+}
+
+// matchSlashCommand returns the command registered in the message's room that the message invokes.
+// Messages from bots never invoke commands.
+func matchSlashCommand(m *models.Message) (*models.SlashCommand, string) {
+	if m.Type != models.MESSAGE_TYPE_TEXT {
+		return nil, ""
+	}
+	var pt models.PayloadText
+	json.Unmarshal(m.Payload, &pt)
+	command, args, ok := models.ParseSlashCommand(pt.Text)
+	if !ok {
+		return nil, ""
+	}
+
+	slashCommands, pd := selectSlashCommands(m.RoomId)
+	if pd != nil {
+		return nil, ""
+	}
+	for _, sc := range slashCommands {
+		if sc.Command != command {
+			continue
+		}
+		if user, pd := selectUser(m.UserId); pd != nil || user.IsBotUser() {
+			return nil, ""
+		}
+		return sc, args
+	}
+	return nil, ""
+}
+
+func invokeSlashCommand(sc *models.SlashCommand, m *models.Message, args string) {
+	bot, pd := selectUser(sc.BotUserId)
+	if pd != nil || !bot.IsBotUser() {
+		logBotError(sc.BotUserId, "Command bot user does not exist.")
+		return
+	}
+
+	res, err := callBot(bot, &models.BotRequest{
+		Type:      models.BOT_REQUEST_TYPE_COMMAND,
+		CommandId: sc.CommandId,
+		Command:   sc.Command,
+		Text:      args,
+		RoomId:    m.RoomId,
+		UserId:    m.UserId,
+		BotUserId: bot.UserId,
+		MessageId: m.MessageId,
+	})
+	if err != nil {
+		logBotError(bot.UserId, err.Error())
+		res = &models.BotResponse{
+			ResponseType: models.BOT_RESPONSE_TYPE_EPHEMERAL,
+			Text:         utils.AppendStrings("/", sc.Command, " failed. Please try again later."),
+		}
+	}
+	replyBot(bot, m.RoomId, m.UserId, res, models.BOT_RESPONSE_TYPE_EPHEMERAL)
+}
+
+// notifyBots forwards a posted message to the bots it is addressed to,
+// that is the other member of a one-on-one room with a bot and bots mentioned as @userId.
+func notifyBots(room *models.Room, m *models.Message) {
+	if m.Type != models.MESSAGE_TYPE_TEXT {
+		return
+	}
+	sender, pd := selectUser(m.UserId)
+	if pd != nil || sender.IsBotUser() {
+		return
+	}
+
+	var pt models.PayloadText
+	json.Unmarshal(m.Payload, &pt)
+	candidateIds := make([]string, 0)
+	for _, match := range mentionRegexp.FindAllStringSubmatch(pt.Text, -1) {
+		candidateIds = append(candidateIds, match[1])
+	}
+	if room.Type != nil && *room.Type == models.ONE_ON_ONE {
+		dRes := datastore.GetProvider().SelectRoomUsersByRoomId(room.RoomId)
+		if dRes.ProblemDetail == nil {
+			for _, ru := range dRes.Data.([]*models.RoomUser) {
+				candidateIds = append(candidateIds, ru.UserId)
+			}
+		}
+	}
+
+	notified := map[string]bool{m.UserId: true}
+	for _, candidateId := range candidateIds {
+		if notified[candidateId] {
+			continue
+		}
+		notified[candidateId] = true
+
+		bot, pd := selectUser(candidateId)
+		if pd != nil || !bot.IsBotUser() {
+			continue
+		}
+		if _, pd := selectRoomUser(room.RoomId, bot.UserId); pd != nil {
+			continue
+		}
+
+		res, err := callBot(bot, &models.BotRequest{
+			Type:      models.BOT_REQUEST_TYPE_MESSAGE,
+			Text:      pt.Text,
+			RoomId:    m.RoomId,
+			UserId:    m.UserId,
+			BotUserId: bot.UserId,
+			MessageId: m.MessageId,
+			Message:   m,
+		})
+		if err != nil {
+			logBotError(bot.UserId, err.Error())
+			continue
+		}
+		replyBot(bot, m.RoomId, m.UserId, res, models.BOT_RESPONSE_TYPE_IN_ROOM)
+	}
+}
+
+func replyBot(bot *models.User, roomId, invokerUserId string, res *models.BotResponse, defaultResponseType string) {
+	if res.IsEmpty() {
+		return
+	}
+
+	message := models.NewAttachmentMessage(roomId, bot.UserId, res.Text, res.Attachments)
+	responseType := res.ResponseType
+	if responseType == "" {
+		responseType = defaultResponseType
+	}
+	if responseType == models.BOT_RESPONSE_TYPE_EPHEMERAL {
+		publishEphemeralMessage(models.NewEphemeralMessage(message, invokerUserId))
+		return
+	}
+
+	mRes := PostMessage(&models.Messages{
+		Messages: []*models.Message{message},
+	})
+	for _, pd := range mRes.Errors {
+		problemDetailBytes, _ := json.Marshal(pd)
+		utils.AppLogger.Error("",
+			zap.String("msg", "Bot reply error."),
+			zap.String("botUserId", bot.UserId),
+			zap.String("problemDetail", string(problemDetailBytes)),
+		)
+	}
+}
+
+// callBot posts the request to the bot endpoint, signed the same way as outgoing webhooks with the bot secret.
+func callBot(bot *models.User, req *models.BotRequest) (*models.BotResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	httpReq, err := http.NewRequest("POST", bot.BotEndpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(botHeaderTimestamp, timestamp)
+	httpReq.Header.Set(botHeaderSignature, utils.AppendStrings("sha256=", signWebhookBody(bot.BotSecret, timestamp, body)))
+
+	timeout, err := strconv.Atoi(utils.Cfg.Webhook.Timeout)
+	if err != nil {
+		timeout = 5
+	}
+	client := &http.Client{
+		Timeout: time.Duration(timeout) * time.Second,
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, errors.New(utils.AppendStrings("bot http status code[", strconv.Itoa(resp.StatusCode), "]"))
+	}
+
+	resBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	res := &models.BotResponse{}
+	if len(bytes.TrimSpace(resBody)) == 0 {
+		return res, nil
+	}
+	if err := json.Unmarshal(resBody, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// publishEphemeralMessage relies on the realtime messaging provider to deliver the message to UserId only.
+func publishEphemeralMessage(em *models.EphemeralMessage) {
+	event := models.NewEvent(models.EVENT_NAME_EPHEMERAL_MESSAGE, em.RoomId, em.SenderUserId, em)
+	event.Recipients = []string{em.UserId}
+	publishEvent(event)
+}
+
+func logBotError(botUserId, detail string) {
+	utils.AppLogger.Error("",
+		zap.String("msg", "Bot callback error."),
+		zap.String("botUserId", botUserId),
+		zap.String("detail", detail),
+	)
+}
+
+func selectSlashCommands(roomId string) ([]*models.SlashCommand, *models.ProblemDetail) {
+	dRes := datastore.GetProvider().SelectSlashCommands(roomId)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	return dRes.Data.([]*models.SlashCommand), nil
+}
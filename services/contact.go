@@ -13,8 +13,23 @@ func GetContacts(userId string) (*models.Users, *models.ProblemDetail) {
 		return nil, dRes.ProblemDetail
 	}
 
+	users := dRes.Data.([]*models.User)
+	userIds := make([]string, 0, len(users))
+	for _, user := range users {
+		if isPresenceVisible(user.IsShowUsers) {
+			userIds = append(userIds, user.UserId)
+		}
+	}
+	presences, pd := selectPresenceMap(userIds)
+	if pd != nil {
+		return nil, pd
+	}
+	for _, user := range users {
+		user.Presence = presences[user.UserId]
+	}
+
 	contacts := &models.Users{
-		Users: dRes.Data.([]*models.User),
+		Users: users,
 	}
 	return contacts, nil
 }
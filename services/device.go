@@ -49,7 +49,7 @@ func PutDevice(put *models.Device) (*models.Device, *models.ProblemDetail) {
 		isExist = false
 	}
 
-	if !isExist || (device.Token != put.Token) {
+	if !isExist || (device.Token != put.Token) || (device.P256dh != put.P256dh) || (device.Auth != put.Auth) {
 		ctx, _ := context.WithCancel(context.Background())
 
 		// When using another user on the same device, delete the notification information
@@ -62,6 +62,10 @@ func PutDevice(put *models.Device) (*models.Device, *models.ProblemDetail) {
 			wg := &sync.WaitGroup{}
 			deleteDevices := dRes.Data.([]*models.Device)
 			for _, deleteDevice := range deleteDevices {
+				// A web device keeps its endpoint when only the keys of the subscription change
+				if deleteDevice.UserId == put.UserId && deleteDevice.Platform == put.Platform {
+					continue
+				}
 				nRes := <-notification.GetProvider().DeleteEndpoint(deleteDevice.NotificationDeviceId)
 				if nRes.ProblemDetail != nil {
 					return nil, nRes.ProblemDetail
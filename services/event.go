@@ -0,0 +1,57 @@
+package services
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/swagchat/chat-api/datastore"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/rtm"
+	"github.com/swagchat/chat-api/utils"
+)
+
+// publishEvent records the event in the change log and sends it to the realtime messaging provider.
+// Every domain event goes through here so that consumers receive the same envelope.
+func publishEvent(event *models.Event) {
+	recordChanges(event)
+	if err := sendEvent(event); err != nil {
+		utils.AppLogger.Error("",
+			zap.String("msg", "Publish error."),
+			zap.String("eventName", event.EventName),
+			zap.String("eventId", event.EventId),
+			zap.String("detail", err.Error()),
+		)
+	}
+}
+
+// sendEvent resolves the recipients of the event and publishes it. An event without recipients is dropped.
+func sendEvent(event *models.Event) error {
+	if pd := resolveRecipients(event); pd != nil {
+		return problemDetailError(pd)
+	}
+	if len(event.Recipients) == 0 {
+		return nil
+	}
+	return rtm.GetMessagingProvider().PublishMessage(&rtm.MessagingInfo{
+		Event: event,
+	})
+}
+
+// resolveRecipients sets the recipients of a room event to the users of the room.
+// The users who blocked the actor do not receive the messages and room events of the actor.
+// Muted users still receive them, so that their open clients stay in sync with the room.
+func resolveRecipients(event *models.Event) *models.ProblemDetail {
+	if event.Recipients != nil || event.RoomId == "" {
+		return nil
+	}
+
+	senderUserId := ""
+	if models.IsBlockableEvent(event.EventName) {
+		senderUserId = event.ActorId
+	}
+	dRes := datastore.GetProvider().SelectRecipientUserIds(event.RoomId, senderUserId)
+	if dRes.ProblemDetail != nil {
+		return dRes.ProblemDetail
+	}
+	event.Recipients = dRes.Data.([]string)
+	return nil
+}
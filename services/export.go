@@ -0,0 +1,358 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/swagchat/chat-api/datastore"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/storage"
+	"github.com/swagchat/chat-api/utils"
+	"go.uber.org/zap"
+)
+
+const exportBatchSize = 500
+
+var exportHtmlTemplate = template.Must(template.New("export").Parse(`
+{{- define "begin" -}}
+<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}}</title>
+<style>
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+.deleted { color: #999; }
+</style>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+<p>Room ID: {{.RoomId}}</p>
+<table>
+<tr><th>Created</th><th>User</th><th>Type</th><th>Message</th></tr>
+{{end -}}
+{{- define "message" -}}
+<tr{{if .Deleted}} class="deleted"{{end}}><td>{{.Created}}</td><td>{{.UserName}} ({{.UserId}})</td><td>{{.Type}}</td><td>{{if .Deleted}}Deleted at {{.Deleted}}{{else}}{{.Text}}{{end}}</td></tr>
+{{end -}}
+{{- define "end" -}}
+</table>
+</body>
+</html>
+{{end -}}
+`))
+
+// exportWriter writes a room export in one format. Messages are written one by one as they are read.
+type exportWriter interface {
+	begin(room *models.Room) error
+	write(message *models.ExportMessage) error
+	end() error
+}
+
+func newExportWriter(w io.Writer, format string) exportWriter {
+	switch format {
+	case models.EXPORT_FORMAT_CSV:
+		return &csvExportWriter{w: csv.NewWriter(w)}
+	case models.EXPORT_FORMAT_HTML:
+		return &htmlExportWriter{w: w}
+	}
+	return &jsonExportWriter{w: w}
+}
+
+type jsonExportWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (ew *jsonExportWriter) begin(room *models.Room) error {
+	roomId, _ := json.Marshal(room.RoomId)
+	name, _ := json.Marshal(room.Name)
+	_, err := io.WriteString(ew.w, utils.AppendStrings(`{"roomId":`, string(roomId), `,"name":`, string(name), `,"messages":[`))
+	return err
+}
+
+func (ew *jsonExportWriter) write(message *models.ExportMessage) error {
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	if ew.count > 0 {
+		if _, err := io.WriteString(ew.w, ","); err != nil {
+			return err
+		}
+	}
+	ew.count++
+	_, err = ew.w.Write(messageBytes)
+	return err
+}
+
+func (ew *jsonExportWriter) end() error {
+	_, err := io.WriteString(ew.w, "]}\n")
+	return err
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (ew *csvExportWriter) begin(room *models.Room) error {
+	return ew.w.Write([]string{"messageId", "created", "userId", "userName", "type", "text", "payload", "deleted"})
+}
+
+func (ew *csvExportWriter) write(message *models.ExportMessage) error {
+	deleted := ""
+	if message.IsDeleted() {
+		deleted = exportTime(message.Deleted)
+	}
+	return ew.w.Write([]string{
+		message.MessageId,
+		exportTime(message.Created),
+		message.UserId,
+		message.UserName,
+		message.Type,
+		message.Text(),
+		message.Payload.String(),
+		deleted,
+	})
+}
+
+func (ew *csvExportWriter) end() error {
+	ew.w.Flush()
+	return ew.w.Error()
+}
+
+type htmlExportWriter struct {
+	w io.Writer
+}
+
+func (ew *htmlExportWriter) begin(room *models.Room) error {
+	return exportHtmlTemplate.ExecuteTemplate(ew.w, "begin", room)
+}
+
+func (ew *htmlExportWriter) write(message *models.ExportMessage) error {
+	deleted := ""
+	if message.IsDeleted() {
+		deleted = exportTime(message.Deleted)
+	}
+	return exportHtmlTemplate.ExecuteTemplate(ew.w, "message", map[string]string{
+		"Created":  exportTime(message.Created),
+		"UserId":   message.UserId,
+		"UserName": message.UserName,
+		"Type":     message.Type,
+		"Text":     message.Text(),
+		"Deleted":  deleted,
+	})
+}
+
+func (ew *htmlExportWriter) end() error {
+	return exportHtmlTemplate.ExecuteTemplate(ew.w, "end", nil)
+}
+
+// PostRoomExport validates an export request.
+// If the export is larger than the sync limit, or async=true is given, it is queued as a background job
+// and the returned job has the queued status. Otherwise the caller streams it with WriteRoomExport.
+func PostRoomExport(roomId, userId string, isAdmin bool, params url.Values) (*models.ExportJob, *models.ProblemDetail) {
+	job := &models.ExportJob{
+		RoomId:         roomId,
+		Format:         models.EXPORT_FORMAT_JSON,
+		IncludeDeleted: isAdmin,
+		RequestedBy:    userId,
+	}
+	if formatArray, ok := params["format"]; ok {
+		job.Format = formatArray[0]
+	}
+	var pd *models.ProblemDetail
+	if job.From, pd = parseExportTime(params, "from"); pd != nil {
+		return nil, pd
+	}
+	if job.To, pd = parseExportTime(params, "to"); pd != nil {
+		return nil, pd
+	}
+	if pd := job.IsValid(); pd != nil {
+		return nil, pd
+	}
+
+	if _, pd := selectRoom(roomId); pd != nil {
+		return nil, pd
+	}
+	if !isAdmin {
+		if userId == "" {
+			return nil, &models.ProblemDetail{
+				Title:     "Only room members or administrators can export messages. (Export room messages)",
+				Status:    http.StatusForbidden,
+				ErrorName: models.ERROR_NAME_OPERATION_NOT_PERMITTED,
+			}
+		}
+		if _, pd := selectRoomUser(roomId, userId); pd != nil {
+			return nil, &models.ProblemDetail{
+				Title:     "Only room members or administrators can export messages. (Export room messages)",
+				Status:    http.StatusForbidden,
+				ErrorName: models.ERROR_NAME_OPERATION_NOT_PERMITTED,
+			}
+		}
+	}
+
+	async := false
+	if asyncArray, ok := params["async"]; ok {
+		async, _ = strconv.ParseBool(asyncArray[0])
+	}
+	if !async {
+		dRes := datastore.GetProvider().SelectCountExportMessages(job.RoomId, job.From, job.To, job.IncludeDeleted)
+		if dRes.ProblemDetail != nil {
+			return nil, dRes.ProblemDetail
+		}
+		syncLimit, _ := strconv.ParseInt(utils.Cfg.Export.SyncLimit, 10, 64)
+		async = dRes.Data.(int64) > syncLimit
+	}
+	if !async {
+		return job, nil
+	}
+
+	job.BeforeSave()
+	dRes := datastore.GetProvider().InsertExportJob(job)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	go runExportJob(job)
+	return job, nil
+}
+
+func GetExportJob(exportJobId, userId string, isAdmin bool) (*models.ExportJob, *models.ProblemDetail) {
+	dRes := datastore.GetProvider().SelectExportJob(exportJobId)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	if dRes.Data == nil {
+		return nil, &models.ProblemDetail{
+			Status: http.StatusNotFound,
+		}
+	}
+	job := dRes.Data.(*models.ExportJob)
+	if !isAdmin && (userId == "" || job.RequestedBy != userId) {
+		return nil, &models.ProblemDetail{
+			Status: http.StatusNotFound,
+		}
+	}
+	return job, nil
+}
+
+// WriteRoomExport streams the messages of the export to w in batches.
+func WriteRoomExport(w io.Writer, job *models.ExportJob) error {
+	room, pd := selectRoom(job.RoomId)
+	if pd != nil {
+		return problemDetailError(pd)
+	}
+
+	ew := newExportWriter(w, job.Format)
+	if err := ew.begin(room); err != nil {
+		return err
+	}
+	var afterId uint64
+	for {
+		dRes := datastore.GetProvider().SelectExportMessages(job.RoomId, job.From, job.To, job.IncludeDeleted, afterId, exportBatchSize)
+		if dRes.ProblemDetail != nil {
+			return problemDetailError(dRes.ProblemDetail)
+		}
+		messages := dRes.Data.([]*models.ExportMessage)
+		for _, message := range messages {
+			if message.IsDeleted() {
+				message.Payload = nil
+			}
+			if err := ew.write(message); err != nil {
+				return err
+			}
+			afterId = message.Id
+		}
+		if len(messages) < exportBatchSize {
+			break
+		}
+	}
+	return ew.end()
+}
+
+func runExportJob(job *models.ExportJob) {
+	job.Status = models.EXPORT_JOB_STATUS_RUNNING
+	updateExportJob(job)
+
+	url, err := writeExportFile(job)
+	if err != nil {
+		utils.AppLogger.Error("",
+			zap.String("msg", "Export job error."),
+			zap.String("exportJobId", job.ExportJobId),
+			zap.String("err", err.Error()),
+		)
+		job.Status = models.EXPORT_JOB_STATUS_FAILED
+		job.Error = err.Error()
+	} else {
+		job.Status = models.EXPORT_JOB_STATUS_SUCCEEDED
+		job.Url = url
+	}
+	updateExportJob(job)
+}
+
+func writeExportFile(job *models.ExportJob) (string, error) {
+	file, err := ioutil.TempFile("", "export-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if err := WriteRoomExport(file, job); err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	url, pd := storage.GetProvider().Post(&storage.AssetInfo{
+		FileName: utils.AppendStrings("export-", job.ExportJobId, ".", job.Format),
+		Data:     file,
+	})
+	if pd != nil {
+		return "", problemDetailError(pd)
+	}
+	return url, nil
+}
+
+func updateExportJob(job *models.ExportJob) {
+	job.BeforeSave()
+	dRes := datastore.GetProvider().UpdateExportJob(job)
+	if dRes.ProblemDetail != nil {
+		logProblemDetail("Export job update error.", dRes.ProblemDetail)
+	}
+}
+
+func parseExportTime(params url.Values, name string) (int64, *models.ProblemDetail) {
+	valueArray, ok := params[name]
+	if !ok || valueArray[0] == "" {
+		return 0, nil
+	}
+	t, err := time.Parse(time.RFC3339, valueArray[0])
+	if err != nil {
+		return 0, &models.ProblemDetail{
+			Title:     "Request parameter error. (Export room messages)",
+			Status:    http.StatusBadRequest,
+			ErrorName: models.ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []models.InvalidParam{
+				models.InvalidParam{
+					Name:   name,
+					Reason: utils.AppendStrings(name, " must be RFC3339 format."),
+				},
+			},
+		}
+	}
+	return t.Unix(), nil
+}
+func exportTime(timestamp int64) string {
+	l, _ := time.LoadLocation("Etc/GMT")
+	return time.Unix(timestamp, 0).In(l).Format(time.RFC3339)
+}
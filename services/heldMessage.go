@@ -0,0 +1,158 @@
+package services
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/swagchat/chat-api/datastore"
+	"github.com/swagchat/chat-api/models"
+)
+
+func GetHeldMessages(params url.Values) (*models.HeldMessages, *models.ProblemDetail) {
+	limit, offset, _, pd := setPagingParams(params)
+	if pd != nil {
+		return nil, pd
+	}
+
+	status := models.HELD_MESSAGE_STATUS_HELD
+	if statusArray, ok := params["status"]; ok {
+		status = statusArray[0]
+	}
+
+	dRes := datastore.GetProvider().SelectHeldMessages(status, limit, offset)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	return &models.HeldMessages{
+		HeldMessages: dRes.Data.([]*models.HeldMessage),
+	}, nil
+}
+
+func GetHeldMessage(heldMessageId string) (*models.HeldMessage, *models.ProblemDetail) {
+	return selectHeldMessage(heldMessageId)
+}
+
+// ApproveHeldMessage claims the held message first, so that concurrent approvals publish it only once.
+// The claim is released when the message can not be published.
+func ApproveHeldMessage(heldMessageId string) (*models.HeldMessage, *models.ProblemDetail) {
+	heldMessage, pd := selectUnresolvedHeldMessage(heldMessageId)
+	if pd != nil {
+		return nil, pd
+	}
+	if pd := claimHeldMessage(heldMessage, models.HELD_MESSAGE_STATUS_APPROVED); pd != nil {
+		return nil, pd
+	}
+
+	if pd := publishHeldMessage(heldMessage); pd != nil {
+		releaseHeldMessage(heldMessage)
+		return nil, pd
+	}
+	return heldMessage, nil
+}
+
+func RejectHeldMessage(heldMessageId string) (*models.HeldMessage, *models.ProblemDetail) {
+	heldMessage, pd := selectUnresolvedHeldMessage(heldMessageId)
+	if pd != nil {
+		return nil, pd
+	}
+	if pd := claimHeldMessage(heldMessage, models.HELD_MESSAGE_STATUS_REJECTED); pd != nil {
+		return nil, pd
+	}
+	return heldMessage, nil
+}
+
+func publishHeldMessage(heldMessage *models.HeldMessage) *models.ProblemDetail {
+	switch heldMessage.Kind {
+	case models.HELD_MESSAGE_KIND_POST:
+		room, pd := selectRoom(heldMessage.RoomId)
+		if pd != nil {
+			return pd
+		}
+		message := &models.Message{
+			MessageId: heldMessage.MessageId,
+			RoomId:    heldMessage.RoomId,
+			UserId:    heldMessage.UserId,
+			Type:      heldMessage.Type,
+			Payload:   heldMessage.Payload,
+		}
+		message.BeforeSave()
+		outboxItems := newMessageOutboxItems(room, message, messagePushText(room, message))
+		dRes := datastore.GetProvider().InsertMessage(message, outboxItems)
+		if dRes.ProblemDetail != nil {
+			return dRes.ProblemDetail
+		}
+		go recordMessageChanges([]*models.Message{message}, outboxItems)
+		go dispatchOutboxItems(outboxItems)
+		go dispatchWebhookEvent(models.WEBHOOK_EVENT_MESSAGE_CREATED, message)
+	case models.HELD_MESSAGE_KIND_EDIT:
+		message, pd := GetMessage(heldMessage.MessageId)
+		if pd != nil {
+			return pd
+		}
+		message.Payload = heldMessage.Payload
+		if _, pd := updateMessage(message); pd != nil {
+			return pd
+		}
+	}
+	return nil
+}
+
+func holdMessage(message *models.Message, kind, reason string) (*models.HeldMessage, *models.ProblemDetail) {
+	heldMessage := models.NewHeldMessage(message, kind, reason)
+	heldMessage.BeforeSave()
+	dRes := datastore.GetProvider().InsertHeldMessage(heldMessage)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	return heldMessage, nil
+}
+
+// claimHeldMessage resolves the held message with status, or returns a conflict when it has been resolved already.
+func claimHeldMessage(heldMessage *models.HeldMessage, status string) *models.ProblemDetail {
+	dRes := datastore.GetProvider().ClaimHeldMessage(heldMessage, status)
+	if dRes.ProblemDetail != nil {
+		return dRes.ProblemDetail
+	}
+	if !dRes.Data.(bool) {
+		return &models.ProblemDetail{
+			Status: http.StatusConflict,
+		}
+	}
+	return nil
+}
+
+// releaseHeldMessage puts the claimed message back in the queue.
+func releaseHeldMessage(heldMessage *models.HeldMessage) {
+	heldMessage.Status = models.HELD_MESSAGE_STATUS_HELD
+	heldMessage.BeforeSave()
+	dRes := datastore.GetProvider().UpdateHeldMessage(heldMessage)
+	if dRes.ProblemDetail != nil {
+		logProblemDetail("Held message release error.", dRes.ProblemDetail)
+	}
+}
+
+func selectHeldMessage(heldMessageId string) (*models.HeldMessage, *models.ProblemDetail) {
+	dRes := datastore.GetProvider().SelectHeldMessage(heldMessageId)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	if dRes.Data == nil {
+		return nil, &models.ProblemDetail{
+			Status: http.StatusNotFound,
+		}
+	}
+	return dRes.Data.(*models.HeldMessage), nil
+}
+
+func selectUnresolvedHeldMessage(heldMessageId string) (*models.HeldMessage, *models.ProblemDetail) {
+	heldMessage, pd := selectHeldMessage(heldMessageId)
+	if pd != nil {
+		return nil, pd
+	}
+	if heldMessage.Status != models.HELD_MESSAGE_STATUS_HELD {
+		return nil, &models.ProblemDetail{
+			Status: http.StatusConflict,
+		}
+	}
+	return heldMessage, nil
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/swagchat/chat-api/datastore"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+var (
+	incomingWebhookLimiter     *utils.RateLimiter
+	incomingWebhookLimiterOnce sync.Once
+)
+
+func getIncomingWebhookLimiter() *utils.RateLimiter {
+	incomingWebhookLimiterOnce.Do(func() {
+		limit, err := strconv.Atoi(utils.Cfg.Webhook.IncomingRateLimit)
+		if err != nil {
+			limit = 0
+		}
+		incomingWebhookLimiter = utils.NewRateLimiter(limit, time.Minute)
+	})
+	return incomingWebhookLimiter
+}
+
+func PostIncomingWebhook(post *models.IncomingWebhook) (*models.IncomingWebhook, *models.ProblemDetail) {
+	if pd := post.IsValid(); pd != nil {
+		return nil, pd
+	}
+	if pd := validateIncomingWebhookMember(post); pd != nil {
+		return nil, pd
+	}
+	post.BeforeSave()
+
+	dRes := datastore.GetProvider().SelectIncomingWebhook(post.IncomingWebhookId)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	if dRes.Data != nil {
+		return nil, &models.ProblemDetail{
+			Status: http.StatusConflict,
+		}
+	}
+
+	dRes = datastore.GetProvider().InsertIncomingWebhook(post)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	return dRes.Data.(*models.IncomingWebhook), nil
+}
+
+func GetIncomingWebhooks(params url.Values) (*models.IncomingWebhooks, *models.ProblemDetail) {
+	roomId := ""
+	if roomIdArray, ok := params["roomId"]; ok {
+		roomId = roomIdArray[0]
+	}
+
+	dRes := datastore.GetProvider().SelectIncomingWebhooks(roomId)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	return &models.IncomingWebhooks{
+		IncomingWebhooks: dRes.Data.([]*models.IncomingWebhook),
+	}, nil
+}
+
+func GetIncomingWebhook(incomingWebhookId string) (*models.IncomingWebhook, *models.ProblemDetail) {
+	return selectIncomingWebhook(incomingWebhookId)
+}
+
+func PutIncomingWebhook(put *models.IncomingWebhook) (*models.IncomingWebhook, *models.ProblemDetail) {
+	incomingWebhook, pd := selectActiveIncomingWebhook(put.IncomingWebhookId)
+	if pd != nil {
+		return nil, pd
+	}
+
+	incomingWebhook.Put(put)
+	if pd := incomingWebhook.IsValid(); pd != nil {
+		return nil, pd
+	}
+	if pd := validateIncomingWebhookMember(incomingWebhook); pd != nil {
+		return nil, pd
+	}
+	incomingWebhook.BeforeSave()
+
+	dRes := datastore.GetProvider().UpdateIncomingWebhook(incomingWebhook)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	return dRes.Data.(*models.IncomingWebhook), nil
+}
+
+// RevokeIncomingWebhook disables the secret url permanently.
+// The record is kept so that the integration stays visible in the admin list.
+func RevokeIncomingWebhook(incomingWebhookId string) *models.ProblemDetail {
+	incomingWebhook, pd := selectActiveIncomingWebhook(incomingWebhookId)
+	if pd != nil {
+		return pd
+	}
+
+	incomingWebhook.Revoked = time.Now().Unix()
+	incomingWebhook.BeforeSave()
+	dRes := datastore.GetProvider().UpdateIncomingWebhook(incomingWebhook)
+	if dRes.ProblemDetail != nil {
+		return dRes.ProblemDetail
+	}
+	return nil
+}
+
+func PostIncomingWebhookMessage(secret string, post *models.IncomingWebhookPayload) (*models.ResponseMessages, *models.ProblemDetail) {
+	dRes := datastore.GetProvider().SelectIncomingWebhookBySecret(secret)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	if dRes.Data == nil {
+		return nil, &models.ProblemDetail{
+			Status: http.StatusNotFound,
+		}
+	}
+	incomingWebhook := dRes.Data.(*models.IncomingWebhook)
+
+	if !getIncomingWebhookLimiter().AllowWithLimit(incomingWebhook.IncomingWebhookId, incomingWebhookRateLimit(incomingWebhook)) {
+		return nil, &models.ProblemDetail{
+			Title:     "Too many requests to the incoming webhook. Please try again later.",
+			Status:    http.StatusTooManyRequests,
+			ErrorName: models.ERROR_NAME_TOO_MANY_REQUESTS,
+		}
+	}
+
+	if pd := post.IsValid(); pd != nil {
+		return nil, pd
+	}
+
+	if _, pd := selectRoomUser(incomingWebhook.RoomId, incomingWebhook.UserId); pd != nil {
+		return nil, &models.ProblemDetail{
+			Title:     "The incoming webhook user is no longer a member of the room.",
+			Status:    http.StatusForbidden,
+			ErrorName: models.ERROR_NAME_OPERATION_NOT_PERMITTED,
+		}
+	}
+
+	return PostMessage(&models.Messages{
+		Messages: []*models.Message{post.Message(incomingWebhook)},
+	}), nil
+}
+
+func incomingWebhookRateLimit(incomingWebhook *models.IncomingWebhook) int {
+	if incomingWebhook.RateLimit > 0 {
+		return incomingWebhook.RateLimit
+	}
+	limit, err := strconv.Atoi(utils.Cfg.Webhook.IncomingRateLimit)
+	if err != nil {
+		return 0
+	}
+	return limit
+}
+
+func validateIncomingWebhookMember(incomingWebhook *models.IncomingWebhook) *models.ProblemDetail {
+	if _, pd := selectRoom(incomingWebhook.RoomId); pd != nil {
+		return &models.ProblemDetail{
+			Title:     "Request parameter error. (Create incoming webhook item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: models.ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []models.InvalidParam{
+				models.InvalidParam{
+					Name:   "roomId",
+					Reason: "roomId is invalid. Not exist room.",
+				},
+			},
+		}
+	}
+
+	if _, pd := selectRoomUser(incomingWebhook.RoomId, incomingWebhook.UserId); pd != nil {
+		return &models.ProblemDetail{
+			Title:     "Request parameter error. (Create incoming webhook item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: models.ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []models.InvalidParam{
+				models.InvalidParam{
+					Name:   "userId",
+					Reason: "userId is invalid. The user is not a member of the room.",
+				},
+			},
+		}
+	}
+
+	return nil
+}
+
+func selectIncomingWebhook(incomingWebhookId string) (*models.IncomingWebhook, *models.ProblemDetail) {
+	dRes := datastore.GetProvider().SelectIncomingWebhook(incomingWebhookId)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	if dRes.Data == nil {
+		return nil, &models.ProblemDetail{
+			Status: http.StatusNotFound,
+		}
+	}
+	return dRes.Data.(*models.IncomingWebhook), nil
+}
+
+func selectActiveIncomingWebhook(incomingWebhookId string) (*models.IncomingWebhook, *models.ProblemDetail) {
+	incomingWebhook, pd := selectIncomingWebhook(incomingWebhookId)
+	if pd != nil {
+		return nil, pd
+	}
+	if incomingWebhook.Revoked != 0 {
+		return nil, &models.ProblemDetail{
+			Status: http.StatusConflict,
+		}
+	}
+	return incomingWebhook, nil
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/swagchat/chat-api/datastore"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func TestMain(m *testing.M) {
+	// A database of its own, as the handler tests may run at the same time
+	utils.Cfg.Datastore.Provider = "sqlite"
+	utils.Cfg.Datastore.SqlitePath = filepath.Join(os.TempDir(), "swagchat_services_test.db")
+	os.Remove(utils.Cfg.Datastore.SqlitePath)
+
+	datastoreProvider := datastore.GetProvider()
+	err := datastoreProvider.Connect()
+	if err != nil {
+		log.Println(err.Error())
+	}
+	datastoreProvider.Init()
+	testRC := m.Run()
+	err = datastoreProvider.DropDatabase()
+	if err != nil {
+		log.Println(err.Error())
+	}
+	os.Exit(testRC)
+}
@@ -1,94 +1,240 @@
 package services
 
 import (
-	"context"
-	"encoding/json"
 	"net/http"
 	"strconv"
-
-	"go.uber.org/zap"
+	"time"
 
 	"github.com/swagchat/chat-api/datastore"
 	"github.com/swagchat/chat-api/models"
-	"github.com/swagchat/chat-api/notification"
-	"github.com/swagchat/chat-api/rtm"
+	"github.com/swagchat/chat-api/moderation"
 	"github.com/swagchat/chat-api/utils"
 )
 
 func PostMessage(posts *models.Messages) *models.ResponseMessages {
 	messageIds := make([]string, 0)
+	heldMessageIds := make([]string, 0)
+	commandMessageIds := make([]string, 0)
 	errors := make([]*models.ProblemDetail, 0)
-	var lastMessage string
 	for _, post := range posts.Messages {
-		room, pd := selectRoom(post.RoomId)
+		room, pd := validateMessage(post)
 		if pd != nil {
-			errors = append(errors, &models.ProblemDetail{
-				Title:     "Request parameter error. (Create message item)",
-				Status:    http.StatusBadRequest,
-				ErrorName: models.ERROR_NAME_INVALID_PARAM,
-				InvalidParams: []models.InvalidParam{
-					models.InvalidParam{
-						Name:   "roomId",
-						Reason: "roomId is invalid. Not exist room.",
-					},
-				},
-			})
+			errors = append(errors, pd)
 			continue
 		}
 
-		_, pd = selectUser(post.UserId)
-		if pd != nil {
-			errors = append(errors, &models.ProblemDetail{
-				Title:     "Request parameter error. (Create message item)",
-				Status:    http.StatusBadRequest,
-				ErrorName: models.ERROR_NAME_INVALID_PARAM,
-				InvalidParams: []models.InvalidParam{
-					models.InvalidParam{
-						Name:   "userId",
-						Reason: "userId is invalid. Not exist user.",
-					},
-				},
-			})
+		post.BeforeSave()
+		if slashCommand, args := matchSlashCommand(post); slashCommand != nil {
+			go invokeSlashCommand(slashCommand, post, args)
+			commandMessageIds = append(commandMessageIds, post.MessageId)
 			continue
 		}
 
-		if pd := post.IsValid(); pd != nil {
-			errors = append(errors, pd)
+		mRes := moderation.Moderate(post)
+		switch mRes.Action {
+		case moderation.ACTION_REJECT:
+			errors = append(errors, mRes.ProblemDetail)
+			continue
+		case moderation.ACTION_HOLD:
+			if _, pd := holdMessage(post, models.HELD_MESSAGE_KIND_POST, mRes.Reason); pd != nil {
+				errors = append(errors, pd)
+				continue
+			}
+			heldMessageIds = append(heldMessageIds, post.MessageId)
 			continue
+		case moderation.ACTION_MASK:
+			post.Payload = mRes.Payload
 		}
 
-		post.BeforeSave()
-		dRes := datastore.GetProvider().InsertMessage(post)
+		outboxItems := newMessageOutboxItems(room, post, messagePushText(room, post))
+		dRes := datastore.GetProvider().InsertMessage(post, outboxItems)
 		if dRes.ProblemDetail != nil {
 			errors = append(errors, dRes.ProblemDetail)
 			continue
 		}
-		if dRes.Data == nil {
-			lastMessage = ""
-		} else {
-			lastMessage = dRes.Data.(string)
-		}
 		messageIds = append(messageIds, post.MessageId)
 
-		mi := &notification.MessageInfo{
-			Text: utils.AppendStrings("[", room.Name, "]", lastMessage),
+		go recordMessageChanges([]*models.Message{post}, outboxItems)
+		go dispatchOutboxItems(outboxItems)
+		go dispatchWebhookEvent(models.WEBHOOK_EVENT_MESSAGE_CREATED, post)
+		go notifyBots(room, post)
+	}
+
+	responseMessages := &models.ResponseMessages{
+		MessageIds:        messageIds,
+		HeldMessageIds:    heldMessageIds,
+		CommandMessageIds: commandMessageIds,
+		Errors:            errors,
+	}
+	return responseMessages
+}
+
+// PostMessageAtomic validates every message before inserting any of them,
+// and inserts them all in a single transaction, together with those held by moderation.
+// Notifications are sent once per room, and slash commands and bots are called, after the transaction is committed.
+func PostMessageAtomic(posts *models.Messages) *models.ResponseMessages {
+	errors := make([]*models.ProblemDetail, 0)
+	if len(posts.Messages) == 0 {
+		errors = append(errors, &models.ProblemDetail{
+			Title:     "Request parameter error. (Create message item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: models.ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []models.InvalidParam{
+				models.InvalidParam{
+					Name:   "messages",
+					Reason: "messages is required, but it's empty.",
+				},
+			},
+		})
+		return &models.ResponseMessages{
+			Errors: errors,
+		}
+	}
+
+	type command struct {
+		slashCommand *models.SlashCommand
+		message      *models.Message
+		args         string
+	}
+	rooms := make(map[string]*models.Room)
+	messages := make([]*models.Message, 0)
+	heldMessages := make([]*models.HeldMessage, 0)
+	commands := make([]*command, 0)
+	for _, post := range posts.Messages {
+		room, pd := validateMessage(post)
+		if pd != nil {
+			errors = append(errors, pd)
+			continue
+		}
+
+		post.BeforeSave()
+		if slashCommand, args := matchSlashCommand(post); slashCommand != nil {
+			commands = append(commands, &command{slashCommand, post, args})
+			continue
+		}
+
+		mRes := moderation.Moderate(post)
+		switch mRes.Action {
+		case moderation.ACTION_REJECT:
+			errors = append(errors, mRes.ProblemDetail)
+			continue
+		case moderation.ACTION_HOLD:
+			heldMessages = append(heldMessages, models.NewHeldMessage(post, models.HELD_MESSAGE_KIND_POST, mRes.Reason))
+			continue
+		case moderation.ACTION_MASK:
+			post.Payload = mRes.Payload
+		}
+
+		rooms[room.RoomId] = room
+		messages = append(messages, post)
+	}
+	if len(errors) > 0 {
+		return &models.ResponseMessages{
+			Errors: errors,
+		}
+	}
+
+	// The outbox is built before the insert, with a single push notification per room
+	messageCounts := make(map[string]int)
+	pushTexts := make(map[string]string)
+	pushMessages := make(map[string][]*models.Message)
+	outboxItems := make([]*models.OutboxItem, 0)
+	for _, message := range messages {
+		messageCounts[message.RoomId]++
+		if message.Type != models.MESSAGE_TYPE_SYSTEM {
+			pushTexts[message.RoomId] = message.LastMessageText()
+			pushMessages[message.RoomId] = append(pushMessages[message.RoomId], message)
 		}
-		if utils.Cfg.Notification.DefaultBadgeCount != "" {
-			dBadgeCount, err := strconv.Atoi(utils.Cfg.Notification.DefaultBadgeCount)
-			if err == nil {
-				mi.Badge = dBadgeCount
+		outboxItems = append(outboxItems, newMessageOutboxItems(rooms[message.RoomId], message, "")...)
+	}
+	for roomId, room := range rooms {
+		pushText := pushTexts[roomId]
+		if messageCounts[roomId] > 1 {
+			pushText = utils.AppendStrings(strconv.Itoa(messageCounts[roomId]), " new messages")
+		}
+		if pushText != "" && room.NotificationTopicId != "" {
+			if item := newOutboxPushItem(room, pushMessages[roomId], pushText); item != nil {
+				outboxItems = append(outboxItems, leaseOutboxItem(item))
 			}
 		}
-		ctx, _ := context.WithCancel(context.Background())
-		go notification.GetProvider().Publish(ctx, room.NotificationTopicId, room.RoomId, mi)
-		go publishMessage(post)
 	}
 
-	responseMessages := &models.ResponseMessages{
-		MessageIds: messageIds,
-		Errors:     errors,
+	heldMessageIds := make([]string, 0)
+	for _, heldMessage := range heldMessages {
+		heldMessage.BeforeSave()
+		heldMessageIds = append(heldMessageIds, heldMessage.MessageId)
 	}
-	return responseMessages
+	dRes := datastore.GetProvider().InsertMessages(messages, outboxItems, heldMessages)
+	if dRes.ProblemDetail != nil {
+		return &models.ResponseMessages{
+			Errors: []*models.ProblemDetail{dRes.ProblemDetail},
+		}
+	}
+
+	messageIds := make([]string, 0)
+	for _, message := range messages {
+		messageIds = append(messageIds, message.MessageId)
+		go dispatchWebhookEvent(models.WEBHOOK_EVENT_MESSAGE_CREATED, message)
+		go notifyBots(rooms[message.RoomId], message)
+	}
+	go recordMessageChanges(messages, outboxItems)
+	go dispatchOutboxItems(outboxItems)
+
+	// Commands are not stored, so they are invoked only once the rest of the batch is committed
+	commandMessageIds := make([]string, 0)
+	for _, c := range commands {
+		commandMessageIds = append(commandMessageIds, c.message.MessageId)
+		go invokeSlashCommand(c.slashCommand, c.message, c.args)
+	}
+
+	return &models.ResponseMessages{
+		MessageIds:        messageIds,
+		HeldMessageIds:    heldMessageIds,
+		CommandMessageIds: commandMessageIds,
+		Errors:            errors,
+	}
+}
+
+func validateMessage(post *models.Message) (*models.Room, *models.ProblemDetail) {
+	room, pd := selectRoom(post.RoomId)
+	if pd != nil {
+		return nil, &models.ProblemDetail{
+			Title:     "Request parameter error. (Create message item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: models.ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []models.InvalidParam{
+				models.InvalidParam{
+					Name:   "roomId",
+					Reason: "roomId is invalid. Not exist room.",
+				},
+			},
+		}
+	}
+
+	_, pd = selectUser(post.UserId)
+	if pd != nil {
+		return nil, &models.ProblemDetail{
+			Title:     "Request parameter error. (Create message item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: models.ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []models.InvalidParam{
+				models.InvalidParam{
+					Name:   "userId",
+					Reason: "userId is invalid. Not exist user.",
+				},
+			},
+		}
+	}
+
+	if pd := post.IsValid(); pd != nil {
+		return nil, pd
+	}
+
+	if post.Type == models.MESSAGE_TYPE_POLL {
+		normalizePollPayload(post)
+	}
+
+	return room, nil
 }
 
 func GetMessage(messageId string) (*models.Message, *models.ProblemDetail) {
@@ -110,29 +256,104 @@ func GetMessage(messageId string) (*models.Message, *models.ProblemDetail) {
 	if dRes.ProblemDetail != nil {
 		return nil, dRes.ProblemDetail
 	}
-	if dRes.Data == nil {
+	if dRes.Data == nil || dRes.Data.(*models.Message).Deleted != 0 {
 		return nil, &models.ProblemDetail{
 			Status: http.StatusNotFound,
 		}
 	}
-	return dRes.Data.(*models.Message), nil
+	message := dRes.Data.(*models.Message)
+
+	if pd := attachPollResult(message); pd != nil {
+		return nil, pd
+	}
+	return message, nil
+}
+
+func DeleteMessage(messageId string) *models.ProblemDetail {
+	message, pd := GetMessage(messageId)
+	if pd != nil {
+		return pd
+	}
+
+	message.Deleted = time.Now().Unix()
+	dRes := datastore.GetProvider().UpdateMessage(message)
+	if dRes.ProblemDetail != nil {
+		return dRes.ProblemDetail
+	}
+
+	go publishEvent(models.NewEvent(models.EVENT_NAME_MESSAGE_DELETE, message.RoomId, message.UserId, message))
+	return nil
 }
 
-func publishMessage(m *models.Message) {
-	m.EventName = "message"
-	bytes, err := json.Marshal(m)
-	if err != nil {
-		utils.AppLogger.Error("",
-			zap.String("msg", err.Error()),
-		)
+// PutMessage edits the payload of a message.
+// When moderation holds the edit, the message is left unchanged and the held message is returned instead.
+func PutMessage(put *models.Message) (*models.Message, *models.HeldMessage, *models.ProblemDetail) {
+	message, pd := GetMessage(put.MessageId)
+	if pd != nil {
+		return nil, nil, pd
+	}
+
+	if put.UserId == "" {
+		return nil, nil, &models.ProblemDetail{
+			Title:     "Request parameter error. (Update message item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: models.ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []models.InvalidParam{
+				models.InvalidParam{
+					Name:   "userId",
+					Reason: "userId is required, but it's empty.",
+				},
+			},
+		}
 	}
-	mi := &rtm.MessagingInfo{
-		Message: string(bytes),
+	if put.UserId != message.UserId {
+		return nil, nil, &models.ProblemDetail{
+			Title:     "Only the author can edit this message. (Update message item)",
+			Status:    http.StatusForbidden,
+			ErrorName: models.ERROR_NAME_OPERATION_NOT_PERMITTED,
+		}
 	}
-	err = rtm.GetMessagingProvider().PublishMessage(mi)
-	if err != nil {
-		utils.AppLogger.Error("",
-			zap.String("msg", err.Error()),
-		)
+
+	if message.Type == models.MESSAGE_TYPE_POLL {
+		return nil, nil, &models.ProblemDetail{
+			Title:     "Request parameter error. (Update message item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: models.ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []models.InvalidParam{
+				models.InvalidParam{
+					Name:   "type",
+					Reason: "Poll messages can not be edited.",
+				},
+			},
+		}
+	}
+
+	message.Put(put)
+	if pd := message.IsValid(); pd != nil {
+		return nil, nil, pd
+	}
+
+	mRes := moderation.Moderate(message)
+	switch mRes.Action {
+	case moderation.ACTION_REJECT:
+		return nil, nil, mRes.ProblemDetail
+	case moderation.ACTION_HOLD:
+		heldMessage, pd := holdMessage(message, models.HELD_MESSAGE_KIND_EDIT, mRes.Reason)
+		return nil, heldMessage, pd
+	case moderation.ACTION_MASK:
+		message.Payload = mRes.Payload
+	}
+
+	message, pd = updateMessage(message)
+	return message, nil, pd
+}
+
+func updateMessage(message *models.Message) (*models.Message, *models.ProblemDetail) {
+	message.BeforeSave()
+	dRes := datastore.GetProvider().UpdateMessage(message)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
 	}
+	go publishEvent(models.NewEvent(models.EVENT_NAME_MESSAGE_EDIT, message.RoomId, message.UserId, message))
+	return message, nil
 }
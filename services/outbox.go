@@ -0,0 +1,352 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/swagchat/chat-api/datastore"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/notification"
+	"github.com/swagchat/chat-api/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	outboxWorkerBatchSize = 100
+
+	// outboxDispatchTimeout bounds a push notification attempt
+	outboxDispatchTimeout = 30 * time.Second
+
+	outboxPurgeInterval = time.Hour
+)
+
+func GetOutboxItems(params url.Values) (*models.OutboxItems, *models.ProblemDetail) {
+	limit, offset, _, pd := setPagingParams(params)
+	if pd != nil {
+		return nil, pd
+	}
+
+	status := params.Get("status")
+	kind := params.Get("kind")
+	if pd := models.IsValidOutboxParams(status, kind); pd != nil {
+		return nil, pd
+	}
+
+	dRes := datastore.GetProvider().SelectOutboxItems(status, kind, limit, offset)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	return &models.OutboxItems{
+		OutboxItems: dRes.Data.([]*models.OutboxItem),
+	}, nil
+}
+
+func GetOutboxItem(outboxItemId string) (*models.OutboxItem, *models.ProblemDetail) {
+	return selectOutboxItem(outboxItemId)
+}
+
+// RequeueOutboxItem resets the attempts of a pending or dead item and dispatches it immediately.
+func RequeueOutboxItem(outboxItemId string) (*models.OutboxItem, *models.ProblemDetail) {
+	item, pd := selectOutboxItem(outboxItemId)
+	if pd != nil {
+		return nil, pd
+	}
+	if item.Status == models.OUTBOX_ITEM_STATUS_SUCCEEDED {
+		return nil, &models.ProblemDetail{
+			Status: http.StatusConflict,
+		}
+	}
+
+	item.Status = models.OUTBOX_ITEM_STATUS_PENDING
+	item.Attempts = 0
+	item.LastError = ""
+	item.NextAttempt = time.Now().Unix() + outboxLease()
+	item.BeforeSave()
+	dRes := datastore.GetProvider().UpdateOutboxItem(item)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+
+	dispatchOutboxItem(item)
+	return item, nil
+}
+
+// RunOutboxDispatcher retries pending outbox items whose next attempt time has come,
+// and purges the items delivered successfully.
+func RunOutboxDispatcher(ctx context.Context) {
+	interval, err := strconv.Atoi(utils.Cfg.Outbox.WorkerInterval)
+	if err != nil || interval <= 0 {
+		interval = 5
+	}
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+	purgeTicker := time.NewTicker(outboxPurgeInterval)
+	defer purgeTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			retryOutboxItems()
+		case <-purgeTicker.C:
+			purgeOutboxItems()
+		}
+	}
+}
+
+func retryOutboxItems() {
+	now := time.Now().Unix()
+	dRes := datastore.GetProvider().SelectRetryableOutboxItems(now, outboxWorkerBatchSize)
+	if dRes.ProblemDetail != nil {
+		logProblemDetail("Outbox retry error.", dRes.ProblemDetail)
+		return
+	}
+
+	items := make([]*models.OutboxItem, 0)
+	for _, item := range dRes.Data.([]*models.OutboxItem) {
+		// Claim the item so that neither the next tick nor another process picks it up while it is in flight
+		nextAttempt := now + outboxLease()
+		cRes := datastore.GetProvider().ClaimOutboxItem(item, nextAttempt)
+		if cRes.ProblemDetail != nil {
+			logProblemDetail("Outbox retry error.", cRes.ProblemDetail)
+			continue
+		}
+		if !cRes.Data.(bool) {
+			continue
+		}
+		item.NextAttempt = nextAttempt
+		items = append(items, item)
+	}
+	go dispatchOutboxItems(items)
+}
+
+// purgeOutboxItems deletes the items delivered successfully more than Outbox.RetentionHours ago.
+func purgeOutboxItems() {
+	hours, err := strconv.ParseInt(utils.Cfg.Outbox.RetentionHours, 10, 64)
+	if err != nil || hours <= 0 {
+		return
+	}
+	dRes := datastore.GetProvider().DeleteSucceededOutboxItems(time.Now().Unix() - hours*3600)
+	if dRes.ProblemDetail != nil {
+		logProblemDetail("Outbox purge error.", dRes.ProblemDetail)
+	}
+}
+
+// newMessageOutboxItems returns the outbox items of a new message: its real-time event,
+// and a push notification with pushText when the room has a notification topic and a recipient.
+// They are leased, so that RunOutboxDispatcher leaves them to the dispatch right after the insert.
+func newMessageOutboxItems(room *models.Room, message *models.Message, pushText string) []*models.OutboxItem {
+	items := make([]*models.OutboxItem, 0, 2)
+	if utils.Cfg.Rtm.Provider != "" {
+		items = append(items, models.NewOutboxRtmItem(message, models.EVENT_NAME_MESSAGE))
+	}
+	if pushText != "" && room.NotificationTopicId != "" {
+		if item := newOutboxPushItem(room, []*models.Message{message}, pushText); item != nil {
+			items = append(items, item)
+		}
+	}
+	for _, item := range items {
+		leaseOutboxItem(item)
+	}
+	return items
+}
+
+// leaseOutboxItem prepares a new item to be dispatched right after it is inserted.
+func leaseOutboxItem(item *models.OutboxItem) *models.OutboxItem {
+	item.NextAttempt = time.Now().Unix() + outboxLease()
+	item.BeforeSave()
+	return item
+}
+
+// newOutboxPushItem returns the push notification of the messages to their recipients, or nil without a recipient.
+func newOutboxPushItem(room *models.Room, messages []*models.Message, text string) *models.OutboxItem {
+	userIds, pd := pushRecipients(room.RoomId, messages, time.Now())
+	if pd != nil {
+		// The push is not worth failing the messages for, it goes to everyone in the room instead
+		logProblemDetail("Push recipients error.", pd)
+	} else if len(userIds) == 0 {
+		return nil
+	}
+
+	push := &models.OutboxPush{
+		Text:    utils.AppendStrings("[", room.Name, "]", text),
+		UserIds: userIds,
+	}
+	if utils.Cfg.Notification.DefaultBadgeCount != "" {
+		dBadgeCount, err := strconv.Atoi(utils.Cfg.Notification.DefaultBadgeCount)
+		if err == nil {
+			push.Badge = dBadgeCount
+		}
+	}
+	return models.NewOutboxPushItem(room, messages[len(messages)-1].MessageId, push)
+}
+
+// pushRecipients returns the users of the room pushed the messages at now. They are the users of the room but the senders,
+// and those blocking them, except the users who muted the room, who are only notified of mentions and are not mentioned,
+// or who are in their quiet hours. The unread counts of the users left out are counted all the same.
+func pushRecipients(roomId string, messages []*models.Message, now time.Time) ([]string, *models.ProblemDetail) {
+	mentioned := make(map[string]map[string]bool)
+	senderIds := make([]string, 0)
+	for _, message := range messages {
+		if mentioned[message.UserId] == nil {
+			mentioned[message.UserId] = make(map[string]bool)
+			senderIds = append(senderIds, message.UserId)
+		}
+		for _, userId := range mentionedUserIds(message) {
+			mentioned[message.UserId][userId] = true
+		}
+	}
+
+	userIds := make([]string, 0)
+	for _, senderId := range senderIds {
+		dRes := datastore.GetProvider().SelectPushSettings(roomId, senderId)
+		if dRes.ProblemDetail != nil {
+			return nil, dRes.ProblemDetail
+		}
+		for _, pushSetting := range dRes.Data.([]*models.PushSetting) {
+			if utils.SearchStringValueInSlice(userIds, pushSetting.UserId) {
+				continue
+			}
+			if pushSetting.IsPushed(mentioned[senderId][pushSetting.UserId], now) {
+				userIds = append(userIds, pushSetting.UserId)
+			}
+		}
+	}
+	return userIds, nil
+}
+
+// mentionedUserIds returns the users mentioned as @userId in a text message.
+func mentionedUserIds(message *models.Message) []string {
+	userIds := make([]string, 0)
+	if message.Type != models.MESSAGE_TYPE_TEXT {
+		return userIds
+	}
+	var pt models.PayloadText
+	json.Unmarshal(message.Payload, &pt)
+	for _, match := range mentionRegexp.FindAllStringSubmatch(pt.Text, -1) {
+		userIds = append(userIds, match[1])
+	}
+	return userIds
+}
+
+// messagePushText is the text pushed for a new message, the room's last message once the message is inserted.
+func messagePushText(room *models.Room, message *models.Message) string {
+	if message.Type != models.MESSAGE_TYPE_SYSTEM {
+		return message.LastMessageText()
+	}
+	if utils.Cfg.SystemMessage.UnreadCount {
+		return room.LastMessage
+	}
+	return ""
+}
+
+func dispatchOutboxItems(items []*models.OutboxItem) {
+	for _, item := range items {
+		dispatchOutboxItem(item)
+	}
+}
+
+// dispatchOutboxItem makes one delivery attempt and records its result.
+// A failed item is retried with backoff, and moved to the dead letters after MaxAttempts.
+func dispatchOutboxItem(item *models.OutboxItem) {
+	item.Attempts++
+	if err := deliverOutboxItem(item); err == nil {
+		item.Status = models.OUTBOX_ITEM_STATUS_SUCCEEDED
+		item.LastError = ""
+	} else {
+		item.LastError = err.Error()
+		maxAttempts, convErr := strconv.Atoi(utils.Cfg.Outbox.MaxAttempts)
+		if convErr != nil {
+			maxAttempts = 10
+		}
+		if item.Attempts >= maxAttempts {
+			item.Status = models.OUTBOX_ITEM_STATUS_DEAD
+			utils.AppLogger.Error("",
+				zap.String("msg", "Outbox item has been moved to the dead letters."),
+				zap.String("outboxItemId", item.OutboxItemId),
+				zap.String("kind", item.Kind),
+				zap.String("detail", item.LastError),
+			)
+		} else {
+			item.NextAttempt = time.Now().Unix() + outboxBackoff(item.Attempts)
+		}
+	}
+
+	item.BeforeSave()
+	dRes := datastore.GetProvider().UpdateOutboxItem(item)
+	if dRes.ProblemDetail != nil {
+		logProblemDetail("Outbox dispatch error.", dRes.ProblemDetail)
+	}
+}
+
+func deliverOutboxItem(item *models.OutboxItem) error {
+	switch item.Kind {
+	case models.OUTBOX_KIND_RTM:
+		event, err := item.Event()
+		if err != nil {
+			return err
+		}
+		return sendEvent(event)
+	case models.OUTBOX_KIND_PUSH:
+		push, err := item.Push()
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), outboxDispatchTimeout)
+		defer cancel()
+		mi := &notification.MessageInfo{
+			Text:    push.Text,
+			Badge:   push.Badge,
+			UserIds: push.UserIds,
+		}
+		nRes := <-notification.GetProvider().Publish(ctx, push.NotificationTopicId, item.RoomId, mi)
+		if nRes.ProblemDetail != nil {
+			return problemDetailError(nRes.ProblemDetail)
+		}
+		return nil
+	}
+	return errors.New(utils.AppendStrings("unknown outbox item kind[", item.Kind, "]"))
+}
+
+// outboxBackoff returns the seconds to wait after the given number of failed attempts.
+func outboxBackoff(attempts int) int64 {
+	interval, err := strconv.ParseInt(utils.Cfg.Outbox.RetryInterval, 10, 64)
+	if err != nil || interval <= 0 {
+		interval = 5
+	}
+	maxInterval, err := strconv.ParseInt(utils.Cfg.Outbox.MaxRetryInterval, 10, 64)
+	if err != nil || maxInterval <= 0 {
+		maxInterval = 600
+	}
+	for i := 1; i < attempts && interval < maxInterval; i++ {
+		interval *= 2
+	}
+	if interval > maxInterval {
+		interval = maxInterval
+	}
+	return interval
+}
+
+// outboxLease is how long an in-flight item is hidden from the dispatcher.
+func outboxLease() int64 {
+	return int64(outboxDispatchTimeout/time.Second) + outboxBackoff(1)
+}
+
+func selectOutboxItem(outboxItemId string) (*models.OutboxItem, *models.ProblemDetail) {
+	dRes := datastore.GetProvider().SelectOutboxItem(outboxItemId)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	if dRes.Data == nil {
+		return nil, &models.ProblemDetail{
+			Status: http.StatusNotFound,
+		}
+	}
+	return dRes.Data.(*models.OutboxItem), nil
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/swagchat/chat-api/datastore"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+// setupRtmStub points the direct rtm provider at a server answering with the status code in status,
+// and returns the function restoring the config.
+func setupRtmStub(status *int32) func() {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(atomic.LoadInt32(status)))
+	}))
+	rtmCfg := *utils.Cfg.Rtm
+	outboxCfg := *utils.Cfg.Outbox
+	utils.Cfg.Rtm.Provider = "direct"
+	utils.Cfg.Rtm.DirectEndpoint = ts.URL
+	utils.Cfg.Rtm.ChannelMode = ""
+	return func() {
+		ts.Close()
+		*utils.Cfg.Rtm = rtmCfg
+		*utils.Cfg.Outbox = outboxCfg
+	}
+}
+
+// insertOutboxItem inserts an rtm item of an event to outbox-user, and returns it as stored.
+func insertOutboxItem(t *testing.T, outboxItemId, status string, attempts int, modified int64) *models.OutboxItem {
+	event := models.NewEvent(models.EVENT_NAME_MESSAGE, "outbox-room", "outbox-user", nil)
+	event.Recipients = []string{"outbox-user"}
+	item := models.NewOutboxRtmItem(&models.Message{RoomId: "outbox-room", UserId: "outbox-user"}, models.EVENT_NAME_MESSAGE)
+	item.OutboxItemId = outboxItemId
+	item.Data, _ = json.Marshal(event)
+	item.Status = status
+	item.Attempts = attempts
+	leaseOutboxItem(item)
+	if dRes := datastore.GetProvider().InsertMessages(nil, []*models.OutboxItem{item}, nil); dRes.ProblemDetail != nil {
+		t.Fatalf("the outbox item could not be inserted: %s", dRes.ProblemDetail.Title)
+	}
+	if modified != 0 {
+		item.Modified = modified
+		if dRes := datastore.GetProvider().UpdateOutboxItem(item); dRes.ProblemDetail != nil {
+			t.Fatalf("the outbox item could not be updated: %s", dRes.ProblemDetail.Title)
+		}
+	}
+	return getOutboxItem(t, outboxItemId)
+}
+
+func getOutboxItem(t *testing.T, outboxItemId string) *models.OutboxItem {
+	item, pd := selectOutboxItem(outboxItemId)
+	if pd != nil {
+		t.Fatalf("the outbox item %s could not be selected: %d", outboxItemId, pd.Status)
+	}
+	return item
+}
+
+func TestDispatchOutboxItem(t *testing.T) {
+	status := int32(http.StatusOK)
+	defer setupRtmStub(&status)()
+	utils.Cfg.Outbox.MaxAttempts = "3"
+	utils.Cfg.Outbox.RetryInterval = "5"
+	utils.Cfg.Outbox.MaxRetryInterval = "600"
+
+	testTable := []struct {
+		testNo      int
+		status      int32
+		attempts    int
+		outStatus   string
+		outAttempts int
+		outError    string
+		outBackoff  int64
+	}{
+		{1, http.StatusOK, 0, models.OUTBOX_ITEM_STATUS_SUCCEEDED, 1, "", 0},
+		// A success after failures clears the error
+		{2, http.StatusOK, 2, models.OUTBOX_ITEM_STATUS_SUCCEEDED, 3, "", 0},
+		// A failure is retried with backoff
+		{3, http.StatusInternalServerError, 0, models.OUTBOX_ITEM_STATUS_PENDING, 1, "http status code[500]", 5},
+		{4, http.StatusInternalServerError, 1, models.OUTBOX_ITEM_STATUS_PENDING, 2, "http status code[500]", 10},
+		// The failure of the last attempt moves the item to the dead letters
+		{5, http.StatusInternalServerError, 2, models.OUTBOX_ITEM_STATUS_DEAD, 3, "http status code[500]", 0},
+	}
+
+	for _, testRecord := range testTable {
+		atomic.StoreInt32(&status, testRecord.status)
+		outboxItemId := utils.AppendStrings("dispatch-outbox-item-", strconv.Itoa(testRecord.testNo))
+		item := insertOutboxItem(t, outboxItemId, models.OUTBOX_ITEM_STATUS_PENDING, testRecord.attempts, 0)
+		if testRecord.attempts > 0 {
+			item.LastError = "http status code[500]"
+		}
+
+		now := time.Now().Unix()
+		dispatchOutboxItem(item)
+		stored := getOutboxItem(t, outboxItemId)
+		if stored.Status != testRecord.outStatus || stored.Attempts != testRecord.outAttempts || stored.LastError != testRecord.outError {
+			t.Fatalf("TestNo %d\nOutbox Item Failure\n[expected]%s %d %s\n[result  ]%s %d %s", testRecord.testNo, testRecord.outStatus, testRecord.outAttempts, testRecord.outError, stored.Status, stored.Attempts, stored.LastError)
+		}
+		if testRecord.outBackoff > 0 {
+			if backoff := stored.NextAttempt - now; backoff < testRecord.outBackoff || backoff > testRecord.outBackoff+1 {
+				t.Fatalf("TestNo %d\nNext Attempt Failure\n[expected]%d\n[result  ]%d", testRecord.testNo, testRecord.outBackoff, backoff)
+			}
+		}
+	}
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	cfg := *utils.Cfg.Outbox
+	defer func() {
+		*utils.Cfg.Outbox = cfg
+	}()
+
+	testTable := []struct {
+		testNo           int
+		retryInterval    string
+		maxRetryInterval string
+		attempts         int
+		out              int64
+	}{
+		{1, "5", "600", 1, 5},
+		{2, "5", "600", 2, 10},
+		{3, "5", "600", 5, 80},
+		// Capped at MaxRetryInterval
+		{4, "5", "600", 8, 600},
+		{5, "5", "600", 100, 600},
+		{6, "5", "12", 3, 12},
+		{7, "700", "600", 1, 600},
+		// Invalid intervals fall back to the defaults
+		{8, "", "", 1, 5},
+		{9, "-1", "0", 3, 20},
+		{10, "abc", "abc", 20, 600},
+	}
+
+	for _, testRecord := range testTable {
+		utils.Cfg.Outbox.RetryInterval = testRecord.retryInterval
+		utils.Cfg.Outbox.MaxRetryInterval = testRecord.maxRetryInterval
+		if out := outboxBackoff(testRecord.attempts); out != testRecord.out {
+			t.Fatalf("TestNo %d\nBackoff Failure\n[expected]%d\n[result  ]%d", testRecord.testNo, testRecord.out, out)
+		}
+	}
+}
+
+func TestClaimOutboxItem(t *testing.T) {
+	item := insertOutboxItem(t, "claim-outbox-item-1", models.OUTBOX_ITEM_STATUS_PENDING, 1, 0)
+	// Another worker selected the same item
+	other := getOutboxItem(t, item.OutboxItemId)
+
+	nextAttempt := item.NextAttempt + 60
+	if cRes := datastore.GetProvider().ClaimOutboxItem(item, nextAttempt); cRes.ProblemDetail != nil || !cRes.Data.(bool) {
+		t.Fatalf("the item was not claimed: %v", cRes.Data)
+	}
+	// The other worker loses the race, as the next attempt has moved
+	if cRes := datastore.GetProvider().ClaimOutboxItem(other, nextAttempt+60); cRes.ProblemDetail != nil || cRes.Data.(bool) {
+		t.Fatalf("the item was claimed twice")
+	}
+	if stored := getOutboxItem(t, item.OutboxItemId); stored.NextAttempt != nextAttempt {
+		t.Fatalf("unexpected next attempt: %d, want %d", stored.NextAttempt, nextAttempt)
+	}
+
+	// Only pending items are claimed
+	dead := insertOutboxItem(t, "claim-outbox-item-2", models.OUTBOX_ITEM_STATUS_DEAD, 3, 0)
+	if cRes := datastore.GetProvider().ClaimOutboxItem(dead, dead.NextAttempt+60); cRes.ProblemDetail != nil || cRes.Data.(bool) {
+		t.Fatalf("a dead item was claimed")
+	}
+}
+
+func TestPurgeOutboxItems(t *testing.T) {
+	cfg := *utils.Cfg.Outbox
+	defer func() {
+		*utils.Cfg.Outbox = cfg
+	}()
+	utils.Cfg.Outbox.RetentionHours = "1"
+
+	old := time.Now().Unix() - 2*3600
+	insertOutboxItem(t, "purge-outbox-item-1", models.OUTBOX_ITEM_STATUS_SUCCEEDED, 1, old)
+	insertOutboxItem(t, "purge-outbox-item-2", models.OUTBOX_ITEM_STATUS_SUCCEEDED, 1, 0)
+	insertOutboxItem(t, "purge-outbox-item-3", models.OUTBOX_ITEM_STATUS_DEAD, 3, old)
+	insertOutboxItem(t, "purge-outbox-item-4", models.OUTBOX_ITEM_STATUS_PENDING, 1, old)
+
+	purgeOutboxItems()
+
+	// Only the items delivered successfully before the retention are deleted
+	testTable := []struct {
+		testNo       int
+		outboxItemId string
+		deleted      bool
+	}{
+		{1, "purge-outbox-item-1", true},
+		{2, "purge-outbox-item-2", false},
+		{3, "purge-outbox-item-3", false},
+		{4, "purge-outbox-item-4", false},
+	}
+	for _, testRecord := range testTable {
+		_, pd := selectOutboxItem(testRecord.outboxItemId)
+		if deleted := pd != nil && pd.Status == http.StatusNotFound; deleted != testRecord.deleted {
+			t.Fatalf("TestNo %d\nDeleted Failure\n[expected]%t\n[result  ]%t", testRecord.testNo, testRecord.deleted, deleted)
+		}
+	}
+
+	// Nothing is purged without a retention
+	utils.Cfg.Outbox.RetentionHours = "0"
+	insertOutboxItem(t, "purge-outbox-item-5", models.OUTBOX_ITEM_STATUS_SUCCEEDED, 1, old)
+	purgeOutboxItems()
+	if _, pd := selectOutboxItem("purge-outbox-item-5"); pd != nil {
+		t.Fatalf("an item was purged without a retention")
+	}
+	if dRes := datastore.GetProvider().DeleteSucceededOutboxItems(old + 1); dRes.ProblemDetail != nil || dRes.Data.(int64) != 1 {
+		t.Fatalf("unexpected deleted count: %v", dRes.Data)
+	}
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/swagchat/chat-api/datastore"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+const pollCloserInterval = 10 * time.Second
+
+// PostPollVote replaces the user's votes on the poll and returns the new tallies.
+func PostPollVote(messageId string, post *models.RequestPollVote) (*models.PollResult, *models.ProblemDetail) {
+	message, pd := GetMessage(messageId)
+	if pd != nil {
+		return nil, pd
+	}
+	if message.Type != models.MESSAGE_TYPE_POLL {
+		return nil, &models.ProblemDetail{
+			Title:     "Request parameter error. (Create poll vote item)",
+			Status:    http.StatusBadRequest,
+			ErrorName: models.ERROR_NAME_INVALID_PARAM,
+			InvalidParams: []models.InvalidParam{
+				models.InvalidParam{
+					Name:   "messageId",
+					Reason: "messageId is invalid. The message is not a poll.",
+				},
+			},
+		}
+	}
+
+	if _, pd := selectRoomUser(message.RoomId, post.UserId); pd != nil {
+		return nil, &models.ProblemDetail{
+			Title:     "Only room members can vote. (Create poll vote item)",
+			Status:    http.StatusForbidden,
+			ErrorName: models.ERROR_NAME_OPERATION_NOT_PERMITTED,
+		}
+	}
+
+	poll, pd := selectPoll(messageId)
+	if pd != nil {
+		return nil, pd
+	}
+	if poll.IsClosed() {
+		if poll.Closed == 0 {
+			go closePoll(poll)
+		}
+		return nil, &models.ProblemDetail{
+			Status: http.StatusConflict,
+		}
+	}
+
+	var pp models.PayloadPoll
+	json.Unmarshal(message.Payload, &pp)
+	if pd := post.IsValid(poll, &pp); pd != nil {
+		return nil, pd
+	}
+
+	nowTimestamp := time.Now().Unix()
+	votes := make([]*models.PollVote, 0, len(post.OptionIds))
+	for _, optionId := range post.OptionIds {
+		votes = append(votes, &models.PollVote{
+			MessageId: messageId,
+			OptionId:  optionId,
+			UserId:    post.UserId,
+			Created:   nowTimestamp,
+		})
+	}
+	dRes := datastore.GetProvider().ReplacePollVotes(messageId, post.UserId, votes)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+
+	pr, pd := selectPollResult(message, poll)
+	if pd != nil {
+		return nil, pd
+	}
+
+	voterId := post.UserId
+	if poll.IsAnonymous {
+		voterId = ""
+	}
+	go publishPollEvent(message, voterId, models.EVENT_NAME_POLL_UPDATE, pr)
+	return pr, nil
+}
+
+// RunPollCloser closes polls whose deadline has passed.
+func RunPollCloser(ctx context.Context) {
+	ticker := time.NewTicker(pollCloserInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			dRes := datastore.GetProvider().SelectExpiredPolls(time.Now().Unix())
+			if dRes.ProblemDetail != nil {
+				logProblemDetail("Poll close error.", dRes.ProblemDetail)
+				continue
+			}
+			for _, poll := range dRes.Data.([]*models.Poll) {
+				closePoll(poll)
+			}
+		}
+	}
+}
+
+func closePoll(poll *models.Poll) {
+	poll.Closed = time.Now().Unix()
+	poll.Modified = poll.Closed
+	dRes := datastore.GetProvider().UpdatePoll(poll)
+	if dRes.ProblemDetail != nil {
+		logProblemDetail("Poll close error.", dRes.ProblemDetail)
+		return
+	}
+
+	dRes = datastore.GetProvider().SelectMessage(poll.MessageId)
+	if dRes.ProblemDetail != nil {
+		logProblemDetail("Poll close error.", dRes.ProblemDetail)
+		return
+	}
+	if dRes.Data == nil {
+		return
+	}
+	message := dRes.Data.(*models.Message)
+
+	pr, pd := selectPollResult(message, poll)
+	if pd != nil {
+		logProblemDetail("Poll close error.", pd)
+		return
+	}
+	publishPollEvent(message, "", models.EVENT_NAME_POLL_CLOSE, pr)
+}
+
+// normalizePollPayload assigns ids to options that were posted without one.
+func normalizePollPayload(m *models.Message) {
+	var pp models.PayloadPoll
+	if err := json.Unmarshal(m.Payload, &pp); err != nil {
+		return
+	}
+	pp.Normalize()
+	payload, err := json.Marshal(&pp)
+	if err != nil {
+		return
+	}
+	m.Payload = utils.JSONText(payload)
+}
+
+// attachPollResult sets the current tallies on poll messages.
+func attachPollResult(m *models.Message) *models.ProblemDetail {
+	if m.Type != models.MESSAGE_TYPE_POLL {
+		return nil
+	}
+	poll, pd := selectPoll(m.MessageId)
+	if pd != nil {
+		return pd
+	}
+	pr, pd := selectPollResult(m, poll)
+	if pd != nil {
+		return pd
+	}
+	m.Poll = pr
+	return nil
+}
+
+// attachPollResults sets the current tallies on the poll messages of a page with two queries.
+func attachPollResults(messages []*models.Message) *models.ProblemDetail {
+	messageIds := make([]string, 0)
+	for _, m := range messages {
+		if m.Type == models.MESSAGE_TYPE_POLL {
+			messageIds = append(messageIds, m.MessageId)
+		}
+	}
+	if len(messageIds) == 0 {
+		return nil
+	}
+
+	dRes := datastore.GetProvider().SelectPolls(messageIds)
+	if dRes.ProblemDetail != nil {
+		return dRes.ProblemDetail
+	}
+	polls := make(map[string]*models.Poll)
+	for _, poll := range dRes.Data.([]*models.Poll) {
+		polls[poll.MessageId] = poll
+	}
+	dRes = datastore.GetProvider().SelectPollVotesByMessageIds(messageIds)
+	if dRes.ProblemDetail != nil {
+		return dRes.ProblemDetail
+	}
+	votes := make(map[string][]*models.PollVote)
+	for _, vote := range dRes.Data.([]*models.PollVote) {
+		votes[vote.MessageId] = append(votes[vote.MessageId], vote)
+	}
+
+	for _, m := range messages {
+		poll, ok := polls[m.MessageId]
+		if !ok {
+			continue
+		}
+		var pp models.PayloadPoll
+		json.Unmarshal(m.Payload, &pp)
+		m.Poll = models.NewPollResult(poll, &pp, votes[m.MessageId])
+	}
+	return nil
+}
+
+func selectPollResult(m *models.Message, poll *models.Poll) (*models.PollResult, *models.ProblemDetail) {
+	dRes := datastore.GetProvider().SelectPollVotes(m.MessageId)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	var pp models.PayloadPoll
+	json.Unmarshal(m.Payload, &pp)
+	return models.NewPollResult(poll, &pp, dRes.Data.([]*models.PollVote)), nil
+}
+
+func publishPollEvent(m *models.Message, userId, eventName string, pr *models.PollResult) {
+	publishEvent(models.NewEvent(eventName, m.RoomId, userId, pr))
+}
+
+func selectPoll(messageId string) (*models.Poll, *models.ProblemDetail) {
+	dRes := datastore.GetProvider().SelectPoll(messageId)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	if dRes.Data == nil {
+		return nil, &models.ProblemDetail{
+			Status: http.StatusNotFound,
+		}
+	}
+	return dRes.Data.(*models.Poll), nil
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/swagchat/chat-api/datastore"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/rtm"
+	"github.com/swagchat/chat-api/utils"
+)
+
+// GetPresence returns the presence of the user. A user who hides it with isShowUsers is shown offline.
+func GetPresence(userId string) (*models.Presence, *models.ProblemDetail) {
+	user, pd := selectUser(userId)
+	if pd != nil {
+		return nil, pd
+	}
+	if !isPresenceVisible(user.IsShowUsers) {
+		return models.NewOfflinePresence(userId), nil
+	}
+	return selectPresence(userId)
+}
+
+// PutPresence sets the status and the status text of the user.
+// It also counts as a heartbeat, so that the users of an external realtime server can keep their presence.
+func PutPresence(put *models.Presence) (*models.Presence, *models.ProblemDetail) {
+	user, pd := selectUser(put.UserId)
+	if pd != nil {
+		return nil, pd
+	}
+
+	if put.Status == "" {
+		put.Status = models.PRESENCE_STATUS_ONLINE
+	}
+	if pd := put.IsValid(); pd != nil {
+		return nil, pd
+	}
+
+	presence, pd := selectPresence(put.UserId)
+	if pd != nil {
+		return nil, pd
+	}
+	changed := presence.Status != put.Status || presence.StatusText != put.StatusText
+	presence.Status = put.Status
+	presence.StatusText = put.StatusText
+	presence.LastSeen = time.Now().Unix()
+	if pd := savePresence(presence); pd != nil {
+		return nil, pd
+	}
+
+	if changed {
+		go publishPresence(user, presence)
+	}
+	return presence, nil
+}
+
+// RunPresenceWorker keeps the presence of the users connected to this process and
+// turns offline the users who have not been seen for the presence timeout.
+func RunPresenceWorker(ctx context.Context) {
+	if rtm.HubEnabled() {
+		rtm.GetHub().SetPresenceListener(updateConnectionPresence)
+	}
+
+	interval, err := strconv.Atoi(utils.Cfg.Presence.HeartbeatInterval)
+	if err != nil || interval <= 0 {
+		interval = 30
+	}
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if rtm.HubEnabled() {
+				heartbeatPresences(rtm.GetHub().ConnectedUserIds())
+			}
+			expirePresences()
+		}
+	}
+}
+
+func updateConnectionPresence(userId string, connected bool) {
+	status := models.PRESENCE_STATUS_OFFLINE
+	if connected {
+		status = models.PRESENCE_STATUS_ONLINE
+	}
+	setPresenceStatus(userId, status)
+}
+
+// heartbeatPresences refreshes the last seen time of the connected users.
+// A user who turned offline while connected, as after a connection moved to another instance, is turned online again.
+func heartbeatPresences(userIds []string) {
+	if len(userIds) == 0 {
+		return
+	}
+	presences, pd := selectPresenceMap(userIds)
+	if pd != nil {
+		logProblemDetail("Presence heartbeat error.", pd)
+		return
+	}
+
+	seenUserIds := make([]string, 0, len(userIds))
+	for _, userId := range userIds {
+		if presences[userId].Status == models.PRESENCE_STATUS_OFFLINE {
+			setPresenceStatus(userId, models.PRESENCE_STATUS_ONLINE)
+		} else {
+			seenUserIds = append(seenUserIds, userId)
+		}
+	}
+	dRes := datastore.GetProvider().UpdatePresencesLastSeen(seenUserIds, time.Now().Unix())
+	if dRes.ProblemDetail != nil {
+		logProblemDetail("Presence heartbeat error.", dRes.ProblemDetail)
+	}
+}
+
+func expirePresences() {
+	timeout, err := strconv.ParseInt(utils.Cfg.Presence.Timeout, 10, 64)
+	if err != nil || timeout <= 0 {
+		timeout = 90
+	}
+	dRes := datastore.GetProvider().SelectExpiredPresences(time.Now().Unix() - timeout)
+	if dRes.ProblemDetail != nil {
+		logProblemDetail("Presence expiration error.", dRes.ProblemDetail)
+		return
+	}
+	for _, presence := range dRes.Data.([]*models.Presence) {
+		setPresenceStatus(presence.UserId, models.PRESENCE_STATUS_OFFLINE)
+	}
+}
+
+// setPresenceStatus turns the user online or offline. An away user stays away while online.
+func setPresenceStatus(userId, status string) {
+	presence, pd := selectPresence(userId)
+	if pd != nil {
+		logProblemDetail("Presence update error.", pd)
+		return
+	}
+
+	changed := false
+	switch {
+	case status == models.PRESENCE_STATUS_OFFLINE && presence.Status != models.PRESENCE_STATUS_OFFLINE:
+		presence.Status = models.PRESENCE_STATUS_OFFLINE
+		presence.StatusText = ""
+		changed = true
+	case status == models.PRESENCE_STATUS_ONLINE && presence.Status == models.PRESENCE_STATUS_OFFLINE:
+		presence.Status = models.PRESENCE_STATUS_ONLINE
+		changed = true
+	}
+	if presence.Status == models.PRESENCE_STATUS_OFFLINE && !changed {
+		return
+	}
+	presence.LastSeen = time.Now().Unix()
+	if pd := savePresence(presence); pd != nil {
+		logProblemDetail("Presence update error.", pd)
+		return
+	}
+
+	if changed {
+		user, pd := selectUser(userId)
+		if pd != nil {
+			return
+		}
+		publishPresence(user, presence)
+	}
+}
+
+// publishPresence sends the presence to the user and the users of the rooms the user belongs to,
+// or to the user only when the user hides it.
+func publishPresence(user *models.User, presence *models.Presence) {
+	event := models.NewEvent(models.EVENT_NAME_PRESENCE, "", user.UserId, presence)
+	if !isPresenceVisible(user.IsShowUsers) {
+		event.Recipients = []string{user.UserId}
+		publishEvent(event)
+		return
+	}
+	publishUserEvent(event)
+}
+
+// attachRoomUsersPresence sets the presence of the users of the room who show it.
+func attachRoomUsersPresence(room *models.Room) *models.ProblemDetail {
+	userIds := make([]string, 0, len(room.Users))
+	for _, user := range room.Users {
+		if isPresenceVisible(user.IsShowUsers) {
+			userIds = append(userIds, user.UserId)
+		}
+	}
+	presences, pd := selectPresenceMap(userIds)
+	if pd != nil {
+		return pd
+	}
+	for _, user := range room.Users {
+		user.Presence = presences[user.UserId]
+	}
+	return nil
+}
+
+func isPresenceVisible(isShowUsers *bool) bool {
+	return isShowUsers == nil || *isShowUsers
+}
+
+// selectPresence returns the presence of the user, offline when the user has never been seen.
+func selectPresence(userId string) (*models.Presence, *models.ProblemDetail) {
+	dRes := datastore.GetProvider().SelectPresence(userId)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	if dRes.Data == nil {
+		return models.NewOfflinePresence(userId), nil
+	}
+	return dRes.Data.(*models.Presence), nil
+}
+
+func selectPresenceMap(userIds []string) (map[string]*models.Presence, *models.ProblemDetail) {
+	dRes := datastore.GetProvider().SelectPresences(userIds)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	presences := make(map[string]*models.Presence, len(userIds))
+	for _, presence := range dRes.Data.([]*models.Presence) {
+		presences[presence.UserId] = presence
+	}
+	for _, userId := range userIds {
+		if presences[userId] == nil {
+			presences[userId] = models.NewOfflinePresence(userId)
+		}
+	}
+	return presences, nil
+}
+
+func savePresence(presence *models.Presence) *models.ProblemDetail {
+	isNew := presence.Created == 0
+	presence.BeforeSave()
+	var dRes datastore.StoreResult
+	if isNew {
+		dRes = datastore.GetProvider().InsertPresence(presence)
+	} else {
+		dRes = datastore.GetProvider().UpdatePresence(presence)
+	}
+	return dRes.ProblemDetail
+}
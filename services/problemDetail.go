@@ -0,0 +1,32 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+// logProblemDetail logs a problem detail of a background task, which has no response to return it in.
+func logProblemDetail(msg string, pd *models.ProblemDetail) {
+	problemDetailBytes, _ := json.Marshal(pd)
+	utils.AppLogger.Error("",
+		zap.String("msg", msg),
+		zap.String("problemDetail", string(problemDetailBytes)),
+	)
+}
+
+// problemDetailError returns the problem detail as an error. Not every problem detail carries one.
+func problemDetailError(pd *models.ProblemDetail) error {
+	if pd.Error != nil {
+		return pd.Error
+	}
+	if pd.Title == "" {
+		return errors.New(http.StatusText(pd.Status))
+	}
+	return errors.New(utils.AppendStrings(pd.Title, " ", pd.Detail))
+}
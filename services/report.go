@@ -0,0 +1,196 @@
+package services
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/swagchat/chat-api/datastore"
+	"github.com/swagchat/chat-api/models"
+)
+
+func PostMessageReport(post *models.Report) (*models.Report, *models.ProblemDetail) {
+	message, pd := GetMessage(post.MessageId)
+	if pd != nil {
+		return nil, pd
+	}
+
+	post.TargetType = models.REPORT_TARGET_MESSAGE
+	post.RoomId = message.RoomId
+	post.TargetUserId = message.UserId
+	if pd := post.IsValid(); pd != nil {
+		return nil, pd
+	}
+
+	// Only users of the room can report its messages
+	_, pd = selectRoomUser(post.RoomId, post.ReporterUserId)
+	if pd != nil {
+		if pd.Status == http.StatusNotFound {
+			return nil, &models.ProblemDetail{
+				Title:     "You do not have permission to report this message.",
+				Status:    http.StatusForbidden,
+				ErrorName: models.ERROR_NAME_OPERATION_NOT_PERMITTED,
+			}
+		}
+		return nil, pd
+	}
+
+	return insertReport(post)
+}
+
+func PostUserReport(post *models.Report) (*models.Report, *models.ProblemDetail) {
+	// User existence check
+	_, pd := selectUser(post.TargetUserId)
+	if pd != nil {
+		return nil, pd
+	}
+
+	post.TargetType = models.REPORT_TARGET_USER
+	post.MessageId = ""
+	if pd := post.IsValid(); pd != nil {
+		return nil, pd
+	}
+
+	_, pd = selectUser(post.ReporterUserId)
+	if pd != nil {
+		return nil, pd
+	}
+
+	if post.RoomId != "" {
+		_, pd := selectRoomUser(post.RoomId, post.TargetUserId)
+		if pd != nil {
+			return nil, &models.ProblemDetail{
+				Title:     "Request parameter error. (Create report item)",
+				Status:    http.StatusBadRequest,
+				ErrorName: models.ERROR_NAME_INVALID_PARAM,
+				InvalidParams: []models.InvalidParam{
+					models.InvalidParam{
+						Name:   "roomId",
+						Reason: "roomId is invalid. Reported user is not a user of this room.",
+					},
+				},
+			}
+		}
+	}
+
+	return insertReport(post)
+}
+
+func GetReports(params url.Values) (*models.Reports, *models.ProblemDetail) {
+	limit, offset, _, pd := setPagingParams(params)
+	if pd != nil {
+		return nil, pd
+	}
+
+	status := models.REPORT_STATUS_OPEN
+	if statusArray, ok := params["status"]; ok {
+		status = statusArray[0]
+	}
+
+	dRes := datastore.GetProvider().SelectReports(status, limit, offset)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	return &models.Reports{
+		Reports: dRes.Data.([]*models.Report),
+	}, nil
+}
+
+func GetReport(reportId string) (*models.Report, *models.ProblemDetail) {
+	return selectReport(reportId)
+}
+
+func TriageReport(reportId string, put *models.ReportResolution) (*models.Report, *models.ProblemDetail) {
+	report, pd := selectOpenReport(reportId)
+	if pd != nil {
+		return nil, pd
+	}
+
+	report.Note = put.Note
+	return updateReportStatus(report, models.REPORT_STATUS_TRIAGED)
+}
+
+// ResolveReport closes the report after running the requested action
+// through the same service paths the public API uses.
+func ResolveReport(reportId string, put *models.ReportResolution) (*models.Report, *models.ProblemDetail) {
+	report, pd := selectOpenReport(reportId)
+	if pd != nil {
+		return nil, pd
+	}
+
+	if pd := put.IsValid(report); pd != nil {
+		return nil, pd
+	}
+
+	switch put.Action {
+	case models.REPORT_ACTION_DELETE_MESSAGE:
+		pd = DeleteMessage(report.MessageId)
+	case models.REPORT_ACTION_REMOVE_FROM_ROOM:
+		_, pd = DeleteRoomUsers(report.RoomId, &models.RequestRoomUserIds{
+			UserIds: []string{report.TargetUserId},
+		})
+	case models.REPORT_ACTION_DEACTIVATE_USER:
+		pd = DeleteUser(report.TargetUserId)
+	}
+	if pd != nil {
+		return nil, pd
+	}
+
+	report.Action = put.Action
+	report.Note = put.Note
+	return updateReportStatus(report, models.REPORT_STATUS_RESOLVED)
+}
+
+func DismissReport(reportId string, put *models.ReportResolution) (*models.Report, *models.ProblemDetail) {
+	report, pd := selectOpenReport(reportId)
+	if pd != nil {
+		return nil, pd
+	}
+
+	report.Note = put.Note
+	return updateReportStatus(report, models.REPORT_STATUS_DISMISSED)
+}
+
+func insertReport(report *models.Report) (*models.Report, *models.ProblemDetail) {
+	report.BeforeSave()
+	dRes := datastore.GetProvider().InsertReport(report)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	return report, nil
+}
+
+func updateReportStatus(report *models.Report, status string) (*models.Report, *models.ProblemDetail) {
+	report.Status = status
+	report.BeforeSave()
+	dRes := datastore.GetProvider().UpdateReport(report)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	return report, nil
+}
+
+func selectReport(reportId string) (*models.Report, *models.ProblemDetail) {
+	dRes := datastore.GetProvider().SelectReport(reportId)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	if dRes.Data == nil {
+		return nil, &models.ProblemDetail{
+			Status: http.StatusNotFound,
+		}
+	}
+	return dRes.Data.(*models.Report), nil
+}
+
+func selectOpenReport(reportId string) (*models.Report, *models.ProblemDetail) {
+	report, pd := selectReport(reportId)
+	if pd != nil {
+		return nil, pd
+	}
+	if report.IsClosed() {
+		return nil, &models.ProblemDetail{
+			Status: http.StatusConflict,
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/swagchat/chat-api/datastore"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/storage"
+	"github.com/swagchat/chat-api/utils"
+	"go.uber.org/zap"
+)
+
+const purgeBatchSize = 500
+
+// purgeMutex keeps the scheduled purger and a manual purge from running at the same time
+var purgeMutex sync.Mutex
+
+func GetRoomRetention(roomId string) (*models.RoomRetention, *models.ProblemDetail) {
+	room, pd := selectRoom(roomId)
+	if pd != nil {
+		return nil, pd
+	}
+	return models.NewRoomRetention(room, retentionDays()), nil
+}
+
+func PutRoomRetention(roomId string, put *models.RoomRetention) (*models.RoomRetention, *models.ProblemDetail) {
+	if pd := put.IsValid(); pd != nil {
+		return nil, pd
+	}
+
+	room, pd := selectRoom(roomId)
+	if pd != nil {
+		return nil, pd
+	}
+	room.PutRetention(put)
+	room.BeforeSave()
+	dRes := datastore.GetProvider().UpdateRoom(room)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	return models.NewRoomRetention(room, retentionDays()), nil
+}
+
+func GetUserLegalHold(userId string) (*models.UserLegalHold, *models.ProblemDetail) {
+	user, pd := selectUser(userId)
+	if pd != nil {
+		return nil, pd
+	}
+	return &models.UserLegalHold{
+		UserId:    user.UserId,
+		LegalHold: &user.LegalHold,
+	}, nil
+}
+
+func PutUserLegalHold(userId string, put *models.UserLegalHold) (*models.UserLegalHold, *models.ProblemDetail) {
+	if pd := put.IsValid(); pd != nil {
+		return nil, pd
+	}
+
+	user, pd := selectUser(userId)
+	if pd != nil {
+		return nil, pd
+	}
+	user.LegalHold = *put.LegalHold
+	user.BeforeSave()
+	dRes := datastore.GetProvider().UpdateUser(user)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	return &models.UserLegalHold{
+		UserId:    user.UserId,
+		LegalHold: &user.LegalHold,
+	}, nil
+}
+
+func GetPurgeReports(params url.Values) (*models.PurgeReports, *models.ProblemDetail) {
+	limit, offset, _, pd := setPagingParams(params)
+	if pd != nil {
+		return nil, pd
+	}
+
+	dRes := datastore.GetProvider().SelectPurgeReports(limit, offset)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	return &models.PurgeReports{
+		PurgeReports: dRes.Data.([]*models.PurgeReport),
+	}, nil
+}
+
+func GetPurgeReport(purgeReportId string) (*models.PurgeReport, *models.ProblemDetail) {
+	dRes := datastore.GetProvider().SelectPurgeReport(purgeReportId)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	if dRes.Data == nil {
+		return nil, &models.ProblemDetail{
+			Status: http.StatusNotFound,
+		}
+	}
+	return dRes.Data.(*models.PurgeReport), nil
+}
+
+// PostPurge runs the purger now and returns its report.
+func PostPurge() (*models.PurgeReport, *models.ProblemDetail) {
+	return purge()
+}
+
+// RunPurger hard deletes expired messages periodically until ctx is done.
+func RunPurger(ctx context.Context) {
+	interval, _ := strconv.Atoi(utils.Cfg.Retention.PurgeInterval)
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, pd := purge(); pd != nil {
+				logProblemDetail("Purge error.", pd)
+			}
+		}
+	}
+}
+
+func purge() (*models.PurgeReport, *models.ProblemDetail) {
+	purgeMutex.Lock()
+	defer purgeMutex.Unlock()
+
+	report := &models.PurgeReport{
+		Started: time.Now().Unix(),
+	}
+	globalDays := retentionDays()
+	dRes := datastore.GetProvider().SelectRetentionRooms(globalDays)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	for _, room := range dRes.Data.([]*models.Room) {
+		roomReport := purgeRoom(room, room.EffectiveRetentionDays(globalDays), report.Started)
+		if roomReport.MessageCount > 0 || len(roomReport.Errors) > 0 {
+			report.Add(roomReport)
+		}
+	}
+
+	report.BeforeSave()
+	dRes = datastore.GetProvider().InsertPurgeReport(report)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	utils.AppLogger.Info("",
+		zap.String("msg", "Purged expired messages."),
+		zap.String("purgeReportId", report.PurgeReportId),
+		zap.Int64("roomCount", report.RoomCount),
+		zap.Int64("messageCount", report.MessageCount),
+		zap.Int64("assetCount", report.AssetCount),
+		zap.Int64("errorCount", report.ErrorCount),
+	)
+	return report, nil
+}
+
+// purgeRoom deletes the room's messages older than days.
+// If an asset can not be deleted, the room stops here so that the message is retried on the next run.
+func purgeRoom(room *models.Room, days int64, now int64) *models.PurgeRoomReport {
+	cutoff := now - days*24*60*60
+	roomReport := &models.PurgeRoomReport{
+		RoomId:        room.RoomId,
+		RetentionDays: days,
+		Cutoff:        exportTime(cutoff),
+	}
+
+	storageProvider := storage.GetProvider()
+	for {
+		dRes := datastore.GetProvider().SelectExpiredMessages(room.RoomId, cutoff, purgeBatchSize)
+		if dRes.ProblemDetail != nil {
+			roomReport.Errors = append(roomReport.Errors, problemDetailError(dRes.ProblemDetail).Error())
+			return roomReport
+		}
+		messages := dRes.Data.([]*models.Message)
+		if len(messages) == 0 {
+			return roomReport
+		}
+
+		messageIds := make([]string, 0, len(messages))
+		failed := false
+		for _, message := range messages {
+			for _, fileName := range messageAssetFileNames(message) {
+				if pd := storageProvider.Delete(&storage.AssetInfo{FileName: fileName}); pd != nil {
+					roomReport.Errors = append(roomReport.Errors, utils.AppendStrings(fileName, ": ", problemDetailError(pd).Error()))
+					failed = true
+					break
+				}
+				roomReport.AssetCount++
+			}
+			if failed {
+				break
+			}
+			messageIds = append(messageIds, message.MessageId)
+		}
+
+		if len(messageIds) > 0 {
+			dRes = datastore.GetProvider().DeleteMessages(room.RoomId, messageIds)
+			if dRes.ProblemDetail != nil {
+				roomReport.Errors = append(roomReport.Errors, problemDetailError(dRes.ProblemDetail).Error())
+				return roomReport
+			}
+			roomReport.MessageCount += int64(len(messageIds))
+		}
+		if failed || len(messages) < purgeBatchSize {
+			return roomReport
+		}
+	}
+}
+
+// messageAssetFileNames returns the file names in storage of the assets of an image message.
+func messageAssetFileNames(message *models.Message) []string {
+	if message.Type != models.MESSAGE_TYPE_IMAGE {
+		return nil
+	}
+	var payloadImage models.PayloadImage
+	json.Unmarshal(message.Payload, &payloadImage)
+
+	var fileNames []string
+	for _, assetUrl := range []string{payloadImage.SourceUrl, payloadImage.ThumbnailUrl} {
+		if assetUrl == "" {
+			continue
+		}
+		u, err := url.Parse(assetUrl)
+		if err != nil {
+			continue
+		}
+		fileName := path.Base(u.Path)
+		if fileName == "." || fileName == "/" || utils.SearchStringValueInSlice(fileNames, fileName) {
+			continue
+		}
+		fileNames = append(fileNames, fileName)
+	}
+	return fileNames
+}
+
+func retentionDays() int64 {
+	days, _ := strconv.ParseInt(utils.Cfg.Retention.Days, 10, 64)
+	return days
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+func TestMessageAssetFileNames(t *testing.T) {
+	testTable := []struct {
+		testNo      int
+		messageType string
+		payload     string
+		out         []string
+	}{
+		{1, models.MESSAGE_TYPE_IMAGE, `{"mime":"image/png","sourceUrl":"http://example.com/assets/source.png","thumbnailUrl":"http://example.com/assets/thumbnail.png"}`, []string{"source.png", "thumbnail.png"}},
+		// The query string is not part of the file name
+		{2, models.MESSAGE_TYPE_IMAGE, `{"sourceUrl":"https://example.com/bucket/a/b/source.jpg?X-Amz-Expires=600"}`, []string{"source.jpg"}},
+		// A thumbnail pointing to the source is deleted once
+		{3, models.MESSAGE_TYPE_IMAGE, `{"sourceUrl":"http://example.com/source.png","thumbnailUrl":"http://example.com/source.png"}`, []string{"source.png"}},
+		{4, models.MESSAGE_TYPE_IMAGE, `{"sourceUrl":"","thumbnailUrl":"http://example.com/"}`, nil},
+		{5, models.MESSAGE_TYPE_IMAGE, `{"sourceUrl":"http://%zz"}`, nil},
+		{6, models.MESSAGE_TYPE_IMAGE, `invalid`, nil},
+		{7, models.MESSAGE_TYPE_TEXT, `{"text":"http://example.com/source.png"}`, nil},
+	}
+
+	for _, testRecord := range testTable {
+		message := &models.Message{
+			Type:    testRecord.messageType,
+			Payload: utils.JSONText(testRecord.payload),
+		}
+		out := messageAssetFileNames(message)
+		if !reflect.DeepEqual(out, testRecord.out) {
+			t.Fatalf("TestNo %d\nFile Names Failure\n[expected]%v\n[result  ]%v", testRecord.testNo, testRecord.out, out)
+		}
+	}
+}
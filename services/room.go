@@ -13,6 +13,7 @@ import (
 	"github.com/swagchat/chat-api/datastore"
 	"github.com/swagchat/chat-api/models"
 	"github.com/swagchat/chat-api/notification"
+	"github.com/swagchat/chat-api/rtm"
 	"github.com/swagchat/chat-api/utils"
 	"go.uber.org/zap"
 )
@@ -59,6 +60,9 @@ func PostRoom(post *models.Room) (*models.Room, *models.ProblemDetail) {
 		return nil, dRes.ProblemDetail
 	}
 	room.Users = dRes.Data.([]*models.UserForRoom)
+	if pd := attachRoomUsersPresence(room); pd != nil {
+		return nil, pd
+	}
 
 	dRes = datastore.GetProvider().SelectRoomUsersByRoomId(room.RoomId)
 	if dRes.ProblemDetail != nil {
@@ -66,9 +70,18 @@ func PostRoom(post *models.Room) (*models.Room, *models.ProblemDetail) {
 	}
 	roomUsers := dRes.Data.([]*models.RoomUser)
 
+	if rtm.HubEnabled() {
+		roomUserIds := make([]string, 0, len(roomUsers))
+		for _, roomUser := range roomUsers {
+			roomUserIds = append(roomUserIds, roomUser.UserId)
+		}
+		rtm.GetHub().Join(room.RoomId, roomUserIds)
+	}
+
 	ctx, _ := context.WithCancel(context.Background())
 	go subscribeByRoomUsers(ctx, roomUsers)
-	go publishUserJoin(room.RoomId)
+	go publishEvent(models.NewEvent(models.EVENT_NAME_ROOM_CREATE, room.RoomId, room.UserId, room))
+	go dispatchWebhookEvent(models.WEBHOOK_EVENT_ROOM_CREATED, room)
 
 	return room, nil
 }
@@ -98,6 +111,9 @@ func GetRoom(roomId string) (*models.Room, *models.ProblemDetail) {
 		return nil, dRes.ProblemDetail
 	}
 	room.Users = dRes.Data.([]*models.UserForRoom)
+	if pd := attachRoomUsersPresence(room); pd != nil {
+		return nil, pd
+	}
 
 	dRes = datastore.GetProvider().SelectCountMessagesByRoomId(roomId)
 	if dRes.ProblemDetail != nil {
@@ -113,6 +129,9 @@ func PutRoom(put *models.Room) (*models.Room, *models.ProblemDetail) {
 		return nil, pd
 	}
 
+	oldName := room.Name
+	oldPictureUrl := room.PictureUrl
+
 	if pd := room.Put(put); pd != nil {
 		return nil, pd
 	}
@@ -128,11 +147,31 @@ func PutRoom(put *models.Room) (*models.Room, *models.ProblemDetail) {
 	}
 	room = dRes.Data.(*models.Room)
 
+	if room.Name != oldName {
+		postSystemMessage(room, room.UserId, &models.PayloadSystem{
+			Action:   models.SYSTEM_ACTION_ROOM_RENAME,
+			OldValue: oldName,
+			NewValue: room.Name,
+		})
+	}
+	if room.PictureUrl != oldPictureUrl {
+		postSystemMessage(room, room.UserId, &models.PayloadSystem{
+			Action:   models.SYSTEM_ACTION_ROOM_PICTURE,
+			OldValue: oldPictureUrl,
+			NewValue: room.PictureUrl,
+		})
+	}
+
 	dRes = datastore.GetProvider().SelectUsersForRoom(room.RoomId)
 	if dRes.ProblemDetail != nil {
 		return nil, dRes.ProblemDetail
 	}
 	room.Users = dRes.Data.([]*models.UserForRoom)
+	if pd := attachRoomUsersPresence(room); pd != nil {
+		return nil, pd
+	}
+
+	go publishEvent(models.NewEvent(models.EVENT_NAME_ROOM_UPDATE, room.RoomId, room.UserId, room))
 	return room, nil
 }
 
@@ -149,12 +188,26 @@ func DeleteRoom(roomId string) *models.ProblemDetail {
 		}
 	}
 
+	// The recipients are resolved before the users of the room are deleted
+	event := models.NewEvent(models.EVENT_NAME_ROOM_DELETE, roomId, room.UserId, room)
+	if pd := resolveRecipients(event); pd != nil {
+		return pd
+	}
+
 	room.Deleted = time.Now().Unix()
 	dRes := datastore.GetProvider().UpdateRoomDeleted(roomId)
 	if dRes.ProblemDetail != nil {
 		return dRes.ProblemDetail
 	}
 
+	// Published before the room is removed from the hub so that its users receive it
+	publishEvent(event)
+	if rtm.HubEnabled() {
+		rtm.GetHub().RemoveRoom(roomId)
+	}
+
+	// The webhook gets a copy, as the goroutine below clears the notification topic while it is marshaled
+	deletedRoom := *room
 	ctx, _ := context.WithCancel(context.Background())
 	go func() {
 		wg := &sync.WaitGroup{}
@@ -164,6 +217,7 @@ func DeleteRoom(roomId string) *models.ProblemDetail {
 		room.NotificationTopicId = ""
 		datastore.GetProvider().UpdateRoom(room)
 	}()
+	go dispatchWebhookEvent(models.WEBHOOK_EVENT_ROOM_DELETED, &deletedRoom)
 
 	return nil
 }
@@ -181,6 +235,9 @@ func GetRoomMessages(roomId string, params url.Values) (*models.Messages, *model
 	messages := &models.Messages{
 		Messages: dRes.Data.([]*models.Message),
 	}
+	if pd := attachPollResults(messages.Messages); pd != nil {
+		return nil, pd
+	}
 
 	dRes = datastore.GetProvider().SelectCountMessagesByRoomId(roomId)
 	if dRes.ProblemDetail != nil {
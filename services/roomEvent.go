@@ -0,0 +1,69 @@
+package services
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+var (
+	roomEventLimiter     *utils.RateLimiter
+	roomEventLimiterOnce sync.Once
+)
+
+func getRoomEventLimiter() *utils.RateLimiter {
+	roomEventLimiterOnce.Do(func() {
+		limit, err := strconv.Atoi(utils.Cfg.Rtm.EventRateLimit)
+		if err != nil {
+			limit = 0
+		}
+		roomEventLimiter = utils.NewRateLimiter(limit, time.Minute)
+	})
+	return roomEventLimiter
+}
+
+// PostRoomEvent forwards a transient event to the realtime messaging provider.
+// Events are never persisted.
+func PostRoomEvent(post *models.RoomEvent) *models.ProblemDetail {
+	if pd := post.IsValid(); pd != nil {
+		return pd
+	}
+
+	// Room existence check
+	_, pd := selectRoom(post.RoomId)
+	if pd != nil {
+		return pd
+	}
+
+	// Room membership check
+	_, pd = selectRoomUser(post.RoomId, post.UserId)
+	if pd != nil {
+		if pd.Status == http.StatusNotFound {
+			return &models.ProblemDetail{
+				Title:     "You do not have permission to send events to this room.",
+				Status:    http.StatusForbidden,
+				ErrorName: models.ERROR_NAME_OPERATION_NOT_PERMITTED,
+			}
+		}
+		return pd
+	}
+
+	if !getRoomEventLimiter().Allow(utils.AppendStrings(post.RoomId, ":", post.UserId)) {
+		return &models.ProblemDetail{
+			Title:     "Too many room events. Please try again later.",
+			Status:    http.StatusTooManyRequests,
+			ErrorName: models.ERROR_NAME_TOO_MANY_REQUESTS,
+		}
+	}
+
+	if post.Payload == nil {
+		post.Payload = utils.JSONText("{}")
+	}
+
+	go publishEvent(models.NewEvent(post.EventName, post.RoomId, post.UserId, post.Payload))
+	return nil
+}
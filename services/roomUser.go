@@ -1,7 +1,6 @@
 package services
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -54,6 +53,20 @@ func PutRoomUsers(roomId string, put *models.RequestRoomUserIds) (*models.RoomUs
 		}
 	}
 
+	joinUserIds := make([]string, 0)
+	for _, userId := range userIds {
+		isMember := false
+		for _, user := range room.Users {
+			if user.UserId == userId {
+				isMember = true
+				break
+			}
+		}
+		if !isMember {
+			joinUserIds = append(joinUserIds, userId)
+		}
+	}
+
 	var zero int64
 	zero = 0
 	roomUsers := make([]*models.RoomUser, 0)
@@ -81,9 +94,25 @@ func PutRoomUsers(roomId string, put *models.RequestRoomUserIds) (*models.RoomUs
 		RoomUsers: dRes.Data.([]*models.RoomUser),
 	}
 
+	if len(joinUserIds) > 0 {
+		postSystemMessage(room, room.UserId, &models.PayloadSystem{
+			Action:  models.SYSTEM_ACTION_USER_JOIN,
+			UserIds: joinUserIds,
+		})
+	}
+
 	ctx, _ := context.WithCancel(context.Background())
 	go subscribeByRoomUsers(ctx, roomUsers)
-	go publishUserJoin(roomId)
+	if rtm.HubEnabled() {
+		rtm.GetHub().Join(roomId, userIds)
+	}
+	if len(joinUserIds) > 0 {
+		go publishMembersEvent(roomId, room.UserId, models.EVENT_NAME_USER_JOIN, joinUserIds)
+		go dispatchWebhookEvent(models.WEBHOOK_EVENT_USER_JOINED, &models.WebhookRoomUsers{
+			RoomId:  roomId,
+			UserIds: joinUserIds,
+		})
+	}
 
 	return returnRoomUsers, nil
 }
@@ -93,6 +122,10 @@ func PutRoomUser(put *models.RoomUser) (*models.RoomUser, *models.ProblemDetail)
 	if pd != nil {
 		return nil, pd
 	}
+	var oldUnreadCount int64
+	if roomUser.UnreadCount != nil {
+		oldUnreadCount = *roomUser.UnreadCount
+	}
 
 	roomUser.Put(put)
 	if pd := roomUser.IsValid(); pd != nil {
@@ -104,7 +137,14 @@ func PutRoomUser(put *models.RoomUser) (*models.RoomUser, *models.ProblemDetail)
 	if dRes.ProblemDetail != nil {
 		return nil, dRes.ProblemDetail
 	}
-	return dRes.Data.(*models.RoomUser), nil
+
+	roomUser = dRes.Data.(*models.RoomUser)
+
+	// The unread count is the read position of the user
+	if roomUser.UnreadCount != nil && *roomUser.UnreadCount != oldUnreadCount {
+		go publishEvent(models.NewEvent(models.EVENT_NAME_MESSAGE_READ, roomUser.RoomId, roomUser.UserId, roomUser))
+	}
+	return roomUser, nil
 }
 
 func DeleteRoomUsers(roomId string, deleteUserIds *models.RequestRoomUserIds) (*models.RoomUsers, *models.ProblemDetail) {
@@ -124,10 +164,38 @@ func DeleteRoomUsers(roomId string, deleteUserIds *models.RequestRoomUserIds) (*
 		return nil, pd
 	}
 
-	dRes := datastore.GetProvider().DeleteRoomUser(roomId, userIds)
+	dRes := datastore.GetProvider().SelectRoomUsersByRoomIdAndUserIds(&roomId, userIds)
 	if dRes.ProblemDetail != nil {
 		return nil, dRes.ProblemDetail
 	}
+	leftUserIds := make([]string, 0)
+	for _, roomUser := range dRes.Data.([]*models.RoomUser) {
+		leftUserIds = append(leftUserIds, roomUser.UserId)
+	}
+
+	dRes = datastore.GetProvider().DeleteRoomUser(roomId, userIds)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+
+	// Published before leaving the room so that the users who left receive it too
+	if len(leftUserIds) > 0 {
+		publishMembersEvent(roomId, room.UserId, models.EVENT_NAME_USER_LEFT, leftUserIds)
+	}
+	if rtm.HubEnabled() {
+		rtm.GetHub().Leave(roomId, leftUserIds)
+	}
+
+	if len(leftUserIds) > 0 {
+		postSystemMessage(room, room.UserId, &models.PayloadSystem{
+			Action:  models.SYSTEM_ACTION_USER_LEFT,
+			UserIds: leftUserIds,
+		})
+		go dispatchWebhookEvent(models.WEBHOOK_EVENT_USER_LEFT, &models.WebhookRoomUsers{
+			RoomId:  roomId,
+			UserIds: leftUserIds,
+		})
+	}
 
 	dRes = datastore.GetProvider().SelectRoomUsersByRoomIdAndUserIds(&roomId, userIds)
 	if dRes.ProblemDetail != nil {
@@ -148,6 +216,51 @@ func DeleteRoomUsers(roomId string, deleteUserIds *models.RequestRoomUserIds) (*
 	return returnRoomUsers, nil
 }
 
+func GetRoomUserDraft(roomId, userId string) (*models.RoomUserDraft, *models.ProblemDetail) {
+	roomUser, pd := selectRoomUser(roomId, userId)
+	if pd != nil {
+		return nil, pd
+	}
+
+	return &models.RoomUserDraft{
+		RoomId:   roomUser.RoomId,
+		UserId:   roomUser.UserId,
+		Draft:    roomUser.Draft,
+		Modified: roomUser.DraftUpdated,
+	}, nil
+}
+
+func PutRoomUserDraft(put *models.RoomUserDraft) (*models.RoomUserDraft, *models.ProblemDetail) {
+	if pd := put.IsValid(); pd != nil {
+		return nil, pd
+	}
+
+	_, pd := selectRoomUser(put.RoomId, put.UserId)
+	if pd != nil {
+		return nil, pd
+	}
+
+	put.Modified = time.Now().Unix()
+	dRes := datastore.GetProvider().UpdateRoomUserDraft(put.RoomId, put.UserId, put.Draft, put.Modified)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	return put, nil
+}
+
+func DeleteRoomUserDraft(roomId, userId string) *models.ProblemDetail {
+	_, pd := selectRoomUser(roomId, userId)
+	if pd != nil {
+		return pd
+	}
+
+	dRes := datastore.GetProvider().UpdateRoomUserDraft(roomId, userId, "", 0)
+	if dRes.ProblemDetail != nil {
+		return dRes.ProblemDetail
+	}
+	return nil
+}
+
 func selectRoomUser(roomId, userId string) (*models.RoomUser, *models.ProblemDetail) {
 	dRes := datastore.GetProvider().SelectRoomUser(roomId, userId)
 	if dRes.ProblemDetail != nil {
@@ -161,42 +274,30 @@ func selectRoomUser(roomId, userId string) (*models.RoomUser, *models.ProblemDet
 	return dRes.Data.(*models.RoomUser), nil
 }
 
-func publishUserJoin(roomId string) {
+// publishMembersEvent publishes the users who joined or left the room with the current user list.
+// It is sent to the users of the room and to the users who left.
+func publishMembersEvent(roomId, actorId, eventName string, userIds []string) {
 	dRes := datastore.GetProvider().SelectUsersForRoom(roomId)
 	if dRes.ProblemDetail != nil {
-		problemDetailBytes, _ := json.Marshal(dRes.ProblemDetail)
-		utils.AppLogger.Error("",
-			zap.String("msg", "Publish error. (Add room's user list)"),
-			zap.String("problemDetail", string(problemDetailBytes)),
-		)
+		logProblemDetail("Publish error. (Room's user list)", dRes.ProblemDetail)
 		return
 	}
 	users := dRes.Data.([]*models.UserForRoom)
-	b, _ := json.Marshal(users)
-	buf := new(bytes.Buffer)
-	buf.Write(b)
-
-	message := &models.Message{
-		RoomId:    roomId,
-		EventName: "userJoin",
-		Payload:   utils.JSONText(buf.String()),
-	}
-	bytes, err := json.Marshal(message)
-	if err != nil {
-		utils.AppLogger.Error("",
-			zap.String("msg", err.Error()),
-		)
-	}
-	mi := &rtm.MessagingInfo{
-		Message: string(bytes),
-	}
-	err = rtm.GetMessagingProvider().PublishMessage(mi)
-	if err != nil {
-		utils.AppLogger.Error("",
-			zap.String("msg", "Publish error. (Add room's user list)"),
-			zap.String("detail", err.Error()),
-		)
+
+	event := models.NewEvent(eventName, roomId, actorId, &models.EventMembers{
+		UserIds: userIds,
+		Users:   users,
+	})
+	event.Recipients = make([]string, 0, len(users)+len(userIds))
+	for _, user := range users {
+		event.Recipients = append(event.Recipients, user.UserId)
+	}
+	for _, userId := range userIds {
+		if !utils.SearchStringValueInSlice(event.Recipients, userId) {
+			event.Recipients = append(event.Recipients, userId)
+		}
 	}
+	publishEvent(event)
 }
 
 func subscribeByRoomUsers(ctx context.Context, roomUsers []*models.RoomUser) {
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/swagchat/chat-api/datastore"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+const (
+	// syncPollInterval is how often a waiting sync reads the change log,
+	// so that it sees the changes recorded by the other instances too
+	syncPollInterval = 2 * time.Second
+
+	syncPurgeInterval = time.Hour
+)
+
+// syncWaiters wakes up the sync requests waiting on this process when a change is recorded for their user
+var syncWaiters = struct {
+	sync.Mutex
+	channels map[string]map[chan struct{}]struct{}
+}{
+	channels: make(map[string]map[chan struct{}]struct{}),
+}
+
+// GetSync returns the changes of the user after the since token.
+// Without changes it waits for one up to the wait parameter, or Sync.MaxWait seconds.
+// Without since it returns the current token only, to be used after fetching everything with GetUser and GetRoomMessages.
+func GetSync(ctx context.Context, userId string, params url.Values) (*models.Sync, *models.ProblemDetail) {
+	if _, pd := selectUser(userId); pd != nil {
+		return nil, pd
+	}
+
+	maxWait, err := strconv.Atoi(utils.Cfg.Sync.MaxWait)
+	if err != nil || maxWait < 0 {
+		maxWait = 30
+	}
+	wait := maxWait
+	if v := params.Get("wait"); v != "" {
+		wait, err = strconv.Atoi(v)
+		if err != nil || wait < 0 {
+			return nil, syncParamError("wait", "wait must be a number of seconds.")
+		}
+		if wait > maxWait {
+			wait = maxWait
+		}
+	}
+
+	dRes := datastore.GetProvider().SelectChangeLogSeqRange(userId)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	seqRange := dRes.Data.([]uint64)
+
+	since := params.Get("since")
+	if since == "" {
+		return &models.Sync{
+			Changes: make([]utils.JSONText, 0),
+			Token:   strconv.FormatUint(seqRange[1], 10),
+		}, nil
+	}
+	sinceSeq, err := strconv.ParseUint(since, 10, 64)
+	if err != nil {
+		return nil, syncParamError("since", "since is incorrect.")
+	}
+	// The changes right after the token have been purged, or the token is not of the sequence of the user
+	if sinceSeq > seqRange[1] || sinceSeq < seqRange[1] && (seqRange[0] == 0 || seqRange[0] > sinceSeq+1) {
+		return nil, &models.ProblemDetail{
+			Title:  "The sync token has expired. Fetch everything again and sync without since.",
+			Status: http.StatusGone,
+		}
+	}
+
+	changed, stop := waitSync(userId)
+	defer stop()
+	ticker := time.NewTicker(syncPollInterval)
+	defer ticker.Stop()
+	timeout := time.NewTimer(time.Duration(wait) * time.Second)
+	defer timeout.Stop()
+
+	for {
+		result, pd := selectSync(userId, sinceSeq)
+		if pd != nil || len(result.Changes) > 0 {
+			return result, pd
+		}
+		select {
+		case <-ctx.Done():
+			return result, nil
+		case <-timeout.C:
+			return result, nil
+		case <-changed:
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunSyncPurger deletes the change log older than Sync.RetentionHours periodically until ctx is done.
+func RunSyncPurger(ctx context.Context) {
+	ticker := time.NewTicker(syncPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purgeChangeLogs()
+		}
+	}
+}
+
+func purgeChangeLogs() {
+	hours, err := strconv.ParseInt(utils.Cfg.Sync.RetentionHours, 10, 64)
+	if err != nil || hours <= 0 {
+		return
+	}
+	dRes := datastore.GetProvider().DeleteChangeLogs(time.Now().Unix() - hours*3600)
+	if dRes.ProblemDetail != nil {
+		logProblemDetail("Change log purge error.", dRes.ProblemDetail)
+	}
+}
+
+// recordChanges adds the event to the change log of its recipients.
+func recordChanges(event *models.Event) {
+	if !models.IsSyncEvent(event.EventName) {
+		return
+	}
+	if pd := resolveRecipients(event); pd != nil {
+		logProblemDetail("Change log error.", pd)
+		return
+	}
+	if len(event.Recipients) == 0 {
+		return
+	}
+
+	changeLogs, err := models.NewChangeLogs(event, time.Now().Unix())
+	if err != nil {
+		logProblemDetail("Change log error.", &models.ProblemDetail{
+			Title: "Json parse error. (Create change log items)",
+			Error: err,
+		})
+		return
+	}
+	dRes := datastore.GetProvider().InsertChangeLogs(changeLogs)
+	if dRes.ProblemDetail != nil {
+		logProblemDetail("Change log error.", dRes.ProblemDetail)
+		return
+	}
+	notifySyncWaiters(event.Recipients)
+}
+
+// recordMessageChanges adds new messages to the change log.
+// A message keeps the event id of its realtime event, so that a client receiving both can drop the duplicate.
+func recordMessageChanges(messages []*models.Message, outboxItems []*models.OutboxItem) {
+	events := make(map[string]*models.Event)
+	for _, item := range outboxItems {
+		if item.Kind != models.OUTBOX_KIND_RTM {
+			continue
+		}
+		if event, err := item.Event(); err == nil {
+			events[item.MessageId] = event
+		}
+	}
+	for _, message := range messages {
+		event, ok := events[message.MessageId]
+		if !ok {
+			event = models.NewEvent(models.EVENT_NAME_MESSAGE, message.RoomId, message.UserId, message)
+		}
+		recordChanges(event)
+	}
+}
+
+func selectSync(userId string, sinceSeq uint64) (*models.Sync, *models.ProblemDetail) {
+	limit, err := strconv.Atoi(utils.Cfg.Sync.Limit)
+	if err != nil || limit <= 0 {
+		limit = 500
+	}
+	dRes := datastore.GetProvider().SelectChangeLogs(userId, sinceSeq, limit)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	changeLogs := dRes.Data.([]*models.ChangeLog)
+
+	result := &models.Sync{
+		Changes: make([]utils.JSONText, 0, len(changeLogs)),
+		Token:   strconv.FormatUint(sinceSeq, 10),
+		HasMore: len(changeLogs) == limit,
+	}
+	for _, changeLog := range changeLogs {
+		result.Changes = append(result.Changes, changeLog.Event)
+		result.Token = strconv.FormatUint(changeLog.Seq, 10)
+	}
+	return result, nil
+}
+
+func waitSync(userId string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	syncWaiters.Lock()
+	if syncWaiters.channels[userId] == nil {
+		syncWaiters.channels[userId] = make(map[chan struct{}]struct{})
+	}
+	syncWaiters.channels[userId][ch] = struct{}{}
+	syncWaiters.Unlock()
+
+	return ch, func() {
+		syncWaiters.Lock()
+		delete(syncWaiters.channels[userId], ch)
+		if len(syncWaiters.channels[userId]) == 0 {
+			delete(syncWaiters.channels, userId)
+		}
+		syncWaiters.Unlock()
+	}
+}
+
+func notifySyncWaiters(userIds []string) {
+	syncWaiters.Lock()
+	defer syncWaiters.Unlock()
+	for _, userId := range userIds {
+		for ch := range syncWaiters.channels[userId] {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+func syncParamError(name, reason string) *models.ProblemDetail {
+	return &models.ProblemDetail{
+		Title:     "Request parameter error. (Sync)",
+		Status:    http.StatusBadRequest,
+		ErrorName: models.ERROR_NAME_INVALID_PARAM,
+		InvalidParams: []models.InvalidParam{
+			models.InvalidParam{
+				Name:   name,
+				Reason: reason,
+			},
+		},
+	}
+}
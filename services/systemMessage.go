@@ -0,0 +1,69 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/swagchat/chat-api/datastore"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+)
+
+// postSystemMessage records a membership or room change in the room history.
+// Failures are logged only, because the change itself has already been applied.
+func postSystemMessage(room *models.Room, userId string, payload *models.PayloadSystem) {
+	if room.IsSystemMessage != nil && !*room.IsSystemMessage {
+		return
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		utils.AppLogger.Error("",
+			zap.String("msg", err.Error()),
+		)
+		return
+	}
+
+	message := &models.Message{
+		RoomId:  room.RoomId,
+		UserId:  userId,
+		Type:    models.MESSAGE_TYPE_SYSTEM,
+		Payload: utils.JSONText(payloadBytes),
+	}
+	message.BeforeSave()
+	pushText := ""
+	if utils.Cfg.SystemMessage.Notification {
+		pushText = systemMessageText(payload)
+	}
+	outboxItems := newMessageOutboxItems(room, message, pushText)
+	dRes := datastore.GetProvider().InsertMessage(message, outboxItems)
+	if dRes.ProblemDetail != nil {
+		pdBytes, _ := json.Marshal(dRes.ProblemDetail)
+		utils.AppLogger.Error("",
+			zap.String("problemDetail", string(pdBytes)),
+			zap.String("err", fmt.Sprintf("%+v", dRes.ProblemDetail.Error)),
+		)
+		return
+	}
+
+	go recordMessageChanges([]*models.Message{message}, outboxItems)
+	go dispatchOutboxItems(outboxItems)
+	go dispatchWebhookEvent(models.WEBHOOK_EVENT_MESSAGE_CREATED, message)
+}
+
+func systemMessageText(payload *models.PayloadSystem) string {
+	switch payload.Action {
+	case models.SYSTEM_ACTION_USER_JOIN:
+		return "Users joined the room"
+	case models.SYSTEM_ACTION_USER_LEFT:
+		return "Users left the room"
+	case models.SYSTEM_ACTION_ROOM_RENAME:
+		return utils.AppendStrings("Room name changed to ", payload.NewValue)
+	case models.SYSTEM_ACTION_ROOM_PICTURE:
+		return "Room picture changed"
+	default:
+		return "Room updated"
+	}
+}
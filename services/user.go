@@ -13,6 +13,7 @@ import (
 	"github.com/swagchat/chat-api/datastore"
 	"github.com/swagchat/chat-api/models"
 	"github.com/swagchat/chat-api/notification"
+	"github.com/swagchat/chat-api/rtm"
 	"github.com/swagchat/chat-api/utils"
 )
 
@@ -65,6 +66,7 @@ func GetUser(userId string) (*models.User, *models.ProblemDetail) {
 	mergeRooms := append(unreadCountRooms, notUnreadCountRooms...)
 	user.Rooms = mergeRooms
 	user.AccessToken = ""
+	user.BotSecret = ""
 	return user, nil
 }
 
@@ -85,6 +87,8 @@ func PutUser(put *models.User) (*models.User, *models.ProblemDetail) {
 	}
 
 	user.AccessToken = ""
+	user.BotSecret = ""
+	go publishUserEvent(models.NewEvent(models.EVENT_NAME_USER_UPDATE, "", user.UserId, user.Profile()))
 	return user, nil
 }
 
@@ -113,6 +117,9 @@ func DeleteUser(userId string) *models.ProblemDetail {
 	if dRes.ProblemDetail != nil {
 		return dRes.ProblemDetail
 	}
+	if rtm.HubEnabled() {
+		rtm.GetHub().DisconnectUser(userId, "user deleted")
+	}
 
 	ctx, _ := context.WithCancel(context.Background())
 	go unsubscribeByUserId(ctx, userId)
@@ -120,6 +127,31 @@ func DeleteUser(userId string) *models.ProblemDetail {
 	return nil
 }
 
+// publishUserEvent sends the event to the user and to the users of the rooms the user belongs to.
+func publishUserEvent(event *models.Event) {
+	roomIds, pd := GetUserRoomIds(event.ActorId)
+	if pd != nil {
+		logProblemDetail("Publish error. (User event)", pd)
+		return
+	}
+
+	recipients := []string{event.ActorId}
+	for _, roomId := range roomIds {
+		dRes := datastore.GetProvider().SelectRoomUsersByRoomId(roomId)
+		if dRes.ProblemDetail != nil {
+			logProblemDetail("Publish error. (User event)", dRes.ProblemDetail)
+			return
+		}
+		for _, roomUser := range dRes.Data.([]*models.RoomUser) {
+			if !utils.SearchStringValueInSlice(recipients, roomUser.UserId) {
+				recipients = append(recipients, roomUser.UserId)
+			}
+		}
+	}
+	event.Recipients = recipients
+	publishEvent(event)
+}
+
 func selectUser(userId string) (*models.User, *models.ProblemDetail) {
 	dRes := datastore.GetProvider().SelectUser(userId, false, false, false)
 	if dRes.ProblemDetail != nil {
@@ -156,3 +188,18 @@ func GetUserUnreadCount(userId string) (*models.UserUnreadCount, *models.Problem
 	}
 	return userUnreadCount, nil
 }
+
+// GetUserRoomIds returns the ids of the rooms the user belongs to.
+func GetUserRoomIds(userId string) ([]string, *models.ProblemDetail) {
+	dRes := datastore.GetProvider().SelectRoomUsersByUserId(userId)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	roomIds := make([]string, 0)
+	if dRes.Data != nil {
+		for _, roomUser := range dRes.Data.([]*models.RoomUser) {
+			roomIds = append(roomIds, roomUser.RoomId)
+		}
+	}
+	return roomIds, nil
+}
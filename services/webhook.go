@@ -0,0 +1,412 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/swagchat/chat-api/datastore"
+	"github.com/swagchat/chat-api/models"
+	"github.com/swagchat/chat-api/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	webhookHeaderEvent     = "X-SwagChat-Webhook-Event"
+	webhookHeaderDelivery  = "X-SwagChat-Webhook-Delivery"
+	webhookHeaderTimestamp = "X-SwagChat-Webhook-Timestamp"
+	webhookHeaderSignature = "X-SwagChat-Webhook-Signature"
+
+	webhookWorkerBatchSize = 100
+)
+
+func PostWebhook(post *models.Webhook) (*models.Webhook, *models.ProblemDetail) {
+	if pd := post.IsValid(); pd != nil {
+		return nil, pd
+	}
+	post.BeforeSave()
+
+	dRes := datastore.GetProvider().SelectWebhook(post.WebhookId)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	if dRes.Data != nil {
+		return nil, &models.ProblemDetail{
+			Status: http.StatusConflict,
+		}
+	}
+
+	dRes = datastore.GetProvider().InsertWebhook(post)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	return dRes.Data.(*models.Webhook), nil
+}
+
+func GetWebhooks() (*models.Webhooks, *models.ProblemDetail) {
+	dRes := datastore.GetProvider().SelectWebhooks()
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	return &models.Webhooks{
+		Webhooks: dRes.Data.([]*models.Webhook),
+	}, nil
+}
+
+func GetWebhook(webhookId string) (*models.Webhook, *models.ProblemDetail) {
+	return selectWebhook(webhookId)
+}
+
+func PutWebhook(put *models.Webhook) (*models.Webhook, *models.ProblemDetail) {
+	webhook, pd := selectWebhook(put.WebhookId)
+	if pd != nil {
+		return nil, pd
+	}
+
+	webhook.Put(put)
+	if pd := webhook.IsValid(); pd != nil {
+		return nil, pd
+	}
+	webhook.BeforeSave()
+
+	dRes := datastore.GetProvider().UpdateWebhook(webhook)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	return dRes.Data.(*models.Webhook), nil
+}
+
+func DeleteWebhook(webhookId string) *models.ProblemDetail {
+	webhook, pd := selectWebhook(webhookId)
+	if pd != nil {
+		return pd
+	}
+
+	webhook.Deleted = time.Now().Unix()
+	dRes := datastore.GetProvider().UpdateWebhook(webhook)
+	if dRes.ProblemDetail != nil {
+		return dRes.ProblemDetail
+	}
+	return nil
+}
+
+func GetWebhookDeliveries(webhookId string, params url.Values) (*models.WebhookDeliveries, *models.ProblemDetail) {
+	limit, offset, _, pd := setPagingParams(params)
+	if pd != nil {
+		return nil, pd
+	}
+
+	status := ""
+	if statusArray, ok := params["status"]; ok {
+		status = statusArray[0]
+	}
+	if webhookId == "" {
+		if webhookIdArray, ok := params["webhookId"]; ok {
+			webhookId = webhookIdArray[0]
+		}
+	}
+
+	dRes := datastore.GetProvider().SelectWebhookDeliveries(webhookId, status, limit, offset)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	return &models.WebhookDeliveries{
+		WebhookDeliveries: dRes.Data.([]*models.WebhookDelivery),
+	}, nil
+}
+
+func GetWebhookDeadLetters(params url.Values) (*models.WebhookDeliveries, *models.ProblemDetail) {
+	params.Set("status", models.WEBHOOK_DELIVERY_STATUS_DEAD)
+	return GetWebhookDeliveries("", params)
+}
+
+func GetWebhookDelivery(deliveryId string) (*models.WebhookDelivery, *models.ProblemDetail) {
+	return selectWebhookDelivery(deliveryId)
+}
+
+// ReplayWebhookDelivery resets the delivery and sends it again immediately.
+// The delivery id is kept so that receivers can de-duplicate.
+func ReplayWebhookDelivery(deliveryId string) (*models.WebhookDelivery, *models.ProblemDetail) {
+	delivery, pd := selectWebhookDelivery(deliveryId)
+	if pd != nil {
+		return nil, pd
+	}
+	if delivery.Status == models.WEBHOOK_DELIVERY_STATUS_PENDING {
+		return nil, &models.ProblemDetail{
+			Status: http.StatusConflict,
+		}
+	}
+
+	webhook, pd := selectWebhook(delivery.WebhookId)
+	if pd != nil {
+		return nil, pd
+	}
+
+	delivery.Status = models.WEBHOOK_DELIVERY_STATUS_PENDING
+	delivery.Attempts = 0
+	delivery.LastStatusCode = 0
+	delivery.LastError = ""
+	delivery.NextAttempt = time.Now().Unix() + webhookLease()
+	delivery.BeforeSave()
+	dRes := datastore.GetProvider().UpdateWebhookDelivery(delivery)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+
+	deliverWebhook(webhook, delivery)
+	return delivery, nil
+}
+
+// RunWebhookWorker retries pending deliveries whose next attempt time has come.
+func RunWebhookWorker(ctx context.Context) {
+	interval, err := strconv.Atoi(utils.Cfg.Webhook.WorkerInterval)
+	if err != nil || interval <= 0 {
+		interval = 5
+	}
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			retryWebhookDeliveries()
+		}
+	}
+}
+
+func retryWebhookDeliveries() {
+	now := time.Now().Unix()
+	dRes := datastore.GetProvider().SelectRetryableWebhookDeliveries(now, webhookWorkerBatchSize)
+	if dRes.ProblemDetail != nil {
+		logProblemDetail("Webhook retry error.", dRes.ProblemDetail)
+		return
+	}
+
+	webhooks := make(map[string]*models.Webhook)
+	for _, delivery := range dRes.Data.([]*models.WebhookDelivery) {
+		// Claim the delivery so that neither the next tick nor another process picks it up while it is in flight
+		nextAttempt := now + webhookLease()
+		cRes := datastore.GetProvider().ClaimWebhookDelivery(delivery, nextAttempt)
+		if cRes.ProblemDetail != nil {
+			logProblemDetail("Webhook retry error.", cRes.ProblemDetail)
+			continue
+		}
+		if !cRes.Data.(bool) {
+			continue
+		}
+		delivery.NextAttempt = nextAttempt
+
+		webhook, ok := webhooks[delivery.WebhookId]
+		if !ok {
+			dRes := datastore.GetProvider().SelectWebhook(delivery.WebhookId)
+			if dRes.ProblemDetail != nil {
+				logProblemDetail("Webhook retry error.", dRes.ProblemDetail)
+				continue
+			}
+			if dRes.Data != nil {
+				webhook = dRes.Data.(*models.Webhook)
+			}
+			webhooks[delivery.WebhookId] = webhook
+		}
+		if webhook == nil || (webhook.IsActive != nil && !*webhook.IsActive) {
+			delivery.Status = models.WEBHOOK_DELIVERY_STATUS_DEAD
+			delivery.LastError = "Webhook has been deleted or deactivated."
+			delivery.BeforeSave()
+			datastore.GetProvider().UpdateWebhookDelivery(delivery)
+			continue
+		}
+
+		go deliverWebhook(webhook, delivery)
+	}
+}
+
+// dispatchWebhookEvent records a delivery for every webhook subscribed to eventName and sends it.
+// Failed deliveries are left to RunWebhookWorker.
+func dispatchWebhookEvent(eventName string, data interface{}) {
+	dRes := datastore.GetProvider().SelectWebhooks()
+	if dRes.ProblemDetail != nil {
+		logProblemDetail("Webhook dispatch error.", dRes.ProblemDetail)
+		return
+	}
+	webhooks := dRes.Data.([]*models.Webhook)
+	if len(webhooks) == 0 {
+		return
+	}
+
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		utils.AppLogger.Error("",
+			zap.String("msg", err.Error()),
+		)
+		return
+	}
+
+	// The message is kept with the delivery so that the delivery is purged together with the message
+	messageId := ""
+	if message, ok := data.(*models.Message); ok {
+		messageId = message.MessageId
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.IsSubscribed(eventName) {
+			continue
+		}
+
+		delivery := &models.WebhookDelivery{
+			WebhookId:   webhook.WebhookId,
+			EventName:   eventName,
+			MessageId:   messageId,
+			Data:        utils.JSONText(dataBytes),
+			NextAttempt: time.Now().Unix() + webhookLease(),
+		}
+		delivery.BeforeSave()
+		dRes := datastore.GetProvider().InsertWebhookDelivery(delivery)
+		if dRes.ProblemDetail != nil {
+			logProblemDetail("Webhook dispatch error.", dRes.ProblemDetail)
+			continue
+		}
+
+		go deliverWebhook(webhook, delivery)
+	}
+}
+
+func deliverWebhook(webhook *models.Webhook, delivery *models.WebhookDelivery) {
+	delivery.Attempts++
+	statusCode, err := postWebhook(webhook, delivery)
+	delivery.LastStatusCode = statusCode
+	if err == nil {
+		delivery.Status = models.WEBHOOK_DELIVERY_STATUS_SUCCEEDED
+		delivery.LastError = ""
+	} else {
+		delivery.LastError = err.Error()
+		maxAttempts, convErr := strconv.Atoi(utils.Cfg.Webhook.MaxAttempts)
+		if convErr != nil {
+			maxAttempts = 8
+		}
+		if delivery.Attempts >= maxAttempts {
+			delivery.Status = models.WEBHOOK_DELIVERY_STATUS_DEAD
+			utils.AppLogger.Error("",
+				zap.String("msg", "Webhook delivery has been moved to the dead letters."),
+				zap.String("deliveryId", delivery.DeliveryId),
+				zap.String("webhookId", delivery.WebhookId),
+				zap.String("detail", delivery.LastError),
+			)
+		} else {
+			delivery.NextAttempt = time.Now().Unix() + webhookBackoff(delivery.Attempts)
+		}
+	}
+
+	delivery.BeforeSave()
+	dRes := datastore.GetProvider().UpdateWebhookDelivery(delivery)
+	if dRes.ProblemDetail != nil {
+		logProblemDetail("Webhook delivery error.", dRes.ProblemDetail)
+	}
+}
+
+// postWebhook sends the delivery as a signed JSON POST.
+// The signature is the hex encoded HMAC-SHA256 of "{timestamp}.{body}" keyed with the webhook secret.
+func postWebhook(webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	body, err := delivery.Body()
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequest("POST", webhook.Url, bytes.NewBuffer(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookHeaderEvent, delivery.EventName)
+	req.Header.Set(webhookHeaderDelivery, delivery.DeliveryId)
+	req.Header.Set(webhookHeaderTimestamp, timestamp)
+	req.Header.Set(webhookHeaderSignature, utils.AppendStrings("sha256=", signWebhookBody(webhook.Secret, timestamp, body)))
+
+	timeout, err := strconv.Atoi(utils.Cfg.Webhook.Timeout)
+	if err != nil {
+		timeout = 5
+	}
+	client := &http.Client{
+		Timeout: time.Duration(timeout) * time.Second,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, errors.New(utils.AppendStrings("webhook http status code[", strconv.Itoa(resp.StatusCode), "]"))
+	}
+	return resp.StatusCode, nil
+}
+
+func signWebhookBody(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff returns the seconds to wait after the given number of failed attempts.
+func webhookBackoff(attempts int) int64 {
+	interval, err := strconv.ParseInt(utils.Cfg.Webhook.RetryInterval, 10, 64)
+	if err != nil || interval <= 0 {
+		interval = 10
+	}
+	maxInterval, err := strconv.ParseInt(utils.Cfg.Webhook.MaxRetryInterval, 10, 64)
+	if err != nil || maxInterval <= 0 {
+		maxInterval = 3600
+	}
+	for i := 1; i < attempts && interval < maxInterval; i++ {
+		interval *= 2
+	}
+	if interval > maxInterval {
+		interval = maxInterval
+	}
+	return interval
+}
+
+// webhookLease is how long an in-flight delivery is hidden from the worker.
+func webhookLease() int64 {
+	timeout, err := strconv.ParseInt(utils.Cfg.Webhook.Timeout, 10, 64)
+	if err != nil {
+		timeout = 5
+	}
+	return timeout + webhookBackoff(1)
+}
+func selectWebhook(webhookId string) (*models.Webhook, *models.ProblemDetail) {
+	dRes := datastore.GetProvider().SelectWebhook(webhookId)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	if dRes.Data == nil {
+		return nil, &models.ProblemDetail{
+			Status: http.StatusNotFound,
+		}
+	}
+	return dRes.Data.(*models.Webhook), nil
+}
+
+func selectWebhookDelivery(deliveryId string) (*models.WebhookDelivery, *models.ProblemDetail) {
+	dRes := datastore.GetProvider().SelectWebhookDelivery(deliveryId)
+	if dRes.ProblemDetail != nil {
+		return nil, dRes.ProblemDetail
+	}
+	if dRes.Data == nil {
+		return nil, &models.ProblemDetail{
+			Status: http.StatusNotFound,
+		}
+	}
+	return dRes.Data.(*models.WebhookDelivery), nil
+}
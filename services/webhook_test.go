@@ -0,0 +1,68 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/swagchat/chat-api/utils"
+)
+
+func TestSignWebhookBody(t *testing.T) {
+	testTable := []struct {
+		testNo    int
+		secret    string
+		timestamp string
+		body      string
+		out       string
+	}{
+		// HMAC-SHA256 of "1500000000.{"eventName":"roomCreated"}" keyed with "secret"
+		{1, "secret", "1500000000", `{"eventName":"roomCreated"}`, "1f84b0234c51e96ed272c4082121a40e00e864e25c21c9e65904b60f8cdb8e00"},
+		{2, "", "0", "", "b849d5a581847b281957065739df36df2463d1977ea8d6e1e4e6cf33fadc68c3"},
+	}
+
+	for _, testRecord := range testTable {
+		out := signWebhookBody(testRecord.secret, testRecord.timestamp, []byte(testRecord.body))
+		if out != testRecord.out {
+			t.Fatalf("TestNo %d\nSignature Failure\n[expected]%s\n[result  ]%s", testRecord.testNo, testRecord.out, out)
+		}
+	}
+
+	// The timestamp is signed, so that a captured delivery can not be replayed with another one
+	if signWebhookBody("secret", "1", []byte("{}")) == signWebhookBody("secret", "2", []byte("{}")) {
+		t.Fatalf("the timestamp is not signed")
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	cfg := *utils.Cfg.Webhook
+	defer func() {
+		*utils.Cfg.Webhook = cfg
+	}()
+
+	testTable := []struct {
+		testNo           int
+		retryInterval    string
+		maxRetryInterval string
+		attempts         int
+		out              int64
+	}{
+		{1, "10", "3600", 1, 10},
+		{2, "10", "3600", 2, 20},
+		{3, "10", "3600", 5, 160},
+		{4, "10", "3600", 10, 3600},
+		{5, "10", "3600", 100, 3600},
+		{6, "10", "15", 2, 15},
+		// Invalid intervals fall back to the defaults
+		{7, "", "", 1, 10},
+		{8, "-1", "0", 3, 40},
+		{9, "abc", "abc", 20, 3600},
+	}
+
+	for _, testRecord := range testTable {
+		utils.Cfg.Webhook.RetryInterval = testRecord.retryInterval
+		utils.Cfg.Webhook.MaxRetryInterval = testRecord.maxRetryInterval
+		out := webhookBackoff(testRecord.attempts)
+		if out != testRecord.out {
+			t.Fatalf("TestNo %d\nBackoff Failure\n[expected]%d\n[result  ]%d", testRecord.testNo, testRecord.out, out)
+		}
+	}
+}
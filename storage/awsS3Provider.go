@@ -100,6 +100,33 @@ func (provider AwsS3StorageProvider) Get(assetInfo *AssetInfo) ([]byte, *models.
 	return nil, nil
 }
 
+func (provider AwsS3StorageProvider) Delete(assetInfo *AssetInfo) *models.ProblemDetail {
+	awsS3Client, err := provider.getSession()
+	if err != nil {
+		return &models.ProblemDetail{
+			Title:     "Create session failed. (Amazon S3)",
+			Status:    http.StatusInternalServerError,
+			ErrorName: "storage-error",
+			Detail:    err.Error(),
+		}
+	}
+
+	filePath := utils.AppendStrings(provider.uploadDirectory, "/", assetInfo.FileName)
+	_, err = awsS3Client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(provider.uploadBucket),
+		Key:    aws.String(filePath),
+	})
+	if err != nil {
+		return &models.ProblemDetail{
+			Title:     "Delete object failed. (Amazon S3)",
+			Status:    http.StatusInternalServerError,
+			ErrorName: "storage-error",
+			Detail:    err.Error(),
+		}
+	}
+	return nil
+}
+
 func (provider AwsS3StorageProvider) getSession() (*s3.S3, error) {
 	sess, err := session.NewSession(&aws.Config{
 		Region:      aws.String(provider.region),
@@ -12,6 +12,7 @@ import (
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 
+	"google.golang.org/api/googleapi"
 	storage "google.golang.org/api/storage/v1"
 )
 
@@ -107,3 +108,19 @@ func (provider GcpStorageProvider) Post(assetInfo *AssetInfo) (string, *models.P
 func (provider GcpStorageProvider) Get(assetInfo *AssetInfo) ([]byte, *models.ProblemDetail) {
 	return nil, nil
 }
+
+func (provider GcpStorageProvider) Delete(assetInfo *AssetInfo) *models.ProblemDetail {
+	filePath := utils.AppendStrings(provider.uploadDirectory, "/", assetInfo.FileName)
+	if err := gcpStorageService.Objects.Delete(provider.uploadBucket, filePath).Do(); err != nil {
+		if e, ok := err.(*googleapi.Error); ok && e.Code == http.StatusNotFound {
+			return nil
+		}
+		return &models.ProblemDetail{
+			Title:     "Delete object failed. (Google Cloud Storage)",
+			Status:    http.StatusInternalServerError,
+			ErrorName: "storage-error",
+			Detail:    err.Error(),
+		}
+	}
+	return nil
+}
@@ -79,3 +79,16 @@ func (provider LocalStorageProvider) Get(assetInfo *AssetInfo) ([]byte, *models.
 
 	return bytes, nil
 }
+
+func (provider LocalStorageProvider) Delete(assetInfo *AssetInfo) *models.ProblemDetail {
+	err := os.Remove(fmt.Sprintf("%s/%s", provider.localPath, assetInfo.FileName))
+	if err != nil && !os.IsNotExist(err) {
+		return &models.ProblemDetail{
+			Title:     "Deleting asset data failed. (Local Storage)",
+			Status:    http.StatusInternalServerError,
+			ErrorName: "storage-error",
+			Detail:    err.Error(),
+		}
+	}
+	return nil
+}
@@ -19,6 +19,7 @@ type Provider interface {
 	Init() error
 	Post(*AssetInfo) (string, *models.ProblemDetail)
 	Get(*AssetInfo) ([]byte, *models.ProblemDetail)
+	Delete(*AssetInfo) *models.ProblemDetail
 }
 
 func GetProvider() Provider {
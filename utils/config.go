@@ -28,16 +28,24 @@ var (
 )
 
 type Config struct {
-	Version      string
-	Port         string
-	Profiling    bool
-	DemoPage     bool `yaml:"demoPage"`
-	ErrorLogging bool `yaml:"errorLogging"`
-	Logging      *Logging
-	Storage      *Storage
-	Datastore    *Datastore
-	Rtm          *Rtm
-	Notification *Notification
+	Version       string
+	Port          string
+	Profiling     bool
+	DemoPage      bool `yaml:"demoPage"`
+	ErrorLogging  bool `yaml:"errorLogging"`
+	Logging       *Logging
+	Storage       *Storage
+	Datastore     *Datastore
+	Rtm           *Rtm
+	Notification  *Notification
+	SystemMessage *SystemMessage `yaml:"systemMessage"`
+	Moderation    *Moderation
+	Webhook       *Webhook
+	Export        *Export
+	Retention     *Retention
+	Outbox        *Outbox
+	Presence      *Presence
+	Sync          *Sync
 }
 
 type Logging struct {
@@ -98,6 +106,30 @@ type Rtm struct {
 	DirectEndpoint string `yaml:"directEndpoint"`
 	QueEndpoint    string `yaml:"queEndpoint"`
 	QueTopic       string `yaml:"queTopic"`
+	EventRateLimit string `yaml:"eventRateLimit"`
+
+	// room or user. In user mode the direct and nsq providers publish each event to a channel per recipient
+	ChannelMode string `yaml:"channelMode"`
+
+	// Builtin WebSocket server. Seconds
+	WsPingInterval string `yaml:"wsPingInterval"`
+	WsDrainTimeout string `yaml:"wsDrainTimeout"`
+
+	// Events queued for a connection. A connection that falls further behind is closed
+	WsSendBufferSize string `yaml:"wsSendBufferSize"`
+
+	// Recent events kept for Server-Sent Events streams resuming with Last-Event-ID
+	SseReplayBufferSize string `yaml:"sseReplayBufferSize"`
+
+	// Redis pub/sub provider
+	RedisEndpoint      string `yaml:"redisEndpoint"`
+	RedisPassword      string `yaml:"redisPassword"`
+	RedisDatabase      string `yaml:"redisDatabase"`
+	RedisPoolSize      string `yaml:"redisPoolSize"`
+	RedisChannelPrefix string `yaml:"redisChannelPrefix"`
+
+	// Seconds. The subscription is pinged this often, and reconnected when nothing is received for twice as long
+	RedisPingInterval string `yaml:"redisPingInterval"`
 }
 
 type Notification struct {
@@ -111,6 +143,115 @@ type Notification struct {
 	AwsSecretAccessKey       string `yaml:"awsSecretAccessKey"`
 	AwsApplicationArnIos     string `yaml:"awsApplicationArnIos"`
 	AwsApplicationArnAndroid string `yaml:"awsApplicationArnAndroid"`
+
+	// FCM HTTP v1. FcmProjectId defaults to the project of the service account
+	FcmCredentialPath string `yaml:"fcmCredentialPath"`
+	FcmProjectId      string `yaml:"fcmProjectId"`
+	FcmEndpoint       string `yaml:"fcmEndpoint"`
+
+	// APNs with a .p8 signing key. ApnsTopic is the bundle id of the app.
+	// ApnsEnvironment is production or sandbox, and ApnsEndpoint overrides its host
+	ApnsKeyPath     string `yaml:"apnsKeyPath"`
+	ApnsKeyId       string `yaml:"apnsKeyId"`
+	ApnsTeamId      string `yaml:"apnsTeamId"`
+	ApnsTopic       string `yaml:"apnsTopic"`
+	ApnsEnvironment string `yaml:"apnsEnvironment"`
+	ApnsEndpoint    string `yaml:"apnsEndpoint"`
+
+	// Web Push with VAPID. WebPushVapidPrivateKey is the base64url encoded P-256 private key,
+	// and WebPushVapidSubject is a mailto: or https: contact of the application server
+	WebPushVapidPrivateKey string `yaml:"webPushVapidPrivateKey"`
+	WebPushVapidSubject    string `yaml:"webPushVapidSubject"`
+
+	// The provider of each platform, to send through several providers at once.
+	// A platform without one uses Provider
+	IosProvider     string `yaml:"iosProvider"`
+	AndroidProvider string `yaml:"androidProvider"`
+	WebProvider     string `yaml:"webProvider"`
+}
+
+type SystemMessage struct {
+	UnreadCount  bool `yaml:"unreadCount"`
+	Notification bool
+}
+
+type Moderation struct {
+	// Comma separated hook names, evaluated in order. "wordFilter", "webhook"
+	Hooks string
+
+	// Word filter
+	WordFilterWords        string `yaml:"wordFilterWords"`
+	WordFilterPatternsPath string `yaml:"wordFilterPatternsPath"`
+	WordFilterAction       string `yaml:"wordFilterAction"`
+
+	// Webhook
+	WebhookEndpoint string `yaml:"webhookEndpoint"`
+	WebhookTimeout  string `yaml:"webhookTimeout"`
+}
+
+type Webhook struct {
+	// Seconds
+	Timeout string
+
+	// Deliveries that failed MaxAttempts times are moved to the dead letters
+	MaxAttempts string `yaml:"maxAttempts"`
+
+	// Seconds. The retry interval doubles on each attempt up to MaxRetryInterval
+	RetryInterval    string `yaml:"retryInterval"`
+	MaxRetryInterval string `yaml:"maxRetryInterval"`
+
+	// Seconds. Interval of polling for deliveries to retry
+	WorkerInterval string `yaml:"workerInterval"`
+
+	// Requests per minute accepted by each incoming webhook unless the hook sets its own limit
+	IncomingRateLimit string `yaml:"incomingRateLimit"`
+}
+
+type Export struct {
+	// Exports with more messages than SyncLimit run as a background job
+	SyncLimit string `yaml:"syncLimit"`
+}
+
+type Outbox struct {
+	// Items that failed MaxAttempts times are moved to the dead letters
+	MaxAttempts string `yaml:"maxAttempts"`
+
+	// Seconds. The retry interval doubles on each attempt up to MaxRetryInterval
+	RetryInterval    string `yaml:"retryInterval"`
+	MaxRetryInterval string `yaml:"maxRetryInterval"`
+
+	// Seconds. Interval of polling for items to retry
+	WorkerInterval string `yaml:"workerInterval"`
+
+	// Hours to keep the items delivered successfully. 0 keeps them forever
+	RetentionHours string `yaml:"retentionHours"`
+}
+
+type Presence struct {
+	// Seconds. Interval of refreshing the last seen time of the users connected to this process
+	HeartbeatInterval string `yaml:"heartbeatInterval"`
+
+	// Seconds. Users not seen for this long turn offline
+	Timeout string `yaml:"timeout"`
+}
+
+type Sync struct {
+	// Seconds. Longest time a sync request waits for a change
+	MaxWait string `yaml:"maxWait"`
+
+	// Maximum number of changes in a sync response
+	Limit string
+
+	// Hours to keep the change log. Clients with an older token have to refetch everything
+	RetentionHours string `yaml:"retentionHours"`
+}
+
+type Retention struct {
+	// Days to keep messages unless the room overrides it. 0 keeps them forever
+	Days string
+
+	// Seconds. Interval of purging expired messages
+	PurgeInterval string `yaml:"purgeInterval"`
 }
 
 func setupConfig() {
@@ -141,25 +282,99 @@ func loadDefaultSettings() {
 	}
 
 	rtm := &Rtm{
-		Provider:       "",
-		DirectEndpoint: "",
-		QueEndpoint:    "",
-		QueTopic:       "",
+		Provider:         "",
+		DirectEndpoint:   "",
+		QueEndpoint:      "",
+		QueTopic:         "",
+		EventRateLimit:   "60",
+		WsPingInterval:   "30",
+		WsDrainTimeout:   "10",
+		WsSendBufferSize: "256",
+
+		ChannelMode: "room",
+
+		SseReplayBufferSize: "1000",
+
+		RedisEndpoint:      "localhost:6379",
+		RedisPassword:      "",
+		RedisDatabase:      "0",
+		RedisPoolSize:      "10",
+		RedisChannelPrefix: "swagchat:",
+		RedisPingInterval:  "30",
+	}
+
+	notification := &Notification{
+		FcmEndpoint:     "https://fcm.googleapis.com",
+		ApnsEnvironment: "production",
+	}
+
+	systemMessage := &SystemMessage{
+		UnreadCount:  false,
+		Notification: false,
+	}
+
+	moderation := &Moderation{
+		Hooks:            "",
+		WordFilterAction: "mask",
+		WebhookTimeout:   "3",
+	}
+
+	webhook := &Webhook{
+		Timeout:           "5",
+		MaxAttempts:       "8",
+		RetryInterval:     "10",
+		MaxRetryInterval:  "3600",
+		WorkerInterval:    "5",
+		IncomingRateLimit: "30",
+	}
+
+	export := &Export{
+		SyncLimit: "5000",
+	}
+
+	retention := &Retention{
+		Days:          "0",
+		PurgeInterval: "3600",
+	}
+
+	outbox := &Outbox{
+		MaxAttempts:      "10",
+		RetryInterval:    "5",
+		MaxRetryInterval: "600",
+		WorkerInterval:   "5",
+		RetentionHours:   "168",
+	}
+
+	presence := &Presence{
+		HeartbeatInterval: "30",
+		Timeout:           "90",
 	}
 
-	notification := &Notification{}
+	sync := &Sync{
+		MaxWait:        "30",
+		Limit:          "500",
+		RetentionHours: "168",
+	}
 
 	Cfg = &Config{
-		Version:      "0",
-		Port:         port,
-		Profiling:    false,
-		DemoPage:     false,
-		ErrorLogging: false,
-		Logging:      logging,
-		Storage:      storage,
-		Datastore:    datastore,
-		Rtm:          rtm,
-		Notification: notification,
+		Version:       "0",
+		Port:          port,
+		Profiling:     false,
+		DemoPage:      false,
+		ErrorLogging:  false,
+		Logging:       logging,
+		Storage:       storage,
+		Datastore:     datastore,
+		Rtm:           rtm,
+		Notification:  notification,
+		SystemMessage: systemMessage,
+		Moderation:    moderation,
+		Webhook:       webhook,
+		Export:        export,
+		Retention:     retention,
+		Outbox:        outbox,
+		Presence:      presence,
+		Sync:          sync,
 	}
 }
 
@@ -361,6 +576,42 @@ func loadEnvironment() {
 	if v = os.Getenv("SC_RTM_QUE_TOPIC"); v != "" {
 		Cfg.Rtm.QueTopic = v
 	}
+	if v = os.Getenv("SC_RTM_EVENT_RATE_LIMIT"); v != "" {
+		Cfg.Rtm.EventRateLimit = v
+	}
+	if v = os.Getenv("SC_RTM_CHANNEL_MODE"); v != "" {
+		Cfg.Rtm.ChannelMode = v
+	}
+	if v = os.Getenv("SC_RTM_WS_PING_INTERVAL"); v != "" {
+		Cfg.Rtm.WsPingInterval = v
+	}
+	if v = os.Getenv("SC_RTM_WS_DRAIN_TIMEOUT"); v != "" {
+		Cfg.Rtm.WsDrainTimeout = v
+	}
+	if v = os.Getenv("SC_RTM_WS_SEND_BUFFER_SIZE"); v != "" {
+		Cfg.Rtm.WsSendBufferSize = v
+	}
+	if v = os.Getenv("SC_RTM_SSE_REPLAY_BUFFER_SIZE"); v != "" {
+		Cfg.Rtm.SseReplayBufferSize = v
+	}
+	if v = os.Getenv("SC_RTM_REDIS_ENDPOINT"); v != "" {
+		Cfg.Rtm.RedisEndpoint = v
+	}
+	if v = os.Getenv("SC_RTM_REDIS_PASSWORD"); v != "" {
+		Cfg.Rtm.RedisPassword = v
+	}
+	if v = os.Getenv("SC_RTM_REDIS_DATABASE"); v != "" {
+		Cfg.Rtm.RedisDatabase = v
+	}
+	if v = os.Getenv("SC_RTM_REDIS_POOL_SIZE"); v != "" {
+		Cfg.Rtm.RedisPoolSize = v
+	}
+	if v = os.Getenv("SC_RTM_REDIS_CHANNEL_PREFIX"); v != "" {
+		Cfg.Rtm.RedisChannelPrefix = v
+	}
+	if v = os.Getenv("SC_RTM_REDIS_PING_INTERVAL"); v != "" {
+		Cfg.Rtm.RedisPingInterval = v
+	}
 
 	// Notification
 	if v = os.Getenv("SC_NOTIFICATION_PROVIDER"); v != "" {
@@ -389,6 +640,161 @@ func loadEnvironment() {
 	if v = os.Getenv("SC_NOTIFICATION_AWS_APPLICATION_ARN_ANDROID"); v != "" {
 		Cfg.Notification.AwsApplicationArnAndroid = v
 	}
+
+	// Notification - FCM
+	if v = os.Getenv("SC_NOTIFICATION_FCM_CREDENTIAL_PATH"); v != "" {
+		Cfg.Notification.FcmCredentialPath = v
+	}
+	if v = os.Getenv("SC_NOTIFICATION_FCM_PROJECT_ID"); v != "" {
+		Cfg.Notification.FcmProjectId = v
+	}
+	if v = os.Getenv("SC_NOTIFICATION_FCM_ENDPOINT"); v != "" {
+		Cfg.Notification.FcmEndpoint = v
+	}
+
+	// Notification - APNs
+	if v = os.Getenv("SC_NOTIFICATION_APNS_KEY_PATH"); v != "" {
+		Cfg.Notification.ApnsKeyPath = v
+	}
+	if v = os.Getenv("SC_NOTIFICATION_APNS_KEY_ID"); v != "" {
+		Cfg.Notification.ApnsKeyId = v
+	}
+	if v = os.Getenv("SC_NOTIFICATION_APNS_TEAM_ID"); v != "" {
+		Cfg.Notification.ApnsTeamId = v
+	}
+	if v = os.Getenv("SC_NOTIFICATION_APNS_TOPIC"); v != "" {
+		Cfg.Notification.ApnsTopic = v
+	}
+	if v = os.Getenv("SC_NOTIFICATION_APNS_ENVIRONMENT"); v != "" {
+		Cfg.Notification.ApnsEnvironment = v
+	}
+	if v = os.Getenv("SC_NOTIFICATION_APNS_ENDPOINT"); v != "" {
+		Cfg.Notification.ApnsEndpoint = v
+	}
+
+	// Notification - Web Push
+	if v = os.Getenv("SC_NOTIFICATION_WEB_PUSH_VAPID_PRIVATE_KEY"); v != "" {
+		Cfg.Notification.WebPushVapidPrivateKey = v
+	}
+	if v = os.Getenv("SC_NOTIFICATION_WEB_PUSH_VAPID_SUBJECT"); v != "" {
+		Cfg.Notification.WebPushVapidSubject = v
+	}
+
+	// Notification - Routing
+	if v = os.Getenv("SC_NOTIFICATION_IOS_PROVIDER"); v != "" {
+		Cfg.Notification.IosProvider = v
+	}
+	if v = os.Getenv("SC_NOTIFICATION_ANDROID_PROVIDER"); v != "" {
+		Cfg.Notification.AndroidProvider = v
+	}
+	if v = os.Getenv("SC_NOTIFICATION_WEB_PROVIDER"); v != "" {
+		Cfg.Notification.WebProvider = v
+	}
+
+	// SystemMessage
+	if v = os.Getenv("SC_SYSTEM_MESSAGE_UNREAD_COUNT"); v != "" {
+		if v == "true" {
+			Cfg.SystemMessage.UnreadCount = true
+		} else if v == "false" {
+			Cfg.SystemMessage.UnreadCount = false
+		}
+	}
+	if v = os.Getenv("SC_SYSTEM_MESSAGE_NOTIFICATION"); v != "" {
+		if v == "true" {
+			Cfg.SystemMessage.Notification = true
+		} else if v == "false" {
+			Cfg.SystemMessage.Notification = false
+		}
+	}
+
+	// Moderation
+	if v = os.Getenv("SC_MODERATION_HOOKS"); v != "" {
+		Cfg.Moderation.Hooks = v
+	}
+	if v = os.Getenv("SC_MODERATION_WORD_FILTER_WORDS"); v != "" {
+		Cfg.Moderation.WordFilterWords = v
+	}
+	if v = os.Getenv("SC_MODERATION_WORD_FILTER_PATTERNS_PATH"); v != "" {
+		Cfg.Moderation.WordFilterPatternsPath = v
+	}
+	if v = os.Getenv("SC_MODERATION_WORD_FILTER_ACTION"); v != "" {
+		Cfg.Moderation.WordFilterAction = v
+	}
+	if v = os.Getenv("SC_MODERATION_WEBHOOK_ENDPOINT"); v != "" {
+		Cfg.Moderation.WebhookEndpoint = v
+	}
+	if v = os.Getenv("SC_MODERATION_WEBHOOK_TIMEOUT"); v != "" {
+		Cfg.Moderation.WebhookTimeout = v
+	}
+
+	// Webhook
+	if v = os.Getenv("SC_WEBHOOK_TIMEOUT"); v != "" {
+		Cfg.Webhook.Timeout = v
+	}
+	if v = os.Getenv("SC_WEBHOOK_MAX_ATTEMPTS"); v != "" {
+		Cfg.Webhook.MaxAttempts = v
+	}
+	if v = os.Getenv("SC_WEBHOOK_RETRY_INTERVAL"); v != "" {
+		Cfg.Webhook.RetryInterval = v
+	}
+	if v = os.Getenv("SC_WEBHOOK_MAX_RETRY_INTERVAL"); v != "" {
+		Cfg.Webhook.MaxRetryInterval = v
+	}
+	if v = os.Getenv("SC_WEBHOOK_WORKER_INTERVAL"); v != "" {
+		Cfg.Webhook.WorkerInterval = v
+	}
+	if v = os.Getenv("SC_WEBHOOK_INCOMING_RATE_LIMIT"); v != "" {
+		Cfg.Webhook.IncomingRateLimit = v
+	}
+
+	// Export
+	if v = os.Getenv("SC_EXPORT_SYNC_LIMIT"); v != "" {
+		Cfg.Export.SyncLimit = v
+	}
+
+	// Retention
+	if v = os.Getenv("SC_RETENTION_DAYS"); v != "" {
+		Cfg.Retention.Days = v
+	}
+	if v = os.Getenv("SC_RETENTION_PURGE_INTERVAL"); v != "" {
+		Cfg.Retention.PurgeInterval = v
+	}
+
+	// Outbox
+	if v = os.Getenv("SC_OUTBOX_MAX_ATTEMPTS"); v != "" {
+		Cfg.Outbox.MaxAttempts = v
+	}
+	if v = os.Getenv("SC_OUTBOX_RETRY_INTERVAL"); v != "" {
+		Cfg.Outbox.RetryInterval = v
+	}
+	if v = os.Getenv("SC_OUTBOX_MAX_RETRY_INTERVAL"); v != "" {
+		Cfg.Outbox.MaxRetryInterval = v
+	}
+	if v = os.Getenv("SC_OUTBOX_WORKER_INTERVAL"); v != "" {
+		Cfg.Outbox.WorkerInterval = v
+	}
+	if v = os.Getenv("SC_OUTBOX_RETENTION_HOURS"); v != "" {
+		Cfg.Outbox.RetentionHours = v
+	}
+
+	// Presence
+	if v = os.Getenv("SC_PRESENCE_HEARTBEAT_INTERVAL"); v != "" {
+		Cfg.Presence.HeartbeatInterval = v
+	}
+	if v = os.Getenv("SC_PRESENCE_TIMEOUT"); v != "" {
+		Cfg.Presence.Timeout = v
+	}
+
+	// Sync
+	if v = os.Getenv("SC_SYNC_MAX_WAIT"); v != "" {
+		Cfg.Sync.MaxWait = v
+	}
+	if v = os.Getenv("SC_SYNC_LIMIT"); v != "" {
+		Cfg.Sync.Limit = v
+	}
+	if v = os.Getenv("SC_SYNC_RETENTION_HOURS"); v != "" {
+		Cfg.Sync.RetentionHours = v
+	}
 }
 
 func parseFlag() {
@@ -536,6 +942,18 @@ func parseFlag() {
 	flag.StringVar(&Cfg.Rtm.DirectEndpoint, "realtimeMessaging.directEndpoint", Cfg.Rtm.DirectEndpoint, "")
 	flag.StringVar(&Cfg.Rtm.QueEndpoint, "realtimeMessaging.queEndpoint", Cfg.Rtm.QueEndpoint, "")
 	flag.StringVar(&Cfg.Rtm.QueTopic, "realtimeMessaging.queTopic", Cfg.Rtm.QueTopic, "")
+	flag.StringVar(&Cfg.Rtm.EventRateLimit, "realtimeMessaging.eventRateLimit", Cfg.Rtm.EventRateLimit, "")
+	flag.StringVar(&Cfg.Rtm.ChannelMode, "realtimeMessaging.channelMode", Cfg.Rtm.ChannelMode, "")
+	flag.StringVar(&Cfg.Rtm.WsPingInterval, "realtimeMessaging.wsPingInterval", Cfg.Rtm.WsPingInterval, "")
+	flag.StringVar(&Cfg.Rtm.WsDrainTimeout, "realtimeMessaging.wsDrainTimeout", Cfg.Rtm.WsDrainTimeout, "")
+	flag.StringVar(&Cfg.Rtm.WsSendBufferSize, "realtimeMessaging.wsSendBufferSize", Cfg.Rtm.WsSendBufferSize, "")
+	flag.StringVar(&Cfg.Rtm.SseReplayBufferSize, "realtimeMessaging.sseReplayBufferSize", Cfg.Rtm.SseReplayBufferSize, "")
+	flag.StringVar(&Cfg.Rtm.RedisEndpoint, "realtimeMessaging.redisEndpoint", Cfg.Rtm.RedisEndpoint, "")
+	flag.StringVar(&Cfg.Rtm.RedisPassword, "realtimeMessaging.redisPassword", Cfg.Rtm.RedisPassword, "")
+	flag.StringVar(&Cfg.Rtm.RedisDatabase, "realtimeMessaging.redisDatabase", Cfg.Rtm.RedisDatabase, "")
+	flag.StringVar(&Cfg.Rtm.RedisPoolSize, "realtimeMessaging.redisPoolSize", Cfg.Rtm.RedisPoolSize, "")
+	flag.StringVar(&Cfg.Rtm.RedisChannelPrefix, "realtimeMessaging.redisChannelPrefix", Cfg.Rtm.RedisChannelPrefix, "")
+	flag.StringVar(&Cfg.Rtm.RedisPingInterval, "realtimeMessaging.redisPingInterval", Cfg.Rtm.RedisPingInterval, "")
 
 	// Notification
 	flag.StringVar(&Cfg.Notification.Provider, "notification.provider", Cfg.Notification.Provider, "")
@@ -547,6 +965,73 @@ func parseFlag() {
 	flag.StringVar(&Cfg.Notification.AwsSecretAccessKey, "notification.awsSecretAccessKey", Cfg.Notification.AwsSecretAccessKey, "")
 	flag.StringVar(&Cfg.Notification.AwsApplicationArnIos, "notification.awsApplicationArnIos", Cfg.Notification.AwsApplicationArnIos, "")
 	flag.StringVar(&Cfg.Notification.AwsApplicationArnAndroid, "notification.awsApplicationArnAndroid", Cfg.Notification.AwsApplicationArnAndroid, "")
+
+	// Notification - FCM
+	flag.StringVar(&Cfg.Notification.FcmCredentialPath, "notification.fcmCredentialPath", Cfg.Notification.FcmCredentialPath, "")
+	flag.StringVar(&Cfg.Notification.FcmProjectId, "notification.fcmProjectId", Cfg.Notification.FcmProjectId, "")
+	flag.StringVar(&Cfg.Notification.FcmEndpoint, "notification.fcmEndpoint", Cfg.Notification.FcmEndpoint, "")
+
+	// Notification - APNs
+	flag.StringVar(&Cfg.Notification.ApnsKeyPath, "notification.apnsKeyPath", Cfg.Notification.ApnsKeyPath, "")
+	flag.StringVar(&Cfg.Notification.ApnsKeyId, "notification.apnsKeyId", Cfg.Notification.ApnsKeyId, "")
+	flag.StringVar(&Cfg.Notification.ApnsTeamId, "notification.apnsTeamId", Cfg.Notification.ApnsTeamId, "")
+	flag.StringVar(&Cfg.Notification.ApnsTopic, "notification.apnsTopic", Cfg.Notification.ApnsTopic, "")
+	flag.StringVar(&Cfg.Notification.ApnsEnvironment, "notification.apnsEnvironment", Cfg.Notification.ApnsEnvironment, "")
+	flag.StringVar(&Cfg.Notification.ApnsEndpoint, "notification.apnsEndpoint", Cfg.Notification.ApnsEndpoint, "")
+
+	// Notification - Web Push
+	flag.StringVar(&Cfg.Notification.WebPushVapidPrivateKey, "notification.webPushVapidPrivateKey", Cfg.Notification.WebPushVapidPrivateKey, "")
+	flag.StringVar(&Cfg.Notification.WebPushVapidSubject, "notification.webPushVapidSubject", Cfg.Notification.WebPushVapidSubject, "")
+
+	// Notification - Routing
+	flag.StringVar(&Cfg.Notification.IosProvider, "notification.iosProvider", Cfg.Notification.IosProvider, "")
+	flag.StringVar(&Cfg.Notification.AndroidProvider, "notification.androidProvider", Cfg.Notification.AndroidProvider, "")
+	flag.StringVar(&Cfg.Notification.WebProvider, "notification.webProvider", Cfg.Notification.WebProvider, "")
+
+	// SystemMessage
+	var systemMessageUnreadCount string
+	flag.StringVar(&systemMessageUnreadCount, "systemMessage.unreadCount", "", "false")
+	var systemMessageNotification string
+	flag.StringVar(&systemMessageNotification, "systemMessage.notification", "", "false")
+
+	// Moderation
+	flag.StringVar(&Cfg.Moderation.Hooks, "moderation.hooks", Cfg.Moderation.Hooks, "")
+	flag.StringVar(&Cfg.Moderation.WordFilterWords, "moderation.wordFilterWords", Cfg.Moderation.WordFilterWords, "")
+	flag.StringVar(&Cfg.Moderation.WordFilterPatternsPath, "moderation.wordFilterPatternsPath", Cfg.Moderation.WordFilterPatternsPath, "")
+	flag.StringVar(&Cfg.Moderation.WordFilterAction, "moderation.wordFilterAction", Cfg.Moderation.WordFilterAction, "")
+	flag.StringVar(&Cfg.Moderation.WebhookEndpoint, "moderation.webhookEndpoint", Cfg.Moderation.WebhookEndpoint, "")
+	flag.StringVar(&Cfg.Moderation.WebhookTimeout, "moderation.webhookTimeout", Cfg.Moderation.WebhookTimeout, "")
+
+	// Webhook
+	flag.StringVar(&Cfg.Webhook.Timeout, "webhook.timeout", Cfg.Webhook.Timeout, "")
+	flag.StringVar(&Cfg.Webhook.MaxAttempts, "webhook.maxAttempts", Cfg.Webhook.MaxAttempts, "")
+	flag.StringVar(&Cfg.Webhook.RetryInterval, "webhook.retryInterval", Cfg.Webhook.RetryInterval, "")
+	flag.StringVar(&Cfg.Webhook.MaxRetryInterval, "webhook.maxRetryInterval", Cfg.Webhook.MaxRetryInterval, "")
+	flag.StringVar(&Cfg.Webhook.WorkerInterval, "webhook.workerInterval", Cfg.Webhook.WorkerInterval, "")
+	flag.StringVar(&Cfg.Webhook.IncomingRateLimit, "webhook.incomingRateLimit", Cfg.Webhook.IncomingRateLimit, "")
+
+	// Export
+	flag.StringVar(&Cfg.Export.SyncLimit, "export.syncLimit", Cfg.Export.SyncLimit, "")
+
+	// Retention
+	flag.StringVar(&Cfg.Retention.Days, "retention.days", Cfg.Retention.Days, "")
+	flag.StringVar(&Cfg.Retention.PurgeInterval, "retention.purgeInterval", Cfg.Retention.PurgeInterval, "")
+
+	// Outbox
+	flag.StringVar(&Cfg.Outbox.MaxAttempts, "outbox.maxAttempts", Cfg.Outbox.MaxAttempts, "")
+	flag.StringVar(&Cfg.Outbox.RetryInterval, "outbox.retryInterval", Cfg.Outbox.RetryInterval, "")
+	flag.StringVar(&Cfg.Outbox.MaxRetryInterval, "outbox.maxRetryInterval", Cfg.Outbox.MaxRetryInterval, "")
+	flag.StringVar(&Cfg.Outbox.WorkerInterval, "outbox.workerInterval", Cfg.Outbox.WorkerInterval, "")
+	flag.StringVar(&Cfg.Outbox.RetentionHours, "outbox.retentionHours", Cfg.Outbox.RetentionHours, "")
+
+	// Presence
+	flag.StringVar(&Cfg.Presence.HeartbeatInterval, "presence.heartbeatInterval", Cfg.Presence.HeartbeatInterval, "")
+	flag.StringVar(&Cfg.Presence.Timeout, "presence.timeout", Cfg.Presence.Timeout, "")
+
+	// Sync
+	flag.StringVar(&Cfg.Sync.MaxWait, "sync.maxWait", Cfg.Sync.MaxWait, "")
+	flag.StringVar(&Cfg.Sync.Limit, "sync.limit", Cfg.Sync.Limit, "")
+	flag.StringVar(&Cfg.Sync.RetentionHours, "sync.retentionHours", Cfg.Sync.RetentionHours, "")
 	flag.Parse()
 
 	if profiling == "true" {
@@ -566,4 +1051,16 @@ func parseFlag() {
 	} else if errorLogging == "false" {
 		Cfg.ErrorLogging = false
 	}
+
+	if systemMessageUnreadCount == "true" {
+		Cfg.SystemMessage.UnreadCount = true
+	} else if systemMessageUnreadCount == "false" {
+		Cfg.SystemMessage.UnreadCount = false
+	}
+
+	if systemMessageNotification == "true" {
+		Cfg.SystemMessage.Notification = true
+	} else if systemMessageNotification == "false" {
+		Cfg.SystemMessage.Notification = false
+	}
 }
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter is a fixed window counter keyed by an arbitrary string.
+type RateLimiter struct {
+	mu       sync.Mutex
+	limit    int
+	interval time.Duration
+	windows  map[string]*rateWindow
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func NewRateLimiter(limit int, interval time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:    limit,
+		interval: interval,
+		windows:  make(map[string]*rateWindow),
+	}
+}
+
+// Allow reports whether one more call is permitted for key in the current window.
+// A limit of zero or less disables limiting.
+func (rl *RateLimiter) Allow(key string) bool {
+	return rl.AllowWithLimit(key, rl.limit)
+}
+
+// AllowWithLimit is Allow with a limit that overrides the one given to NewRateLimiter.
+func (rl *RateLimiter) AllowWithLimit(key string, limit int) bool {
+	if limit <= 0 {
+		return true
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	w, ok := rl.windows[key]
+	if !ok || now.Sub(w.start) >= rl.interval {
+		rl.sweep(now)
+		rl.windows[key] = &rateWindow{start: now, count: 1}
+		return true
+	}
+	if w.count >= limit {
+		return false
+	}
+	w.count++
+	return true
+}
+
+func (rl *RateLimiter) sweep(now time.Time) {
+	for k, w := range rl.windows {
+		if now.Sub(w.start) >= rl.interval {
+			delete(rl.windows, k)
+		}
+	}
+}